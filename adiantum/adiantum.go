@@ -0,0 +1,338 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package adiantum implements the Adiantum and HPolyC tweakable,
+// length-preserving ciphers of Crowley and Biggers on top of
+// github.com/tmthrgd/chacha20.
+//
+// Both are wide-block ciphers built for disk sector encryption on processors
+// without AES instructions, and are supported by Linux's fscrypt and dm-crypt.
+// A message of at least BlockSize bytes is split into P_L and its final 16
+// bytes P_R, and encrypted as
+//
+//	P_M = P_R + H(T, P_L)
+//	C_M = AES-256(K_E, P_M)
+//	C_L = P_L XOR XChaCha(K, C_M || 1 || 0^56)
+//	C_R = C_M - H(T, C_L)
+//
+// where T is the tweak and + and - are modulo 2^128. The subkeys are taken
+// from the keystream of XChaCha(K, 1 || 0^184). Every bit of the ciphertext
+// depends on every bit of the plaintext.
+//
+// Adiantum hashes with NH and Poly1305, while HPolyC uses Poly1305 alone,
+// which is simpler but slower for long messages. Both use XChaCha12 by
+// default, with the subkey derived by HChaCha12, as Linux does.
+package adiantum
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+
+	"github.com/tmthrgd/chacha20"
+	"golang.org/x/crypto/poly1305"
+)
+
+const (
+	// KeySize is the length of keys, in bytes.
+	KeySize = chacha20.KeySize
+
+	// BlockSize is the length of the shortest message that can be
+	// encrypted, in bytes.
+	BlockSize = aes.BlockSize
+)
+
+const (
+	nhMessageSize = 1024                 // the length of an NH message, in bytes
+	nhKeyWords    = nhMessageSize/4 + 12 // the length of the NH key, in 32-bit words
+	nhHashSize    = 32                   // the length of NH's output, in bytes
+)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	// ErrInvalidRounds is returned when the number of rounds is not 8, 12
+	// or 20.
+	ErrInvalidRounds = errors.New("invalid number of rounds")
+)
+
+// Cipher is an instance of Adiantum or HPolyC with a particular key.
+type Cipher struct {
+	key    [KeySize]byte
+	rounds int
+
+	block cipher.Block
+
+	hpolyc bool
+
+	// Poly1305 keys with a zero s, so that the MAC is the unfinalised
+	// polynomial hash. For HPolyC, only keyT is used.
+	keyT, keyM [32]byte
+
+	keyNH [nhKeyWords]uint32
+}
+
+// New returns Adiantum, with XChaCha12 and AES-256, using the given 256-bit
+// key. This is the variant used by Linux.
+func New(key []byte) (*Cipher, error) {
+	return NewRounds(key, 12)
+}
+
+// NewRounds returns Adiantum using XChaCha8, XChaCha12 or XChaCha20, as
+// selected by rounds. In most cases New should be used instead.
+func NewRounds(key []byte, rounds int) (*Cipher, error) {
+	return newCipher(key, rounds, false)
+}
+
+// NewHPolyC returns HPolyC, with XChaCha12 and AES-256, using the given
+// 256-bit key.
+func NewHPolyC(key []byte) (*Cipher, error) {
+	return NewHPolyCRounds(key, 12)
+}
+
+// NewHPolyCRounds returns HPolyC using XChaCha8, XChaCha12 or XChaCha20, as
+// selected by rounds. In most cases NewHPolyC should be used instead.
+func NewHPolyCRounds(key []byte, rounds int) (*Cipher, error) {
+	return newCipher(key, rounds, true)
+}
+
+func newCipher(key []byte, rounds int, hpolyc bool) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	switch rounds {
+	case 8, 12, 20:
+	default:
+		return nil, ErrInvalidRounds
+	}
+
+	c := &Cipher{rounds: rounds, hpolyc: hpolyc}
+	copy(c.key[:], key)
+
+	// K_E || K_T || K_M || K_NH for Adiantum, K_E || K_H for HPolyC.
+	size := 32 + 16
+	if !hpolyc {
+		size += 16 + 4*nhKeyWords
+	}
+
+	nonce := [chacha20.XNonceSize]byte{1}
+	subKeys := make([]byte, size)
+	c.xorKeyStream(subKeys, subKeys, &nonce)
+
+	block, err := aes.NewCipher(subKeys[:32])
+	if err != nil {
+		panic(err)
+	}
+
+	c.block = block
+	copy(c.keyT[:16], subKeys[32:])
+
+	if !hpolyc {
+		copy(c.keyM[:16], subKeys[48:])
+
+		for i := range c.keyNH {
+			c.keyNH[i] = binary.LittleEndian.Uint32(subKeys[64+4*i:])
+		}
+	}
+
+	for i := range subKeys {
+		subKeys[i] = 0
+	}
+
+	return c, nil
+}
+
+// xorKeyStream XORs src with the keystream of XChaCha under nonce, writing
+// the result to dst.
+func (c *Cipher) xorKeyStream(dst, src []byte, nonce *[chacha20.XNonceSize]byte) {
+	s, err := chacha20.NewRounds(c.key[:], nonce[:], c.rounds)
+	if err != nil {
+		panic(err)
+	}
+
+	s.XORKeyStream(dst, src)
+}
+
+// xorMessage XORs src with the keystream of XChaCha under the nonce
+// m || 1 || 0^56, writing the result to dst.
+func (c *Cipher) xorMessage(dst, src []byte, m *[BlockSize]byte) {
+	var nonce [chacha20.XNonceSize]byte
+	copy(nonce[:], m[:])
+	nonce[BlockSize] = 1
+
+	c.xorKeyStream(dst, src, &nonce)
+}
+
+// Encrypt encrypts src with the given tweak, writing the result to dst. src
+// must be at least BlockSize bytes long and dst must be the same length as
+// src. dst and src may overlap entirely or not at all.
+//
+// Linux uses a 32-byte tweak, holding the sector number and, depending on
+// the policy, the file nonce, but any length may be used.
+func (c *Cipher) Encrypt(dst, src, tweak []byte) {
+	c.crypt(dst, src, tweak, true)
+}
+
+// Decrypt decrypts src with the given tweak, writing the result to dst. It
+// has the same restrictions as Encrypt.
+func (c *Cipher) Decrypt(dst, src, tweak []byte) {
+	c.crypt(dst, src, tweak, false)
+}
+
+func (c *Cipher) crypt(dst, src, tweak []byte, encrypt bool) {
+	if len(src) < BlockSize {
+		panic("adiantum: input not full block")
+	}
+
+	if len(dst) != len(src) {
+		panic("adiantum: output length does not match input")
+	}
+
+	n := len(src) - BlockSize
+
+	var m [BlockSize]byte
+	copy(m[:], src[n:])
+	add(&m, c.hash(tweak, src[:n]))
+
+	if encrypt {
+		c.block.Encrypt(m[:], m[:])
+		c.xorMessage(dst[:n], src[:n], &m)
+	} else {
+		c.xorMessage(dst[:n], src[:n], &m)
+		c.block.Decrypt(m[:], m[:])
+	}
+
+	sub(&m, c.hash(tweak, dst[:n]))
+	copy(dst[n:], m[:])
+}
+
+// hash computes H(T, M).
+func (c *Cipher) hash(tweak, msg []byte) *[BlockSize]byte {
+	if c.hpolyc {
+		return c.hashHPolyC(tweak, msg)
+	}
+
+	return c.hashAdiantum(tweak, msg)
+}
+
+// hashHPolyC computes Poly1305(K_H, le32(8*len(T)) || T || pad || M).
+func (c *Cipher) hashHPolyC(tweak, msg []byte) *[BlockSize]byte {
+	if uint64(len(tweak)) >= 1<<29 {
+		panic("adiantum: tweak too long")
+	}
+
+	var hdr [4]byte
+	binary.LittleEndian.PutUint32(hdr[:], uint32(8*len(tweak)))
+
+	m := poly1305.New(&c.keyT)
+	m.Write(hdr[:])
+	m.Write(tweak)
+
+	var pad [16]byte
+	m.Write(pad[:(16-(len(hdr)+len(tweak))%16)%16])
+
+	m.Write(msg)
+
+	var out [BlockSize]byte
+	m.Sum(out[:0])
+	return &out
+}
+
+// hashAdiantum computes Poly1305(K_T, le128(8*len(M)) || T) +
+// Poly1305(K_M, NH(K_NH, M)).
+func (c *Cipher) hashAdiantum(tweak, msg []byte) *[BlockSize]byte {
+	var hdr [16]byte
+	binary.LittleEndian.PutUint64(hdr[:], 8*uint64(len(msg)))
+
+	t := poly1305.New(&c.keyT)
+	t.Write(hdr[:])
+	t.Write(tweak)
+
+	var out [BlockSize]byte
+	t.Sum(out[:0])
+
+	m := poly1305.New(&c.keyM)
+
+	var h [nhHashSize]byte
+	for len(msg) >= nhMessageSize {
+		c.nh(&h, msg[:nhMessageSize])
+		m.Write(h[:])
+
+		msg = msg[nhMessageSize:]
+	}
+
+	if len(msg) != 0 {
+		// The final NH message is zero padded to a multiple of 16 bytes.
+		var buf [nhMessageSize]byte
+		copy(buf[:], msg)
+
+		c.nh(&h, buf[:(len(msg)+15)&^15])
+		m.Write(h[:])
+	}
+
+	var sum [BlockSize]byte
+	m.Sum(sum[:0])
+
+	add(&out, &sum)
+	return &out
+}
+
+// nh computes the NH hash of msg, whose length must be a multiple of 16 and
+// no more than nhMessageSize, writing it to out.
+func (c *Cipher) nh(out *[nhHashSize]byte, msg []byte) {
+	var sums [4]uint64
+
+	k := c.keyNH[:]
+	for ; len(msg) >= 16; msg = msg[16:] {
+		m0 := binary.LittleEndian.Uint32(msg[0:])
+		m1 := binary.LittleEndian.Uint32(msg[4:])
+		m2 := binary.LittleEndian.Uint32(msg[8:])
+		m3 := binary.LittleEndian.Uint32(msg[12:])
+
+		for i := range sums {
+			sums[i] += uint64(m0+k[4*i+0]) * uint64(m2+k[4*i+2])
+			sums[i] += uint64(m1+k[4*i+1]) * uint64(m3+k[4*i+3])
+		}
+
+		k = k[4:]
+	}
+
+	for i, sum := range sums {
+		binary.LittleEndian.PutUint64(out[8*i:], sum)
+	}
+}
+
+// add sets x to x + y modulo 2^128, treating both as little-endian.
+func add(x, y *[BlockSize]byte) {
+	x0, x1 := binary.LittleEndian.Uint64(x[0:]), binary.LittleEndian.Uint64(x[8:])
+	y0, y1 := binary.LittleEndian.Uint64(y[0:]), binary.LittleEndian.Uint64(y[8:])
+
+	r0 := x0 + y0
+	r1 := x1 + y1
+	if r0 < x0 {
+		r1++
+	}
+
+	binary.LittleEndian.PutUint64(x[0:], r0)
+	binary.LittleEndian.PutUint64(x[8:], r1)
+}
+
+// sub sets x to x - y modulo 2^128, treating both as little-endian.
+func sub(x, y *[BlockSize]byte) {
+	x0, x1 := binary.LittleEndian.Uint64(x[0:]), binary.LittleEndian.Uint64(x[8:])
+	y0, y1 := binary.LittleEndian.Uint64(y[0:]), binary.LittleEndian.Uint64(y[8:])
+
+	r0 := x0 - y0
+	r1 := x1 - y1
+	if x0 < y0 {
+		r1--
+	}
+
+	binary.LittleEndian.PutUint64(x[0:], r0)
+	binary.LittleEndian.PutUint64(x[8:], r1)
+}
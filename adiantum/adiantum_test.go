@@ -0,0 +1,1424 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package adiantum
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// A selection of the Adiantum and HPolyC reference test vectors published by
+// the authors at https://github.com/google/adiantum. The vectors with a
+// 32-byte tweak match the way Linux uses Adiantum, and those of 4096 bytes
+// are the size of a filesystem block under fscrypt.
+var testVectors = []struct {
+	hpolyc bool
+	rounds int
+
+	key, tweak, plaintext, ciphertext []byte
+}{
+	{
+		false, 12,
+		mustHexDecode("7fc7152ae1f5fda4176769aec92bba82a314e7cfadfd8540da7b7d24bdf17d07"),
+		nil,
+		mustHexDecode("9be382c65ac19fad4659b80bacc857a0"),
+		mustHexDecode("820ae44477dd9a186f80288b25070e85"),
+	},
+	{
+		false, 12,
+		mustHexDecode("fa60e3250b4e123a25073b4c3e1c7837db0a16a544c8c77171cedc3e82cbf3fa"),
+		mustHexDecode("e1e64d4ca5c74440c7546ba3544eb81b7f"),
+		mustHexDecode("6063deb6e2abae701abefd8e10c80b83d471e008d56c66cff229b9752e8da6"),
+		mustHexDecode("a56c9b7608b51b213edd21fa6d67b483d646543d92fab95e1a74d95cabedbb"),
+	},
+	{
+		false, 12,
+		mustHexDecode("a52824341a3cd8f705918fee851f357f803dfc9b94f6fc9e190900a904314f11"),
+		mustHexDecode("a1ba4995ff346db8cd875d5efdea85db8a7b5eb25d57dd62aca98c41429475b7"),
+		mustHexDecode("69b4e88c37e86782f1ec5d04e5149113dff2871b69811d71709e9c3bde497011" +
+			"a0a3db0d544f6669d7db80a7709268ce81042cc6abaee56015e96fefaa8fa7a7" +
+			"638ff2f077f1a8eae1b71f9eab9e4b3f07875b6fcda8afb9fa700b52b8a8a79e" +
+			"075fa60eb39b791379c33e8d1c2c68c8511d3c7b7d79772a5665c5542328b003"),
+		mustHexDecode("9e16abed4ba7425ac6fb4e76ffbe03a00fe3adbae4982b0e2148a0b865482748" +
+			"845454b29a947be64b29e9cf0591801a3af34196851d9f74515663fa7c288549" +
+			"f72ff9f21846f53380a33cceb25793f5aebda9f57b30c49366e0307716e4a031" +
+			"ba70bc6813f5b09ac1fc7efe55805c4874a6aaa3acdcc2f58dde34867860758d"),
+	},
+	{
+		false, 12,
+		mustHexDecode("ebe5113a72eb10be70cfe3eac274a448290f8f3fcf4c282a4e1e3cc3279f1613"),
+		mustHexDecode("843ea27c0672b2ad887665b41a29271245b68d0e4b8704fcb5cd1c4de806f1cb"),
+		mustHexDecode("8eb6079b7ce4a4a2416c241dc0774ed94aa42cb6e455027fc4ecabc25c634092" +
+			"382462db6582107f21a5393a3f387ead6c7bc93f898fa808bd31573c7a456730" +
+			"a9275834bee3a4c3ffc29f43f004ba1eb6f3c4ce097a2e427dad97c9779a3a78" +
+			"6caf7c2a46b441861a20f25b1a60c9c4475d10a4d2156a194fd55137d506701a" +
+			"3e78f02eaab52abd83097ccb29acd79cbf80fd9dd4cf64caf8c9f1772ebb3926" +
+			"acd9bece247fbba282baeb5f65c5f1568a52024d45236debb0607bd86eb298d2" +
+			"af76f2339bf3bb95c050aac747f6b3f37716cb1495bf1d32450c75522ce8d731" +
+			"c087b0973030c55e50706eb04b4e381946ca386aca7dfe05c8807c146c24b542" +
+			"28044cff9820081090310378d8a1e6f952c2fc3ea768ceeb595debd8644ef88b" +
+			"2462cf173684c072604f3e47da723b0ece0ba99c51dca5b97173084e2231fd88" +
+			"29fc8d173a7ae5b90b9c6ddbcedbde81735a169d3c7288511016f3116e325f4c" +
+			"87ce882cd2aff5b7d822edc9ae687fc53062bec9e027a1b557743660b86b8cec" +
+			"14aded69c9d8a55b38075bf33e744890611723dd44bc9d120a3a63b2ab86b867" +
+			"85d6b25dde4ac1732a7c538ed67d0ee43babc53d327918b7d6504df08a37bbd3" +
+			"8dd808d77daa2452f790e3aad6497a47ec37ad748bc1b7fe4f701462228c63c2" +
+			"1c4e38c363b7bf53bd1faca694c581fae0eb81e9d91d323c8512ca6165d166d8" +
+			"e20ec3a3ff0dd3eedfcc3e01f59b455c33b5b08d361adff8a381bedb3d4bf6c6" +
+			"df7fb089bd393250bbb2e35cbb4b1898086651e74dfbfc4e22426f61db7f2788" +
+			"293f02a9c68330cc8bd5647b7c7616beb68b26b88316f26bd1dc206b425aef7a" +
+			"a960b81ad30d4ecb756bc58043387fad9c56d9c4f10174f016538d69bef25d92" +
+			"3438c884f91afc2616cbae7d382167744c40aa6b97e0b02ff53ef6e224c822a4" +
+			"a888278644755b2934084ba1fe0c26e5ac26f6210cfbde14fed7beee4893d699" +
+			"569ccf22ada25341fd58a168dcc4ef20a1eecf2b43b657d8fe018025dfd23544" +
+			"0d1515c3fc49bfd0bf2f958109a6b6d72103fe52b7a8324d751e4644bc2b6104" +
+			"1b1ceb39868fe949ce78a55e67c5e9ef43f8f135224361c127b509b2b8e15e26" +
+			"ccf36fb2b755309887fce7a8c89486a1d9a03c7416b32598bac6844a27a658fe" +
+			"e1680430c8db44524eb2a46ff763f2d663361704f806dbeb9917a51b6190a39f" +
+			"05ae3ee4dbc81c8e772788dfd3225ac59cd622f8c4d8929d16cc54253b6fdbc0" +
+			"78d8e3b30369d75df8080463619d76f9ad1dc4309f75896bfb62baaecb1b6ce5" +
+			"7eea586baece9b484b80d45e7153a72473caf53ebb5ed31c33e3ec5ba0329d25" +
+			"0e0c28293951c570ec608f77fc067a3319d57a6e94eaa3eb13a42e09d8816583" +
+			"03638bb5c989987369538eabf1d22f67bda6166ed08bc12593d2507c1fe111d0" +
+			"580d2f72e75edba2559ae00921ac61854b2095736326e3834b5b400314b04416" +
+			"bde00eb76656d730b3fd8ad3da6aa73d980911b70006245af74294a60eb16d48" +
+			"74b1a7e6920a159af5fa551a6cdd7108d0f78d0e7c674dc6e6de7888883c5e23" +
+			"46d225a4fba3263f2bfd9c20da72e1818fe6ae081d6715de86691dc61e6db75c" +
+			"dd43725a7da7d8d71e66c590f6517691b3e339817508fac50670691b2c2074e0" +
+			"53b00c9ddaa95bdd1c386c9e3bc47a82939ebb75fb194a55657a3cdacb665c13" +
+			"1797e8bdae24d976fb8c73debdb41be0b92ce8e01d3fa82c1e815b77e7df6d06" +
+			"7c9af02b5dfc86d5b1adbca873486167d6bac8e8e2b8ee4036223e61f6c816e4" +
+			"0e88ad715358e16c8f4f894b3e9c7fe9adc228c23a29f3eca92839bac286e106" +
+			"f38be3950c87b81b72358e8f6d18c81ca55d579d738abb9e210512d7e0211c16" +
+			"3a9585bcb0710b366c448def3bec3f8e24a9e3a76323ca096296790c810541f2" +
+			"072026e58e105403057bfe0ccc8c50e5ca334d487a03d5644909f25c5dfe2b30" +
+			"bf2914298b9b7c964707864d4e4df147d1102aa8d3158cf22ff43adfd0a7cb5a" +
+			"ad99394adf60bef9914ef594efc55632338678a3d64c297ce8ac06b5f5015c9f" +
+			"02c8e8bf5c1a7f4d28a5b9daa95ee74bf43de91d28aa1a8a76c86c19613c9e29" +
+			"cdbeffe01cb867b5a446f8b98aa2f67cef23730ce9720a0d9b40d8fb0c9caba8"),
+		mustHexDecode("cb78879cc713c130dd2c7db297ab066947878a122b5d86d72ee67a0d585de701" +
+			"780effc7c5d294d6dd6b381fa4e33de7c58ab5be65112be12b8e84e8e0007fdd" +
+			"1515abbd2294f7ce996ffd0e9b16ebeb24c7bbc6e16c57ba84ab16f257d6429d" +
+			"56925b4418d4a21b1ea9dc7a1688c44f6d779a2e82a9c3eea4ca051b0edc4896" +
+			"d050211f46c7c77053cd1e4e5f2d4bb286e53ae61dec7b9d8fd641c6bb004fe6" +
+			"02470773506bcfb29e1c01c909ccc35227e663e05b55604d72d0da4beccb725d" +
+			"374af5b8d9e20810f3b9dc07c00210149fe68fc4c4e1397b47eaae7cdd27a84c" +
+			"6b0f4cf8ff164ecbec88330d15108266a73d2cb6bc2ee4ce4c2f4b460f6778a5" +
+			"ff6a7d0d5e6dabfb5999d81f30d433e87d11aee3bad03fa7a55e43daf30f3a5f" +
+			"bab047b20860f4ed35230ce94f81c4c5a835dc99523319d400018d5a10823978" +
+			"fc7224634a38c56ffeec2f260c3c1cf64d997a7759fe10a5a135bf2f15fa4e52" +
+			"e6d51c889075d5ccdb2ab1f0705489c7eb1d6e6145a35048cddb32ba7f6bafef" +
+			"50cb0d36f7293a100273ca8f3f5d8217919ad81515e3e14143ef85a6b0c73b0f" +
+			"f0a5aa6677705e70ce17846845392c25c6c15f7ee8fae43a47517b9d54849804" +
+			"5ff75f3c34e7a31deab76d05ab28e42cb17f08a85d07bffe3972448751c573e4" +
+			"9a5fdd46bc4eb139e478b8bfdc5b889bc13fd9d0b35adfaa536a916d2a09f00b" +
+			"5ee8b2a0b473071dc83384e6dae6add6ad91014e1442342ce5f99921561f6c2b" +
+			"4ce3d59e04dc9a16d154e9c2f7c0d5062fa1382a558823f8b0db8732c94eb00c" +
+			"c5057858a12e757568dceadd0c33165ee7dcfd4274beae603c374b27f52c5f55" +
+			"4a0b64fda201659c279f5e87d5958866098442ab00e258c39745f193e234373d" +
+			"fe938c17b9796506f758e51b3b4eda3617e356ec260f2efad1b92b3e7f1de34b" +
+			"67df435310baa3fb5d5ad8c4ab197e12aa83f1c0a1e0bf725fe86839ef1abeee" +
+			"6f477919edf2a14ae5fcb558ae6382cb160b94bb3e0249c43c33f1ec1b11719b" +
+			"5b80f16f881c0536a8d8ee44b518c31462ba98b9c02a7093b3d81169951d437b" +
+			"39c19105c4e31ec21e5de7debefdae994b8f831ef49bb02b666e62248de01b22" +
+			"59ebbd2a6b2e37179e1f66cb66b4fb2c36225d7356c1b027e0f01be4478bc6dc" +
+			"7c0c3d29cb3310fec3c31eff4c9b2786e2b0afb789ce6169e7003e92ea5f9ec1" +
+			"fa6b20e2412382eb07764c4c2a9633be89a9a8b99a7d271848237046f387a791" +
+			"58b874baedc6b2a14db6439ae1a241a535d3908ac74db7880be3749f84fcd973" +
+			"f2860cadeb5d70ac6507148e57f6dcb4c2027cd689e28a3e8e083c1237afe1a8" +
+			"04115cae5a2b60a0033c7aa23892bece09a25e0fc2b2b506c297979b092f04fe" +
+			"2ce7a3c442e9a340a552072c3b891aa528b19305980c2f3dc6f583ac241d289f" +
+			"32664d70b7e0abb875c5f3d27b263eec64e6f770e7f8108e67d2b3876940069a" +
+			"2f6a1afd620cee312ebe589777d109081f8d422934d5d8b51fd72118e3e72e4a" +
+			"42fcdb19e9eeb922ad5c07e9c807e5e995a20d3046e2655101a57485e2526e07" +
+			"c9f53309de7862a9302ad386e5462e60ff74b05fec76b7d15e4d61973c9c99c3" +
+			"41652147f9b106ec18f83fc738fa7b1462796a0b0cf52cb7abcf63496d1f46a8" +
+			"bc7d4253756bca38ac8be7a1a192196b0d75805b7d358670126be53ee585a0a4" +
+			"d6775e4d245784a9e5a4bf25fb36653b813961ec5e4a7e105819135c0f79eccf" +
+			"bb5f6921c3a75aff3bc7859b47bc3eadbf5460b65b3ffc5068837624b0c33f93" +
+			"0dce360a589dcce952bbd00b65e50f628216aad2ba5a4cd067b54e841c026ea3" +
+			"aa225496c8d99c581563f4981aa1d911642556b5038e29857588d1d2e4e62748" +
+			"139c2baafbd36e2ce6d4e48bd9f7011646f95c887a939e2da6eb012a72e47fb4" +
+			"780c5018d38e65a71bf9285d8970962fa1c29b34fc7c276393e6e3a49d17977e" +
+			"13799c4b2c23912c4fb11d4bb4616ee83235c3417a5060c83ed83f38fcc2a2e0" +
+			"3a21258fc222ed0431b87269af6c6dab2516958792c7463f47056cada0a61df0" +
+			"662e011ac3bee4f651eca39581e1ccabc171650ae653fbb85369ad8bab8ba7cd" +
+			"8f150125b11f9c3b9b47ad3838896b1c8a33dd8a0623060b7f70be7ea180bc7a"),
+	},
+	{
+		false, 20,
+		mustHexDecode("d381721823ff6f4a2574290d518a0e13c1535d308dee750d14d669c915a90c60"),
+		mustHexDecode("659bd4a87d291df4c4d69b6a28ab64e2628197c581aaf944c1725982af16c82c"),
+		mustHexDecode("c76b526a10f0cc09c1121d6d21a678f505a3696091369857ba0c14ccf32d7303" +
+			"c6b25fc81627375dd00b87b250947b5804f4e07f6e578ec94184c1b17e4b9112" +
+			"3a8b5d50827bcbd99ad94e1806239ed4a52098efb5dae5c08a6a837715841eae" +
+			"78949ddfb7d1ea67aab01415fa672184d3412aceba4b4ae89562a955f080adbd" +
+			"abafdd4fa57c1336ed5e4f72ad4bf1d0884eec2c88105eea12c0160129a3a055" +
+			"aa68f3e99d3b0d3b6decf8a02df0908d1ce288d42471f9b3c19fc5d67670c52e" +
+			"9cacdb90bd8372ba6eb5a55383a9a5bf7d060e3c2ad204b51e19380916d2821f" +
+			"751856b8960ba6f9cf62d9325da9d71dece4df1bbef136eee37bb52feef8533d" +
+			"6ab770a9fc9c5725f28910d3b8a88c30ae234f0e13664fe1b6c0e4f8ef93bd6e" +
+			"15856be360811d68d731878909abd5961df36d6780ca07315da7e4fb3ef29b33" +
+			"5218c830fe2dca1e79927a605cb65887a436a267928ba4b7f186dfdcc07e8f63" +
+			"d2a2dc78eb4fd89647cab891f9f794215f9a9f5bb840414b66696a72d0cb70b7" +
+			"93b5379605374fe58ca75a4e8bb784eac7fc196e1f5aa1ac187d523bb3346299" +
+			"e49e31043fc08d84177c25485267112767bb5a85ca56b25ce6ecd5963d15fcfb" +
+			"2225f413e5934b9a77f15218fa165e490345a808fab34192795033cad0d74255" +
+			"c39a0c4ed9a43c86809f53d1a42ed1bcf1546e93a465998edf29c0646307bbea"),
+		mustHexDecode("e033f6e0b4a5dd2bddcefc121efc2df28bc7ebc1c42ae8440f3d97192e6da238" +
+			"9da6aae196b908e80b70485cedb59bcb8b40887e6973f71671bb5bfca3475da6" +
+			"ae3a64c4e7b8a8e7b13219dbe301b8f0a486b44cc2de5cd26c77d2e818b70ac9" +
+			"3d53b5c45cf08c06dc90e074471b0bf6d2716bc4f197002d6357441f8cf4e69b" +
+			"e07addec327342327f3567600dcf10526122538d8ebb337659d910cedfefc041" +
+			"d533296ada46a451f0993d9631ddb5cb3e2a1fc75c79d3c520a1b1391bc60a70" +
+			"26399507ad7ac969fe81c7880838afad9e8dfbe8240d22b80eedbe37537ca6c6" +
+			"7862eca359d9c69db80e6977842d6a4cc5d9b2a02ba880cce91e9c5ac4a1b237" +
+			"069b303267f7e7d242c7df4ed4cba01294a1348593504b0a3c7d492501416b96" +
+			"a912bb0bc0d7d0931f7038b821eef6a7eeebe781a413b487fac1b0b5378b74a2" +
+			"4ec7c2ad3d623ff83442e5ae451363fefc2a174661a9d31c4caff0096226661e" +
+			"74cfd6683d7dd8b7e7e6f8f00820f7471c52aa0f3e21a3f2bf2f9516a8c8c88c" +
+			"990f5dfbfa2b588a7ed6740260f0d05b65a8acea8d684634269d4fb19a8ec01a" +
+			"f1edc67a83fd8a57f2e6e4bafcc63cad5b19502f3acc064604513f9197f0d207" +
+			"e793897eb5320f03e5589e7472ebc238000c917269ed7d6dc871f0ecff80d91c" +
+			"9ed2fa15fc6c4ebcb1a6bdbd7040ca20b878d2a3c6f3799cc727e16a29ada403"),
+	},
+	{
+		false, 8,
+		mustHexDecode("9fd336b18507df1901eaf95268bfcee7d049f3ba58fb87189fca24ca61a3f0da"),
+		mustHexDecode("eac6725e66d4c7bda16eab09b55839ae40"),
+		mustHexDecode("c7d67365cbf3f53eb9a7bfb154cbac01eeb594174092fdad8fdb27223db10bf7" +
+			"a74670d031dbf9dbb9b9404a0aba776f35369eeb68e29ed7efc25e210db3b087" +
+			"d643356e22a0b7ec26e07d48f55d58d329b71f7ee95a02a4b1de109fe1a85e05" +
+			"b6a259ca3ebcd194094e1b37299c15ef8c7253be6f252c6888080c00807a8564"),
+		mustHexDecode("e846458a52c42935edc303b678407ddc37eef5215ebed5f80977019e2f46d91f" +
+			"7a12949b485b8bc70faa3455e443590aa329eafceba74abd4061b49f949c218a" +
+			"63e6a539ff167d9b04f02eade067bcb3ad9191db85f43fcf39b104fda8e12f80" +
+			"ff6ad4b5330d6a75e7d1a575f09982f3ecdd7fca79442abc8f03bce97036f5f1"),
+	},
+	{
+		false, 12,
+		mustHexDecode("504ac8940113d30cd9aaf34cf83f2c399eecc6a897f28e7394b84c400328afc2"),
+		mustHexDecode("9eaa0b7f122ae3df0503931bdac8d7988af9df63b1cd15aa028e41a0751d170e"),
+		mustHexDecode("e28a35a7100d656ead77ce07bb678303"),
+		mustHexDecode("1bd78be5997c26ad16302f1edc72ce14"),
+	},
+	{
+		false, 12,
+		mustHexDecode("39121af86e18114af95aa7eed5998253a82d586ae0e2520b5ec48f2dd68fcbc1"),
+		mustHexDecode("59584412e7d37d0fb53eff16c61f2734c5b9913f6161869d4513d9cf696cdd83"),
+		mustHexDecode("8de4729d239caed4774d260dfe35d49510504bd0ec2c58eeb935e91df988e3"),
+		mustHexDecode("7972b878f31b17d28605b232a1b01763e4fc20138874f501ce009de75b92da"),
+	},
+	{
+		false, 12,
+		mustHexDecode("838aa7d63110b167bfedf6931d2ec94c18ab982ced5a1430c9e04b67b50d6cb4"),
+		mustHexDecode("799aea9210d80b6ab4cf4929db50ce54f293091dccd61af7804974837650af2c"),
+		mustHexDecode("ce7a3cde954b2f63395f508739fb5e4217cdff5e5c7767219caeada6bf89c27e" +
+			"99feec253d947fcf4352ad879d125408c7b8e25c4e4fc06e1cffc13066d42e60" +
+			"e6c6faf5c1c8b1d08983130035523f08b76277bd9b6635d3572494e62c2e9eda" +
+			"44f96bae0bd79f55864e1b4be232209c0315d16e2256c75ce451bcd821d0c419" +
+			"18ce6273ad0c31a666ed1a7d54cba47cebeddf80028d264bd497139debe70b09" +
+			"994de6bab53837ff7dc5f2b98aa8004dff43b422c00b72ea5b3ec3dbc8a7b050" +
+			"48906d8af73062d83acff9cd6a67ab55647064da23ed5826f6902a6e5a98d48e" +
+			"546a9d1d29ef84fa3cba2b5e34457dfc454f13b7ddd72bb71ab4865ecf3554c3" +
+			"b60de7cd4644a4c4482fd0fe72e1f0921f53e4954503b99ec8e0cc049cdd1919" +
+			"a3cf87ecf1840e65bcc9e7122645e62e9ee4796ca004dbca729729fc2043d037" +
+			"64f3339014cf00a2f91ba49b304bd07a0d522b1ad1eae8848b4461b1fd4ddbf7" +
+			"0bd5553283b271428a7f80c6ff9416dfb5fe59e7b5a4589c88d2b4638bcb9b9f" +
+			"c65c941b418ba266da0dbc9d3a59d866d067fa506fe6d07ad10623420e142065" +
+			"2073aa34aca76de52328a0cf573e19003a852f9d7915294c9ff73da3243ca068" +
+			"c64c445a87e7bc0fbb19ea3e37c43bcc1eddfafa710e37d53ac51e905ef0131f" +
+			"7a35b26329b627f20a575c43e2c7024ac656f0c1a7d8c63c81d45e165e2a7777"),
+		mustHexDecode("b114a86350cf03717a74e0517a0051ac1d0a0e45fb471581c672610bc810e7e2" +
+			"0118c2bf8539d3b66648df1d90fbf12ae80aafde8fa0451e7020b0361281c4ff" +
+			"b31e38f94126414349d1c5a6eb7be141174992009356bb4b6675ff826bf6d903" +
+			"bffaaccc112fa28fdf4b12d49ab9f9143a01362ff4bacdfa5b6016e21c2bed9a" +
+			"017e765e49f9845a13a0b613f8b8eb0be930709d6713d29ba9465e44ed81b927" +
+			"6922f86c886898f9fde90b0054a58b85a54d050431980e3b5b3ea056f8e81697" +
+			"1951a37cebe94f7641b7c080fbb99cd9597f9cb84c9e5b801fa78bd0df531a5b" +
+			"fa23ef44b31f422bdbc9a59a283770685fb8ad247e0f73f3e90507135dc5f418" +
+			"3f6ce7b6587aa67b3f94de1dae41dd21111ee72abc005e2c318d9fd02c2227e7" +
+			"5ec5884bcf904afb701d5e820b062fec244b9f8c5ff78419999e6d9f93832b87" +
+			"0cc59c36a50cd042557855806cfeb22b870a57687483494fa4fa2a30818d9182" +
+			"2c5095bf0404d547acd9dfcda2b41d8ec912f36cf34aa9e4ce7c6d1af869549e" +
+			"9b726e5d7eda229c1213b1b269de995419b485c2f9ca8aec543b20890b2b14af" +
+			"02823d183121bdaef84038fc1e67e2a13fccc022b075d7da9341b79779f592fb" +
+			"b2dedda10aa258ed081ca99d26f52ddf6318580a6cdfa470602ca23cdc82ce7a" +
+			"c8265edea4d0b3aab04026e9706bbee46a65c352e8a6f7b953c9b6aa4dd15b23"),
+	},
+	{
+		false, 12,
+		mustHexDecode("ebd9864ebc6775f8ad7ab3e32887c85386252a6364c7b755806cf2fd656e1e20"),
+		mustHexDecode("bd85d280ca946988d057aeb212021094d8c61ed37ddb572292ca99adf206fbca"),
+		mustHexDecode("dfefdb7063708c8e337a08e74c18e6d7c79decc4889fad832810d0eacfa167d3" +
+			"6e36a965429fffcaf56a1c28535f1e81390665f10aedb7eda78d6da842f70f43" +
+			"ec82268659875cfcdf8026f6aa77ed616585299b78e16e653fff9e0ced4ae83f" +
+			"41cf98a99232d08b544f77a1c3d94c8be36bc4dcde54a4e27e0858552d90029f" +
+			"b8bcd05b3ff256398980dfc3991a0f0ef3bc72f3f8289e573ff767b09f31fd35" +
+			"6b85daaa4a1377d6cfcd4ea67bcd1a8ad996d8784ffc1569d9b51fbe2ef8d5b6" +
+			"6b00819e4c1bdc907d970ff5fafcd14f003b9581bc9d85d8c4d1f6073ee143cf" +
+			"7035ea6c1153f0b1da5c87d8b3380d2968439273865a7b80d7edffa1d04f9e78" +
+			"496b26242e0c0d70eb1634aff088852d3a73a9b862b7e9b14c26076d3d76237c" +
+			"ac58bfddb38f79594847e270f4fcf2e18b126aceda6ef9275ed9c244d9d77a69" +
+			"4fa676dc96a9ae6f32705ad0c0afcaa71597473232e323e64415f21f50e80226" +
+			"283434c256b49772fc0877c488894e6cbb0420ff37d8b9ff5fdf424e2379c981" +
+			"7467cb19dc04e3a8a7b6ce8fced28b2f81374379b2d12392e3a0a13e8da44296" +
+			"8d6878547efe92e3dcd8c5cb6cc6fa373fda3e8bd1f101f00efd96175da7e65f" +
+			"91e30e7eb9fbb916f2e7f87a7fe51cb56f98e697be46dcfd767c5fac7815666b" +
+			"8f774778a225d829debd9bbb232cdf6fa9fd398dc0894737abc5bc02d0782e22" +
+			"f091f8cf12aedba48e4b7ee9e37d43b829230ad505c3e5c93a23f4d8a95c0fba" +
+			"fac6be789b7eea9e95b0b9602975576b4a09cf208be07cbb368ec576cbd82e55" +
+			"0b113eb30e84c9e53742bebd707294bfde0e794bf3a0054d52f31ac5d3dc9b21" +
+			"d5b0b94fd922a07207d65f3bc92cc9711ab3aaa592abc2a364f43c7bc370815f" +
+			"5cce2148b26636364329739705c7e20a2fa184a70de3d93043f0a47e9eab9d53" +
+			"a191f1838837dabd55c213c1434775192f87cd305a0adfabd0fc8b4e00c60fee" +
+			"43f4951acab97c460d32fda8024432924ef2b1c5c9f6ad9ff00cd4093c8f2be4" +
+			"f1b0bf2d408709d6288a58926df13015cb9c8efd4a65d0f934aa38c03c412c18" +
+			"776a19fdcb7996352f0abaf46bfcf25f74a64968589c3379b424c0d28deb6428" +
+			"720d37325542574b8d6e66c4ce29e6e4287750422759df71e6dbfbbc9fb0b5ae" +
+			"23059e6264fe465ec1091a31fbd90a37693b540b84b9e8f2eab80d515d354cf2" +
+			"ec727480fcc90aa567a77555b77aced2c232b6fa9384a38b6b9e3658b22e8d2e" +
+			"7f2c5325ffcf8eccce3a3ae6ae33ef04379d74e22ea4a36d3a1d71479104c084" +
+			"bd1339a3718bc85a027368338392658b475b0284e17a15b8f106d17f6f834399" +
+			"6c76d65b10615315f7d44723f1ef0cd50f2cbcba5566a8ba9b0bcc0674cbd616" +
+			"76eede7f1cb5e42117adcb2dd007efd8a4f9dc9213d9a4aa6fbc4a9fc5ef5908" +
+			"b0b65cfda752ff686d93b90cf31aebcc4d0d5a914971362b6b99de1df30e404f" +
+			"f42130ea5e529e8ef61e2dbd26d34a2a0f255a8a8d04602897fe7b5c76b25866" +
+			"12b360ec414ccaa80bd0296998dd34469ce8a929fe196548d57a51fbc8192b6c" +
+			"e0eb4ff78699c4b5196d290b887081785ba1386bd2f7373fea0daf0ca06c6d28" +
+			"66c435948185a4c28da31341264e846b5ac71dbcf140e40bcaa9f0cdbbaebafb" +
+			"8ddba7204ed5c322cc91872df8ca662cb3af147992f4f287b503ef6dcf43e888" +
+			"a43de49c1c7916c5034b087bd3398f43cb16ef0cf2a4dfc51f9fb25fc78e9152" +
+			"22d0354418ece767471d02dd2f6cc1050e2031dd6751d3546c05bd47b88b62b8" +
+			"55f4a79ee780a1ce58a64c5eb28a7304216e974e7bb5e7f2b46bf54159ecf185" +
+			"cc6b87fec80107957fc6a49b795ff85b8d5a2c757f7c012a085761428a13c9a1" +
+			"36dd54a54560ee838f17053d77da8e50ae826efbebd3a14c7cf5d519c99d20d0" +
+			"4107e464bdce2f847d4ab1ba698254b5fd8833493e4b63208ce0e9698ba5538e" +
+			"ef3dcb204531f375fc9687faca28ac741471e2ffa163392389e512d4222b16ee" +
+			"b359e1e10f91d882b7cdb3513f2aeb99eacb124a50011edda2911e1336e74db2" +
+			"49f0cec37675f7782597121e07015c8acc3b690e475648a2b3073a3d0e4b5333" +
+			"2011c3844fa540292ebe72ce46dfd7ee3c603d19aacfaba2747225398f1410b3" +
+			"025319fd44e8eaeedf62d81e1999e1184e566c58678724a02add925f993e87c7" +
+			"a9ab1c13b152062e2610bc40f5efce62c7cc4c6cef03e247837eb3cd073065d8" +
+			"9531b6390b2d8f8ea2865040fe3a41cf8a6db7663701cc33d893bf22ae67737b" +
+			"a370a32252acd89c1a133bdd115aa9686995d49251f09680f9b3fe6ef88f5526" +
+			"b4cf75a9769402b81e3e62219a917bd3445873a2c5ad5f9593b78c0c4bbdf0b5" +
+			"04446529137779912717fb3ae0180dccb6cefcb09062091293e94805a5c805c6" +
+			"db2f96905d41df9e2af9c07d851766b7ce8254da8d212787a812836870bdda84" +
+			"daf05ed48e5b6cdfe8ca2a210ca43102995668d64004fd1bceaef1c12946350c" +
+			"34f082c426110a0ce12d097c575e44b77b6fca5067680882f3ff16e5463623b2" +
+			"2027616d00cf29390f921314fc306821ec71eb3c5dedefb9e0a758ffe4ffb0d3" +
+			"c1d97cf4d1e4cd02010d5ef7fdb7bb861dd35b4e26d2159afbac42b010d254f7" +
+			"c93b566ecfa76bd53d6542b1cc49fbc1a68009be40d4adb6beaf912eb90ebc3b" +
+			"1178c579b907dbf5537cbad2eb5909ccb336565ef50e8a29ffb417acd4c277b6" +
+			"91cd07c1217f0feeaec02431815eadcdff974199c4ef94d82058fad95fb2dbcc" +
+			"05ea524e246d85d488fd925514876ccfd63d46b7112f581847a770aff93514e4" +
+			"d59769b589be9efd5941c711288567f1158276252389fd99fa79c5f8efee361c" +
+			"f49e58a46cfaf8093be018cf25b4eb0cac8d99e658b0dd2703e66f663fb9d7e6" +
+			"25ca151451f2c76406e753113a3551c5a2945304dc209b82ca2c87212cd7687a" +
+			"8b5421de391dbc0e28b4e14ee60447add76ee67b662dce7977a42e5fbfe93295" +
+			"9ea002d6cd4f9c1704feb0c38a1fc488602ef37f0d590545730f94305ce53222" +
+			"71011a553d8d055d2f157067606ac396a8cb68fdbdb417b583c4231d792762ba" +
+			"6e3a497387c31bf1cb2a4dca91fd06559d07202dc4cbcead97db2c9332b9563c" +
+			"3d713b86baed34e8da9604be92609409d4a8d4dfdd035c06b69e48d613de6db2" +
+			"e13df3111078559b45d39b8ac6e7e9b7f57c33675607eaaf888d8838ed49bff0" +
+			"f81e2c2637e49c0a9ab5a40bd01551859dbb676ea4e7d50c10addf0927d733cd" +
+			"1461a860adb7cf52ae7247bb97c5b24a8c6c5a7369bf0c453dbec75dc656d78d" +
+			"a83a34d0728792566aaef837d4b5e721e87654911d6242491c3313908c7678fa" +
+			"81be9896bcbb4fd6947e26ab554151f700fe7a3693a0cbe22b731359656ee733" +
+			"9008445701ae65771c724c0563089e1959ebd8884df7efcd9fba7e19768683ad" +
+			"2914fd5c83d81bbe40ae9aaa7ff3ab9c350f883d5106895c2d1c634d2579cb2f" +
+			"f7da7e4645fc4594ac59fb3b67031cbc672c383362ae6959b205b903d7416d1c" +
+			"023e14ac9e4c8ade1cf9e15ddc5300d3486174ea079949a5ce8021f71bc7975e" +
+			"ec90697dac5afaa7902730adb645e3bd8627ebdce202d7453c5b62ebd761a1aa" +
+			"a0dea1014c185b4b046e874f314e83285a5e7de8e4bf317fd6bd86ecb4958514" +
+			"4ea85a664a6cc8c89f45227263eb8014c367350368f4b37336adfbff6f6aaf22" +
+			"62cbd0de2d5a01110526d4a0abc45f059588fd6f9f264b9c5850e80a599f5ed4" +
+			"64ac5f181415bdaa146f2ccfb9d6b7b97e8eb2c772660e6a7595c71e4ef5881e" +
+			"746ae613908ef3ddab3815a1a0cde94d74d902d615b16ed8b8c5329f7f4a843b" +
+			"817505e678f286df6740d58eb4092ed7836922febf379a3d53cc921fd7408247" +
+			"9566307b648e3e05491f4333d2e2513f220ff759a40638671d1d06b84b7479f0" +
+			"7cf3082c6d7b0b72fa2ac4f236c54e912d13ab116be4f8841ff6f6136bfa2eef" +
+			"5bf1880d58b7b016afee73a0cf7827e65342abed9d740d9e355089edaa0b9c2f" +
+			"a1522f392c568d244b2bbe63e69dab58e57247496f5fa04fb1d6964ed01928f7" +
+			"63b088b3e4db8f4e8ddf2fd59da4753a6e23fd0e1fc893c0286ffe6b198953e2" +
+			"bff564d7e3d131318c8fb1fe6c379c8cec4e68791ed9441244b032b1a0284ef0" +
+			"e7e268c1e0f94d0f9be9f91aad0f0a95bba88e1b61b1154a4057e9d528fedc2d" +
+			"b3e1cb1df101ec135dd39f2e7e6e2dc8ecb2d91f46e00ce6d958b93e5d36adff" +
+			"e7dcd7364e828fb7b6c38c64c022df08387b548df21cdf8076eaa0f5e22a2819" +
+			"964027ecb56a418d4a72681c9ddf7d6e9d4b8923f3bad1f28baf8e30baee3e3c" +
+			"df828a2517e16515b889d03b2225d0e6177d7595452cc425c29c0f22d84b9d4d" +
+			"cd9211fa917a7b3b0aa059466cb69f4255bccfb6f770596c8f274157041a3689" +
+			"9820a2c73e56163f4c7efacb071b67bea8f2144a857f68b41230b247ab7ce3ab" +
+			"e63bcfb3065b2304b615898dfc607fe68ae0333e6b70212343671f9376bc987f" +
+			"ad00f2af7963f0692b5742cdbfa1f183dceac927c7d2916979964af695afe575" +
+			"5ee1ec3264131924d8fb6f3b99a3e23f3d8608909cd51f5f9d3e1c1e055d410f" +
+			"324194fe4a878f3682bdc815afbb242b363fb32922fe3a1690658743663b7887" +
+			"06900e653c384bd07addf509b7a4cc4e7434ddb2dab2a48a17eb688891899ba2" +
+			"02a7cdc0f1fe7f9e167b58dbe42dcb2beaa1ced853bd77c9db055022bcf05ec6" +
+			"98ea6154cbd54127a75c303359f6bce4552b5d513bc578aa5070b4e79c16e9a0" +
+			"f1c11f3faa9c878f5218794a581b8006cd58b37363f83d78096bcd72c59c66ee" +
+			"dbe92a5a40cb29ff95aea6c872a3cf9cf2972a554e6a0fee288827015bc84200" +
+			"ad5523f2c529177f463b3fcb9058cb083c53bd37f27d8fb55f3131ceebdf7647" +
+			"18d5c0380b0d1d1f1a5b47e816298347bf46be157d261c4ea39add1fe6cf00f1" +
+			"a69f77741c4b2012f1554541a033f833e152a57b53069125d1aae9649e432d0b" +
+			"06841940811a190bbde96ff87fea0f287ebbb80aa9e38800228e824017585092" +
+			"1c3ba3edfdbea8bb6054c089bf609bd2fe51bcf56f51fe5867bdea3bedb523e3" +
+			"a2ca84a3d70e0d2270f808a311a0796dbae2ac4cc0a3af61cd1a1b63910090c6" +
+			"cfa456fcde75a595add9c71bb96dd36bb367a6cab0839fa2816204d0d54aa6e4" +
+			"dd530fabe0644ba0ed07c936160e5750dbeb4d0c0784716ee1cfebe46daa85da" +
+			"5d2117c5de14825c33e97e0eb404d20a650f4a8b8dece80e24bd7d68f1a92020" +
+			"7de8071cf15cd1e1bb07b58262a35cfb646914e9e6c74523d03eedeb814908e4" +
+			"8860668356d29243bebb89daa0d3ee4a20bae334d07162807c490452a11327b0" +
+			"69c3e70fdfba7abef0572208b1b2b256a9f693bea4d1fdfbb1eaf86a5022e0f9" +
+			"550f411572ee6bf389af491846b284c84e2756a0812844b010187c6b01050c19" +
+			"2d03a1ec5f674fa9a04f077707ef83ac5761aa109adbe719cdeca7b79e1df1a2" +
+			"c2c76da6c40554911082aa43747505e7f3d7c3171534a2839bdd361ccc516aa7" +
+			"7550173e515a5c2ffac9146371b5f6082c36511a5450db14bd736cd94370f755" +
+			"e9d806562409890fe6ab17204ad3b2a9b466bbedd11682112a4aafe9da45dcf6" +
+			"e1b533b98b9af3bd1bcf626d312f610611f10f88805d2167d7c49dde5c0b253c" +
+			"c89fdb94c2c4613cd79367f99c37f4420122ed12de528d758db92c30259f75cf"),
+		mustHexDecode("9f5fb78dd32a2f6ccc20d97cd83e2c29b64d4294eeb2a519723b03bb5c7493c1" +
+			"33bffe3469f4bb6019ec10543f9c94856a72f709ee35ef4ea27f60a8bbcb2868" +
+			"8b8fe1e93799f5234bda00b908a389fe8029988189135987ff1e5144e108b8ca" +
+			"67a4233e5909f12d5cb422cb4db80ab40017de18097a1753770650364d297d6e" +
+			"ea640b91c8948eae4f24fb16cde47d384a0d43327e4fff6a8b1b5d0a1fdd97df" +
+			"4b8bccad67dd91511e741b111f9d1711346d1536e9d1b91798fc1dba0d541413" +
+			"de387aefd11cddfe7f00fd3817568da567a693f5b13526139560010109e25c3b" +
+			"a788361aa2e5fb4e49bd21fc69caba91952d2513cbccadf6adf0bf85f76bc5d3" +
+			"31ba589fa85e9b06c7d0c2c5f986bcd0e9bc74d10bd8f33f97462a888e08a5c0" +
+			"a3ead66d5c9d516b3240a896f800533ba7e7258d8f840469f9ff9b99845a04d2" +
+			"64bd19de3dd154e39784b8f5cac8d53b7a16fcf7200ec7a411342bed70baae24" +
+			"ed29588604a1e21e8efef53f8890e865653e61375abe20e47fc8151241e42a23" +
+			"1a8a33548cc180f842f661392011b40474abd08ade97749d28e0464534cefea2" +
+			"cd4e7840c29c0b62c7ea7abc2091a044bb0b8f9b632f05c04334e53dd60c6dfe" +
+			"b956eb80936ca30bd8928e835d3816ad28ab144f749e644fa7d8bc84fdacb07b" +
+			"972d8d4c8b8bd7f494c4098f3282e426f931635ddbf96a82d281d39341ccc9ff" +
+			"daeb3d36a3a601457e84474393d32a46ee69f392331fa4a87c2baaf29b1a3ca0" +
+			"ad62abf1d7878b91af09adb06f2d6f862a51146f982eecec128233a50acf9038" +
+			"46710751b7a0bd06647f4bf4258aec9cb0a157bf93fb8a7fb4282b1719d40e2a" +
+			"898daba8005f9c894cd2f25441fb153e9e2d0a87833dd463c97032fb9cbf42f6" +
+			"cb5ed2a227736ff30fa1a0f8996c4b0c62e642b14fcabbf0a5128eef7fed1d51" +
+			"e01d8e21cb7653ef6a7b79adddba45ed84a9a8d6952828153725c63684fb86f1" +
+			"4ddcd6236df0014b0890fcf18ec394700f3c6d78bb20a3666f53bdc11ce7c623" +
+			"2cfa67803da48df1402faaca73f2ee9140ba8cb8a1141beed0fb7347de0005af" +
+			"2f90ca403df74a93f8a466fda89735eed2957afb359a1e6cd062532bdbeca623" +
+			"26c545e1152f858472c846b1fbeb3d652a48a7fe885095275e76ea37968e0dac" +
+			"e4ea5fcd41f8bcd57dcadafe5f3c9736c1a05e6427c2b90c9012a3b59798083f" +
+			"d3bcb6b5c0655dfd058b878ebd3c446e647efcd620961023b6b5966eb39562e8" +
+			"b9697093bb28c25363ab98aa9c2736c818d7ab420e7aff99520b60701c2d9d94" +
+			"25f944d3bf3be657d913c9bade832c7a0539aeb1561a0ee448ab4d8ebe12c867" +
+			"defe709fa80cbbd205620a4dcadf2229bff8b79ce5a2d3c86df86f4c64d17e3c" +
+			"bc5b9a35b3f7f7d6b7998c1650d0ddce6a33b7306e8eaabf78831f514d53f62e" +
+			"c11e92ff8eda7a74703bfc62cd6624274f83989906b79e1d27af6e1340786966" +
+			"d86dab60e5c7c0aa9d3bd1a3fc417d9fc92bbe7c97f4ac220a983138fdf7408c" +
+			"a18693382e104a0f5ff43ba7c3dfd8ccbe387d1899d862a56f599d9f2fce3f9f" +
+			"f1b2b677411e377ae71a9961ac95ac269a79777cd6000767cfe3c5e190515565" +
+			"8ec552de130ab291c0ad668933ae46c93430a794c984fc311541c78305557802" +
+			"2a71f86c610a87fcc8374040051ecd4a8a03658057f4725476bbc85c1d27160a" +
+			"f3446865b935030bd840f529ca83d4c4bc305b24be0c52b180aa1ca422fc853e" +
+			"accedc9c4a9d2b076acc822f6955b44a0fec59f5ad8a41bec658fd7b812cabfa" +
+			"958c33ba8481d9963e350b5bcd263dd43e63efc721acb6d27882b6ea13a3d618" +
+			"81868b40abea5f0c316ec18f9a1954b27ff8710236517f2991aeed1e6d727af5" +
+			"483e167f8019e9e4f5a3918294ebef53b1ece587439a4faac83ad80c821b0b42" +
+			"5065e407f59ae3170cb03ffe83c7b18634ad171dc48c316f032308848c7aa38d" +
+			"1c86dd7e65c369fab45d7ae021b40f4d0685388600d8d9fd81bb0d34dd21ac6b" +
+			"5fa750bfc0eebcb177db09689c324d58be62f20c426ce233c3a521767a8f3f56" +
+			"fa60f3b60abf323bc6246f257df359eb73e4ccf42ed7f31d9dc36beedd9963a3" +
+			"87185eddeeff6eb6359cac6b162d57053adecb23fc6da3dc723f44169fe18a7c" +
+			"66bb2a4c5d503c18f808c923c962c26c98fa4087aeaec5fb3ec805ca3d5c826e" +
+			"17343489a4d9c7644731e303a02e75e5f4689428ddeff37b8ab9c7dc6559d6bd" +
+			"9d80a3608403a659748e70c73cb13df1ed290e050b63b15b754cdf0e7258b539" +
+			"2a77bcd4dee007582282a3cba8002713f6827d90498a917b635877dbebf20d63" +
+			"26ae848fce279cfe459f994f222362a9cf405ec96ed6daf5b1979cbca78b1322" +
+			"83604ba34b2d28bf975f5d140287af7983182025eaafd78c7f1b1e73bb03dd29" +
+			"97535ff13665bffa374f0ca32d3577b937558377f28a260f840cbeda75a2ed23" +
+			"c83db6dbfd5b137169cd221d95f445b7e510bf4f6480f66c6f6300f6eab8875a" +
+			"0560962b9e8993268022291ab197824f7b1b850af7a460c47c30a950e8b9982a" +
+			"f82d87efdbf71db18059e14d199e8bb6bacb34da70716b4e43167642a2b15655" +
+			"869b5a5649c9a80a47682e799abf2afd7e64c4a32472a365998f1efc96dcb28c" +
+			"f6a6092f446df898521751b45af59d21e272f32314c1a9e5dd60ced5dd2d2ef2" +
+			"3a0228b50ceeb9cb7057881d1fc3323608b7c35687dff860b2b9f0ac699ab4f7" +
+			"5e15e53c5c58080d2ee82df4d715d9e9c17f1889c660b820d64eecde2e730729" +
+			"49a0c9f8834560bbbde829659290f239ff83a44c1d8db5e54a021d18b68d40d2" +
+			"00a1813834138faa5c758f44c837295b45f1ddf0874d8af9a7d01947d80288d2" +
+			"e7146d711b94351f18c29c67e8729883d0a733661fbcfc295e02e0c76f7079f9" +
+			"9ea994dd2c7c3b30e78bc91e892cc89b235c898bc82d7cbd9be5379bd34c00a0" +
+			"cff436ee27e9db63be10a6988ec170dad624dcd9afa6524b9951fa03f7cf40e3" +
+			"a3c677cb9a00335ef8d8af62e3f9779b7b2416245af2bbe1b828ad12283046ba" +
+			"5c06fbb07e3df4aa4294f224e0b36e3aeeb3d342ea98529fc75bfafe1b6a7e28" +
+			"e1217094cec7b04fbf6aaeb47fdee6a1669fd8ee782e344d969b178aceadd665" +
+			"bc976d270d32fe96323e761c46db905d8d4eb45a9d1e54277766a5a0eba9a59b" +
+			"e79e134af1d5f0f1e1e444115f203bc11f00f7bcc19b739f0c9989f92dea2603" +
+			"0dfce9633a1ecd9724c381f9be25bb1442168da6978526ddfd5d48a2a6049138" +
+			"5273b7bae62de2ff0fd211a64ef5c5e7caef349b6d1f047d85b27b2c0b6d651d" +
+			"f1f7a2edf10751e512f50680cd528db40d8712696a43d067d11809f597e31480" +
+			"313ef1bb5fbf26ac9aba6aeb1c1f926a70bd0ec8b78bc590e2da3207c123d6dd" +
+			"936041fedfa9dcc8a1bd264f353349ebe8357ba74d4fde93857cfe313541ab8d" +
+			"aff1f1fdae6ca391c95538c1d60ffd298329fc125c97ee9053369e1f11ef0ceb" +
+			"e0bb75bf84d9ea67beaa61e788399a85b970433dd135cc76bca6f6db6de4799b" +
+			"c89731bfadcd128e9cac5861b30d26981d6c7fb1ba190580a43be3f18a15de59" +
+			"0b52ce432cbc9fb6603c62d5f444122752cabac94ea481eb67c067e173b7735d" +
+			"d1a59290f2175c9e2ce98562d367007979e623bc633a7a272d5b931d3d7035f2" +
+			"88ea9e156d63bef2f9e01ac4e88e7d316c0ce765c66f3eb85218eda7fa993bea" +
+			"30cca28c8a8e0a4968d2341888559e44cf4ac8f34b1ff7b45e08fd6c75983e01" +
+			"48a72ad1e081db943a8f74246169c7fc2da865da71f71b2b599189768ffc6684" +
+			"c41bece106f6172329ec4b821165260069f7154883b5395243b2a675c5b00ce9" +
+			"3ee14113fe74e6b2cf74525464c749d356bb6a94a73643c1dbccd1e11beb5649" +
+			"f8a3e7e75efc4ace0b8b3e431b669a562b52f53a73c33b1bac69aa096c879487" +
+			"e3128c7030cab79beb34ebecf4bf911bf640bc15a12024d1750ac26d9fd2917b" +
+			"12dd19de86732a81b84b6aee930884f99f27ecbe672b9b50c25d037521e7a9e4" +
+			"bdefb9cf24210b5425fb91f3878a2a9bda38e90aa62e9ea6067f9df65f7878c9" +
+			"3375b74b371184cabf39bad1d84af79e56919ece1662af850f1bfd40f6d19ab6" +
+			"c132ffb1c218bbea6710855a0a87e1df7726acf1ed74dd1d3a69fadf39c855d8" +
+			"7da0e43eb65c5f659178e16f50311c5725b3890fc2cda523ad950d51ad03cb3a" +
+			"4bb412fa8b5dddc5cdea3aaa48a4d9fc3ffb6685ed67100b40ae150e967e9163" +
+			"ddd8143ea1e3710aa845570c3310bac83a9df610fbfb1f40c29ab940347f3058" +
+			"baa4b281d982bc98209d7b4d950886fd0e1486b3188365d872100866c7ceb42e" +
+			"0b65e03477a7f4fb0aedc83074d61c0fca961c34d66887b24cfce17d87fb6cc2" +
+			"56218811a0dd50a000b99d4231db233bef5c6ed91fb02b1056af2e37a8c95ff8" +
+			"785d992216affcdfe7a57cb5e1c8a7758b4782879fd936f27d95ccb282118270" +
+			"c1b9554847101ee246f9688070ff5b42b02331e38789dc45a2380c09345270a3" +
+			"c649fabe4dfc03060e9eb4db5493225b33d05bedc1b49899616afbecd6a8bcb8" +
+			"ac600dcdef2f0ad4aac03001ca62da1c7000e827e04063e067f53e93e291f838" +
+			"0e2e5148f64da0aebfbf81aa4757a225e80e80677328dd26c5c45f8314d69120" +
+			"cd779acde3ea5aa6966540450c9ed04e28e9296e6726fe03cfd512e978e58cf5" +
+			"feaeed5d2d336686a4e33c8a6e7ecb7b7b63ebddfdc5f1a172e1432749c4b733" +
+			"abddfcd787c56c264cd606535020ea9d719b2e4a7dedb9c4f7986604613547e2" +
+			"f0a026eb89b25c6324c8f2375b115cfb1915e313ba82b2e4d486ea5aee7aa5d4" +
+			"f35cbbbd5e639a9eec13f768d1bf5ed39d11eb89a6c3878ad8a9c70cacc138e9" +
+			"cc641994f09f64bf007ecb32771436b63ef3c2cc3d313f17f6e8c33cec7ca4de" +
+			"0afc63a0c426fd43ce2e00955ea6b37eb1a4bf2bf5904144abfe9c6ba5f198ef" +
+			"b893de2b7d9ee4fa7dac13783367a703d82891b9db091f6db392b63974074025" +
+			"30498c0eadb8b51acb17e23a8dae270dcf22f35d1fa9ddb6e86bd20c062a66b7" +
+			"294a51ae20613fdaee30aff96d32679100da0c2ca872b440f4e34aef96db8d63" +
+			"1f025557150a4b4171f13c643a7fd83755e2e29a3d8fd5658b2d660005bc4123" +
+			"8041191a50fe1afcf71a116c7fbbfc4f452312f3eb97702b81fd7fd9203d1d41" +
+			"40c86fb6cc5b2050693669973e79cde63fd9535ea337ad3d925536f631b46c5f" +
+			"2eccd442f9bd213107aaa0d4cb21efec517c21438b89b95e5068bdafb252e402" +
+			"f239d1b88445b5114b8c62a15638942ba8a121dbab3a323062823e0770298a21" +
+			"78dc7598e8702a0c528d755beab410f825c37b85012c53c8f216c98cd15e15d0" +
+			"c534dcecdf205e7d91c67ed1e3820e9214722bf3a6c294ccf32cc2e1c91714e4" +
+			"b49cf36aa52b06bc094e10aed91330227492c6c47a1c5c5f902750557f35adb3" +
+			"4aab59c1800eb7525a806e8055cc7a31748789a51fa4bf0f90a20de4ecfdf23f" +
+			"c45c63eae98bbb87aa08d78425537205c3615bfc12b4790302007ee322d479a4" +
+			"52491d9e7e56e0244a88ccff359eebbb76cc8693339fe2e6c9a1c11eb57d3fb4" +
+			"8b25d7652a37f0b4fd91570c31260359f047f1aa347cf47ad028ebf92e72acf5" +
+			"e9cfe10073cc24a21ea17a768dd5277e5a6ba59673e2137d30709e70e8eacba9" +
+			"0d1496b67d993affced69e91171655e0a8005d4c54453d326e25e279818744e9"),
+	},
+	{
+		false, 20,
+		mustHexDecode("504ac8940113d30cd9aaf34cf83f2c399eecc6a897f28e7394b84c400328afc2"),
+		mustHexDecode("9eaa0b7f122ae3df0503931bdac8d7988af9df63b1cd15aa028e41a0751d170e"),
+		mustHexDecode("e28a35a7100d656ead77ce07bb678303"),
+		mustHexDecode("14e3c9b1ea2c73bbba8bdcd6e168ba68"),
+	},
+	{
+		false, 20,
+		mustHexDecode("39121af86e18114af95aa7eed5998253a82d586ae0e2520b5ec48f2dd68fcbc1"),
+		mustHexDecode("59584412e7d37d0fb53eff16c61f2734c5b9913f6161869d4513d9cf696cdd83"),
+		mustHexDecode("8de4729d239caed4774d260dfe35d49510504bd0ec2c58eeb935e91df988e3"),
+		mustHexDecode("eee519613e9edfb3fa5d54b48bb12352ff23f1b5b3969601a69ef0c1338cf0"),
+	},
+	{
+		false, 20,
+		mustHexDecode("9135f6ba3694446ef57bafe756150c8d984b5dc09939d97571a66b80a192de6b"),
+		mustHexDecode("daf393881970d27a8fe57abcec74c0f16b46377992911d153be4892cf9507f5c"),
+		mustHexDecode("66d2d9aa76918d0478d393ebe49d88ad146b0596556017049d4df00d4978ccfc" +
+			"c746f33ff5213951d188843e34de8619a43b751898890a93e96ebf52a163f8a2" +
+			"77ab57ed5ec964ed5c1a1db614bc7b2627cef1fec574d09d60778736fd705403" +
+			"8b9a3611f90f7d1a66c5f021bbfc84cd45bcdfc081d3df0f1420ff20050c4738"),
+		mustHexDecode("fdbdfe14fba60ff23a70f99467e62bb1a36a5b17fa582e3d516409f917900679" +
+			"80883e337bcec48c3c828ee4d4e3d75a49fd857344c866b2c1402288e0816a59" +
+			"276988ed2e5b380e9a8d4079672e9662e7202bc7aa82db5fa45a93668b7055fc" +
+			"d59c868da98fd5bfc563311ea683f035ae75a3ef5a10a7aa24508441d0bf109a"),
+	},
+	{
+		false, 20,
+		mustHexDecode("8f12edb532a835e1acfcd3b11bea3122684308939c9622ee3596be153a21bcbc"),
+		mustHexDecode("b874029b6ad6cf7713443980b8a9cc029fcc2a36cbcdd8d3885210f48f667059"),
+		mustHexDecode("c4bf6cb7cd6f420182c1b6d879dd5c735693dcbcaa0c87fd98bdb72b8c2e6d30" +
+			"20bdf08412ec284a0c540fc70e945260127a093e219ce60ec64663c56456be8c" +
+			"c6014f842b44560133f5268f6009e5f82b3cd23c4a8c3fb9eef3d4e7226a5c04" +
+			"36c35316c3e9c802a50ad7363db8afab9d12c5dcb196c66cad97a61bdd1186e9" +
+			"04403a6f560f0257ed1758c6c93a9af8f3fcf914dcc62e77bafc5e88cc4b6f57" +
+			"93f1ee0a9c86479277c8852c77c080361140a107ca76ad162823db41ee205fc3" +
+			"f323eebe6a90064349341b638fdd25a0537ce4bb2979e7796098bdedbcfb6e9e" +
+			"31abd97a68335b436985c18875d6c0aa4a1d9c859221e769f8096b88aae3b84d" +
+			"655838dc31072d3360f83e647e92f6804e145ff034cd52d132dd993dd68de507" +
+			"060635e1e426d0496cc500873508681658ea78da619cef3082058099d3a6f8df" +
+			"7915c783a939d2fe433879d8a5b5006470323afa8d76ec5de8ba6b0812040d6d" +
+			"fbd458ccf2ba473621fb23670cf4f2866bf639176a7a0ac4567e898cfdb5c7bc" +
+			"fc0327b7a063666124e93eb4b810ee69680d04b588dd30380f2b726fb28ab89f" +
+			"123a829aa28376c0d4cbfc3653c5be50b5427838f72d23c58a8e98969496ee40" +
+			"65db9575ae9d62b0c0401d46f4230eb93b20824f652faf1483eee868174c32d7" +
+			"0b72cbc5ad5a5ca82940d5cf517691dcaa472f35052995b38ea34d786b722f7b" +
+			"a7255592844eb693bd410644ece42bd55f9fe24eb26df01fb2c9069c243bcbdf" +
+			"66a44479b62f8d9dce1aecd0f132dba7cd793ef2a151781d8b46c2a4bf353d84" +
+			"75eb5dd16a23cbb9159cdc91ae90731e2b7b6dff2e3d97be77e98e15ebfc28b2" +
+			"5b094fe908f8c7ad77a856bd584b57bedc2451ab000965ecca1d6d6255c47079" +
+			"c7975ee2ba9ecf4c0c6220671782c154b335d92454ea38a1522d9d567307c78b" +
+			"6879c38349cb371bf6143d39eb7daeefb549ab93ccc8aa11bd90db476cdc8662" +
+			"a1bafa8d31be5126e288080911baa13086082c8c99c6433414934b803db36078" +
+			"c7cf76ce8f110a570f2cf771f394c6c05ba7295aa24dfb1fc06dface3d7a6c5e" +
+			"99d6a472033bac5c7b8c58a31ce6c750227fa2760bc0f24a0d1a1dbe20408c00" +
+			"27e9151509ae8d5bc6d67e78b0aaf75f637f4cc36362ea357035006081a55aac" +
+			"c999728045de184f85fd50003d40d0346b1481f4e74e266d3e323bb4a3c0e538" +
+			"ee3fe71354ddfb10eed025d4533ebd37bab0f79055607ce312bb0e80a70b9be7" +
+			"13fe06e4902eb6fa59908c0cdb4932d0538af35c9a5e42141624424fcaedb08b" +
+			"2db1630cb95eb986aac759648b91778315826e0281f9a1da564832268025374b" +
+			"0a4c20854ec28f92107e752aa0b681d77321643861ae1236fe3a2ef24ffbb074" +
+			"afef62071d67709f8c2ce9391c066e4d51be1ff86a55f82a338b32355f705a5f" +
+			"b6c4ffb92302580b311d593a15a36d46209a69384d24f3e188a7c1a3fcafcf91" +
+			"1a4a681ae81d73947190eaa4789333034e6fc7eb61cd5239d85c03f0cfcebd22" +
+			"8888496b5076a8bc2287f6986c2ebe4d3d63ca3c88cfe826d94d84c8473bc1da" +
+			"11bcb0af4eaf4cfef7e581583eb9966c129c7ff6fa2937fd4b42601cad2595da" +
+			"3ee50d9cb50a7efa3c5993a5d9336c2ba0dc30d5af0f709d33ed966c9f4099c3" +
+			"e1b1c1111e86e87d7de482ba8a8455ec8caf7fa445e143dabcef1715db608d62" +
+			"d112e2cb0eeed1923271591e81f7f7175e6500ec13b2a1ede8b8df11c1e257cc" +
+			"5b3569a5ddace04ddab4c9cfb9bc8c603b89c1a2caf558e22bc47ef89e7b9563" +
+			"78e87821cc484e5d2330c2adb09475846d40f4f4676ee7491bf38dc9239dae45" +
+			"c946a1981c2405d6ff0b5abc8efef17c6c90c8d0d5a72887615d52cbf3cb0658" +
+			"7cc24a354c8a2984dc2a605cad4acdff4fa201e0c037755bed037cce22844e05" +
+			"a6072e50f6bcd1bf86679d30425ab10ea683025f7f579a807bb9cb2a1466330f" +
+			"0c5963eb82c136ece38ec2fbf73b53626f48555e487ab54d8304cd0a0852ebbc" +
+			"4733766f6eac9c77a289a585e9900afd07774e42bf52bba4c9f2b14a1a7c64b5" +
+			"8b946c00f6fdba0e170a35e61dd19d0717e15240e8e22f43b9c482bca8b83880" +
+			"4fc6b99119aaee70e85bafd14429d414b96d518e17d76b62270771f19c3b5ca1"),
+		mustHexDecode("bbd2b6778938e82f26b6a4d112d3445d0fabc0b3f330edd1da351f68b50c701b" +
+			"75e5e9e3b66d9424d68a826aecc868a17a89b7ac8354b6c44c275d38a1ae2f85" +
+			"a1f85cb890e5126907a02d9ec52e8f925f1418b30f60393f5d112a3fa9414b3b" +
+			"2151cf18492e6414d4701bdb0a6af122469a47ef2aaa993f6d287eeb144eb0df" +
+			"7b34603ddcd710da5c10edbb2e4067ff5ab5b67c976555b51563a9618d95df05" +
+			"6a28f3f750c9a112b33ea1a8403c2324fb98d154b8bc2b967d357a6219af826b" +
+			"b0c72b465cacdcb0f4a3155a841400fa33339d84c0de886277fdc4de98ed1bfd" +
+			"a3b8939e7e460328879e359ad5e3ad865ebea4caa57045df4eefe31fbe4d867b" +
+			"11447bfea16d32f69bb851f9410356de1bcce23355afb84acd0f4c761f47eca9" +
+			"1a09218997f9109e87cdfddd4e52ce89e2ba0b9126e0a14b68d5d11affa975e4" +
+			"f6ea93e5e48f34072748f4792eca8496372f71c824d0c4d91a514f8839f50e6e" +
+			"52d4cd7b8afc6992a6a098766fb871453974e6ffacbd5557888d5352786418d2" +
+			"be7f50d3c81a8eaf89d5a7b4eb06c6040a34faa895e0b74c04f1b2dd65f1e5ac" +
+			"24db61d4726e8aed8fd94626082caf0b789c0989cb5b26062cad33b448505d02" +
+			"14c0f776ca6c84aa8327f0ecfe1320a6d1b8cedea26e6479b230e5d7aa0c914c" +
+			"3742071f3900741bd99ba3cfabfbdd697f6b3e7f65805afe4ab79b773b514aae" +
+			"f653026e2a737ccee94581d680dadc2d0fa5099142ba163dec4b6d828fe55219" +
+			"631af8a2a10b9b4aa16a01681ca485fe77a73f1644cab7140dc033c7d4f5fb30" +
+			"fac87398e3d5c8cb38171c638bd9cc6c095b463d1393302623e4664a8dedf944" +
+			"dcddc45d6fdec796d31c4ed9b76fa9d982e805defc2b28432999eb5da9972081" +
+			"8b909265b656e7cf2cc8794ad824d094754b6148cb32e38be9f263a52347400c" +
+			"e4928fdb9bee8e461f6ff814e3a017d68797ebaa2a68a38d12542c48ea5327cc" +
+			"6bca5329e69fab7d1e25525e037a13991bd41cf45c8394e044565cadae90f760" +
+			"10df9bc7e8c875a4456e03669aaacd39b058d9cea3f06f471584176ee35bd8ed" +
+			"3f8bb7a526e0ff0e66e26c30bcb096c3778db4e639aedea39001a50747df104b" +
+			"a8fd67c8201a5307ec2ced7ee15d8c653fb13d0d646ad259767a76fc38610cb2" +
+			"4fc34d18bd0bb3217ed9da559b9457ed7dfbaf796164b6e8313c70c15c59dc58" +
+			"4a3cf93aac481dc5883af82ff82a526b5a2e243443e53d4d6009cbf4219bc4e8" +
+			"11ee8bf75ab242433ffad3805999459fdd12463c12e3bed5389d695e69a5c9e1" +
+			"55ac61005fd5bfe08a35a8c2baebfc2a5b072652331268a03f0c8032dc79021c" +
+			"e027fa83a790f7b3e41ab72248f74ccec9374c9caba29845acf2e54dc3065206" +
+			"495a363b66eb96899646eea751dbb8b113bba0a78d0689a1de61eade521fc4c0" +
+			"077d53f7898e7a7b3237b93f3986400e3944d6da22c332659d64f7ed0dadd290" +
+			"fb2321a3fc9bc83a2664282eba78bfb515c8b8ccb60332466947d63d055d500a" +
+			"8c58619fd1a505c782b09c9a2af7247a152d9aa933190244680305464b5324c1" +
+			"93ecba83656d870dbcecb360ac4600272bb83052c6e1048e84f0140cae2ba7b0" +
+			"0d126117b404f309038e4ce812bb5c19c705bcc98760ddfca319bcf24cd9df69" +
+			"210fb5bf3f52d8042e3fd7df7d1d7d0ec91c62bcf4899dead04a7dfc42ba7599" +
+			"abef446699a80fab0db1ba0f551be1fe36e48ce0c391a50e2eb9799ebe012f55" +
+			"7a50576daeedbb4dea5ee098d1e7d82e09bc4089287a03e7fe999ac893c3dd56" +
+			"81fb48adb7b04e3869b9b8e12a1ed5c971d0e98c487f115235a52515ff08ab8c" +
+			"7ab7d2aeed45810e56540dcd1ff6d823e82ce33dfbc431360a5dc7908834d346" +
+			"de1597964d1b8912fddf830d8709ade86424b3d9dbadc4fc8df5ee0032a98adb" +
+			"5230d2de2ae8a572baf817aae54126ac4043b6a6233fc925b53b1ffeeb1c8346" +
+			"a386a9f09b06a55a750715109b2686c1f4e5beaab0598cbb38cd5375fcb009f3" +
+			"a2118869fa4a344ef9f1f3a8d8151f14837e58f88ca8d8070cde423467133b28" +
+			"e95f77ebaa34afaba9f3a4a1086f6893959dd08068a0bdaa43294fdf5bae3e50" +
+			"639cd8291fa1dab72fd329ab9aa494dcf5915b1c8a584c1f9d6a86e70e705bdd"),
+	},
+	{
+		true, 12,
+		mustHexDecode("7fc7152ae1f5fda4176769aec92bba82a314e7cfadfd8540da7b7d24bdf17d07"),
+		nil,
+		mustHexDecode("9be382c65ac19fad4659b80bacc857a0"),
+		mustHexDecode("820ae44477dd9a186f80288b25070e85"),
+	},
+	{
+		true, 12,
+		mustHexDecode("fa60e3250b4e123a25073b4c3e1c7837db0a16a544c8c77171cedc3e82cbf3fa"),
+		mustHexDecode("e1e64d4ca5c74440c7546ba3544eb81b7f"),
+		mustHexDecode("6063deb6e2abae701abefd8e10c80b83d471e008d56c66cff229b9752e8da6"),
+		mustHexDecode("d4e4a09e91d2fbe43232baa2e08f8fede1723b4b37455ea4a1ea633bf9745a"),
+	},
+	{
+		true, 12,
+		mustHexDecode("d381721823ff6f4a2574290d518a0e13c1535d308dee750d14d669c915a90c60"),
+		mustHexDecode("659bd4a87d291df4c4d69b6a28ab64e2628197c581aaf944c1725982af16c82c"),
+		mustHexDecode("c76b526a10f0cc09c1121d6d21a678f505a3696091369857ba0c14ccf32d7303" +
+			"c6b25fc81627375dd00b87b250947b5804f4e07f6e578ec94184c1b17e4b9112" +
+			"3a8b5d50827bcbd99ad94e1806239ed4a52098efb5dae5c08a6a837715841eae" +
+			"78949ddfb7d1ea67aab01415fa672184d3412aceba4b4ae89562a955f080adbd" +
+			"abafdd4fa57c1336ed5e4f72ad4bf1d0884eec2c88105eea12c0160129a3a055" +
+			"aa68f3e99d3b0d3b6decf8a02df0908d1ce288d42471f9b3c19fc5d67670c52e" +
+			"9cacdb90bd8372ba6eb5a55383a9a5bf7d060e3c2ad204b51e19380916d2821f" +
+			"751856b8960ba6f9cf62d9325da9d71dece4df1bbef136eee37bb52feef8533d" +
+			"6ab770a9fc9c5725f28910d3b8a88c30ae234f0e13664fe1b6c0e4f8ef93bd6e" +
+			"15856be360811d68d731878909abd5961df36d6780ca07315da7e4fb3ef29b33" +
+			"5218c830fe2dca1e79927a605cb65887a436a267928ba4b7f186dfdcc07e8f63" +
+			"d2a2dc78eb4fd89647cab891f9f794215f9a9f5bb840414b66696a72d0cb70b7" +
+			"93b5379605374fe58ca75a4e8bb784eac7fc196e1f5aa1ac187d523bb3346299" +
+			"e49e31043fc08d84177c25485267112767bb5a85ca56b25ce6ecd5963d15fcfb" +
+			"2225f413e5934b9a77f15218fa165e490345a808fab34192795033cad0d74255" +
+			"c39a0c4ed9a43c86809f53d1a42ed1bcf1546e93a465998edf29c0646307bbea"),
+		mustHexDecode("6baa027d06f8b2231a86f923188ab625696d0030f558e6e871442d07517678a5" +
+			"75fa733a02275291eb8196f60a6f066c42e2c6d1ac1f77dd5449ce28a6cb61f9" +
+			"2220f4da59d54e426c89e93cfcfb90aa34d1f9916f7e76b931d0efbbe26cf6c9" +
+			"5f61e1ffec43d09f3b6718efddfca3c2acae9e634fbc757963abbc4af9c929b3" +
+			"425107bb1f96cf9bef8d8f5f98bd59a8d66eb5963f0141f49e8a57960e0a6a41" +
+			"12808f4bac50e6045e9741407de8bbe224c6ea7b4fb995c44685d493bdda02aa" +
+			"e0bf97568d7b758863851d0f2b3d2c24a34996a737074b6dbf0809320a3bae35" +
+			"b19125596bdf14a1dfdc1a80984202545ad0eb1d8b1f29d5b597f830de7c13c0" +
+			"53c97f11cb2b9942d84ad59f7bd79acb3a4b6820bec303a5c54c1d976c4d981b" +
+			"d615ff53a6ce3f67328a3a6730509e6f76e33d768e45497bd29db2658901e0e6" +
+			"6294bfc4cebf07c882980f7ab478e2c7e91d185c9fd0b83871a6334df5d174c3" +
+			"aba92fcacb86ad154f2ad5ee666a4d6bb55fd7f42da6840bbf2a0ae0a3dfd5e8" +
+			"fc5207f427ce3fd712d9d841241f017cde6c050a8f8bb01ce7cf02bcf1cfa69e" +
+			"0e04b249610ae8383440ae9cd509c131d2be9b5fcb6ee28b4f05c3091a216fda" +
+			"49a148bd877b58be2b8dbdc50769e46ab0599b7cec84f0f6cc33e94ba0505cf3" +
+			"76c1f96d72e47e48cd42c1d43478c14c7f2eee6712d6c506f9525f61ddea2899"),
+	},
+	{
+		true, 20,
+		mustHexDecode("a52824341a3cd8f705918fee851f357f803dfc9b94f6fc9e190900a904314f11"),
+		mustHexDecode("a1ba4995ff346db8cd875d5efdea85db8a7b5eb25d57dd62aca98c41429475b7"),
+		mustHexDecode("69b4e88c37e86782f1ec5d04e5149113dff2871b69811d71709e9c3bde497011" +
+			"a0a3db0d544f6669d7db80a7709268ce81042cc6abaee56015e96fefaa8fa7a7" +
+			"638ff2f077f1a8eae1b71f9eab9e4b3f07875b6fcda8afb9fa700b52b8a8a79e" +
+			"075fa60eb39b791379c33e8d1c2c68c8511d3c7b7d79772a5665c5542328b003"),
+		mustHexDecode("93adfc2ce4ba184b453f125d3d6e20eb627b7467864bd4a64dbc883b7fef5797" +
+			"64bc0c986d19c4eb0754acb0652b8e196ff0bc1d8ff6e6b179a63e37b54f772a" +
+			"5b5952f17b11f0d5467d0f18b06e489157d15f29b4f7b18017431dba20e474af" +
+			"41cc351daa75a53429e69383347d88dcf697da8d393452032ff9f14ff2e26250"),
+	},
+	{
+		true, 8,
+		mustHexDecode("9fd336b18507df1901eaf95268bfcee7d049f3ba58fb87189fca24ca61a3f0da"),
+		mustHexDecode("eac6725e66d4c7bda16eab09b55839ae40"),
+		mustHexDecode("c7d67365cbf3f53eb9a7bfb154cbac01eeb594174092fdad8fdb27223db10bf7" +
+			"a74670d031dbf9dbb9b9404a0aba776f35369eeb68e29ed7efc25e210db3b087" +
+			"d643356e22a0b7ec26e07d48f55d58d329b71f7ee95a02a4b1de109fe1a85e05" +
+			"b6a259ca3ebcd194094e1b37299c15ef8c7253be6f252c6888080c00807a8564"),
+		mustHexDecode("e8f2387bf933530b172c78b64d6a7c8ee8dfee72f6ea62369ca0da75114436ff" +
+			"3b82690393c8f92c7825db06044ad0478912a0dc0ff30b6ca541c3adb5c57b52" +
+			"b4e0ff5e050506221237be0972011f1cc8afcde7b1cb88227003d19f540aad19" +
+			"22b40e03941b8f09c4561b9ba6a4222bba0a7956af49f7a3f04a20d5b28c46d3"),
+	},
+	{
+		true, 12,
+		mustHexDecode("504ac8940113d30cd9aaf34cf83f2c399eecc6a897f28e7394b84c400328afc2"),
+		mustHexDecode("9eaa0b7f122ae3df0503931bdac8d7988af9df63b1cd15aa028e41a0751d170e"),
+		mustHexDecode("e28a35a7100d656ead77ce07bb678303"),
+		mustHexDecode("ef17acb65e897c3acf5fc99bf8627625"),
+	},
+	{
+		true, 12,
+		mustHexDecode("39121af86e18114af95aa7eed5998253a82d586ae0e2520b5ec48f2dd68fcbc1"),
+		mustHexDecode("59584412e7d37d0fb53eff16c61f2734c5b9913f6161869d4513d9cf696cdd83"),
+		mustHexDecode("8de4729d239caed4774d260dfe35d49510504bd0ec2c58eeb935e91df988e3"),
+		mustHexDecode("363a98fcbbe58df319ec61e380a0e061ab70f24c9d38d7bfeaffbd84d50a07"),
+	},
+	{
+		true, 12,
+		mustHexDecode("9135f6ba3694446ef57bafe756150c8d984b5dc09939d97571a66b80a192de6b"),
+		mustHexDecode("daf393881970d27a8fe57abcec74c0f16b46377992911d153be4892cf9507f5c"),
+		mustHexDecode("66d2d9aa76918d0478d393ebe49d88ad146b0596556017049d4df00d4978ccfc" +
+			"c746f33ff5213951d188843e34de8619a43b751898890a93e96ebf52a163f8a2" +
+			"77ab57ed5ec964ed5c1a1db614bc7b2627cef1fec574d09d60778736fd705403" +
+			"8b9a3611f90f7d1a66c5f021bbfc84cd45bcdfc081d3df0f1420ff20050c4738"),
+		mustHexDecode("2c05ad4cf47af0135e4c7f9658dec2fba4877bc92dae25c5508b159e76965aa0" +
+			"367d9f9012f47b5b5921d9ce29c0d6d779f297c306fa54681c4cf99774fde271" +
+			"e2e9ed276cd9387fcebf5b003cb6afa77fbbe814b1cbdf899607f0f74c37ff78" +
+			"4cc0e1e3c5fd3ce7dd7435d26d36cd5b0bc6b3e30087c8a1d459c3e1121532b2"),
+	},
+	{
+		true, 12,
+		mustHexDecode("8f12edb532a835e1acfcd3b11bea3122684308939c9622ee3596be153a21bcbc"),
+		mustHexDecode("b874029b6ad6cf7713443980b8a9cc029fcc2a36cbcdd8d3885210f48f667059"),
+		mustHexDecode("c4bf6cb7cd6f420182c1b6d879dd5c735693dcbcaa0c87fd98bdb72b8c2e6d30" +
+			"20bdf08412ec284a0c540fc70e945260127a093e219ce60ec64663c56456be8c" +
+			"c6014f842b44560133f5268f6009e5f82b3cd23c4a8c3fb9eef3d4e7226a5c04" +
+			"36c35316c3e9c802a50ad7363db8afab9d12c5dcb196c66cad97a61bdd1186e9" +
+			"04403a6f560f0257ed1758c6c93a9af8f3fcf914dcc62e77bafc5e88cc4b6f57" +
+			"93f1ee0a9c86479277c8852c77c080361140a107ca76ad162823db41ee205fc3" +
+			"f323eebe6a90064349341b638fdd25a0537ce4bb2979e7796098bdedbcfb6e9e" +
+			"31abd97a68335b436985c18875d6c0aa4a1d9c859221e769f8096b88aae3b84d" +
+			"655838dc31072d3360f83e647e92f6804e145ff034cd52d132dd993dd68de507" +
+			"060635e1e426d0496cc500873508681658ea78da619cef3082058099d3a6f8df" +
+			"7915c783a939d2fe433879d8a5b5006470323afa8d76ec5de8ba6b0812040d6d" +
+			"fbd458ccf2ba473621fb23670cf4f2866bf639176a7a0ac4567e898cfdb5c7bc" +
+			"fc0327b7a063666124e93eb4b810ee69680d04b588dd30380f2b726fb28ab89f" +
+			"123a829aa28376c0d4cbfc3653c5be50b5427838f72d23c58a8e98969496ee40" +
+			"65db9575ae9d62b0c0401d46f4230eb93b20824f652faf1483eee868174c32d7" +
+			"0b72cbc5ad5a5ca82940d5cf517691dcaa472f35052995b38ea34d786b722f7b" +
+			"a7255592844eb693bd410644ece42bd55f9fe24eb26df01fb2c9069c243bcbdf" +
+			"66a44479b62f8d9dce1aecd0f132dba7cd793ef2a151781d8b46c2a4bf353d84" +
+			"75eb5dd16a23cbb9159cdc91ae90731e2b7b6dff2e3d97be77e98e15ebfc28b2" +
+			"5b094fe908f8c7ad77a856bd584b57bedc2451ab000965ecca1d6d6255c47079" +
+			"c7975ee2ba9ecf4c0c6220671782c154b335d92454ea38a1522d9d567307c78b" +
+			"6879c38349cb371bf6143d39eb7daeefb549ab93ccc8aa11bd90db476cdc8662" +
+			"a1bafa8d31be5126e288080911baa13086082c8c99c6433414934b803db36078" +
+			"c7cf76ce8f110a570f2cf771f394c6c05ba7295aa24dfb1fc06dface3d7a6c5e" +
+			"99d6a472033bac5c7b8c58a31ce6c750227fa2760bc0f24a0d1a1dbe20408c00" +
+			"27e9151509ae8d5bc6d67e78b0aaf75f637f4cc36362ea357035006081a55aac" +
+			"c999728045de184f85fd50003d40d0346b1481f4e74e266d3e323bb4a3c0e538" +
+			"ee3fe71354ddfb10eed025d4533ebd37bab0f79055607ce312bb0e80a70b9be7" +
+			"13fe06e4902eb6fa59908c0cdb4932d0538af35c9a5e42141624424fcaedb08b" +
+			"2db1630cb95eb986aac759648b91778315826e0281f9a1da564832268025374b" +
+			"0a4c20854ec28f92107e752aa0b681d77321643861ae1236fe3a2ef24ffbb074" +
+			"afef62071d67709f8c2ce9391c066e4d51be1ff86a55f82a338b32355f705a5f" +
+			"b6c4ffb92302580b311d593a15a36d46209a69384d24f3e188a7c1a3fcafcf91" +
+			"1a4a681ae81d73947190eaa4789333034e6fc7eb61cd5239d85c03f0cfcebd22" +
+			"8888496b5076a8bc2287f6986c2ebe4d3d63ca3c88cfe826d94d84c8473bc1da" +
+			"11bcb0af4eaf4cfef7e581583eb9966c129c7ff6fa2937fd4b42601cad2595da" +
+			"3ee50d9cb50a7efa3c5993a5d9336c2ba0dc30d5af0f709d33ed966c9f4099c3" +
+			"e1b1c1111e86e87d7de482ba8a8455ec8caf7fa445e143dabcef1715db608d62" +
+			"d112e2cb0eeed1923271591e81f7f7175e6500ec13b2a1ede8b8df11c1e257cc" +
+			"5b3569a5ddace04ddab4c9cfb9bc8c603b89c1a2caf558e22bc47ef89e7b9563" +
+			"78e87821cc484e5d2330c2adb09475846d40f4f4676ee7491bf38dc9239dae45" +
+			"c946a1981c2405d6ff0b5abc8efef17c6c90c8d0d5a72887615d52cbf3cb0658" +
+			"7cc24a354c8a2984dc2a605cad4acdff4fa201e0c037755bed037cce22844e05" +
+			"a6072e50f6bcd1bf86679d30425ab10ea683025f7f579a807bb9cb2a1466330f" +
+			"0c5963eb82c136ece38ec2fbf73b53626f48555e487ab54d8304cd0a0852ebbc" +
+			"4733766f6eac9c77a289a585e9900afd07774e42bf52bba4c9f2b14a1a7c64b5" +
+			"8b946c00f6fdba0e170a35e61dd19d0717e15240e8e22f43b9c482bca8b83880" +
+			"4fc6b99119aaee70e85bafd14429d414b96d518e17d76b62270771f19c3b5ca1"),
+		mustHexDecode("a70c93b022d8ac850c1cd8a020d4b5cc7a9ff4cb31738f9c90003da543438291" +
+			"22058e69b537afcf77fe61080eb26cbc770dbfc8e6a22fcf4d2e8ad0063de27a" +
+			"e331e6be9da10f2f09976aea8cc0c120005b6f8e95b763d87f78b159576c86eb" +
+			"450d39621e733152a114957b6f68fb54885a773db74f5449df6cbb5b8c6a5a7c" +
+			"6c3c9ab9d02fd221134b7901333da081d29c48c917a5333177a9c8f4031d79e7" +
+			"931be55469383b27dcc4cb05e1d5ff7b650d81425c40dd0704597979ed2faf71" +
+			"47ae1f29e54a29eec3f21734560c176bd42105e50950b0829de8221d5611aefe" +
+			"3df820fdfe8351af281b9b23f016522fd2640658e28e7c6acf450f1a53714770" +
+			"43a74d428a69a74382dd953c3a92d8da34e0a1605bbd4871fe2ed2a295f8900c" +
+			"3f54227ba357d07c67717cea7ddbc3d7f9d534acfa1934b54c33aed398913742" +
+			"e5c8b585310d3349052bea2ac60245807dd75d4d8a648fb28983024bbff25279" +
+			"8b3976144aad983945acbb12304c0f406f1286c4769310a03865487cd202ff6d" +
+			"a7a827f9b857fcac74a4165668f5db06985e5c4e072da0c3903a22a245cb6333" +
+			"a528b19c58050cea645b59bdeb580c6604a2f9aa7a27f57b907c0f175334d489" +
+			"7196fa8ee653805574fdb27c76287ace5c7002ee462841d962d57f71e2ed2a5e" +
+			"e04fc246cabbe3472180b16d043a13e7cd2ccc3fc889ae58da525dabc8a4769b" +
+			"280155438b1800b9b3b7652cf68fa723dc7f9cb51f21cbd801ad1bb5e84f70b9" +
+			"9bf7808431220ccf5042ba98a737479369fd1ed944901e6348d3b24cadb9cb12" +
+			"4cf4275ee771c9ada1c3b2617e97c84f53848c51c052ec6b0803ab2c12d3ca38" +
+			"4f997ed8c9cdf9aad76e95b56c26d44d0c929a694262ef6d8ae8331d02832a84" +
+			"448b6d301cf2031bd07025a5c5d6b4c00c253a217ffafe201f611f87b8ddcc88" +
+			"3f9898163e166b2e665f90b1184640c7644216069d09f464fc38c3ab05ec83bc" +
+			"51a5a5de1d22be14a9a93d58c05a92c298fe630dab9b5a8d1068d277cea1b23a" +
+			"dbe2ce8f45b1422b891b9393b880319002806ade4288c380e7fb366744f5fcb6" +
+			"9d3fbd0448e616d4df16cce471725e1376da5d88191a5b9bd3df4d097d67d4a6" +
+			"d7757b3f9ac6c5afd0643e49d5835bbb79e524d1042f930d676d245aba5ccc27" +
+			"0b7fa62b85c6d0672f26f7fc04329e7b0e314d69f06c2e1e604e6dea0c93b069" +
+			"322d9ac855535dd3f29c2edda56b5d8d07a35778fe1acce9f690e15e7eb1ae84" +
+			"cee96da62abc9b57acb4143fab5f04855e2bb6faec5f41d0ee397436d5c5be48" +
+			"3d7967729c38d6761192ab134de112b676af15f17f4f20a3681711c7481345ea" +
+			"70274894db6d85202e9358d8da7c294e436213449a4ede6f75d04bd94d34b52d" +
+			"ffd39d08756dafed5bb3b6696d2ae2870e12652338b7c5552a04b9db1de8d335" +
+			"8800b799a7627b44e4eb256a7ce32aa15d825f3b64f3723940679e50c3b2acfe" +
+			"5ab2576713c3740b0aee76c292f34256bf5ffc129da85896882e88e5a73125e1" +
+			"1975ee4da346bd556295a0e5aeb8ffef03848d4471b6f54b009477cee4475abf" +
+			"92211e16f24c02c9f292a03ffa639bd9c86dff798fcf0d22328b43f5f087d826" +
+			"bff2d3e300866d0d3d8413139ca8d891f6918122af060a42b5c1e172b2846ce9" +
+			"58d3a7960de2e3835ea1724b5483fa93e5d36490cff66c8558f6cc45d020fbee" +
+			"997213527748f7bf04a62c51fd171e90d881f0786e9614736e48514a508ef00b" +
+			"467f0b01bea498429e1488aa88799a95ff97d1badd1c67086fb5b321e2ac276c" +
+			"948b0964fef844b3cbd6dc91db7757c0eaa7383b92aa4796948e3b99bc2a9edf" +
+			"ce6ee7ffb9999b7cbd96df9cbe2c53dfa762dec0a47e8756d021e8304494b1e1" +
+			"34df18fe82b1d1544f7942cf3b5d3ff7456c29f6954aff347e5dec02f311a008" +
+			"0cb36c70ada191b3d1a76c062b3eacbd1da89e14f2b2f60d91e24f8f0396c4ef" +
+			"42ba4559d7faeab8d74d1c32289f243dedb50f8666ea9c685b484e10de6c27df" +
+			"0bf14079c6c8985587b30428913daed242880a400268165eb2c4367c44b2bc8a" +
+			"21f08c04b10d1b4ccfbb52b0eb58335e3ce50c718b3f58d546bcf8c8642b8002" +
+			"d6d2f7109f7d775b4a3acf8f16343d6103e224b29c2a3a00f78340341f128d6d"),
+	},
+	{
+		true, 12,
+		mustHexDecode("ebd9864ebc6775f8ad7ab3e32887c85386252a6364c7b755806cf2fd656e1e20"),
+		mustHexDecode("bd85d280ca946988d057aeb212021094d8c61ed37ddb572292ca99adf206fbca"),
+		mustHexDecode("dfefdb7063708c8e337a08e74c18e6d7c79decc4889fad832810d0eacfa167d3" +
+			"6e36a965429fffcaf56a1c28535f1e81390665f10aedb7eda78d6da842f70f43" +
+			"ec82268659875cfcdf8026f6aa77ed616585299b78e16e653fff9e0ced4ae83f" +
+			"41cf98a99232d08b544f77a1c3d94c8be36bc4dcde54a4e27e0858552d90029f" +
+			"b8bcd05b3ff256398980dfc3991a0f0ef3bc72f3f8289e573ff767b09f31fd35" +
+			"6b85daaa4a1377d6cfcd4ea67bcd1a8ad996d8784ffc1569d9b51fbe2ef8d5b6" +
+			"6b00819e4c1bdc907d970ff5fafcd14f003b9581bc9d85d8c4d1f6073ee143cf" +
+			"7035ea6c1153f0b1da5c87d8b3380d2968439273865a7b80d7edffa1d04f9e78" +
+			"496b26242e0c0d70eb1634aff088852d3a73a9b862b7e9b14c26076d3d76237c" +
+			"ac58bfddb38f79594847e270f4fcf2e18b126aceda6ef9275ed9c244d9d77a69" +
+			"4fa676dc96a9ae6f32705ad0c0afcaa71597473232e323e64415f21f50e80226" +
+			"283434c256b49772fc0877c488894e6cbb0420ff37d8b9ff5fdf424e2379c981" +
+			"7467cb19dc04e3a8a7b6ce8fced28b2f81374379b2d12392e3a0a13e8da44296" +
+			"8d6878547efe92e3dcd8c5cb6cc6fa373fda3e8bd1f101f00efd96175da7e65f" +
+			"91e30e7eb9fbb916f2e7f87a7fe51cb56f98e697be46dcfd767c5fac7815666b" +
+			"8f774778a225d829debd9bbb232cdf6fa9fd398dc0894737abc5bc02d0782e22" +
+			"f091f8cf12aedba48e4b7ee9e37d43b829230ad505c3e5c93a23f4d8a95c0fba" +
+			"fac6be789b7eea9e95b0b9602975576b4a09cf208be07cbb368ec576cbd82e55" +
+			"0b113eb30e84c9e53742bebd707294bfde0e794bf3a0054d52f31ac5d3dc9b21" +
+			"d5b0b94fd922a07207d65f3bc92cc9711ab3aaa592abc2a364f43c7bc370815f" +
+			"5cce2148b26636364329739705c7e20a2fa184a70de3d93043f0a47e9eab9d53" +
+			"a191f1838837dabd55c213c1434775192f87cd305a0adfabd0fc8b4e00c60fee" +
+			"43f4951acab97c460d32fda8024432924ef2b1c5c9f6ad9ff00cd4093c8f2be4" +
+			"f1b0bf2d408709d6288a58926df13015cb9c8efd4a65d0f934aa38c03c412c18" +
+			"776a19fdcb7996352f0abaf46bfcf25f74a64968589c3379b424c0d28deb6428" +
+			"720d37325542574b8d6e66c4ce29e6e4287750422759df71e6dbfbbc9fb0b5ae" +
+			"23059e6264fe465ec1091a31fbd90a37693b540b84b9e8f2eab80d515d354cf2" +
+			"ec727480fcc90aa567a77555b77aced2c232b6fa9384a38b6b9e3658b22e8d2e" +
+			"7f2c5325ffcf8eccce3a3ae6ae33ef04379d74e22ea4a36d3a1d71479104c084" +
+			"bd1339a3718bc85a027368338392658b475b0284e17a15b8f106d17f6f834399" +
+			"6c76d65b10615315f7d44723f1ef0cd50f2cbcba5566a8ba9b0bcc0674cbd616" +
+			"76eede7f1cb5e42117adcb2dd007efd8a4f9dc9213d9a4aa6fbc4a9fc5ef5908" +
+			"b0b65cfda752ff686d93b90cf31aebcc4d0d5a914971362b6b99de1df30e404f" +
+			"f42130ea5e529e8ef61e2dbd26d34a2a0f255a8a8d04602897fe7b5c76b25866" +
+			"12b360ec414ccaa80bd0296998dd34469ce8a929fe196548d57a51fbc8192b6c" +
+			"e0eb4ff78699c4b5196d290b887081785ba1386bd2f7373fea0daf0ca06c6d28" +
+			"66c435948185a4c28da31341264e846b5ac71dbcf140e40bcaa9f0cdbbaebafb" +
+			"8ddba7204ed5c322cc91872df8ca662cb3af147992f4f287b503ef6dcf43e888" +
+			"a43de49c1c7916c5034b087bd3398f43cb16ef0cf2a4dfc51f9fb25fc78e9152" +
+			"22d0354418ece767471d02dd2f6cc1050e2031dd6751d3546c05bd47b88b62b8" +
+			"55f4a79ee780a1ce58a64c5eb28a7304216e974e7bb5e7f2b46bf54159ecf185" +
+			"cc6b87fec80107957fc6a49b795ff85b8d5a2c757f7c012a085761428a13c9a1" +
+			"36dd54a54560ee838f17053d77da8e50ae826efbebd3a14c7cf5d519c99d20d0" +
+			"4107e464bdce2f847d4ab1ba698254b5fd8833493e4b63208ce0e9698ba5538e" +
+			"ef3dcb204531f375fc9687faca28ac741471e2ffa163392389e512d4222b16ee" +
+			"b359e1e10f91d882b7cdb3513f2aeb99eacb124a50011edda2911e1336e74db2" +
+			"49f0cec37675f7782597121e07015c8acc3b690e475648a2b3073a3d0e4b5333" +
+			"2011c3844fa540292ebe72ce46dfd7ee3c603d19aacfaba2747225398f1410b3" +
+			"025319fd44e8eaeedf62d81e1999e1184e566c58678724a02add925f993e87c7" +
+			"a9ab1c13b152062e2610bc40f5efce62c7cc4c6cef03e247837eb3cd073065d8" +
+			"9531b6390b2d8f8ea2865040fe3a41cf8a6db7663701cc33d893bf22ae67737b" +
+			"a370a32252acd89c1a133bdd115aa9686995d49251f09680f9b3fe6ef88f5526" +
+			"b4cf75a9769402b81e3e62219a917bd3445873a2c5ad5f9593b78c0c4bbdf0b5" +
+			"04446529137779912717fb3ae0180dccb6cefcb09062091293e94805a5c805c6" +
+			"db2f96905d41df9e2af9c07d851766b7ce8254da8d212787a812836870bdda84" +
+			"daf05ed48e5b6cdfe8ca2a210ca43102995668d64004fd1bceaef1c12946350c" +
+			"34f082c426110a0ce12d097c575e44b77b6fca5067680882f3ff16e5463623b2" +
+			"2027616d00cf29390f921314fc306821ec71eb3c5dedefb9e0a758ffe4ffb0d3" +
+			"c1d97cf4d1e4cd02010d5ef7fdb7bb861dd35b4e26d2159afbac42b010d254f7" +
+			"c93b566ecfa76bd53d6542b1cc49fbc1a68009be40d4adb6beaf912eb90ebc3b" +
+			"1178c579b907dbf5537cbad2eb5909ccb336565ef50e8a29ffb417acd4c277b6" +
+			"91cd07c1217f0feeaec02431815eadcdff974199c4ef94d82058fad95fb2dbcc" +
+			"05ea524e246d85d488fd925514876ccfd63d46b7112f581847a770aff93514e4" +
+			"d59769b589be9efd5941c711288567f1158276252389fd99fa79c5f8efee361c" +
+			"f49e58a46cfaf8093be018cf25b4eb0cac8d99e658b0dd2703e66f663fb9d7e6" +
+			"25ca151451f2c76406e753113a3551c5a2945304dc209b82ca2c87212cd7687a" +
+			"8b5421de391dbc0e28b4e14ee60447add76ee67b662dce7977a42e5fbfe93295" +
+			"9ea002d6cd4f9c1704feb0c38a1fc488602ef37f0d590545730f94305ce53222" +
+			"71011a553d8d055d2f157067606ac396a8cb68fdbdb417b583c4231d792762ba" +
+			"6e3a497387c31bf1cb2a4dca91fd06559d07202dc4cbcead97db2c9332b9563c" +
+			"3d713b86baed34e8da9604be92609409d4a8d4dfdd035c06b69e48d613de6db2" +
+			"e13df3111078559b45d39b8ac6e7e9b7f57c33675607eaaf888d8838ed49bff0" +
+			"f81e2c2637e49c0a9ab5a40bd01551859dbb676ea4e7d50c10addf0927d733cd" +
+			"1461a860adb7cf52ae7247bb97c5b24a8c6c5a7369bf0c453dbec75dc656d78d" +
+			"a83a34d0728792566aaef837d4b5e721e87654911d6242491c3313908c7678fa" +
+			"81be9896bcbb4fd6947e26ab554151f700fe7a3693a0cbe22b731359656ee733" +
+			"9008445701ae65771c724c0563089e1959ebd8884df7efcd9fba7e19768683ad" +
+			"2914fd5c83d81bbe40ae9aaa7ff3ab9c350f883d5106895c2d1c634d2579cb2f" +
+			"f7da7e4645fc4594ac59fb3b67031cbc672c383362ae6959b205b903d7416d1c" +
+			"023e14ac9e4c8ade1cf9e15ddc5300d3486174ea079949a5ce8021f71bc7975e" +
+			"ec90697dac5afaa7902730adb645e3bd8627ebdce202d7453c5b62ebd761a1aa" +
+			"a0dea1014c185b4b046e874f314e83285a5e7de8e4bf317fd6bd86ecb4958514" +
+			"4ea85a664a6cc8c89f45227263eb8014c367350368f4b37336adfbff6f6aaf22" +
+			"62cbd0de2d5a01110526d4a0abc45f059588fd6f9f264b9c5850e80a599f5ed4" +
+			"64ac5f181415bdaa146f2ccfb9d6b7b97e8eb2c772660e6a7595c71e4ef5881e" +
+			"746ae613908ef3ddab3815a1a0cde94d74d902d615b16ed8b8c5329f7f4a843b" +
+			"817505e678f286df6740d58eb4092ed7836922febf379a3d53cc921fd7408247" +
+			"9566307b648e3e05491f4333d2e2513f220ff759a40638671d1d06b84b7479f0" +
+			"7cf3082c6d7b0b72fa2ac4f236c54e912d13ab116be4f8841ff6f6136bfa2eef" +
+			"5bf1880d58b7b016afee73a0cf7827e65342abed9d740d9e355089edaa0b9c2f" +
+			"a1522f392c568d244b2bbe63e69dab58e57247496f5fa04fb1d6964ed01928f7" +
+			"63b088b3e4db8f4e8ddf2fd59da4753a6e23fd0e1fc893c0286ffe6b198953e2" +
+			"bff564d7e3d131318c8fb1fe6c379c8cec4e68791ed9441244b032b1a0284ef0" +
+			"e7e268c1e0f94d0f9be9f91aad0f0a95bba88e1b61b1154a4057e9d528fedc2d" +
+			"b3e1cb1df101ec135dd39f2e7e6e2dc8ecb2d91f46e00ce6d958b93e5d36adff" +
+			"e7dcd7364e828fb7b6c38c64c022df08387b548df21cdf8076eaa0f5e22a2819" +
+			"964027ecb56a418d4a72681c9ddf7d6e9d4b8923f3bad1f28baf8e30baee3e3c" +
+			"df828a2517e16515b889d03b2225d0e6177d7595452cc425c29c0f22d84b9d4d" +
+			"cd9211fa917a7b3b0aa059466cb69f4255bccfb6f770596c8f274157041a3689" +
+			"9820a2c73e56163f4c7efacb071b67bea8f2144a857f68b41230b247ab7ce3ab" +
+			"e63bcfb3065b2304b615898dfc607fe68ae0333e6b70212343671f9376bc987f" +
+			"ad00f2af7963f0692b5742cdbfa1f183dceac927c7d2916979964af695afe575" +
+			"5ee1ec3264131924d8fb6f3b99a3e23f3d8608909cd51f5f9d3e1c1e055d410f" +
+			"324194fe4a878f3682bdc815afbb242b363fb32922fe3a1690658743663b7887" +
+			"06900e653c384bd07addf509b7a4cc4e7434ddb2dab2a48a17eb688891899ba2" +
+			"02a7cdc0f1fe7f9e167b58dbe42dcb2beaa1ced853bd77c9db055022bcf05ec6" +
+			"98ea6154cbd54127a75c303359f6bce4552b5d513bc578aa5070b4e79c16e9a0" +
+			"f1c11f3faa9c878f5218794a581b8006cd58b37363f83d78096bcd72c59c66ee" +
+			"dbe92a5a40cb29ff95aea6c872a3cf9cf2972a554e6a0fee288827015bc84200" +
+			"ad5523f2c529177f463b3fcb9058cb083c53bd37f27d8fb55f3131ceebdf7647" +
+			"18d5c0380b0d1d1f1a5b47e816298347bf46be157d261c4ea39add1fe6cf00f1" +
+			"a69f77741c4b2012f1554541a033f833e152a57b53069125d1aae9649e432d0b" +
+			"06841940811a190bbde96ff87fea0f287ebbb80aa9e38800228e824017585092" +
+			"1c3ba3edfdbea8bb6054c089bf609bd2fe51bcf56f51fe5867bdea3bedb523e3" +
+			"a2ca84a3d70e0d2270f808a311a0796dbae2ac4cc0a3af61cd1a1b63910090c6" +
+			"cfa456fcde75a595add9c71bb96dd36bb367a6cab0839fa2816204d0d54aa6e4" +
+			"dd530fabe0644ba0ed07c936160e5750dbeb4d0c0784716ee1cfebe46daa85da" +
+			"5d2117c5de14825c33e97e0eb404d20a650f4a8b8dece80e24bd7d68f1a92020" +
+			"7de8071cf15cd1e1bb07b58262a35cfb646914e9e6c74523d03eedeb814908e4" +
+			"8860668356d29243bebb89daa0d3ee4a20bae334d07162807c490452a11327b0" +
+			"69c3e70fdfba7abef0572208b1b2b256a9f693bea4d1fdfbb1eaf86a5022e0f9" +
+			"550f411572ee6bf389af491846b284c84e2756a0812844b010187c6b01050c19" +
+			"2d03a1ec5f674fa9a04f077707ef83ac5761aa109adbe719cdeca7b79e1df1a2" +
+			"c2c76da6c40554911082aa43747505e7f3d7c3171534a2839bdd361ccc516aa7" +
+			"7550173e515a5c2ffac9146371b5f6082c36511a5450db14bd736cd94370f755" +
+			"e9d806562409890fe6ab17204ad3b2a9b466bbedd11682112a4aafe9da45dcf6" +
+			"e1b533b98b9af3bd1bcf626d312f610611f10f88805d2167d7c49dde5c0b253c" +
+			"c89fdb94c2c4613cd79367f99c37f4420122ed12de528d758db92c30259f75cf"),
+		mustHexDecode("966c78c32834978f16c9523bad59e31047d88519da82ab798413e3439bfa2281" +
+			"fdddc92e9cfe43e1ea0587187d70b181bc147ed1c6cf02614df0c915917406ba" +
+			"964d8466b372bb0365c79ebccea9fcbd6df4bd762752f2d3af665e76bab67ecb" +
+			"4ac21ce0a26f818b91ba2fb609265fd42f55874d293fd7c2362095481e7cf596" +
+			"17b28495c44746a423354a14e733cf4baa1840d7a2b1f6a22e5c5d6fca3bba88" +
+			"62c2f8f4e45fc294803ec1ee35904265184b8d039b42048c246813a3cd7e7a84" +
+			"c70d1669eb7c7bae793b53c7e5b0c7b0dd62525a29fdb2fb88b3ec602a2d2370" +
+			"efaf3824e7002da400165e1bbcfd7c33617473e19a0798a401f778cb0f4ddd50" +
+			"5c69a862a13ffbbe3511681a46230a03715f139cdeed81adde18a5e7505ec8fd" +
+			"21a20d0dc949a4b84a6403075e385ac1fb119d3316dd3bb89657759c16df2e43" +
+			"41af5b6e8148e5d1de541316d7b7a37acbd6bed6e878e553bc5a5acfeaa5bfbe" +
+			"32dbed4a14a0dc1a9c0007b8a5679d4eb7aa4e825869c33b5179c924f508851d" +
+			"1f8894e509c7094c6e8487017948c6d5e4311c5155ee160ed76b84f6b16cf419" +
+			"e80654eedc25cc90ccb0104462ea76055bd6a2cfa12ae8fc9c8e8050d882211f" +
+			"6a50d5346103a80fccaee30336d1ba1725477c0672183a54cf57249a9704de09" +
+			"d4e90fc5d3dbce83aaabc887ee56583b8f69490b2a6a162ffa183eedc479bf2a" +
+			"29af859114c9c4df94e9cd759d2b8612489e9e61d9a40f48c8a2d57e77fb37aa" +
+			"27c4e220eac22b1a12fca8a09d970894ca07f0839942a25bb79f2e019a798001" +
+			"861fa7249cd7870cbac141d1e9282b74a1f6ae65c988e37fb15b5c362a0469a6" +
+			"e606ee92a4fd68e60d02b0d5c998578fd2621203452eaf58dc5149d89e06a4fe" +
+			"1425b2212dbe7bdfd582434e3ed6dc0c4990ba3f77c4c878276eb0549a426cb1" +
+			"40c49424b7f78f7125f1162869f340a74e3982151cb472b2e7a68b7459b81db0" +
+			"2283cef17657a61304328bf38f364dc2321c166eba9ef30792bc401b6589b017" +
+			"78b24e611d01725e6337a76b06a3bc3ffeb43c3751bd0b5a595f5c4f59382abb" +
+			"86e13de70e25a3f7f5f71612dbfde826d289e4506d1bfa68491041699d18530a" +
+			"35b8bcd379dd118a5f4f3c1ae43492786d7dd5bd571b91b730672bd3da306eab" +
+			"4be82d26732c90b752f69ae5137b712ae5e07027e27fa1de67e1b1de0d2ee5fc" +
+			"a358f3e440b5b981ceedb8f85182ad3c25730007450bd0430e4b008e5d67c103" +
+			"6cd6c1dd617c21816c23f38db23edd2708ddfd0c4a0ce66cdfeb0f72c5afcc10" +
+			"d8e050f269021379046bbf6029e33ecfb55b3b1b3d9b0d5721a589ffe970bf9b" +
+			"2b04b33a0abfc7ccce8ffec8c2c78e3b60775bd9681a3ceeba853834a5ce8338" +
+			"a0e68e54784dd5fa8d932443ba8fa015d6383b102af0bfc2d41a0d20ea40410d" +
+			"9e07c7f510a16232048675a252fe0cbf7edec6d9142fc5227b4698338bc85c61" +
+			"8580018e1117f30e3b5aad75eb7e0a0b5bc1cd00c626c4a1fd452b2a9a5497a5" +
+			"ebced760704cb98b95eaec8b1d9a88cc18b6b97fc0ac718deed277818d510735" +
+			"31105ff0f77e20cbc579a16ea324dcb5ca5e5b85749639a6ddb358ac4c669003" +
+			"318b478402324bcfea96cc1265c2eec670959d3f7b8703ef8adce9e772ddd6bb" +
+			"a139d2032e486849ccaf911bb0275e628eb8e536f011622545b978fd98689846" +
+			"729d2817a34a21a9cd26562f9a59617082e15c3ab793f4d4cf953174ca510336" +
+			"1f768612cca845ea83344686cae3b85b7c16cb0d50a76753e2f7a4035aabde8d" +
+			"184a6915f9e8a3696f755744c2afc49cf7d8d106c1981392218c91ea3c385a85" +
+			"be294c824895f260efa26d67d8cfe1c6e2e13dca711b0952a1f6b553d74c0965" +
+			"f06eea5c9384aa51f0bd78312aea779ddc4d1e44749c78ec62572c61ada5b40e" +
+			"6bea49e5d7a963948421deb810bf737e32e820eca800732c5b6d256bebd35dd4" +
+			"4841bafa50b689b59d39c31ed8ec3c9cb0bbdb3a87604dc3d4b56e6de3003a86" +
+			"4334cc06327531ae428ee7d8c943e547eb61584cb5d03452b7669d82406c28bd" +
+			"277a5c6eb7c3a90cef933969d314eb7248da28ffac5faad25de83e7199a1024c" +
+			"63591cf6ba3eb59f1d2cc358ebf424339a88d5008ec42af9c769125618c360f5" +
+			"60900eb597f8a7eb5ac6823611cf804d3354f49d92d39bbd708228116c6d25b4" +
+			"6a39b62efa0cebaf359e03c017dda146faf8235689ac69ac48fbededb2524601" +
+			"062948a3fce47f5ff641034c2f237b9263108bd0580f09e187d50d107dbcb286" +
+			"3df13e9f6cb8a99237e45039c734669fb7b9cf4a7b38a51c3c19e06cbd497d15" +
+			"73195d0a97dbbd8d6f2bff677ce0cb0fb38b6b5bfbb555f7a320b43cdf142c9c" +
+			"f87812c8cd2eafc564c23a3fbcaee84a515d9fdc67b75fd50b450301111d62e8" +
+			"7018d827f221bb14bed2d786ff717187e58fd9459af22c7cba41a343fd340b66" +
+			"ccb3d6899a057cbe91f8e42bcd555de21056ce338f007c144dbc3b13a1fa405a" +
+			"7637f863ba20a0c54a2baa6f05997824294707cd03df6b0fab27a62d26fd7351" +
+			"4c82b283156e1fc7901cbbbd49f1c5a89963229fcfe168c1253acf503e833376" +
+			"99b33930d9c264d778d8f86972e03c1e0e037184e5f54a101d8430551942d642" +
+			"4e6d649f0175da00611e4e41048d37fd161e58698b2dca77b25560c2a0d48e14" +
+			"f9694aa234c35a8274c8c75c7a43890915cc7707ff3d7ec168c8b411f1d00f92" +
+			"aa067e2bcee46cd7424f14d74ac691afd04cfa3ad3735abb8c47fb565e1f7229" +
+			"f0301e7b6da3d015a40c4e749a31dea132e87caceb9ad599ec6da70e3e158264" +
+			"da727cfc2122d4fd1a5c031bb6ae5bf7c95501ea48f1f6e9afee41e1faee6330" +
+			"451045952b9fde80dacf75fcfbb22389111408985d443b90ce5eb2c629d46602" +
+			"8067217239d4b26b6be86d3df5474ac2909a6031afb0369dd9ad07e98173fccd" +
+			"fdbce7cf7ff60dea2c5d59707e97aadce5ffacd530bdeabb55b5ef648b123f9c" +
+			"a66ef956fb0b77b1f35d3e3350e7438fc9ad4f01cfa2b8daa37d6f35f7e5e6e0" +
+			"2d42682a92e442b1816f95fe44aef6e7c5ee3b1b1ebd4461e05a12d2103da5a2" +
+			"708b863ec548e34562b1bcf2d5f8512faae814fae2f3d68b6414ed367be4c820" +
+			"e210abbfcee993179595a94a649d2bc521e711ee1b263f655c855f66c5fb1dbc" +
+			"2b00bf19715ac20a475557b9d37980d415342c87601e7a4b658ebaa4430795f3" +
+			"415a5304bb1e8334d71489a665aa50b1554d60a6eb4acf6f5b1f7879ffe352c2" +
+			"19628891892c939dcac61e6272c6632399ab9074851ec8712bd6b34a98c62fb2" +
+			"3bdcf409b66cfc97b6fc30e435522f0bbc0b39903086ee22e3a3b1f7dac42c4e" +
+			"6406564efa7983da11fbd28eeca4235856e9c868a55cedfeb03d156bba5d2f41" +
+			"96e02dd5e0f2eb76a011401e4367d3890d90741620499007386c5779a80054ea" +
+			"a4e007df0382a755d8a34282ec79eb209c9974b32ce78e78bc7f6d1dcf6dfbcc" +
+			"fb86cb28ddb18205da5a6cb3d92bfc6cf336b06ea1af2f5f4cf508363893cfc7" +
+			"960ae4dbb25e6dbf9fa15a31e6e26e80aaa248a2d7b2f61ac61df694eb0ab431" +
+			"a96f84c2760155846ec2cb0644d59013c21cd568c6dc6bcd4113ea10bc91f23e" +
+			"f6f9513ee45cccb5759f8ac25f48aa9cf473778ccf65079d745bd160acc93c7d" +
+			"80094a6ba6eb593242d59ac4123a2641cda3b284a08e6f13ed3350d1a7a86044" +
+			"b8ccb51be3248eef4633d262c320cb241c405c55b3f0fe291ba2e51e182587c0" +
+			"79cf433682df63f43622e45e384dcf21c8d52d33915816cdd898d043fad77335" +
+			"47514d7d663177d7199dbce54f889424b3b96214f2dab33088b6e6997be9a279" +
+			"63a56689d3f0632bee95fca19e75229b2ec280561e08f6a38852663069ddcc08" +
+			"57c58f68cd57fab684b6b4086b0cb3aa49417e6093b09b4bd74a1d4ca2528434" +
+			"e916aa5ee1a2e6ea9e3e2134746049e0037b8ff57cac5b431b5631d8ee03383e" +
+			"c093838b64b0cbdd48e6288a8d4a3c1cc6eb25db6200a591ce5da4a004f00c98" +
+			"e10684d86e73bcd71a50fdcb04b6dc9dcbb62c44609e323c02724be9c45cfdc7" +
+			"7eb7ca5e08d96008c39006a35dad37df04a8d97f1abc37c8ed5e09800fe52435" +
+			"9892acdccacab66b1890f2f81e881a4c6300bf4d74f90a5f59e59552c0350025" +
+			"aea954e19c984008be169712a6e02d2f4e17659f42aeb3ac77b6e2c5e56dc105" +
+			"3f60e4246467b01be040f8116f79e1080f558088ccc10634db0884153a153414" +
+			"8ed9269481dd86b0823264ceb1a5345c0e0bd0621bf0478b8125f70c6bcce658" +
+			"9ddda85f0bbb8c5d8837987f5a6694770d2533f9b2f7aafb221b309e36a01ec2" +
+			"8c4fcfc0892ad0e0f05fdc9dc30f0a703a7982769d996fd7aa02ccce2a25a1ff" +
+			"c4b2f3cf36aef53e1e0cf11e6cb4bbf93d5c9474bbccaf675447814877ae514e" +
+			"6af896b5209201f71d2437db34279a70f1570882312ff5f1d52d9f4cb6bba4ef" +
+			"10fb050ac57277301bc267254f5b851e50932321413e4ee5394f8a8335979384" +
+			"74a4314188da087416aee99e98adf2ec14facd0c6bf3a635d0302944242e1729" +
+			"999854b9749aeb280b0dbbb4ffbf6a1cdfd6864ae39aebc46d64c9bbb4e62669" +
+			"d7255109ccebc7cbd7c55a3e6425c1df100588d57856473de78cd9ecc1594b9a" +
+			"b10fa1979087234e9028a2a6984341e1f5e8eef56ba7d760f10837d196c15799" +
+			"e71993ac9c120ba4cc485ebf51b7008df248c9143977b14b20f55690ff2daf83" +
+			"0fbfb325819f6fd55ce32651b68a79d4f26823b25f743a2105e9909df113fb5b" +
+			"e3023c537fe3d81d724a0db16dad444a990eea04e431feb498879177985696a7" +
+			"279fa13a1c5167ab3edb013fc350bd8c94ae8ae18a86a941b1b41bc1efb3f83d" +
+			"0bfa51a96cb5008571f37f0bca8e07e7ead363897c36434745ebbd5f5ad6f1ba" +
+			"bf025b6d94182225c2ca8d96e2d49e50972f85901a70c700394e3911700eddbe" +
+			"428c5bfb5c835cdc9f744ac9ee0abc694f5283aeeeeda1c3ff86eb1b7e90bf7f" +
+			"5b8853e8487bc2c26e1060fefce7852e8aa5df7c893b42240da9c56ca465fd80" +
+			"7c0ea43b22fb26b084b53d885cb0f27e1e81fb2b3cf696ca709e4097458debbb" +
+			"304ee2be39c8eda8498a37afd2d8267b5e6960dea9202c6f2557bc7033dcb941" +
+			"a0491c236155ca9c194201fc36291cafa318333cf616c8133f9214d5b4f72f18" +
+			"bddf6af63e2f05a2f45f2dad905acf11bf4b62d3738109a182afedea6a71756c" +
+			"bd58cbb1c5691a2f6adbde15ee205fcb62d057dd217ee2694cd2ec32f7b87114" +
+			"0331c16e3f0148c4f7b2623688750387d14015f7dca8bb259a3203a0b14fe423" +
+			"7321d7f6dde03be6674d2e3a058e17f8e3de55e19caf19b401590d4656ff178a" +
+			"2b8213039d83e04193df55cf40e8a6e4c9fadf11cf52dbcf7d2b4e9481b2a5b4" +
+			"b88ea4ecb24f35e7304a4bb1eb8867e333ba6ebcf1f889a6aff87daacc21f630" +
+			"0eca5aac35da0f49e55670a400a96eff90f2e0fb399092cb44874d7d9aca2da5" +
+			"6bbdc1a8bfeff45cd22109b2719ccd79223b81ba969f7bd758ef7c4f0a7104ec" +
+			"a43ae625b3eb28c4758f3d2eb28c30d600df507d13e625bdc990945a31a85291" +
+			"25cd6194fb2fd65c408e85ca2c587dc8324dfae616ddb4d1a985321e3aa3de7c" +
+			"a88c9d4f6533002e7635a83b267e89b5b5fec9b2f11df7627f9a0c9a9ddee0e6" +
+			"179f7a2a32fbd3bf184a366517b008eaa195884b1491b348256befde9820a95a"),
+	},
+	{
+		true, 20,
+		mustHexDecode("504ac8940113d30cd9aaf34cf83f2c399eecc6a897f28e7394b84c400328afc2"),
+		mustHexDecode("9eaa0b7f122ae3df0503931bdac8d7988af9df63b1cd15aa028e41a0751d170e"),
+		mustHexDecode("e28a35a7100d656ead77ce07bb678303"),
+		mustHexDecode("2504f3e07510b8ab53200c6a09041b9a"),
+	},
+	{
+		true, 20,
+		mustHexDecode("39121af86e18114af95aa7eed5998253a82d586ae0e2520b5ec48f2dd68fcbc1"),
+		mustHexDecode("59584412e7d37d0fb53eff16c61f2734c5b9913f6161869d4513d9cf696cdd83"),
+		mustHexDecode("8de4729d239caed4774d260dfe35d49510504bd0ec2c58eeb935e91df988e3"),
+		mustHexDecode("1034a799d545c5dd6de28480a53526d53688e948609d26a9bb4f292f2be3a0"),
+	},
+	{
+		true, 20,
+		mustHexDecode("838aa7d63110b167bfedf6931d2ec94c18ab982ced5a1430c9e04b67b50d6cb4"),
+		mustHexDecode("799aea9210d80b6ab4cf4929db50ce54f293091dccd61af7804974837650af2c"),
+		mustHexDecode("ce7a3cde954b2f63395f508739fb5e4217cdff5e5c7767219caeada6bf89c27e" +
+			"99feec253d947fcf4352ad879d125408c7b8e25c4e4fc06e1cffc13066d42e60" +
+			"e6c6faf5c1c8b1d08983130035523f08b76277bd9b6635d3572494e62c2e9eda" +
+			"44f96bae0bd79f55864e1b4be232209c0315d16e2256c75ce451bcd821d0c419" +
+			"18ce6273ad0c31a666ed1a7d54cba47cebeddf80028d264bd497139debe70b09" +
+			"994de6bab53837ff7dc5f2b98aa8004dff43b422c00b72ea5b3ec3dbc8a7b050" +
+			"48906d8af73062d83acff9cd6a67ab55647064da23ed5826f6902a6e5a98d48e" +
+			"546a9d1d29ef84fa3cba2b5e34457dfc454f13b7ddd72bb71ab4865ecf3554c3" +
+			"b60de7cd4644a4c4482fd0fe72e1f0921f53e4954503b99ec8e0cc049cdd1919" +
+			"a3cf87ecf1840e65bcc9e7122645e62e9ee4796ca004dbca729729fc2043d037" +
+			"64f3339014cf00a2f91ba49b304bd07a0d522b1ad1eae8848b4461b1fd4ddbf7" +
+			"0bd5553283b271428a7f80c6ff9416dfb5fe59e7b5a4589c88d2b4638bcb9b9f" +
+			"c65c941b418ba266da0dbc9d3a59d866d067fa506fe6d07ad10623420e142065" +
+			"2073aa34aca76de52328a0cf573e19003a852f9d7915294c9ff73da3243ca068" +
+			"c64c445a87e7bc0fbb19ea3e37c43bcc1eddfafa710e37d53ac51e905ef0131f" +
+			"7a35b26329b627f20a575c43e2c7024ac656f0c1a7d8c63c81d45e165e2a7777"),
+		mustHexDecode("af276b4329daea3b67a1dfd173fd7743c5f158f9a55937298d6cf7d1b3c00c87" +
+			"a1fc008e1f9a0210982447d716660651f743d7195d78c2cc941621067d0ae1bd" +
+			"96bdd7614d90d1f30d2ea80b2f2fc0898f511694b670da5b89072fc80e537027" +
+			"3b8f624965d55cbb6b499b159e303b018593159166c23789ed1b31afb2a0b33e" +
+			"cd049b815e1b01f37bd2cb3614136edb31e281e7cdcca7f6531e5fddba330a79" +
+			"e9697e8b6ff201cd35568f32ce7cafa5c93ca1f2f511e1cd3a2c4efc435391a9" +
+			"072a1043deebaf489274f8145249a06990a0f6051062e497c1fe7252b7ec2296" +
+			"b5a81edcd87fa07e2601166ba6c43565df1da5c54aa4ecfc571b896dd0cc308a" +
+			"9e02d74aee3b6ad1410efc7246f075ffbb89304e9434c94e1c2568a451658356" +
+			"50886a7928f05686cd92a4c0b34b1418dbcbd113cc7db6a052e0057d53b650bd" +
+			"4256eb97c9d0cc4ac287759a16ba04d69e6a7092c211d3a8e764581d176ec65b" +
+			"e9673c3cfb91037bf05d3e8a77ff3863fdcd99f23bf191155480cd8432114a71" +
+			"69c93e2b892ce5ea24185e28f0a397f1a8d8e235bcfa71a97aaa98fdecc22a21" +
+			"1a6dfb28b91aad42a2678e8ab845686133d17e951f94c99921f5404a1765983e" +
+			"5eb80bf89be30b520792612fd158f0d67847cff90802173dde0472405665b261" +
+			"c49e54c9d0e34d005238291c2ff935a64634eb195c4fd7ae02ccf9c5d3b53501"),
+	},
+	{
+		true, 20,
+		mustHexDecode("8f12edb532a835e1acfcd3b11bea3122684308939c9622ee3596be153a21bcbc"),
+		mustHexDecode("b874029b6ad6cf7713443980b8a9cc029fcc2a36cbcdd8d3885210f48f667059"),
+		mustHexDecode("c4bf6cb7cd6f420182c1b6d879dd5c735693dcbcaa0c87fd98bdb72b8c2e6d30" +
+			"20bdf08412ec284a0c540fc70e945260127a093e219ce60ec64663c56456be8c" +
+			"c6014f842b44560133f5268f6009e5f82b3cd23c4a8c3fb9eef3d4e7226a5c04" +
+			"36c35316c3e9c802a50ad7363db8afab9d12c5dcb196c66cad97a61bdd1186e9" +
+			"04403a6f560f0257ed1758c6c93a9af8f3fcf914dcc62e77bafc5e88cc4b6f57" +
+			"93f1ee0a9c86479277c8852c77c080361140a107ca76ad162823db41ee205fc3" +
+			"f323eebe6a90064349341b638fdd25a0537ce4bb2979e7796098bdedbcfb6e9e" +
+			"31abd97a68335b436985c18875d6c0aa4a1d9c859221e769f8096b88aae3b84d" +
+			"655838dc31072d3360f83e647e92f6804e145ff034cd52d132dd993dd68de507" +
+			"060635e1e426d0496cc500873508681658ea78da619cef3082058099d3a6f8df" +
+			"7915c783a939d2fe433879d8a5b5006470323afa8d76ec5de8ba6b0812040d6d" +
+			"fbd458ccf2ba473621fb23670cf4f2866bf639176a7a0ac4567e898cfdb5c7bc" +
+			"fc0327b7a063666124e93eb4b810ee69680d04b588dd30380f2b726fb28ab89f" +
+			"123a829aa28376c0d4cbfc3653c5be50b5427838f72d23c58a8e98969496ee40" +
+			"65db9575ae9d62b0c0401d46f4230eb93b20824f652faf1483eee868174c32d7" +
+			"0b72cbc5ad5a5ca82940d5cf517691dcaa472f35052995b38ea34d786b722f7b" +
+			"a7255592844eb693bd410644ece42bd55f9fe24eb26df01fb2c9069c243bcbdf" +
+			"66a44479b62f8d9dce1aecd0f132dba7cd793ef2a151781d8b46c2a4bf353d84" +
+			"75eb5dd16a23cbb9159cdc91ae90731e2b7b6dff2e3d97be77e98e15ebfc28b2" +
+			"5b094fe908f8c7ad77a856bd584b57bedc2451ab000965ecca1d6d6255c47079" +
+			"c7975ee2ba9ecf4c0c6220671782c154b335d92454ea38a1522d9d567307c78b" +
+			"6879c38349cb371bf6143d39eb7daeefb549ab93ccc8aa11bd90db476cdc8662" +
+			"a1bafa8d31be5126e288080911baa13086082c8c99c6433414934b803db36078" +
+			"c7cf76ce8f110a570f2cf771f394c6c05ba7295aa24dfb1fc06dface3d7a6c5e" +
+			"99d6a472033bac5c7b8c58a31ce6c750227fa2760bc0f24a0d1a1dbe20408c00" +
+			"27e9151509ae8d5bc6d67e78b0aaf75f637f4cc36362ea357035006081a55aac" +
+			"c999728045de184f85fd50003d40d0346b1481f4e74e266d3e323bb4a3c0e538" +
+			"ee3fe71354ddfb10eed025d4533ebd37bab0f79055607ce312bb0e80a70b9be7" +
+			"13fe06e4902eb6fa59908c0cdb4932d0538af35c9a5e42141624424fcaedb08b" +
+			"2db1630cb95eb986aac759648b91778315826e0281f9a1da564832268025374b" +
+			"0a4c20854ec28f92107e752aa0b681d77321643861ae1236fe3a2ef24ffbb074" +
+			"afef62071d67709f8c2ce9391c066e4d51be1ff86a55f82a338b32355f705a5f" +
+			"b6c4ffb92302580b311d593a15a36d46209a69384d24f3e188a7c1a3fcafcf91" +
+			"1a4a681ae81d73947190eaa4789333034e6fc7eb61cd5239d85c03f0cfcebd22" +
+			"8888496b5076a8bc2287f6986c2ebe4d3d63ca3c88cfe826d94d84c8473bc1da" +
+			"11bcb0af4eaf4cfef7e581583eb9966c129c7ff6fa2937fd4b42601cad2595da" +
+			"3ee50d9cb50a7efa3c5993a5d9336c2ba0dc30d5af0f709d33ed966c9f4099c3" +
+			"e1b1c1111e86e87d7de482ba8a8455ec8caf7fa445e143dabcef1715db608d62" +
+			"d112e2cb0eeed1923271591e81f7f7175e6500ec13b2a1ede8b8df11c1e257cc" +
+			"5b3569a5ddace04ddab4c9cfb9bc8c603b89c1a2caf558e22bc47ef89e7b9563" +
+			"78e87821cc484e5d2330c2adb09475846d40f4f4676ee7491bf38dc9239dae45" +
+			"c946a1981c2405d6ff0b5abc8efef17c6c90c8d0d5a72887615d52cbf3cb0658" +
+			"7cc24a354c8a2984dc2a605cad4acdff4fa201e0c037755bed037cce22844e05" +
+			"a6072e50f6bcd1bf86679d30425ab10ea683025f7f579a807bb9cb2a1466330f" +
+			"0c5963eb82c136ece38ec2fbf73b53626f48555e487ab54d8304cd0a0852ebbc" +
+			"4733766f6eac9c77a289a585e9900afd07774e42bf52bba4c9f2b14a1a7c64b5" +
+			"8b946c00f6fdba0e170a35e61dd19d0717e15240e8e22f43b9c482bca8b83880" +
+			"4fc6b99119aaee70e85bafd14429d414b96d518e17d76b62270771f19c3b5ca1"),
+		mustHexDecode("fc33190fd3896c1ec7522c56fae7604406b210558ae1618b78af16614466e8a7" +
+			"737541ae23a14291b43eb7749e662c1dd6b3d7de10bcb120d54c71b5f039316a" +
+			"92aad4126eab86b292cc89d261abd23b1f1f32647cada8dd0dca41026ffcfb4c" +
+			"444e7a84b605e9f49cd897df6d3a789d2bf822046712cbc9bb2c9b6426d20560" +
+			"95e213d1c4898508854462d1c9e3b6fd1caed8904d5075d11123b0776e6c59f2" +
+			"484a28d169517c3e8bafb10d762566b0fd5f188e0bf21f3d7f501d88483a6877" +
+			"bb890328c1c8b87f7920e95a40ddd235b8e307a251fabf8577bcabad365aaceb" +
+			"ad663479e61d669d4345d189c7a8d109b9503c969ced6c89c456bca758737c23" +
+			"67e804261f88580cc9238e92fae6fe1cce1e3578cac8b3fbbac6db38ba4987c1" +
+			"fc258c006ff43de833abf5fdf1e77d8691c1a96a5c164d1a224862da479b5d18" +
+			"11e564be5fa388cd0d2ad4100170969630cb67fcf22c21cb034cd585361ba243" +
+			"5c5c3e8e3669ec303ecfc9590f488d62097a6a8fa7233283803dc1deeb95c922" +
+			"18a23f6b32e0f9e90e7754c67d411c7588d982f38785526fbaa6209ad601736a" +
+			"71cb7906ea001e1530efe860d11832130e3cdc5bc7cfe7ee38bdac623ec3bfd3" +
+			"1245b42d890ec9d3e3c6aed9e8fd3e8f454eaa8d8e50418392d0918505f31377" +
+			"06127e5d03ceeb14459f57e04b5570f4ee9190a26436086069c6c4e9a0a6ddeb" +
+			"775f642a9e3bc8bcaf79aa82264b597f8a330b59018d0860515dd72a9c752a40" +
+			"4c201e824cc649b7b8180cbb397b97d5758ae21d2baf5c7b939c66b7bee36da7" +
+			"60541c9d9c81d28cdc92c7a54025fef09862a323931fe7ff44e0b9effbcb1c6f" +
+			"e9899badc28e4f3965adfeb4880971bc255a54625e5bf08bf0f654137aba3b8e" +
+			"208b90404898456be2b0c397f7f3c3bfc8029f6a151d09b9422d5c8a0189605e" +
+			"ec48fec445e4e056538767ae272fbb32391b36ae4d1c4397f5a3aa6988d22964" +
+			"c63607d0ef188d9a267f62e56d412093e77c3bdab1009daa6e37b120f1240b1e" +
+			"27de9bf835905b95f70fb5614f947f03517f3371cca0e8eca6436dcac290e914" +
+			"a07adf8ddf7a4a7db352925ad4e38e2f80b8e28e823bd2dea4b95efa33bc4a42" +
+			"ca0a017d95fc299653a280b15af33f77dda01d1f2bad0cb990c91484ffd0bd0d" +
+			"de60cd80ee4223f76b0a55fd922d192cf051129bf99767788b75ed3d0e00cc62" +
+			"60ec2df9216d1824bea8f8ca5452a19a5ff08d333930256368d176af61d8f754" +
+			"60cc0933808680e51f0972669acc436bbf3f8cadca790044cdbbdda39f548fec" +
+			"9258d15d867450560a5fb389f7bca1a63890b68f26c44909640168acf0742411" +
+			"357bcada07e9731472dddb6b000e989d961332bea606afbdade0ac87cb99f906" +
+			"5151842b6ac62ddc692c112b10081a8327062f6e5235c6dd2671225929d8c94c" +
+			"6979df2fdb4a1a3a6ae3eb2ac2bce43efc46e70b7f12401e72b071ee3cb3da19" +
+			"7ebdfb501a081d0fc286783a04166ed819b98bc1401d12d8fc892189c26a9c06" +
+			"8548035354c68405b0e0b71fc5ed1e6b9e2e53adc0830d52358e97fd884d6d9f" +
+			"57fd25f27289a3675e195b256f031917f4a2e471028d926f6945b354fab6a329" +
+			"eb72d3a1f4a05c3edd8849a8a497d784468ef72d1a5ab12fb7a68625d7e552c4" +
+			"3b36128ecb38d7d5e6796eae480872b59dc95dfdb76b9b746a91cdc303e53b1c" +
+			"4b9160608838507d336919ede795cefab5092345787589435d02cf5cde073f5f" +
+			"391938cd5b36acb3f1fe30174127dc84ab30f89f0f9e956205c3ae058a9f218c" +
+			"942ec4a0ad722b8908ae04546327a5047cd21b5055f618c1362bb197fb8be8a7" +
+			"5856bf5acef2465ca6f99fee65b56ad323252065d80265314fb6b8fc870cb3e2" +
+			"b16fc22c18a907c1dcba56c703a3ac5b09a7784371900fb274312a8896e40b44" +
+			"ed991ed120f25bf02f129a4cb06b5b358dddfbfdd1043a7c006cd2ff6fd66edc" +
+			"cf097e5d2e9309fe9f825f9b12d26f0e3f3cd43227c5e815730a1a0ce8ccec18" +
+			"a0872f8b3a084cde1f2614ba4fa7b12531c269f4658ba78d13281c2fabb640ea" +
+			"0f889827ba140448aa6d48ca1105f7500560796109bf356fb8967b6b273b8f2b" +
+			"682c33711f3a2cea8d36b80642f42205e3d8c2a37f54d2b8f4525eb0f0190391"),
+	},
+}
+
+func newTestCipher(t testing.TB, key []byte, rounds int, hpolyc bool) *Cipher {
+	newRounds := NewRounds
+	if hpolyc {
+		newRounds = NewHPolyCRounds
+	}
+
+	c, err := newRounds(key, rounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestVectors(t *testing.T) {
+	for i, v := range testVectors {
+		c := newTestCipher(t, v.key, v.rounds, v.hpolyc)
+
+		ct := make([]byte, len(v.plaintext))
+		c.Encrypt(ct, v.plaintext, v.tweak)
+
+		if !bytes.Equal(ct, v.ciphertext) {
+			t.Errorf("test vector %d: Encrypt: expected %x, got %x", i, v.ciphertext, ct)
+			continue
+		}
+
+		pt := make([]byte, len(v.ciphertext))
+		c.Decrypt(pt, v.ciphertext, v.tweak)
+
+		if !bytes.Equal(pt, v.plaintext) {
+			t.Errorf("test vector %d: Decrypt: expected %x, got %x", i, v.plaintext, pt)
+		}
+	}
+}
+
+func TestDefaultRounds(t *testing.T) {
+	for _, v := range testVectors {
+		if v.rounds != 12 {
+			continue
+		}
+
+		newDefault := New
+		if v.hpolyc {
+			newDefault = NewHPolyC
+		}
+
+		c, err := newDefault(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ct := make([]byte, len(v.plaintext))
+		c.Encrypt(ct, v.plaintext, v.tweak)
+
+		if !bytes.Equal(ct, v.ciphertext) {
+			t.Errorf("expected %x, got %x", v.ciphertext, ct)
+		}
+	}
+}
+
+func TestInPlace(t *testing.T) {
+	for i, v := range testVectors {
+		c := newTestCipher(t, v.key, v.rounds, v.hpolyc)
+
+		buf := append([]byte(nil), v.plaintext...)
+		c.Encrypt(buf, buf, v.tweak)
+
+		if !bytes.Equal(buf, v.ciphertext) {
+			t.Errorf("test vector %d: in place Encrypt differs", i)
+			continue
+		}
+
+		c.Decrypt(buf, buf, v.tweak)
+
+		if !bytes.Equal(buf, v.plaintext) {
+			t.Errorf("test vector %d: in place Decrypt differs", i)
+		}
+	}
+}
+
+func TestDiffusion(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	key := make([]byte, KeySize)
+	rand.Read(key)
+
+	for _, hpolyc := range []bool{false, true} {
+		c := newTestCipher(t, key, 12, hpolyc)
+
+		sector := make([]byte, 4096)
+		rand.Read(sector)
+
+		tweak := make([]byte, 32)
+
+		ct := make([]byte, len(sector))
+		c.Encrypt(ct, sector, tweak)
+
+		// Changing any part of the input must change the whole output.
+		for _, i := range []int{0, 1000, len(sector) - 17, len(sector) - 1} {
+			sector[i] ^= 1
+
+			ct2 := make([]byte, len(sector))
+			c.Encrypt(ct2, sector, tweak)
+
+			sector[i] ^= 1
+
+			if bytes.Equal(ct[:16], ct2[:16]) || bytes.Equal(ct[len(ct)-16:], ct2[len(ct)-16:]) {
+				t.Errorf("hpolyc=%t: flipping byte %d did not change the whole ciphertext", hpolyc, i)
+			}
+		}
+
+		tweak[0] ^= 1
+
+		ct2 := make([]byte, len(sector))
+		c.Encrypt(ct2, sector, tweak)
+
+		if bytes.Equal(ct[:16], ct2[:16]) {
+			t.Errorf("hpolyc=%t: changing the tweak did not change the ciphertext", hpolyc)
+		}
+	}
+}
+
+func TestBadParameters(t *testing.T) {
+	if _, err := New(make([]byte, KeySize-1)); err != ErrInvalidKey {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+
+	if _, err := NewHPolyCRounds(make([]byte, KeySize), 10); err != ErrInvalidRounds {
+		t.Errorf("expected ErrInvalidRounds, got %v", err)
+	}
+}
+
+func BenchmarkAdiantum(b *testing.B) {
+	benchmark(b, false)
+}
+
+func BenchmarkHPolyC(b *testing.B) {
+	benchmark(b, true)
+}
+
+func benchmark(b *testing.B, hpolyc bool) {
+	c := newTestCipher(b, make([]byte, KeySize), 12, hpolyc)
+
+	sector := make([]byte, 4096)
+	tweak := make([]byte, 32)
+
+	b.SetBytes(int64(len(sector)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.Encrypt(sector, sector, tweak)
+	}
+}
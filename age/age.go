@@ -0,0 +1,473 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package age implements the age v1 file format, as described at
+// https://age-encryption.org/v1, on top of github.com/tmthrgd/chacha20.
+//
+// A file is encrypted with a random 128-bit file key, which is wrapped for
+// each recipient in a stanza of the header. The header is
+//
+//	age-encryption.org/v1
+//	-> type arg...
+//	base64(body)
+//	--- base64(mac)
+//
+// with any number of stanzas, where mac is HMAC-SHA-256 of the header up to
+// and including the "---", keyed with HKDF-SHA-256(file key, "", "header").
+// It is followed by a random 16-byte nonce and the payload, which is the
+// plaintext encrypted with ChaCha20-Poly1305 as a STREAM, as implemented by
+// github.com/tmthrgd/chacha20/stream, in 64 KiB chunks under the key
+// HKDF-SHA-256(file key, nonce, "payload"). Each chunk's nonce is an 11-byte
+// big-endian counter followed by a byte that is 1 for the final chunk and 0
+// otherwise.
+//
+// The wrapping and unwrapping of the file key is left to implementations of
+// Recipient and Identity, such as the X25519 and scrypt recipient types of
+// the specification.
+//
+// Files may also be wrapped in the ASCII armor written by age -a, with
+// ArmorWriter and ArmorReader:
+//
+//	r, err := age.NewReader(age.NewArmorReader(f), identity)
+package age
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+	"github.com/tmthrgd/chacha20/stream"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// FileKeySize is the length of file keys, in bytes.
+	FileKeySize = 16
+
+	// ChunkSize is the size of each chunk of plaintext.
+	ChunkSize = 64 * 1024
+)
+
+const (
+	intro        = "age-encryption.org/v1\n"
+	versionLabel = "age-encryption.org/"
+	stanzaPrefix = "->"
+	footerPrefix = "---"
+
+	columnsPerLine = 64
+	bytesPerLine   = columnsPerLine / 4 * 3
+
+	nonceSize = 16
+
+	// As the payload key is unique to each file, the counter takes the whole
+	// nonce. stream's 32-bit counter covers the low bytes of the 11-byte
+	// counter, so the remaining bytes are a zero prefix.
+	noncePrefixSize = chacha20poly1305.NonceSize - stream.NonceOverhead
+)
+
+var (
+	// ErrIncorrectIdentity is returned by Identity.Unwrap when the identity
+	// is not a recipient of the file.
+	ErrIncorrectIdentity = errors.New("age: incorrect identity for recipient block")
+
+	// ErrNoIdentityMatch is returned by NewReader when none of the
+	// identities is a recipient of the file.
+	ErrNoIdentityMatch = errors.New("age: no identity matched any of the recipients")
+
+	// ErrInvalidHeader is returned when the input does not begin with a
+	// valid header and payload nonce.
+	ErrInvalidHeader = errors.New("invalid header")
+
+	// ErrUnsupportedVersion is returned when the header is for a version of
+	// age other than v1.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+
+	// ErrInvalidStanza is returned by NewWriter when a Recipient returns a
+	// stanza that cannot be encoded.
+	ErrInvalidStanza = errors.New("age: invalid recipient stanza")
+
+	errHeaderMAC    = errors.New("age: bad header MAC")
+	errFileKey      = errors.New("age: identity returned invalid file key")
+	errNoRecipients = errors.New("age: no recipients")
+)
+
+var b64 = base64.RawStdEncoding.Strict()
+
+// Stanza is a recipient stanza of the header. It holds the file key wrapped
+// for one recipient.
+type Stanza struct {
+	// Type identifies the kind of recipient, such as "X25519" or "scrypt".
+	Type string
+
+	// Args are the arguments of the stanza, such as an ephemeral share or
+	// a salt. Type and each argument must be non-empty strings of printable
+	// ASCII characters other than space.
+	Args []string
+
+	// Body is the wrapped file key.
+	Body []byte
+}
+
+// Recipient wraps file keys for a recipient.
+type Recipient interface {
+	// Wrap returns the stanzas that allow the recipient to unwrap fileKey.
+	Wrap(fileKey []byte) ([]*Stanza, error)
+}
+
+// Identity unwraps file keys for a recipient.
+type Identity interface {
+	// Unwrap returns the file key from the stanzas that are addressed to
+	// the identity. It returns ErrIncorrectIdentity if there are none, and
+	// any other error if the file is malformed and must not be decrypted.
+	Unwrap(stanzas []*Stanza) ([]byte, error)
+}
+
+// isValidString reports whether s may be used as a stanza type or argument.
+func isValidString(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decodeString decodes canonical unpadded base64. Unlike
+// base64.Encoding.DecodeString, it does not ignore newlines.
+func decodeString(s string) ([]byte, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return nil, ErrInvalidHeader
+	}
+
+	return b64.DecodeString(s)
+}
+
+func writeStanza(b *bytes.Buffer, s *Stanza) error {
+	if !isValidString(s.Type) {
+		return ErrInvalidStanza
+	}
+
+	b.WriteString(stanzaPrefix + " " + s.Type)
+
+	for _, arg := range s.Args {
+		if !isValidString(arg) {
+			return ErrInvalidStanza
+		}
+
+		b.WriteString(" " + arg)
+	}
+
+	b.WriteByte('\n')
+
+	// The body ends with a line shorter than columnsPerLine, which may be
+	// empty.
+	body := b64.EncodeToString(s.Body)
+	for {
+		n := len(body)
+		if n > columnsPerLine {
+			n = columnsPerLine
+		}
+
+		b.WriteString(body[:n])
+		b.WriteByte('\n')
+
+		if n < columnsPerLine {
+			return nil
+		}
+
+		body = body[n:]
+	}
+}
+
+// readStanza reads the stanza whose first line is line.
+func readStanza(r *bufio.Reader, hdr *bytes.Buffer, line string) (*Stanza, error) {
+	args := strings.Split(strings.TrimSuffix(line, "\n"), " ")
+	if args[0] != stanzaPrefix || len(args) < 2 {
+		return nil, ErrInvalidHeader
+	}
+
+	for _, arg := range args[1:] {
+		if !isValidString(arg) {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	s := &Stanza{
+		Type: args[1],
+		Args: args[2:],
+	}
+
+	for {
+		line, err := readLine(r, hdr)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := decodeString(strings.TrimSuffix(line, "\n"))
+		if err != nil || len(b) > bytesPerLine {
+			return nil, ErrInvalidHeader
+		}
+
+		s.Body = append(s.Body, b...)
+
+		if len(b) < bytesPerLine {
+			return s, nil
+		}
+	}
+}
+
+// readLine reads a line, including its newline, and appends it to hdr.
+func readLine(r *bufio.Reader, hdr *bytes.Buffer) (string, error) {
+	line, err := r.ReadString('\n')
+	switch err {
+	case nil:
+	case io.EOF:
+		return "", ErrInvalidHeader
+	default:
+		return "", err
+	}
+
+	hdr.WriteString(line)
+	return line, nil
+}
+
+// readHeader reads the header from r. It returns the stanzas, the MAC and the
+// header up to and including the "---" that the MAC covers.
+func readHeader(r *bufio.Reader) (stanzas []*Stanza, mac, hdr []byte, err error) {
+	var b bytes.Buffer
+
+	line, err := readLine(r, &b)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if line != intro {
+		if strings.HasPrefix(line, versionLabel) {
+			return nil, nil, nil, ErrUnsupportedVersion
+		}
+
+		return nil, nil, nil, ErrInvalidHeader
+	}
+
+	for {
+		peek, err := r.Peek(len(footerPrefix))
+		switch err {
+		case nil:
+		case io.EOF:
+			return nil, nil, nil, ErrInvalidHeader
+		default:
+			return nil, nil, nil, err
+		}
+
+		if string(peek) != footerPrefix {
+			line, err := readLine(r, &b)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			s, err := readStanza(r, &b, line)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			stanzas = append(stanzas, s)
+			continue
+		}
+
+		n := b.Len() + len(footerPrefix)
+
+		line, err := readLine(r, &b)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if !strings.HasPrefix(line, footerPrefix+" ") {
+			return nil, nil, nil, ErrInvalidHeader
+		}
+
+		mac, err := decodeString(strings.TrimSuffix(line[len(footerPrefix)+1:], "\n"))
+		if err != nil || len(mac) != sha256.Size {
+			return nil, nil, nil, ErrInvalidHeader
+		}
+
+		return stanzas, mac, b.Bytes()[:n], nil
+	}
+}
+
+// headerMAC returns the MAC of hdr, the header up to and including the "---".
+func headerMAC(fileKey, hdr []byte) []byte {
+	m := hmac.New(sha256.New, hkdfKey(fileKey, nil, "header", sha256.Size))
+	m.Write(hdr)
+	return m.Sum(nil)
+}
+
+func hkdfKey(secret, salt []byte, info string, n int) []byte {
+	key := make([]byte, n)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), key); err != nil {
+		panic(err)
+	}
+
+	return key
+}
+
+// payloadAEAD returns the ChaCha20-Poly1305 AEAD for the payload.
+func payloadAEAD(fileKey, nonce []byte) cipher.AEAD {
+	aead, err := chacha20poly1305.New(hkdfKey(fileKey, nonce, "payload", chacha20poly1305.KeySize))
+	if err != nil {
+		panic(err)
+	}
+
+	return aead
+}
+
+// Writer encrypts data written to it as an age file and writes it to an
+// underlying io.Writer. Close must be called to write the final chunk.
+type Writer struct {
+	s *stream.Writer
+}
+
+// NewWriter returns a Writer that encrypts to w for each of recipients. The
+// header is written to w before NewWriter returns.
+func NewWriter(w io.Writer, recipients ...Recipient) (*Writer, error) {
+	if len(recipients) == 0 {
+		return nil, errNoRecipients
+	}
+
+	fileKey := make([]byte, FileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	var hdr bytes.Buffer
+	hdr.WriteString(intro)
+
+	for _, r := range recipients {
+		stanzas, err := r.Wrap(fileKey)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range stanzas {
+			if err := writeStanza(&hdr, s); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	hdr.WriteString(footerPrefix)
+	mac := headerMAC(fileKey, hdr.Bytes())
+
+	hdr.WriteString(" " + b64.EncodeToString(mac) + "\n")
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	hdr.Write(nonce)
+
+	if _, err := w.Write(hdr.Bytes()); err != nil {
+		return nil, err
+	}
+
+	s, err := stream.NewWriter(w, payloadAEAD(fileKey, nonce), make([]byte, noncePrefixSize), ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{s}, nil
+}
+
+// Write encrypts p and writes it to the underlying io.Writer.
+func (w *Writer) Write(p []byte) (n int, err error) {
+	return w.s.Write(p)
+}
+
+// Close seals and writes the final chunk. It does not close the underlying
+// io.Writer.
+func (w *Writer) Close() error {
+	return w.s.Close()
+}
+
+// Reader decrypts an age file read from an underlying io.Reader. It only
+// returns plaintext from chunks that have been authenticated.
+type Reader struct {
+	s *stream.Reader
+}
+
+// NewReader reads and authenticates the header from r, with the file key
+// unwrapped by the first of identities that is a recipient of the file, and
+// returns a Reader that decrypts the payload. It returns ErrNoIdentityMatch
+// if none of identities is a recipient.
+func NewReader(r io.Reader, identities ...Identity) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	stanzas, mac, hdr, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileKey []byte
+	for _, id := range identities {
+		fileKey, err = id.Unwrap(stanzas)
+		if err == ErrIncorrectIdentity {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		break
+	}
+
+	if fileKey == nil {
+		return nil, ErrNoIdentityMatch
+	}
+
+	if len(fileKey) != FileKeySize {
+		return nil, errFileKey
+	}
+
+	if !hmac.Equal(headerMAC(fileKey, hdr), mac) {
+		return nil, errHeaderMAC
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(br, nonce); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidHeader
+		}
+
+		return nil, err
+	}
+
+	s, err := stream.NewReader(br, payloadAEAD(fileKey, nonce), make([]byte, noncePrefixSize), ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{s}, nil
+}
+
+// Read reads decrypted plaintext into p. It returns an error if the payload
+// fails to authenticate, including when it has been truncated, and io.EOF
+// once the final chunk has been read.
+func (r *Reader) Read(p []byte) (n int, err error) {
+	return r.s.Read(p)
+}
@@ -0,0 +1,432 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package age
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+)
+
+const x25519Label = "age-encryption.org/v1/X25519"
+
+var errTestStanza = errors.New("invalid test stanza")
+
+// wrapKey and unwrapKey wrap a file key with ChaCha20-Poly1305 and a zero
+// nonce, as the X25519 and scrypt recipient types do.
+func wrapKey(key, fileKey []byte) []byte {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil)
+}
+
+func unwrapKey(key, body []byte) ([]byte, error) {
+	if len(body) != FileKeySize+chacha20poly1305.Overhead {
+		return nil, errTestStanza
+	}
+
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		panic(err)
+	}
+
+	fileKey, err := aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), body, nil)
+	if err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+
+	return fileKey, nil
+}
+
+// testX25519Recipient and testX25519Identity implement the X25519 recipient
+// type of the specification.
+type testX25519Recipient struct {
+	public []byte
+}
+
+func (r *testX25519Recipient) Wrap(fileKey []byte) ([]*Stanza, error) {
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeral); err != nil {
+		return nil, err
+	}
+
+	share, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := curve25519.X25519(ephemeral, r.public)
+	if err != nil {
+		return nil, err
+	}
+
+	key := hkdfKey(shared, append(append([]byte(nil), share...), r.public...), x25519Label, chacha20poly1305.KeySize)
+
+	return []*Stanza{{
+		Type: "X25519",
+		Args: []string{b64.EncodeToString(share)},
+		Body: wrapKey(key, fileKey),
+	}}, nil
+}
+
+type testX25519Identity struct {
+	secret, public []byte
+}
+
+func newTestX25519Identity(secret []byte) *testX25519Identity {
+	public, err := curve25519.X25519(secret, curve25519.Basepoint)
+	if err != nil {
+		panic(err)
+	}
+
+	return &testX25519Identity{secret, public}
+}
+
+func (i *testX25519Identity) Recipient() *testX25519Recipient {
+	return &testX25519Recipient{i.public}
+}
+
+func (i *testX25519Identity) Unwrap(stanzas []*Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type != "X25519" {
+			continue
+		}
+
+		if len(s.Args) != 1 {
+			return nil, errTestStanza
+		}
+
+		share, err := decodeString(s.Args[0])
+		if err != nil || len(share) != curve25519.PointSize {
+			return nil, errTestStanza
+		}
+
+		shared, err := curve25519.X25519(i.secret, share)
+		if err != nil {
+			return nil, errTestStanza
+		}
+
+		key := hkdfKey(shared, append(append([]byte(nil), share...), i.public...), x25519Label, chacha20poly1305.KeySize)
+
+		fileKey, err := unwrapKey(key, s.Body)
+		if err == ErrIncorrectIdentity {
+			continue
+		}
+
+		return fileKey, err
+	}
+
+	return nil, ErrIncorrectIdentity
+}
+
+// testFileKeyRecipient records the file key and wraps it in a stanza of its
+// own type.
+type testFileKeyRecipient struct {
+	fileKey []byte
+	stanzas []*Stanza
+}
+
+func (r *testFileKeyRecipient) Wrap(fileKey []byte) ([]*Stanza, error) {
+	r.fileKey = append([]byte(nil), fileKey...)
+	return r.stanzas, nil
+}
+
+func newTestIdentity(t testing.TB) *testX25519Identity {
+	secret := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatal(err)
+	}
+
+	return newTestX25519Identity(secret)
+}
+
+func encrypt(t testing.TB, plaintext []byte, recipients ...Recipient) []byte {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, recipients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func decrypt(file []byte, identities ...Identity) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(file), identities...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	id := newTestIdentity(t)
+
+	for _, size := range []int{0, 1, 1000, ChunkSize - 1, ChunkSize, ChunkSize + 1, 2 * ChunkSize, 3*ChunkSize + 17} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+
+		file := encrypt(t, plaintext, id.Recipient())
+
+		got, err := decrypt(file, id)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", size, err)
+		}
+
+		if !bytes.Equal(got, plaintext) {
+			t.Fatalf("%d bytes: round trip failed", size)
+		}
+	}
+}
+
+func TestArmor(t *testing.T) {
+	id := newTestIdentity(t)
+
+	for _, size := range []int{0, 1, bytesPerLine - 1, bytesPerLine, bytesPerLine + 1, ChunkSize + 1} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+
+		var buf bytes.Buffer
+		aw := NewArmorWriter(&buf)
+
+		w, err := NewWriter(aw, id.Recipient())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write(plaintext); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		armored := buf.String()
+		if !strings.HasPrefix(armored, armorHeader+"\n") || !strings.HasSuffix(armored, "\n"+armorFooter+"\n") {
+			t.Fatalf("%d bytes: invalid armor:\n%s", size, armored)
+		}
+
+		for _, line := range strings.Split(armored, "\n") {
+			if len(line) > columnsPerLine {
+				t.Fatalf("%d bytes: line of %d columns", size, len(line))
+			}
+		}
+
+		// Whitespace around the armor and CRLF line endings are accepted.
+		armored = "\n  \n" + strings.Replace(armored, "\n", "\r\n", -1) + " \n"
+
+		r, err := NewReader(NewArmorReader(strings.NewReader(armored)), id)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", size, err)
+		}
+
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", size, err)
+		}
+
+		if !bytes.Equal(got, plaintext) {
+			t.Fatalf("%d bytes: round trip failed", size)
+		}
+	}
+}
+
+func TestMultipleRecipients(t *testing.T) {
+	id1, id2, other := newTestIdentity(t), newTestIdentity(t), newTestIdentity(t)
+
+	file := encrypt(t, []byte("hello"), id1.Recipient(), id2.Recipient())
+
+	for _, ids := range [][]Identity{{id1}, {id2}, {other, id2}} {
+		if got, err := decrypt(file, ids...); err != nil || string(got) != "hello" {
+			t.Errorf("decryption failed: %v", err)
+		}
+	}
+
+	if _, err := decrypt(file, other); err != ErrNoIdentityMatch {
+		t.Errorf("expected %v, got %v", ErrNoIdentityMatch, err)
+	}
+
+	if _, err := decrypt(file); err != ErrNoIdentityMatch {
+		t.Errorf("expected %v with no identities, got %v", ErrNoIdentityMatch, err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	r := &testFileKeyRecipient{
+		stanzas: []*Stanza{
+			{Type: "test", Args: []string{"a", "b"}, Body: bytes.Repeat([]byte{0xaa}, 2*bytesPerLine)},
+			{Type: "empty"},
+		},
+	}
+
+	plaintext := bytes.Repeat([]byte{0x5a}, ChunkSize+100)
+	file := encrypt(t, plaintext, r)
+
+	body := b64.EncodeToString(r.stanzas[0].Body)
+	hdr := intro +
+		"-> test a b\n" + body[:columnsPerLine] + "\n" + body[columnsPerLine:] + "\n\n" +
+		"-> empty\n\n" +
+		"---"
+
+	if !bytes.HasPrefix(file, []byte(hdr)) {
+		t.Fatalf("invalid header:\n%s", file[:len(hdr)])
+	}
+
+	rest := file[len(hdr):]
+	mac := " " + b64.EncodeToString(headerMAC(r.fileKey, []byte(hdr))) + "\n"
+
+	if !bytes.HasPrefix(rest, []byte(mac)) {
+		t.Fatalf("invalid MAC line %q", rest[:len(mac)])
+	}
+
+	rest = rest[len(mac):]
+	nonce, payload := rest[:nonceSize], rest[nonceSize:]
+
+	// Open each chunk with its nonce of an 11-byte big-endian counter and
+	// a final chunk flag.
+	aead, err := chacha20poly1305.New(hkdfKey(r.fileKey, nonce, "payload", chacha20poly1305.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed := ChunkSize + chacha20poly1305.Overhead
+
+	chunkNonce := make([]byte, chacha20poly1305.NonceSize)
+	if got, err := aead.Open(nil, chunkNonce, payload[:sealed], nil); err != nil || !bytes.Equal(got, plaintext[:ChunkSize]) {
+		t.Errorf("first chunk failed to open: %v", err)
+	}
+
+	chunkNonce[10], chunkNonce[11] = 1, 1
+	if got, err := aead.Open(nil, chunkNonce, payload[sealed:], nil); err != nil || !bytes.Equal(got, plaintext[ChunkSize:]) {
+		t.Errorf("final chunk failed to open: %v", err)
+	}
+
+	got, err := decrypt(file, &testFileKeyIdentity{r.fileKey})
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("decryption failed: %v", err)
+	}
+}
+
+// testFileKeyIdentity returns fileKey for any file.
+type testFileKeyIdentity struct {
+	fileKey []byte
+}
+
+func (i *testFileKeyIdentity) Unwrap(stanzas []*Stanza) ([]byte, error) {
+	return i.fileKey, nil
+}
+
+func TestTampering(t *testing.T) {
+	id := newTestIdentity(t)
+	file := encrypt(t, []byte("tamper with me"), id.Recipient())
+
+	end := bytes.Index(file, []byte("\n---")) + len("\n---")
+
+	for i := range file {
+		tampered := append([]byte(nil), file...)
+		tampered[i] ^= 0x01
+
+		_, err := decrypt(tampered, id)
+		switch {
+		case err == nil:
+			t.Errorf("decryption succeeded with byte %d modified", i)
+		case i < end && err == ErrNoIdentityMatch:
+			// The stanza no longer unwraps.
+		case i < end && err != ErrInvalidHeader && err != ErrUnsupportedVersion &&
+			err != errHeaderMAC && err != errTestStanza:
+			t.Errorf("byte %d of header modified: unexpected error %v", i, err)
+		}
+	}
+
+	if _, err := decrypt(file[:len(file)-1], id); err == nil {
+		t.Error("decryption succeeded with truncated payload")
+	}
+
+	// The MAC line is a space, 43 characters of base64 and a newline.
+	if _, err := decrypt(file[:end+45+nonceSize/2], id); err != ErrInvalidHeader {
+		t.Errorf("expected %v with truncated nonce, got %v", ErrInvalidHeader, err)
+	}
+}
+
+func TestInvalidStanza(t *testing.T) {
+	for _, s := range []*Stanza{
+		{Type: ""},
+		{Type: "a b"},
+		{Type: "test", Args: []string{""}},
+		{Type: "test", Args: []string{"a\n"}},
+		{Type: "test", Args: []string{"\x7f"}},
+	} {
+		if _, err := NewWriter(ioutil.Discard, &testFileKeyRecipient{stanzas: []*Stanza{s}}); err != ErrInvalidStanza {
+			t.Errorf("%q %q: expected %v, got %v", s.Type, s.Args, ErrInvalidStanza, err)
+		}
+	}
+
+	if _, err := NewWriter(ioutil.Discard); err == nil {
+		t.Error("NewWriter succeeded without recipients")
+	}
+}
+
+func TestBadFileKey(t *testing.T) {
+	file := encrypt(t, nil, &testFileKeyRecipient{})
+
+	if _, err := decrypt(file, &testFileKeyIdentity{make([]byte, FileKeySize+1)}); err != errFileKey {
+		t.Errorf("expected %v, got %v", errFileKey, err)
+	}
+}
+
+func TestUnsupportedVersion(t *testing.T) {
+	file := strings.Replace(string(encrypt(t, nil, &testFileKeyRecipient{})), "/v1\n", "/v2\n", 1)
+
+	if _, err := decrypt([]byte(file), &testFileKeyIdentity{make([]byte, FileKeySize)}); err != ErrUnsupportedVersion {
+		t.Errorf("expected %v, got %v", ErrUnsupportedVersion, err)
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	plaintext := make([]byte, 1024*1024)
+	r := &testFileKeyRecipient{stanzas: []*Stanza{{Type: "bench"}}}
+
+	b.SetBytes(int64(len(plaintext)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		w, err := NewWriter(ioutil.Discard, r)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := w.Write(plaintext); err != nil {
+			b.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package age
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	armorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+	armorFooter = "-----END AGE ENCRYPTED FILE-----"
+
+	// maxArmorWhitespace is the most whitespace that is accepted before the
+	// header and after the footer.
+	maxArmorWhitespace = 1024
+)
+
+var (
+	// ErrInvalidArmor is returned by ArmorReader when the input is not a
+	// valid ASCII armored file.
+	ErrInvalidArmor = errors.New("age: invalid armor")
+
+	errClosedArmor = errors.New("age: write to closed ArmorWriter")
+)
+
+var armorB64 = base64.StdEncoding.Strict()
+
+// ArmorWriter encodes data written to it in the ASCII armor produced by
+// age -a and writes it to an underlying io.Writer. It is a strict PEM
+// encoding, with a type of "AGE ENCRYPTED FILE", no headers and padded
+// base64 in lines of 64 columns. Close must be called to write the final
+// line and the footer.
+type ArmorWriter struct {
+	w io.Writer
+
+	buf  [bytesPerLine]byte
+	n    int
+	line [columnsPerLine + 1]byte
+
+	started bool
+	err     error
+}
+
+// NewArmorWriter returns an ArmorWriter that writes to w. It is usually
+// passed to NewWriter, in which case the Writer must be closed before the
+// ArmorWriter.
+func NewArmorWriter(w io.Writer) *ArmorWriter {
+	return &ArmorWriter{w: w}
+}
+
+// Write encodes p and writes it to the underlying io.Writer.
+func (w *ArmorWriter) Write(p []byte) (n int, err error) {
+	if err := w.start(); err != nil {
+		return 0, err
+	}
+
+	for len(p) != 0 {
+		if w.n == len(w.buf) {
+			if err := w.flush(); err != nil {
+				return n, err
+			}
+		}
+
+		m := copy(w.buf[w.n:], p)
+		w.n += m
+		n += m
+		p = p[m:]
+	}
+
+	return n, nil
+}
+
+// Close writes the final line and the footer. It does not close the
+// underlying io.Writer.
+func (w *ArmorWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	if w.n != 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+
+	w.err = errClosedArmor
+
+	if _, err := io.WriteString(w.w, armorFooter+"\n"); err != nil {
+		w.err = err
+		return err
+	}
+
+	return nil
+}
+
+// start writes the header if it has not already been written.
+func (w *ArmorWriter) start() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if !w.started {
+		w.started = true
+
+		if _, err := io.WriteString(w.w, armorHeader+"\n"); err != nil {
+			w.err = err
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flush encodes and writes the buffered line.
+func (w *ArmorWriter) flush() error {
+	m := armorB64.EncodedLen(w.n)
+	armorB64.Encode(w.line[:m], w.buf[:w.n])
+	w.line[m] = '\n'
+	w.n = 0
+
+	if _, err := w.w.Write(w.line[:m+1]); err != nil {
+		w.err = err
+		return err
+	}
+
+	return nil
+}
+
+// ArmorReader decodes the ASCII armor produced by ArmorWriter or age -a from
+// an underlying io.Reader. Whitespace is accepted before the header and after
+// the footer, and lines may end in CRLF, but the encoding is otherwise strict.
+type ArmorReader struct {
+	r *bufio.Reader
+
+	buf    [bytesPerLine]byte
+	unread []byte
+
+	started bool
+	err     error
+}
+
+// NewArmorReader returns an ArmorReader that reads from r. It is usually
+// passed to NewReader.
+func NewArmorReader(r io.Reader) *ArmorReader {
+	return &ArmorReader{r: bufio.NewReader(r)}
+}
+
+// Read reads decoded data into p. It returns ErrInvalidArmor if the armor is
+// malformed and io.EOF once the footer has been read.
+func (r *ArmorReader) Read(p []byte) (n int, err error) {
+	for len(r.unread) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		r.err = r.readLine()
+	}
+
+	n = copy(p, r.unread)
+	r.unread = r.unread[n:]
+	return n, nil
+}
+
+// readLine reads and decodes the next line. If it is the last line of data,
+// the footer and any whitespace following it are read as well.
+func (r *ArmorReader) readLine() error {
+	var whitespace int
+	for !r.started {
+		line, err := r.line()
+		if err != nil {
+			return err
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			whitespace += len(line) + 1
+			if whitespace > maxArmorWhitespace {
+				return ErrInvalidArmor
+			}
+
+			continue
+		}
+
+		if string(line) != armorHeader {
+			return ErrInvalidArmor
+		}
+
+		r.started = true
+	}
+
+	line, err := r.line()
+	if err != nil {
+		return err
+	}
+
+	if string(line) == armorFooter {
+		return r.trailer()
+	}
+
+	if len(line) == 0 || len(line) > columnsPerLine {
+		return ErrInvalidArmor
+	}
+
+	n, err := armorB64.Decode(r.buf[:], line)
+	if err != nil {
+		return ErrInvalidArmor
+	}
+
+	r.unread = r.buf[:n]
+
+	// Only the last line is shorter than a full line.
+	if n == len(r.buf) {
+		return nil
+	}
+
+	line, err = r.line()
+	if err != nil {
+		return err
+	}
+
+	if string(line) != armorFooter {
+		return ErrInvalidArmor
+	}
+
+	return r.trailer()
+}
+
+// line reads a line without its line ending. The input must not end before
+// the footer.
+func (r *ArmorReader) line() ([]byte, error) {
+	line, err := r.r.ReadSlice('\n')
+	switch err {
+	case nil:
+	case io.EOF:
+		if len(line) == 0 {
+			return nil, ErrInvalidArmor
+		}
+	case bufio.ErrBufferFull:
+		return nil, ErrInvalidArmor
+	default:
+		return nil, err
+	}
+
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	return line, nil
+}
+
+// trailer reads what follows the footer, which may only be whitespace.
+func (r *ArmorReader) trailer() error {
+	buf, err := ioutil.ReadAll(io.LimitReader(r.r, maxArmorWhitespace))
+	if err != nil {
+		return err
+	}
+
+	if len(buf) == maxArmorWhitespace || len(bytes.TrimSpace(buf)) != 0 {
+		return ErrInvalidArmor
+	}
+
+	return io.EOF
+}
@@ -0,0 +1,520 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build go1.16
+
+package age
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+	"github.com/tmthrgd/chacha20/stream"
+	"golang.org/x/crypto/scrypt"
+
+	agetest "c2sp.org/CCTV/age"
+)
+
+// bech32Decode decodes a BIP 173 Bech32 string.
+func bech32Decode(s string) (hrp string, data []byte, err error) {
+	const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, errors.New("mixed case")
+	}
+
+	s = strings.ToLower(s)
+
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, errors.New("invalid separator")
+	}
+
+	hrp = s[:pos]
+
+	var values []byte
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+
+	values = append(values, 0)
+
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+
+	n := len(values)
+
+	for i := pos + 1; i < len(s); i++ {
+		v := strings.IndexByte(charset, s[i])
+		if v < 0 {
+			return "", nil, errors.New("invalid character")
+		}
+
+		values = append(values, byte(v))
+	}
+
+	gen := [...]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+
+		for i, g := range gen {
+			if (b>>uint(i))&1 != 0 {
+				chk ^= g
+			}
+		}
+	}
+
+	if chk != 1 {
+		return "", nil, errors.New("invalid checksum")
+	}
+
+	var acc, bits uint
+	for _, v := range values[n : len(values)-6] {
+		acc = acc<<5 | uint(v)
+		bits += 5
+
+		if bits >= 8 {
+			bits -= 8
+			data = append(data, byte(acc>>bits))
+		}
+	}
+
+	if bits >= 5 || acc&(1<<bits-1) != 0 {
+		return "", nil, errors.New("invalid padding")
+	}
+
+	return hrp, data, nil
+}
+
+// errUnknownIdentity is returned by parseTestX25519Identity for identities of
+// types other than X25519, such as hybrid post-quantum identities.
+var errUnknownIdentity = errors.New("unknown identity type")
+
+func parseTestX25519Identity(s string) (*testX25519Identity, error) {
+	hrp, secret, err := bech32Decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if hrp != "age-secret-key-" {
+		return nil, errUnknownIdentity
+	}
+
+	if len(secret) != 32 {
+		return nil, errors.New("invalid X25519 identity")
+	}
+
+	return newTestX25519Identity(secret), nil
+}
+
+// testScryptIdentity implements the scrypt recipient type of the
+// specification.
+type testScryptIdentity struct {
+	passphrase []byte
+}
+
+const (
+	scryptLabel         = "age-encryption.org/v1/scrypt"
+	scryptMaxWorkFactor = 22
+)
+
+var scryptWorkFactor = regexp.MustCompile(`^[1-9][0-9]*$`)
+
+func (i *testScryptIdentity) Unwrap(stanzas []*Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type != "scrypt" {
+			continue
+		}
+
+		// An scrypt stanza must be the only one.
+		if len(stanzas) != 1 || len(s.Args) != 2 {
+			return nil, errTestStanza
+		}
+
+		salt, err := decodeString(s.Args[0])
+		if err != nil || len(salt) != 16 {
+			return nil, errTestStanza
+		}
+
+		if !scryptWorkFactor.MatchString(s.Args[1]) {
+			return nil, errTestStanza
+		}
+
+		logN, err := strconv.Atoi(s.Args[1])
+		if err != nil || logN > scryptMaxWorkFactor {
+			return nil, errTestStanza
+		}
+
+		key, err := scrypt.Key(i.passphrase, append([]byte(scryptLabel), salt...), 1<<uint(logN), 8, 1, chacha20poly1305.KeySize)
+		if err != nil {
+			return nil, err
+		}
+
+		fileKey, err := unwrapKey(key, s.Body)
+		if err == ErrIncorrectIdentity {
+			continue
+		}
+
+		return fileKey, err
+	}
+
+	return nil, ErrIncorrectIdentity
+}
+
+type testkitVector struct {
+	expect      string
+	payloadHash []byte
+	fileKey     []byte
+	identities  []Identity
+	armored     bool
+	compressed  bool
+	file        []byte
+}
+
+func parseTestkitVector(t *testing.T, contents []byte) *testkitVector {
+	v := new(testkitVector)
+
+	for {
+		i := bytes.IndexByte(contents, '\n')
+		if i < 0 {
+			t.Fatal("invalid test file: no payload")
+		}
+
+		line := string(contents[:i])
+		contents = contents[i+1:]
+
+		if line == "" {
+			break
+		}
+
+		parts := strings.SplitN(line, ": ", 2)
+		if len(parts) != 2 {
+			t.Fatalf("invalid test file: malformed line %q", line)
+		}
+
+		var err error
+		switch key, value := parts[0], parts[1]; key {
+		case "expect":
+			v.expect = value
+		case "payload":
+			v.payloadHash, err = hex.DecodeString(value)
+		case "file key":
+			v.fileKey, err = hex.DecodeString(value)
+		case "identity":
+			var id *testX25519Identity
+			id, err = parseTestX25519Identity(value)
+			if err == errUnknownIdentity {
+				t.Skipf("%s identities are not implemented", strings.SplitN(value, "1", 2)[0])
+			}
+
+			v.identities = append(v.identities, id)
+		case "passphrase":
+			v.identities = append(v.identities, &testScryptIdentity{[]byte(value)})
+		case "armored":
+			v.armored = true
+		case "compressed":
+			if value != "zlib" {
+				t.Skipf("unknown compression %q", value)
+			}
+
+			v.compressed = true
+		case "comment":
+		default:
+			// Vectors with unknown keys are meant to be ignored.
+			t.Skipf("unknown header key %q", key)
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v.file = contents
+
+	if v.compressed {
+		zr, err := zlib.NewReader(bytes.NewReader(contents))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v.file, err = ioutil.ReadAll(zr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return v
+}
+
+func TestTestkit(t *testing.T) {
+	entries, err := fs.ReadDir(agetest.Vectors, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		contents, err := fs.ReadFile(agetest.Vectors, entry.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(entry.Name(), func(t *testing.T) {
+			v := parseTestkitVector(t, contents)
+			if v.armored && !testTestkitArmor(t, v) {
+				return
+			}
+
+			testTestkitVector(t, v)
+
+			if v.expect == "success" {
+				testTestkitRoundTrip(t, v)
+			}
+		})
+	}
+}
+
+// testTestkitArmor decodes the armor of v, replacing v.file with the decoded
+// file. It reports whether the armor was valid.
+func testTestkitArmor(t *testing.T, v *testkitVector) bool {
+	file, err := ioutil.ReadAll(NewArmorReader(bytes.NewReader(v.file)))
+	if err == ErrInvalidArmor {
+		if v.expect != "armor failure" {
+			t.Fatalf("expected %s, got %v", v.expect, err)
+		}
+
+		return false
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.expect == "armor failure" {
+		t.Fatalf("expected %s, got valid armor", v.expect)
+	}
+
+	// The armor is encoded identically, but for line endings and the
+	// whitespace that is accepted around it.
+	var b bytes.Buffer
+
+	aw := NewArmorWriter(&b)
+	if _, err := aw.Write(file); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	armored := strings.TrimSpace(strings.Replace(string(v.file), "\r\n", "\n", -1)) + "\n"
+	if b.String() != armored {
+		t.Errorf("armor encoded differently:\n%s\nexpected:\n%s", b.Bytes(), armored)
+	}
+
+	v.file = file
+	return true
+}
+
+func testTestkitVector(t *testing.T, v *testkitVector) {
+	r, err := NewReader(bytes.NewReader(v.file), v.identities...)
+	switch {
+	case err == errHeaderMAC:
+		if v.expect != "HMAC failure" {
+			t.Fatalf("expected %s, got %v", v.expect, err)
+		}
+
+		return
+	case err == ErrNoIdentityMatch:
+		if v.expect != "no match" {
+			t.Fatalf("expected %s, got %v", v.expect, err)
+		}
+
+		return
+	case err != nil:
+		if v.expect != "header failure" {
+			t.Fatalf("expected %s, got %v", v.expect, err)
+		}
+
+		return
+	case v.expect != "success" && v.expect != "payload failure":
+		t.Fatalf("expected %s, got a valid header", v.expect)
+	}
+
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		if v.expect != "payload failure" {
+			t.Fatalf("expected %s, got %v", v.expect, err)
+		}
+
+		if v.payloadHash == nil {
+			return
+		}
+
+		// The reference implementation releases a full chunk before
+		// reading what follows it, while Reader holds it back until it
+		// knows whether it is the last. Any plaintext returned must be a
+		// prefix of what it releases.
+		released := testkitReleased(t, v)
+		if sum := sha256.Sum256(released); !bytes.Equal(sum[:], v.payloadHash) {
+			t.Fatal("partial payload hash mismatch")
+		}
+
+		if !bytes.HasPrefix(released, out) {
+			t.Errorf("returned %d bytes of unauthenticated plaintext", len(out))
+		}
+
+		return
+	}
+
+	if v.expect != "success" {
+		t.Fatalf("expected %s, got success", v.expect)
+	}
+
+	if sum := sha256.Sum256(out); !bytes.Equal(sum[:], v.payloadHash) {
+		t.Error("payload hash mismatch")
+	}
+}
+
+// testkitPayload returns the payload nonce and the encrypted chunks of the
+// file.
+func testkitPayload(t *testing.T, v *testkitVector) (nonce, payload []byte) {
+	br := bufio.NewReader(bytes.NewReader(v.file))
+
+	if _, _, _, err := readHeader(br); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := ioutil.ReadAll(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return payload[:nonceSize], payload[nonceSize:]
+}
+
+// testkitReleased returns the plaintext the reference implementation releases
+// before failing. It opens each full chunk without looking at what follows it,
+// as either a middle or a final chunk.
+func testkitReleased(t *testing.T, v *testkitVector) []byte {
+	nonce, payload := testkitPayload(t, v)
+	aead := payloadAEAD(v.fileKey, nonce)
+
+	var out []byte
+
+	chunkNonce := make([]byte, chacha20poly1305.NonceSize)
+	for i := uint32(0); len(payload) != 0; i++ {
+		chunk := payload
+		if len(chunk) > ChunkSize+chacha20poly1305.Overhead {
+			chunk = chunk[:ChunkSize+chacha20poly1305.Overhead]
+		}
+
+		payload = payload[len(chunk):]
+
+		binary.BigEndian.PutUint32(chunkNonce[noncePrefixSize:], i)
+		chunkNonce[len(chunkNonce)-1] = 0
+
+		last := len(chunk) < ChunkSize+chacha20poly1305.Overhead
+		if last {
+			chunkNonce[len(chunkNonce)-1] = 1
+		}
+
+		plaintext, err := aead.Open(nil, chunkNonce, chunk, nil)
+		if err != nil && !last {
+			last = true
+			chunkNonce[len(chunkNonce)-1] = 1
+			plaintext, err = aead.Open(nil, chunkNonce, chunk, nil)
+		}
+
+		if err != nil {
+			break
+		}
+
+		out = append(out, plaintext...)
+
+		if last {
+			break
+		}
+	}
+
+	return out
+}
+
+// testTestkitRoundTrip checks that the header and payload of a valid file
+// are encoded identically by this package.
+func testTestkitRoundTrip(t *testing.T, v *testkitVector) {
+	br := bufio.NewReader(bytes.NewReader(v.file))
+
+	stanzas, mac, hdr, err := readHeader(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(intro)
+
+	for _, s := range stanzas {
+		if err := writeStanza(&b, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b.WriteString(footerPrefix)
+
+	if !bytes.Equal(b.Bytes(), hdr) {
+		t.Errorf("header encoded differently:\n%s\nexpected:\n%s", b.Bytes(), hdr)
+	}
+
+	if !bytes.Equal(headerMAC(v.fileKey, hdr), mac) {
+		t.Error("header MAC mismatch")
+	}
+
+	nonce, payload := testkitPayload(t, v)
+	aead := payloadAEAD(v.fileKey, nonce)
+	prefix := make([]byte, noncePrefixSize)
+
+	sr, err := stream.NewReader(bytes.NewReader(payload), aead, prefix, ChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := ioutil.ReadAll(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ct bytes.Buffer
+
+	sw, err := stream.NewWriter(&ct, aead, prefix, ChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sw.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(ct.Bytes(), payload) {
+		t.Error("payload encoded differently")
+	}
+}
@@ -3,8 +3,8 @@
 // Modified BSD License license that can be found in
 // the LICENSE file.
 
-// Package chacha20 provides an AVX/AVX2/pure-Go implementation of ChaCha20, a
-// fast, secure stream cipher.
+// Package chacha20 provides an AVX/AVX2/SSE2/pure-Go implementation of ChaCha20,
+// a fast, secure stream cipher.
 //
 // From Bernstein, Daniel J. "ChaCha, a variant of Salsa20." Workshop Record of
 // SASC. 2008. (http://cr.yp.to/chacha/chacha-20080128.pdf):
@@ -22,13 +22,19 @@ package chacha20
 
 import (
 	"crypto/cipher"
+	"encoding/binary"
 	"errors"
+
+	"github.com/tmthrgd/chacha20/internal/ref"
 )
 
 const (
 	// KeySize is the length of ChaCha20 keys, in bytes.
 	KeySize = 32
 
+	// KeySize128 is the length of 128-bit ChaCha20 keys, in bytes.
+	KeySize128 = 16
+
 	// NonceSize is the length of ChaCha20 nonces, in bytes.
 	//
 	// In most cases either RFCNonceSize or DraftNonceSize should
@@ -45,15 +51,30 @@ const (
 
 	// XNonceSize is the length of XChaCha20 nonces, in bytes.
 	XNonceSize = 24
+
+	// HNonceSize is the length of HChaCha20 nonces, in bytes.
+	HNonceSize = 16
+
+	// HChaChaSize is the length of HChaCha20 output, in bytes.
+	HChaChaSize = 32
+
+	// OpenSSLIVSize is the length of the IVs taken by NewOpenSSL, in bytes.
+	OpenSSLIVSize = 16
 )
 
 var (
-	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long,
+	// or KeySize128 bytes long for New128.
 	ErrInvalidKey = errors.New("invalid key length")
 
 	// ErrInvalidNonce is returned when the provided nonce is not RFCNonceSize,
-	// DraftNonceSize or XNonceSize bytes long.
+	// DraftNonceSize or XNonceSize bytes long, or the IV passed to NewOpenSSL
+	// is not OpenSSLIVSize bytes long.
 	ErrInvalidNonce = errors.New("invalid nonce length")
+
+	// ErrInvalidRounds is returned when the number of rounds is not 8, 12
+	// or 20.
+	ErrInvalidRounds = errors.New("invalid number of rounds")
 )
 
 // New creates and returns a new cipher.Stream. The key argument must be 256
@@ -74,3 +95,123 @@ func New(key, nonce []byte) (cipher.Stream, error) {
 		return NewDraft(key, nonce)
 	}
 }
+
+// NewRounds creates and returns a new cipher.Stream for ChaCha8, ChaCha12 or
+// ChaCha20, as selected by rounds. The key and nonce arguments are as for New.
+// For 192-bit nonces the subkey is derived with the same number of rounds of
+// HChaCha, as XChaCha12 is in Adiantum.
+//
+// Only ChaCha20 is accelerated; ChaCha8 and ChaCha12 always use the pure-Go
+// implementation.
+//
+// In most cases New, NewRFC, NewDraft or NewXChaCha should be used instead.
+func NewRounds(key, nonce []byte, rounds int) (cipher.Stream, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	switch len(nonce) {
+	case DraftNonceSize, RFCNonceSize, XNonceSize:
+	default:
+		return nil, ErrInvalidNonce
+	}
+
+	switch rounds {
+	case 20:
+		return New(key, nonce)
+	case 8, 12:
+		return ref.NewRounds(key, nonce, rounds)
+	default:
+		return nil, ErrInvalidRounds
+	}
+}
+
+// New128 creates and returns a new cipher.Stream that uses a 128-bit key with
+// the "expand 16-byte k" constants from the original ChaCha specification. The
+// key argument must be 128 bits long, and the nonce argument must be either 64,
+// 96 or 192 bits long, selecting the same variant as New. For XChaCha20, the
+// 128-bit key is only used by HChaCha20 to derive a 256-bit subkey.
+//
+// 128-bit keys are provided for interoperability with existing systems only.
+// In all other cases a 256-bit key should be used instead.
+func New128(key, nonce []byte) (cipher.Stream, error) {
+	if len(key) != KeySize128 {
+		return nil, ErrInvalidKey
+	}
+
+	switch len(nonce) {
+	case DraftNonceSize, RFCNonceSize, XNonceSize:
+		return new128(key, nonce), nil
+	default:
+		return nil, ErrInvalidNonce
+	}
+}
+
+// NewOpenSSL creates and returns a new cipher.Stream that is compatible with
+// OpenSSL's EVP_chacha20. The key argument must be 256 bits long, and the iv
+// argument must be 128 bits long. As in OpenSSL, the iv is a 32-bit little
+// endian block counter followed by a 96-bit nonce, with which NewOpenSSL
+// behaves like NewRFC with the counter already set.
+//
+// Like OpenSSL, the block counter carries into the first word of the nonce
+// rather than wrapping.
+func NewOpenSSL(key, iv []byte) (cipher.Stream, error) {
+	if len(iv) != OpenSSLIVSize {
+		return nil, ErrInvalidNonce
+	}
+
+	s, err := NewRFC(key, iv[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	SetCounter(s, uint64(binary.LittleEndian.Uint32(iv)))
+	return s, nil
+}
+
+// HChaCha20 derives a 256-bit subkey from a 256-bit key and a 128-bit nonce as
+// XChaCha20 does, writing it to out.
+func HChaCha20(out *[HChaChaSize]byte, key *[KeySize]byte, nonce *[HNonceSize]byte) {
+	hChaCha20(out, key, nonce)
+}
+
+// SetCounter sets the block counter of s, which must have been returned by
+// one of the ctors in this package, so that the next call to XORKeyStream
+// continues from 64*counter bytes into the keystream. Any buffered keystream
+// is discarded.
+//
+// The counter of a stream created with a 96-bit nonce is only 32 bits long,
+// and SetCounter panics if counter is larger than that.
+//
+// Moving the counter backwards reuses keystream. This must never be done while
+// encrypting different plaintexts.
+func SetCounter(s cipher.Stream, counter uint64) {
+	cs, ok := s.(interface {
+		SetCounter(counter uint64)
+	})
+	if !ok {
+		panic("chacha20: SetCounter called with a foreign cipher.Stream")
+	}
+
+	cs.SetCounter(counter)
+}
+
+// SetNonce replaces the nonce of s, which must have been returned by NewRFC,
+// NewDraft or New, and resets the block counter to zero, discarding any
+// buffered keystream. nonce must be the same length as the nonce s was
+// created with. SetNonce panics if s is an XChaCha20 stream, as the subkey
+// of XChaCha20 is derived from the nonce.
+//
+// SetNonce allows one stream to be used for many messages under the same key
+// without allocating a new stream for each. A nonce must still never be used
+// twice with the same key.
+func SetNonce(s cipher.Stream, nonce []byte) {
+	ns, ok := s.(interface {
+		SetNonce(nonce []byte)
+	})
+	if !ok {
+		panic("chacha20: SetNonce called with a foreign cipher.Stream")
+	}
+
+	ns.SetNonce(nonce)
+}
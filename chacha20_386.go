@@ -0,0 +1,286 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build 386,!gccgo,!appengine
+
+package chacha20
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+
+	"github.com/tmthrgd/chacha20/internal/ref"
+	"github.com/tmthrgd/chacha20/internal/vec"
+	"github.com/tmthrgd/chacha20/internal/xor"
+)
+
+var useSSE2 = hasSSE2()
+
+// useRef is true when the SSE2 implementation cannot be used.
+var useRef = !useSSE2
+
+var useAVX, useAVX2 = false, false
+
+// NewRFC creates and returns a new cipher.Stream. The key argument must be 256
+// bits long, and the nonce argument must be 96 bits long. The nonce must be
+// randomly generated or used only once. This Stream instance must not be used
+// to encrypt more than 2^38 bytes (256 gigabytes).
+func NewRFC(key, nonce []byte) (cipher.Stream, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	if len(nonce) != RFCNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	if !useSSE2 {
+		return ref.NewRFC(key, nonce)
+	}
+
+	s := &stream{rfc: true}
+	copy(s.state[:32], key)
+	copy(s.state[36:], nonce)
+	return s, nil
+}
+
+// NewDraft creates and returns a new cipher.Stream. The key argument must be
+// 256 bits long, and the nonce argument must be 64 bits long. The nonce must
+// be randomly generated or used only once. This Stream instance must not be
+// used to encrypt more than 2^70 bytes (~1 zettabyte).
+func NewDraft(key, nonce []byte) (cipher.Stream, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	if len(nonce) != DraftNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	if !useSSE2 {
+		return ref.NewDraft(key, nonce)
+	}
+
+	s := new(stream)
+	copy(s.state[:32], key)
+	copy(s.state[40:], nonce)
+	return s, nil
+}
+
+// NewXChaCha creates and returns a new cipher.Stream. The key argument must be
+// 256 bits long, and the nonce argument must be 192 bits long. The nonce must
+// be randomly generated or only used once. This Stream instance must not be
+// used to encrypt more than 2^70 bytes (~1 zetta byte).
+func NewXChaCha(key, nonce []byte) (cipher.Stream, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	if len(nonce) != XNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	if !useSSE2 {
+		return ref.NewXChaCha(key, nonce)
+	}
+
+	var hKey [KeySize]byte
+	copy(hKey[:], key)
+
+	var hNonce [HNonceSize]byte
+	copy(hNonce[:], nonce[:HNonceSize])
+
+	var subKey [HChaChaSize]byte
+	hchacha_20_sse2(&hKey, &hNonce, &subKey, false)
+
+	s := &stream{xchacha: true}
+	copy(s.state[:32], subKey[:])
+	copy(s.state[40:], nonce[HNonceSize:])
+	return s, nil
+}
+
+// new128 returns a stream for a 128-bit key and a nonce whose length has
+// already been validated by New128.
+func new128(key, nonce []byte) cipher.Stream {
+	if !useSSE2 {
+		s, _ := ref.New128(key, nonce)
+		return s
+	}
+
+	s := &stream{key128: true}
+
+	// The 128-bit key fills both halves of the key words.
+	copy(s.state[:16], key)
+	copy(s.state[16:32], key)
+
+	switch len(nonce) {
+	case RFCNonceSize:
+		s.rfc = true
+		copy(s.state[36:], nonce)
+	case DraftNonceSize:
+		copy(s.state[40:], nonce)
+	case XNonceSize:
+		var hKey [KeySize]byte
+		copy(hKey[:], s.state[:32])
+
+		var hNonce [HNonceSize]byte
+		copy(hNonce[:], nonce[:HNonceSize])
+
+		var subKey [HChaChaSize]byte
+		hchacha_20_sse2(&hKey, &hNonce, &subKey, true)
+
+		// The derived subkey is a 256-bit key.
+		s.key128 = false
+		s.xchacha = true
+		copy(s.state[:32], subKey[:])
+		copy(s.state[40:], nonce[HNonceSize:])
+	}
+
+	return s
+}
+
+func hChaCha20(out *[HChaChaSize]byte, key *[KeySize]byte, nonce *[HNonceSize]byte) {
+	if !useSSE2 {
+		ref.HChaCha20(out, key[:], nonce[:])
+		return
+	}
+
+	hchacha_20_sse2(key, nonce, out, false)
+}
+
+type stream struct {
+	state   [48]byte
+	key128  bool
+	rfc     bool // whether the counter is only 32 bits
+	xchacha bool // whether the key is an HChaCha20 subkey
+
+	backing [64]byte
+	buffer  []byte
+}
+
+// SetCounter sets the block counter, discarding any buffered keystream.
+func (s *stream) SetCounter(counter uint64) {
+	if s.rfc {
+		if counter > uint64(^uint32(0)) {
+			panic("chacha20: counter too large for a 96-bit nonce")
+		}
+
+		binary.LittleEndian.PutUint32(s.state[32:], uint32(counter))
+	} else {
+		binary.LittleEndian.PutUint64(s.state[32:], counter)
+	}
+
+	b := s.buffer
+	for i := range b {
+		b[i] = 0
+	}
+
+	s.buffer = nil
+}
+
+// SetNonce replaces the nonce, which must be the same length as the nonce
+// the stream was created with, and resets the block counter to zero,
+// discarding any buffered keystream.
+func (s *stream) SetNonce(nonce []byte) {
+	if s.xchacha {
+		panic("chacha20: cannot replace the nonce of an XChaCha20 stream")
+	}
+
+	if s.rfc {
+		if len(nonce) != RFCNonceSize {
+			panic("chacha20: invalid nonce length")
+		}
+
+		copy(s.state[36:], nonce)
+	} else {
+		if len(nonce) != DraftNonceSize {
+			panic("chacha20: invalid nonce length")
+		}
+
+		copy(s.state[40:], nonce)
+	}
+
+	s.SetCounter(0)
+}
+
+// SetKey replaces the key with a 256-bit key and resets the block counter to
+// zero, discarding any buffered keystream.
+func (s *stream) SetKey(key []byte) {
+	s.key128 = false
+	copy(s.state[:32], key)
+
+	s.SetCounter(0)
+}
+
+func (s *stream) XORKeyStream(dst, src []byte) {
+	if len(src) == 0 {
+		return
+	}
+
+	if len(s.buffer) != 0 {
+		i := xor.Bytes(dst, s.buffer, src)
+
+		b := s.buffer[:i]
+		for j := range b {
+			b[j] = 0
+		}
+
+		s.buffer = s.buffer[i:]
+		src = src[i:]
+		dst = dst[i:]
+
+		if len(src) == 0 {
+			return
+		}
+	}
+
+	chacha_20_core_sse2(&dst[0], &src[0], uint32(len(src)), &s.state, s.key128)
+
+	if todo := len(src) & 63; todo != 0 {
+		// The backing buffer is always zero here, so this leaves the
+		// keystream for the next block in it.
+		chacha_20_core_sse2(&s.backing[0], &s.backing[0], 64, &s.state, s.key128)
+
+		xor.Bytes(dst[len(src)-todo:], s.backing[:todo], src[len(src)-todo:])
+
+		b := s.backing[:todo]
+		for i := range b {
+			b[i] = 0
+		}
+
+		s.buffer = s.backing[todo:]
+	}
+}
+
+// XORKeyStreamVec XORs the fragments of src with the keystream as a single
+// message, passing whole blocks directly to the assembly implementation even
+// when they straddle fragments.
+func (s *stream) XORKeyStreamVec(dst, src [][]byte) {
+	vec.XORKeyStream(s, dst, src, 64)
+}
+
+// Buffered returns the number of bytes of keystream left over from a partial
+// block.
+func (s *stream) Buffered() int {
+	return len(s.buffer)
+}
+
+// XORBlocks XORs src, a whole number of 64-byte blocks, with the keystream.
+// There must be no buffered keystream.
+func (s *stream) XORBlocks(dst, src []byte) {
+	chacha_20_core_sse2(&dst[0], &src[0], uint32(len(src)), &s.state, s.key128)
+}
+
+// This function is implemented in sse2_386.s
+//go:noescape
+func hasSSE2() bool
+
+// This function is implemented in chacha20_386.s
+//go:noescape
+func chacha_20_core_sse2(out, in *byte, in_len uint32, state *[48]byte, key128 bool)
+
+// This function is implemented in chacha20_386.s
+//go:noescape
+func hchacha_20_sse2(key *[KeySize]byte, nonce *[HNonceSize]byte, out *[HChaChaSize]byte, key128 bool)
@@ -0,0 +1,162 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build 386,!gccgo,!appengine
+
+// This code follows the structure of the SSE2 implementation in SUPERCOP
+// (http://bench.cr.yp.to/supercop.html) that hchacha20_x64_amd64.s was
+// translated from, restricted to the eight XMM registers available on 386.
+
+#include "textflag.h"
+
+// "expand 32-byte k"
+DATA ·sigma<>+0x00(SB)/4, $0x61707865
+DATA ·sigma<>+0x04(SB)/4, $0x3320646e
+DATA ·sigma<>+0x08(SB)/4, $0x79622d32
+DATA ·sigma<>+0x0c(SB)/4, $0x6b206574
+GLOBL ·sigma<>(SB), (NOPTR+RODATA), $16
+
+// "expand 16-byte k", for 128-bit keys
+DATA ·tau<>+0x00(SB)/4, $0x61707865
+DATA ·tau<>+0x04(SB)/4, $0x3120646e
+DATA ·tau<>+0x08(SB)/4, $0x79622d36
+DATA ·tau<>+0x0c(SB)/4, $0x6b206574
+GLOBL ·tau<>(SB), (NOPTR+RODATA), $16
+
+// 1 as the low 64-bit lane, used to advance the block counter.
+DATA ·one<>+0x00(SB)/8, $1
+DATA ·one<>+0x08(SB)/8, $0
+GLOBL ·one<>(SB), (NOPTR+RODATA), $16
+
+// ROTL rotates each 32-bit word of x left by n bits, clobbering t.
+#define ROTL(n, t, x) \
+	MOVO x, t; \
+	PSLLL $n, x; \
+	PSRLL $(32-n), t; \
+	PXOR t, x
+
+// ROTL16 rotates each 32-bit word of x left by 16 bits.
+#define ROTL16(x) \
+	PSHUFLW $0xb1, x, x; \
+	PSHUFHW $0xb1, x, x
+
+// QUARTERROUND applies the ChaCha quarter round to each column of the rows
+// a, b, c and d, clobbering t.
+#define QUARTERROUND(a, b, c, d, t) \
+	PADDL b, a; \
+	PXOR a, d; \
+	ROTL16(d); \
+	PADDL d, c; \
+	PXOR c, b; \
+	ROTL(12, t, b); \
+	PADDL b, a; \
+	PXOR a, d; \
+	ROTL(8, t, d); \
+	PADDL d, c; \
+	PXOR c, b; \
+	ROTL(7, t, b)
+
+// DOUBLEROUND applies a column round and then a diagonal round to the state
+// held in rows a, b, c and d, clobbering t.
+#define DOUBLEROUND(a, b, c, d, t) \
+	QUARTERROUND(a, b, c, d, t); \
+	PSHUFL $0x39, b, b; \
+	PSHUFL $0x4e, c, c; \
+	PSHUFL $0x93, d, d; \
+	QUARTERROUND(a, b, c, d, t); \
+	PSHUFL $0x93, b, b; \
+	PSHUFL $0x4e, c, c; \
+	PSHUFL $0x39, d, d
+
+// CONSTANTS loads the address of the constants selected by the key128
+// argument into r.
+#define CONSTANTS(key128, r) \
+	LEAL ·sigma<>(SB), r; \
+	CMPB key128, $0; \
+	JE 2(PC); \
+	LEAL ·tau<>(SB), r
+
+// func chacha_20_core_sse2(out, in *byte, in_len uint32, state *[48]byte, key128 bool)
+TEXT ·chacha_20_core_sse2(SB),NOSPLIT,$0-17
+	MOVL out+0(FP), DI
+	MOVL in+4(FP), SI
+	MOVL in_len+8(FP), CX
+	MOVL state+12(FP), AX
+	CONSTANTS(key128+16(FP), DX)
+
+	SHRL $6, CX
+	JZ done
+
+	MOVOU 0(AX), X5
+	MOVOU 16(AX), X6
+	MOVOU 32(AX), X7
+
+loop:
+	MOVOU 0(DX), X0
+	MOVO X5, X1
+	MOVO X6, X2
+	MOVO X7, X3
+
+	MOVL $20, BX
+
+rounds:
+	DOUBLEROUND(X0, X1, X2, X3, X4)
+	SUBL $2, BX
+	JNZ rounds
+
+	MOVOU 0(DX), X4
+	PADDL X4, X0
+	PADDL X5, X1
+	PADDL X6, X2
+	PADDL X7, X3
+
+	MOVOU 0(SI), X4
+	PXOR X4, X0
+	MOVOU X0, 0(DI)
+	MOVOU 16(SI), X4
+	PXOR X4, X1
+	MOVOU X1, 16(DI)
+	MOVOU 32(SI), X4
+	PXOR X4, X2
+	MOVOU X2, 32(DI)
+	MOVOU 48(SI), X4
+	PXOR X4, X3
+	MOVOU X3, 48(DI)
+
+	MOVOU ·one<>(SB), X4
+	PADDQ X4, X7
+
+	ADDL $64, SI
+	ADDL $64, DI
+	DECL CX
+	JNZ loop
+
+	MOVOU X7, 32(AX)
+
+done:
+	RET
+
+// func hchacha_20_sse2(key *[32]byte, nonce *[16]byte, out *[32]byte, key128 bool)
+TEXT ·hchacha_20_sse2(SB),NOSPLIT,$0-13
+	MOVL key+0(FP), AX
+	MOVL nonce+4(FP), SI
+	MOVL out+8(FP), DI
+	CONSTANTS(key128+12(FP), DX)
+
+	MOVOU 0(DX), X0
+	MOVOU 0(AX), X1
+	MOVOU 16(AX), X2
+	MOVOU 0(SI), X3
+
+	MOVL $20, BX
+
+hchacha_rounds:
+	DOUBLEROUND(X0, X1, X2, X3, X4)
+	SUBL $2, BX
+	JNZ hchacha_rounds
+
+	MOVOU X0, 0(DI)
+	MOVOU X3, 16(DI)
+	RET
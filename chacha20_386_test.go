@@ -0,0 +1,87 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build 386,!gccgo,!appengine
+
+package chacha20
+
+import (
+	"crypto/cipher"
+	"testing"
+)
+
+func testChaCha20SSE2(t *testing.T, newChaCha20 func(key, nonce []byte) (cipher.Stream, error), vectors []testVector) {
+	if !useSSE2 {
+		t.Skip("skipping: do not have SSE2 implementation")
+	}
+
+	testChaCha20(t, newChaCha20, vectors)
+}
+
+func testChaCha20NoSSE2(t *testing.T, newChaCha20 func(key, nonce []byte) (cipher.Stream, error), vectors []testVector) {
+	oldSSE2 := useSSE2
+	useSSE2 = false
+	defer func() {
+		useSSE2 = oldSSE2
+	}()
+
+	testChaCha20(t, newChaCha20, vectors)
+}
+
+func TestRFCChaCha20SSE2(t *testing.T) {
+	testChaCha20SSE2(t, NewRFC, rfcTestVectors)
+}
+
+func TestRFCChaCha20NoSSE2(t *testing.T) {
+	testChaCha20NoSSE2(t, NewRFC, rfcTestVectors)
+}
+
+func TestDraftChaCha20SSE2(t *testing.T) {
+	testChaCha20SSE2(t, NewDraft, draftTestVectors)
+}
+
+func TestDraftChaCha20NoSSE2(t *testing.T) {
+	testChaCha20NoSSE2(t, NewDraft, draftTestVectors)
+}
+
+func TestXChaCha20SSE2(t *testing.T) {
+	testChaCha20SSE2(t, NewXChaCha, xTestVectors)
+}
+
+func TestXChaCha20NoSSE2(t *testing.T) {
+	testChaCha20NoSSE2(t, NewXChaCha, xTestVectors)
+}
+
+func TestRFCChaCha20128SSE2(t *testing.T) {
+	testChaCha20SSE2(t, New128, rfc128TestVectors)
+}
+
+func TestRFCChaCha20128NoSSE2(t *testing.T) {
+	testChaCha20NoSSE2(t, New128, rfc128TestVectors)
+}
+
+func TestDraftChaCha20128SSE2(t *testing.T) {
+	testChaCha20SSE2(t, New128, draft128TestVectors)
+}
+
+func TestDraftChaCha20128NoSSE2(t *testing.T) {
+	testChaCha20NoSSE2(t, New128, draft128TestVectors)
+}
+
+func TestXChaCha20128SSE2(t *testing.T) {
+	testChaCha20SSE2(t, New128, x128TestVectors)
+}
+
+func TestXChaCha20128NoSSE2(t *testing.T) {
+	testChaCha20NoSSE2(t, New128, x128TestVectors)
+}
+
+func TestOpenSSLChaCha20SSE2(t *testing.T) {
+	testChaCha20SSE2(t, NewOpenSSL, openSSLTestVectors)
+}
+
+func TestOpenSSLChaCha20NoSSE2(t *testing.T) {
+	testChaCha20NoSSE2(t, NewOpenSSL, openSSLTestVectors)
+}
@@ -9,15 +9,12 @@ package chacha20
 
 import (
 	"crypto/cipher"
+	"encoding/binary"
 
+	"github.com/tmthrgd/chacha20/internal/vec"
 	"github.com/tmthrgd/chacha20/internal/xor"
 )
 
-const (
-	hNonceSize  = 16
-	hChaChaSize = 32
-)
-
 const useRef = false
 
 var useAVX, useAVX2 = hasAVX()
@@ -35,7 +32,7 @@ func NewRFC(key, nonce []byte) (cipher.Stream, error) {
 		return nil, ErrInvalidNonce
 	}
 
-	s := new(stream)
+	s := &stream{rfc: true}
 	copy(s.state[:32], key)
 	copy(s.state[36:], nonce)
 	return s, nil
@@ -76,25 +73,121 @@ func NewXChaCha(key, nonce []byte) (cipher.Stream, error) {
 	var hKey [KeySize]byte
 	copy(hKey[:], key)
 
-	var hNonce [hNonceSize]byte
-	copy(hNonce[:], nonce[:hNonceSize])
+	var hNonce [HNonceSize]byte
+	copy(hNonce[:], nonce[:HNonceSize])
 
-	var subKey [hChaChaSize]byte
-	hchacha_20_x64(&hKey, &hNonce, &subKey)
+	var subKey [HChaChaSize]byte
+	hchacha_20_x64(&hKey, &hNonce, &subKey, false)
 
-	s := new(stream)
+	s := &stream{xchacha: true}
 	copy(s.state[:32], subKey[:])
-	copy(s.state[40:], nonce[hNonceSize:])
+	copy(s.state[40:], nonce[HNonceSize:])
 	return s, nil
 }
 
+// new128 returns a stream for a 128-bit key and a nonce whose length has
+// already been validated by New128.
+func new128(key, nonce []byte) cipher.Stream {
+	s := &stream{key128: true}
+
+	// The 128-bit key fills both halves of the key words.
+	copy(s.state[:16], key)
+	copy(s.state[16:32], key)
+
+	switch len(nonce) {
+	case RFCNonceSize:
+		s.rfc = true
+		copy(s.state[36:], nonce)
+	case DraftNonceSize:
+		copy(s.state[40:], nonce)
+	case XNonceSize:
+		var hKey [KeySize]byte
+		copy(hKey[:], s.state[:32])
+
+		var hNonce [HNonceSize]byte
+		copy(hNonce[:], nonce[:HNonceSize])
+
+		var subKey [HChaChaSize]byte
+		hchacha_20_x64(&hKey, &hNonce, &subKey, true)
+
+		// The derived subkey is a 256-bit key.
+		s.key128 = false
+		s.xchacha = true
+		copy(s.state[:32], subKey[:])
+		copy(s.state[40:], nonce[HNonceSize:])
+	}
+
+	return s
+}
+
+func hChaCha20(out *[HChaChaSize]byte, key *[KeySize]byte, nonce *[HNonceSize]byte) {
+	hchacha_20_x64(key, nonce, out, false)
+}
+
 type stream struct {
-	state [48]byte
+	state   [48]byte
+	key128  bool
+	rfc     bool // whether the counter is only 32 bits
+	xchacha bool // whether the key is an HChaCha20 subkey
 
 	backing [128]byte
 	buffer  []byte
 }
 
+// SetCounter sets the block counter, discarding any buffered keystream.
+func (s *stream) SetCounter(counter uint64) {
+	if s.rfc {
+		if counter > uint64(^uint32(0)) {
+			panic("chacha20: counter too large for a 96-bit nonce")
+		}
+
+		binary.LittleEndian.PutUint32(s.state[32:], uint32(counter))
+	} else {
+		binary.LittleEndian.PutUint64(s.state[32:], counter)
+	}
+
+	b := s.buffer
+	for i := range b {
+		b[i] = 0
+	}
+
+	s.buffer = nil
+}
+
+// SetNonce replaces the nonce, which must be the same length as the nonce
+// the stream was created with, and resets the block counter to zero,
+// discarding any buffered keystream.
+func (s *stream) SetNonce(nonce []byte) {
+	if s.xchacha {
+		panic("chacha20: cannot replace the nonce of an XChaCha20 stream")
+	}
+
+	if s.rfc {
+		if len(nonce) != RFCNonceSize {
+			panic("chacha20: invalid nonce length")
+		}
+
+		copy(s.state[36:], nonce)
+	} else {
+		if len(nonce) != DraftNonceSize {
+			panic("chacha20: invalid nonce length")
+		}
+
+		copy(s.state[40:], nonce)
+	}
+
+	s.SetCounter(0)
+}
+
+// SetKey replaces the key with a 256-bit key and resets the block counter to
+// zero, discarding any buffered keystream.
+func (s *stream) SetKey(key []byte) {
+	s.key128 = false
+	copy(s.state[:32], key)
+
+	s.SetCounter(0)
+}
+
 func (s *stream) XORKeyStream(dst, src []byte) {
 	if len(src) == 0 {
 		return
@@ -119,11 +212,11 @@ func (s *stream) XORKeyStream(dst, src []byte) {
 
 	switch {
 	case useAVX2:
-		chacha_20_core_avx2(&dst[0], &src[0], uint64(len(src)), &s.state)
+		chacha_20_core_avx2(&dst[0], &src[0], uint64(len(src)), &s.state, s.key128)
 	case useAVX:
-		chacha_20_core_avx(&dst[0], &src[0], uint64(len(src)), &s.state)
+		chacha_20_core_avx(&dst[0], &src[0], uint64(len(src)), &s.state, s.key128)
 	default:
-		chacha_20_core_x64(&dst[0], &src[0], uint64(len(src)), &s.state)
+		chacha_20_core_x64(&dst[0], &src[0], uint64(len(src)), &s.state, s.key128)
 	}
 
 	var minSize uint
@@ -134,18 +227,18 @@ func (s *stream) XORKeyStream(dst, src []byte) {
 	}
 
 	if todo := int(uint(len(src)) &^ -minSize); todo != 0 {
-		copy(s.backing[:todo], src[len(src)-todo:])
-
+		// The backing buffer is always zero here, so this leaves the
+		// keystream for the next 128 bytes in it.
 		switch {
 		case useAVX2:
-			chacha_20_core_avx2(&s.backing[0], &s.backing[0], 128, &s.state)
+			chacha_20_core_avx2(&s.backing[0], &s.backing[0], 128, &s.state, s.key128)
 		case useAVX:
-			chacha_20_core_avx(&s.backing[0], &s.backing[0], 128, &s.state)
+			chacha_20_core_avx(&s.backing[0], &s.backing[0], 128, &s.state, s.key128)
 		default:
-			chacha_20_core_x64(&s.backing[0], &s.backing[0], 128, &s.state)
+			chacha_20_core_x64(&s.backing[0], &s.backing[0], 128, &s.state, s.key128)
 		}
 
-		copy(dst[len(src)-todo:], s.backing[:todo])
+		xor.Bytes(dst[len(src)-todo:], s.backing[:todo], src[len(src)-todo:])
 
 		b := s.backing[:todo]
 		for i := range b {
@@ -156,6 +249,37 @@ func (s *stream) XORKeyStream(dst, src []byte) {
 	}
 }
 
+// XORKeyStreamVec XORs the fragments of src with the keystream as a single
+// message, passing whole blocks directly to the assembly implementation even
+// when they straddle fragments.
+func (s *stream) XORKeyStreamVec(dst, src [][]byte) {
+	blockSize := 64
+	if useAVX2 {
+		blockSize = 128
+	}
+
+	vec.XORKeyStream(s, dst, src, blockSize)
+}
+
+// Buffered returns the number of bytes of keystream left over from a partial
+// block.
+func (s *stream) Buffered() int {
+	return len(s.buffer)
+}
+
+// XORBlocks XORs src, a whole number of 64-byte blocks or 128 bytes with AVX2,
+// with the keystream. There must be no buffered keystream.
+func (s *stream) XORBlocks(dst, src []byte) {
+	switch {
+	case useAVX2:
+		chacha_20_core_avx2(&dst[0], &src[0], uint64(len(src)), &s.state, s.key128)
+	case useAVX:
+		chacha_20_core_avx(&dst[0], &src[0], uint64(len(src)), &s.state, s.key128)
+	default:
+		chacha_20_core_x64(&dst[0], &src[0], uint64(len(src)), &s.state, s.key128)
+	}
+}
+
 //go:generate perl chacha20_x64.pl golang-no-avx chacha20_x64_amd64.s
 //go:generate perl chacha20_avx.pl golang-no-avx chacha20_avx_amd64.s
 //go:generate perl chacha20_avx2.pl golang-no-avx chacha20_avx2_amd64.s
@@ -167,16 +291,16 @@ func hasAVX() (avx, avx2 bool)
 
 // This function is implemented in chacha20_x64_amd64.s
 //go:noescape
-func chacha_20_core_x64(out, in *byte, in_len uint64, state *[48]byte)
+func chacha_20_core_x64(out, in *byte, in_len uint64, state *[48]byte, key128 bool)
 
 // This function is implemented in chacha20_avx_amd64.s
 //go:noescape
-func chacha_20_core_avx(out, in *byte, in_len uint64, state *[48]byte)
+func chacha_20_core_avx(out, in *byte, in_len uint64, state *[48]byte, key128 bool)
 
 // This function is implemented in chacha20_avx2_amd64.s
 //go:noescape
-func chacha_20_core_avx2(out, in *byte, in_len uint64, state *[48]byte)
+func chacha_20_core_avx2(out, in *byte, in_len uint64, state *[48]byte, key128 bool)
 
 // This function is implemented in hchacha20_x64_amd64.s
 //go:noescape
-func hchacha_20_x64(key *[KeySize]byte, nonce *[hNonceSize]byte, out *[hChaChaSize]byte)
+func hchacha_20_x64(key *[KeySize]byte, nonce *[HNonceSize]byte, out *[HChaChaSize]byte, key128 bool)
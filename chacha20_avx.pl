@@ -132,6 +132,24 @@ DATA chacha20_consts<>+0x0e(SB)/1, \$" "
 DATA chacha20_consts<>+0x0f(SB)/1, \$"k"
 GLOBL chacha20_consts<>(SB), RODATA, \$16
 
+DATA chacha20_tau<>+0x00(SB)/1, \$"e"
+DATA chacha20_tau<>+0x01(SB)/1, \$"x"
+DATA chacha20_tau<>+0x02(SB)/1, \$"p"
+DATA chacha20_tau<>+0x03(SB)/1, \$"a"
+DATA chacha20_tau<>+0x04(SB)/1, \$"n"
+DATA chacha20_tau<>+0x05(SB)/1, \$"d"
+DATA chacha20_tau<>+0x06(SB)/1, \$" "
+DATA chacha20_tau<>+0x07(SB)/1, \$"1"
+DATA chacha20_tau<>+0x08(SB)/1, \$"6"
+DATA chacha20_tau<>+0x09(SB)/1, \$"-"
+DATA chacha20_tau<>+0x0a(SB)/1, \$"b"
+DATA chacha20_tau<>+0x0b(SB)/1, \$"y"
+DATA chacha20_tau<>+0x0c(SB)/1, \$"t"
+DATA chacha20_tau<>+0x0d(SB)/1, \$"e"
+DATA chacha20_tau<>+0x0e(SB)/1, \$" "
+DATA chacha20_tau<>+0x0f(SB)/1, \$"k"
+GLOBL chacha20_tau<>(SB), RODATA, \$16
+
 DATA rol8<>+0x00(SB)/1, \$3
 DATA rol8<>+0x01(SB)/1, \$0
 DATA rol8<>+0x02(SB)/1, \$1
@@ -197,13 +215,17 @@ my ($out, $in, $in_len, $key_ptr, $nr)
 
 if ($flavour =~ /^golang/) {
     $code.=<<___;
-TEXT ·chacha_20_core_avx(SB),\$0-32
+TEXT ·chacha_20_core_avx(SB),\$0-33
 	movq	out+0(FP), DI
 	movq	in+8(FP), SI
 	movq	in_len+16(FP), DX
 	movq	state+24(FP), BX
 
 	movq	\$chacha20_consts<>(SB), R12
+	cmpb	key128+32(FP), \$0
+	je	chacha20_avx_sigma
+	movq	\$chacha20_tau<>(SB), R12
+chacha20_avx_sigma:
 	movq	\$rol8<>(SB), R13
 	movq	\$rol16<>(SB), R14
 	movq	\$avxInc<>(SB), R15
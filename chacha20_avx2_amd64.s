@@ -39,6 +39,40 @@ DATA chacha20_consts<>+0x1e(SB)/1, $" "
 DATA chacha20_consts<>+0x1f(SB)/1, $"k"
 GLOBL chacha20_consts<>(SB), RODATA, $32
 
+DATA chacha20_tau<>+0x00(SB)/1, $"e"
+DATA chacha20_tau<>+0x01(SB)/1, $"x"
+DATA chacha20_tau<>+0x02(SB)/1, $"p"
+DATA chacha20_tau<>+0x03(SB)/1, $"a"
+DATA chacha20_tau<>+0x04(SB)/1, $"n"
+DATA chacha20_tau<>+0x05(SB)/1, $"d"
+DATA chacha20_tau<>+0x06(SB)/1, $" "
+DATA chacha20_tau<>+0x07(SB)/1, $"1"
+DATA chacha20_tau<>+0x08(SB)/1, $"6"
+DATA chacha20_tau<>+0x09(SB)/1, $"-"
+DATA chacha20_tau<>+0x0a(SB)/1, $"b"
+DATA chacha20_tau<>+0x0b(SB)/1, $"y"
+DATA chacha20_tau<>+0x0c(SB)/1, $"t"
+DATA chacha20_tau<>+0x0d(SB)/1, $"e"
+DATA chacha20_tau<>+0x0e(SB)/1, $" "
+DATA chacha20_tau<>+0x0f(SB)/1, $"k"
+DATA chacha20_tau<>+0x10(SB)/1, $"e"
+DATA chacha20_tau<>+0x11(SB)/1, $"x"
+DATA chacha20_tau<>+0x12(SB)/1, $"p"
+DATA chacha20_tau<>+0x13(SB)/1, $"a"
+DATA chacha20_tau<>+0x14(SB)/1, $"n"
+DATA chacha20_tau<>+0x15(SB)/1, $"d"
+DATA chacha20_tau<>+0x16(SB)/1, $" "
+DATA chacha20_tau<>+0x17(SB)/1, $"1"
+DATA chacha20_tau<>+0x18(SB)/1, $"6"
+DATA chacha20_tau<>+0x19(SB)/1, $"-"
+DATA chacha20_tau<>+0x1a(SB)/1, $"b"
+DATA chacha20_tau<>+0x1b(SB)/1, $"y"
+DATA chacha20_tau<>+0x1c(SB)/1, $"t"
+DATA chacha20_tau<>+0x1d(SB)/1, $"e"
+DATA chacha20_tau<>+0x1e(SB)/1, $" "
+DATA chacha20_tau<>+0x1f(SB)/1, $"k"
+GLOBL chacha20_tau<>(SB), RODATA, $32
+
 DATA rol8<>+0x00(SB)/1, $3
 DATA rol8<>+0x01(SB)/1, $0
 DATA rol8<>+0x02(SB)/1, $1
@@ -119,13 +153,17 @@ DATA avx2Inc<>+0x10(SB)/8, $0x2
 DATA avx2Inc<>+0x18(SB)/8, $0x0
 GLOBL avx2Inc<>(SB), RODATA, $32
 
-TEXT ·chacha_20_core_avx2(SB),$0-32
+TEXT ·chacha_20_core_avx2(SB),$0-33
 	MOVQ	out+0(FP),DI
 	MOVQ	in+8(FP),SI
 	MOVQ	in_len+16(FP),DX
 	MOVQ	state+24(FP),BX
 
 	MOVQ	$chacha20_consts<>(SB),R11
+	CMPB	key128+32(FP),$0
+	JE	chacha20_avx2_sigma
+	MOVQ	$chacha20_tau<>(SB),R11
+chacha20_avx2_sigma:
 	MOVQ	$rol8<>(SB),R12
 	MOVQ	$rol16<>(SB),R13
 	MOVQ	$avx2Init<>(SB),R14
@@ -3,7 +3,7 @@
 // Modified BSD License license that can be found in
 // the LICENSE file.
 
-// +build !amd64 gccgo appengine
+// +build !amd64,!386 gccgo appengine
 
 package chacha20
 
@@ -64,3 +64,14 @@ func NewXChaCha(key, nonce []byte) (cipher.Stream, error) {
 
 	return ref.NewXChaCha(key, nonce)
 }
+
+// new128 returns a stream for a 128-bit key and a nonce whose length has
+// already been validated by New128.
+func new128(key, nonce []byte) cipher.Stream {
+	s, _ := ref.New128(key, nonce)
+	return s
+}
+
+func hChaCha20(out *[HChaChaSize]byte, key *[KeySize]byte, nonce *[HNonceSize]byte) {
+	ref.HChaCha20(out, key[:], nonce[:])
+}
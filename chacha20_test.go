@@ -202,6 +202,239 @@ var xTestVectors = []testVector{
 	},
 }
 
+// The 128-bit key cases from https://tools.ietf.org/html/draft-strombergson-chacha-test-vectors-01
+var draft128TestVectors = []testVector{
+	testVector{
+		mustHexDecode("00000000000000000000000000000000"),
+		mustHexDecode("0000000000000000"),
+		mustHexDecode("89670952608364fd00b2f90936f031c8e756e15dba04b8493d00429259b20f46" +
+			"cc04f111246b6c2ce066be3bfb32d9aa0fddfbc12123d4b9e44f34dca05a103f" +
+			"6cd135c2878c832b5896b134f6142a9d4d8d0d8f1026d20a0a81512cbce6e975" +
+			"8a7143d021978022a384141a80cea3062f41f67a752e66ad3411984c787e30ad"),
+		0,
+	},
+	testVector{
+		mustHexDecode("01000000000000000000000000000000"),
+		mustHexDecode("0000000000000000"),
+		mustHexDecode("ae56060d04f5b597897ff2af1388dbceff5a2a4920335dc17a3cb1b1b10fbe70" +
+			"ece8f4864d8c7cdf0076453a8291c7dbeb3aa9c9d10e8ca36be4449376ed7c42" +
+			"fc3d471c34a36fbbf616bc0a0e7c523030d944f43ec3e78dd6a12466547cb4f7" +
+			"b3cebd0a5005e762e562d1375b7ac44593a991b85d1a60fba2035dfaa2a642d5"),
+		0,
+	},
+	testVector{
+		mustHexDecode("00000000000000000000000000000000"),
+		mustHexDecode("0100000000000000"),
+		mustHexDecode("1663879eb3f2c9949e2388caa343d361bb132771245ae6d027ca9cb010dc1fa7" +
+			"178dc41f8278bc1f64b3f12769a24097f40d63a86366bdb36ac08abe60c07fe8" +
+			"b057375c89144408cc744624f69f7f4ccbd93366c92fc4dfcada65f1b959d8c6" +
+			"4dfc50de711fb46416c2553cc60f21bbfd006491cb17888b4fb3521c4fdd8745"),
+		0,
+	},
+	testVector{
+		mustHexDecode("ffffffffffffffffffffffffffffffff"),
+		mustHexDecode("ffffffffffffffff"),
+		mustHexDecode("992947c3966126a0e660a3e95db048de091fb9e0185b1e41e41015bb7ee50150" +
+			"399e4760b262f9d53f26d8dd19e56f5c506ae0c3619fa67fb0c408106d0203ee" +
+			"40ea3cfa61fa32a2fda8d1238a2135d9d4178775240f99007064a6a7f0c731b6" +
+			"7c227c52ef796b6bed9f9059ba0614bcf6dd6e38917f3b150e576375be50ed67"),
+		0,
+	},
+	testVector{
+		mustHexDecode("55555555555555555555555555555555"),
+		mustHexDecode("5555555555555555"),
+		mustHexDecode("357d7d94f966778f5815a2051dcb04133b26b0ead9f57dd09927837bc3067e4b" +
+			"6bf299ad81f7f50c8da83c7810bfc17bb6f4813ab6c326957045fd3fd5e19915" +
+			"ec744a6b9bf8cbdcb36d8b6a5499c68a08ef7be6cc1e93f2f5bcd2cad4e47c18" +
+			"a3e5d94b5666382c6d130d822dd56aacb0f8195278e7b292495f09868ddf12cc"),
+		0,
+	},
+	testVector{
+		mustHexDecode("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+		mustHexDecode("aaaaaaaaaaaaaaaa"),
+		mustHexDecode("fc79acbd58526103862776aab20f3b7d8d3149b2fab65766299316b6e5b16684" +
+			"de5de548c1b7d083efd9e3052319e0c6254141da04a6586df800f64d46b01c87" +
+			"1f05bc67e07628ebe6f6865a2177e0b66a558aa7cc1e8ff1a98d27f7071f8335" +
+			"efce4537bb0ef7b573b32f32765f29007da53bba62e7a44d006f41eb28fe15d6"),
+		0,
+	},
+	testVector{
+		mustHexDecode("00112233445566778899aabbccddeeff"),
+		mustHexDecode("0f1e2d3c4b5a6978"),
+		mustHexDecode("d1abf630467eb4f67f1cfb47cd626aae8afedbbe4ff8fc5fe9cfae307e74ed45" +
+			"1f1404425ad2b54569d5f18148939971abb8fafc88ce4ac7fe1c3d1f7a1eb7ca" +
+			"e76ca87b61a9713541497760dd9ae059350cad0dcedfaa80a883119a1a6f987f" +
+			"d1ce91fd8ee0828034b411200a9745a285554475d12afc04887fef3516d12a2c"),
+		0,
+	},
+	testVector{
+		mustHexDecode("c46ec1b18ce8a878725a37e780dfb735"),
+		mustHexDecode("1ada31d5cf688221"),
+		mustHexDecode("826abdd84460e2e9349f0ef4af5b179b426e4b2d109a9c5bb44000ae51bea90a" +
+			"496beeef62a76850ff3f0402c4ddc99f6db07f151c1c0dfac2e56565d6289625" +
+			"5b23132e7b469c7bfb88fa95d44ca5ae3e45e848a4108e98bad7a9eb15512784" +
+			"a6a9e6e591dce674120acaf9040ff50ff3ac30ccfb5e14204f5e4268b90a8804"),
+		0,
+	},
+}
+
+// 128-bit key variants of the above, generated with an independent
+// implementation.
+var rfc128TestVectors = []testVector{
+	testVector{
+		mustHexDecode("00000000000000000000000000000000"),
+		mustHexDecode("000000000000000000000000"),
+		mustHexDecode("89670952608364fd00b2f90936f031c8e756e15dba04b8493d00429259b20f46" +
+			"cc04f111246b6c2ce066be3bfb32d9aa0fddfbc12123d4b9e44f34dca05a103f" +
+			"6cd135c2878c832b5896b134f6142a9d4d8d0d8f1026d20a0a81512cbce6e975" +
+			"8a7143d021978022a384141a80cea3062f41f67a752e66ad3411984c787e30ad"),
+		0,
+	},
+	testVector{
+		mustHexDecode("000102030405060708090a0b0c0d0e0f"),
+		mustHexDecode("000000090000004a00000000"),
+		mustHexDecode("c2467c2cd62541aaf1c0e48694dfd56597b023dbe4c77dc4ae5ab2e3e0fcd8ff" +
+			"5640fab4f1d1c7d1560746302c12010492e65d233d92a70a468e876b94484dd1" +
+			"4002d2aa7c1eb02f08c738f456e085cdccb22eefe06c9ef6ee0945bef0cb1a68" +
+			"7cc0f87f556fd67466a2b838d11e2f2ec9e370266762c00bbacb832422dffc8b"),
+		1,
+	},
+}
+
+var x128TestVectors = []testVector{
+	testVector{
+		mustHexDecode("00000000000000000000000000000000"),
+		mustHexDecode("000000000000000000000000000000000000000000000000"),
+		mustHexDecode("b37f67cac4b3cbfa26058249a058a238a847d7ad731321be213f736354150b6b" +
+			"6c4a05553c095218bf5d1a1535ecf4a55e7aa0962f0db42a611f36039cd1c472" +
+			"c9121b214783080817dd082a909da4b0fedcc12431c8761a4c1a42c2f829c861" +
+			"5465735fbbc73ce98d9029b47fd3e48504e29fa44e6f71397072863fba4e7ab2"),
+		0,
+	},
+	testVector{
+		mustHexDecode("000102030405060708090a0b0c0d0e0f"),
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555658"),
+		mustHexDecode("75d5eae6f28881e70677fc13f753d8da1a8af40665fa30ff9e523a8c6e225b9a" +
+			"9c61e77daef5aa918cdea57e8b72ca491f24ba3c20617dae50db939945f302cc" +
+			"e28f401eacb1e7321e22f84f89c9e00c8e1fb61d00aa504a2db7a274e05f297c" +
+			"211e190afc57a735c469e55782279704773279e3e1ece9cae6352a0e595667f5"),
+		0,
+	},
+}
+
+// generated with OpenSSL's EVP_chacha20, by
+// openssl enc -chacha20 -K <key> -iv <iv> </dev/zero
+var openSSLTestVectors = []testVector{
+	testVector{
+		mustHexDecode("0000000000000000000000000000000000000000000000000000000000000000"),
+		mustHexDecode("00000000000000000000000000000000"),
+		mustHexDecode("76b8e0ada0f13d90405d6ae55386bd28bdd219b8a08ded1aa836efcc8b770dc7" +
+			"da41597c5157488d7724e03fb8d84a376a43b8f41518a11cc387b669b2ee6586"),
+		0,
+	},
+	testVector{
+		mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"),
+		mustHexDecode("01000000000000090000004a00000000"),
+		mustHexDecode("10f1e7e4d13b5915500fdd1fa32071c4c7d1f4c733c068030422aa9ac3d46c4e" +
+			"d2826446079faa0914c2d705d98b02a2b5129cd1de164eb9cbd083e8a2503c4e"),
+		0,
+	},
+	testVector{
+		mustHexDecode("1c9240a5eb55d38af333888604f6b5f0473917c1402b80099dca5cbc207075c0"),
+		mustHexDecode("07000000000000000000000000000002"),
+		mustHexDecode("66ffbf9fe52addbfc638a54a889e4f7f8c8500e6a4d05861c9fb3323679a8260" +
+			"4d903192b4161f399c34db40c18b2e6fa8edccba0acff6bb78483144ee146396" +
+			"fcbc5ce39638b232224c07a23c9f81d39efe4fc64f6406cedd0a4e47792a8219" +
+			"e8509d68"),
+		0,
+	},
+	testVector{
+		mustHexDecode("7a8b2f0c1e3d4c5b6a79889796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3"),
+		mustHexDecode("ffffffff0102030405060708090a0b0c"),
+		mustHexDecode("6d92b1601a19d05993d34e4ab044233fe74fb651c6aff2d136c9fcc8c9b6148f" +
+			"66bb497cbd747fb838d6ffac2e3b0cf9b1340248d86fdaa155ec182d5704bb48" +
+			"0931e144ee06c577cbb72fe664d4d6852eb4c041e927858e6f7e328e487a95dc" +
+			"58b8ae76dda2bae0eee6fc2527cceb5943ee9b1f50783d3320e4625465cbc349" +
+			"89ed2bd6b67ad2f4178f1cb7ed3a946380253bc0634f4326d7cb63af23b0f57b" +
+			"bc288ae9d59da92a0c7cfa99fd2a90a201ac481fcefccaeafbe46799cf8d32ac"),
+		0,
+	},
+}
+
+// from draft-strombergson-chacha-test-vectors-01 TC1 and generated with a
+// Python reference implementation
+var chacha8TestVectors = []testVector{
+	{
+		make([]byte, KeySize),
+		make([]byte, DraftNonceSize),
+		mustHexDecode("3e00ef2f895f40d67f5bb8e81f09a5a12c840ec3ce9a7f3b181be188ef711a1e" +
+			"984ce172b9216f419f445367456d5619314a42a3da86b001387bfdb80e0cfe42"),
+		0,
+	},
+	{
+		mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"),
+		mustHexDecode("0001020304050607"),
+		mustHexDecode("40e1aaea1c843baa28b18eb728fec05dce47b0e824bf9a5d3f1bb1aad13b37fb" +
+			"bf0b0e146732c16380efeab70a1b6edff9acedc876b70d98b61f192290537973" +
+			"83fe5024dbc0b0d23bd9601805290632acee2e13d5bc50d4e03782e20f0b8e6a" +
+			"6b3477eea8cca765c2ca3713af644f179f7ba0e52fcd8aec6f01cfae891245a0" +
+			"cf7fea9231a9c43ed7c6f1cb0b4c6e1cb8b81b7a267b44bf0291b9422640d1c7" +
+			"4a0b664e1c7c7a0614b66e697a1139ff7fe1e63ebde4549f9c0c17e4bbe6e495" +
+			"dede0ea7d7c3c41fa456b7aec559fd8015acf0e949c435735f993c36d8cd4ae1" +
+			"a6761d1f73f61d65b74f5c277fe8fae472f23d53b937b2e39b28961323763fe4" +
+			"37ec6d58f04620ee445f22bac69d4f5ec717e695f6e03e45904f2ba330ae31ad" +
+			"ec9c94bd1f622d7ce45c644f"),
+		0,
+	},
+}
+
+// from draft-strombergson-chacha-test-vectors-01 TC1 and generated with a
+// Python reference implementation
+var chacha12TestVectors = []testVector{
+	{
+		make([]byte, KeySize),
+		make([]byte, DraftNonceSize),
+		mustHexDecode("9bf49a6a0755f953811fce125f2683d50429c3bb49e074147e0089a52eae155f" +
+			"0564f879d27ae3c02ce82834acfa8c793a629f2ca0de6919610be82f411326be"),
+		0,
+	},
+	{
+		mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"),
+		mustHexDecode("0001020304050607"),
+		mustHexDecode("6898eb04f3d151985e28e882f35daf28d2a1689f79081ffb08cdc48edbbd3dcd" +
+			"683c764f3dd7302293928ca3d4ef4194e6e22f41a72204a14b89115d06ca29fb" +
+			"0b9f6eba3da6793a928afe76cdf62a5d5b0898bb9bb2348612189fdb825e5aa7" +
+			"559c9ec79ff80d05079fad81e9bc2521b2ebcb179cebeade91f20ff3e13192d6" +
+			"0de2ee983ec07047e7827594773c28448d89e9b96bb0f8665b1a56f85abebd58" +
+			"4a446e17d5a6fb847a1dbf341ece5124ff5f80d4a57fb7edf65a2907939b2f3c" +
+			"9654ccbfa2e5225edc8d799bf7ce296d6c8f9234cec0bd7b91b3d2ddc27f93ff" +
+			"8591ddb362b54fab111a7da9d5b4187661ed0e691f7aa5959fb83112427a95bb" +
+			"eb0507f6a894a9109400c07cea60fd1ae32f7b66be3cba59ff4b7503dbf9742d" +
+			"9078dd90d981736a5db52427"),
+		0,
+	},
+}
+
+// generated with a Python reference implementation, the subkey is derived
+// with HChaCha12
+var xChaCha12TestVectors = []testVector{
+	{
+		mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"),
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		mustHexDecode("ae8f1abcb48412bdd9808328e8d1d2067b782093fb1811787be59d2ed257b2fd" +
+			"76b48f849152aa0165d6c42914e975fe2fa4f04015cb52a3ae59909aa26e5d4b" +
+			"8badae8d12349e8e9cd6b146a9144de65f26f2d10bcbcc00e2803f80afb23ec7" +
+			"f26ca9e27d5087f9ab1bc413ff4fb5d8f2920ad769fbde16c7796b85665caf09" +
+			"8f3bb9bb3c589c348b5f837edc7c46d3ffcb94a751e2dd59d4d1c987aeeec06e" +
+			"5f291d51198d07821ab365b265f84cf948a718475a279fdb497078e295daeef9" +
+			"46c7615bcad24444e67c7477b93d81f531562dcbbcff783153d3f2d5fd23c324" +
+			"606b7f7f8a980b82c09758eddc9bd9e1ea1574a22ce161f4a4a7657e1ae66106" +
+			"44d3b73c1a2bf503395ecd8f4b2f3feafd743f7ff06cc195366ebb64daf91ca2" +
+			"6850e1eeb4ed3eb83674477a"),
+		0,
+	},
+}
+
 func testChaCha20(t *testing.T, newChaCha20 func(key, nonce []byte) (cipher.Stream, error), vectors []testVector) {
 	for i, vector := range vectors {
 		t.Run(fmt.Sprintf("vector%d", i), func(t *testing.T) {
@@ -326,6 +559,89 @@ func TestXChaCha20Go(t *testing.T) {
 	testChaCha20(t, ref.NewXChaCha, xTestVectors)
 }
 
+func TestRFCChaCha20128x64(t *testing.T) {
+	testChaCha20x64(t, New128, rfc128TestVectors)
+}
+
+func TestRFCChaCha20128AVX(t *testing.T) {
+	testChaCha20AVX(t, New128, rfc128TestVectors)
+}
+
+func TestRFCChaCha20128AVX2(t *testing.T) {
+	testChaCha20AVX2(t, New128, rfc128TestVectors)
+}
+
+func TestRFCChaCha20128Go(t *testing.T) {
+	testChaCha20(t, ref.New128, rfc128TestVectors)
+}
+
+func TestDraftChaCha20128x64(t *testing.T) {
+	testChaCha20x64(t, New128, draft128TestVectors)
+}
+
+func TestDraftChaCha20128AVX(t *testing.T) {
+	testChaCha20AVX(t, New128, draft128TestVectors)
+}
+
+func TestDraftChaCha20128AVX2(t *testing.T) {
+	testChaCha20AVX2(t, New128, draft128TestVectors)
+}
+
+func TestDraftChaCha20128Go(t *testing.T) {
+	testChaCha20(t, ref.New128, draft128TestVectors)
+}
+
+func TestXChaCha20128x64(t *testing.T) {
+	testChaCha20x64(t, New128, x128TestVectors)
+}
+
+func TestXChaCha20128AVX(t *testing.T) {
+	testChaCha20AVX(t, New128, x128TestVectors)
+}
+
+func TestXChaCha20128AVX2(t *testing.T) {
+	testChaCha20AVX2(t, New128, x128TestVectors)
+}
+
+func TestXChaCha20128Go(t *testing.T) {
+	testChaCha20(t, ref.New128, x128TestVectors)
+}
+
+func TestOpenSSLChaCha20x64(t *testing.T) {
+	testChaCha20x64(t, NewOpenSSL, openSSLTestVectors)
+}
+
+func TestOpenSSLChaCha20AVX(t *testing.T) {
+	testChaCha20AVX(t, NewOpenSSL, openSSLTestVectors)
+}
+
+func TestOpenSSLChaCha20AVX2(t *testing.T) {
+	testChaCha20AVX2(t, NewOpenSSL, openSSLTestVectors)
+}
+
+func withRounds(newChaCha func(key, nonce []byte, rounds int) (cipher.Stream, error), rounds int) func(key, nonce []byte) (cipher.Stream, error) {
+	return func(key, nonce []byte) (cipher.Stream, error) {
+		return newChaCha(key, nonce, rounds)
+	}
+}
+
+func TestChaCha8(t *testing.T) {
+	testChaCha20(t, withRounds(NewRounds, 8), chacha8TestVectors)
+}
+
+func TestChaCha12(t *testing.T) {
+	testChaCha20(t, withRounds(NewRounds, 12), chacha12TestVectors)
+}
+
+func TestXChaCha12(t *testing.T) {
+	testChaCha20(t, withRounds(NewRounds, 12), xChaCha12TestVectors)
+}
+
+func TestRoundsChaCha20(t *testing.T) {
+	testChaCha20(t, withRounds(NewRounds, 20), rfcTestVectors)
+	testChaCha20(t, withRounds(NewRounds, 20), xTestVectors)
+}
+
 func testBadSize(t *testing.T, newChaCha20 func(key, nonce []byte) (cipher.Stream, error), keysize, nonceSize int, expect error) {
 	key := make([]byte, keysize)
 	nonce := make([]byte, nonceSize)
@@ -377,6 +693,44 @@ func TestNewBadNonceSize(t *testing.T) {
 	testBadSize(t, New, KeySize, 3, ErrInvalidNonce)
 }
 
+func TestRoundsBadSizes(t *testing.T) {
+	for _, rounds := range []int{8, 12, 20} {
+		testBadSize(t, withRounds(NewRounds, rounds), KeySize, XNonceSize, nil)
+		testBadSize(t, withRounds(NewRounds, rounds), 3, XNonceSize, ErrInvalidKey)
+		testBadSize(t, withRounds(NewRounds, rounds), KeySize, 3, ErrInvalidNonce)
+	}
+
+	for _, rounds := range []int{0, 10, 21} {
+		testBadSize(t, withRounds(NewRounds, rounds), KeySize, RFCNonceSize, ErrInvalidRounds)
+	}
+}
+
+func TestOpenSSLRightSizes(t *testing.T) {
+	testBadSize(t, NewOpenSSL, KeySize, OpenSSLIVSize, nil)
+}
+
+func TestOpenSSLBadKeySize(t *testing.T) {
+	testBadSize(t, NewOpenSSL, 3, OpenSSLIVSize, ErrInvalidKey)
+}
+
+func TestOpenSSLBadNonceSize(t *testing.T) {
+	testBadSize(t, NewOpenSSL, KeySize, RFCNonceSize, ErrInvalidNonce)
+}
+
+func Test128RightSizes(t *testing.T) {
+	testBadSize(t, New128, KeySize128, RFCNonceSize, nil)
+	testBadSize(t, New128, KeySize128, DraftNonceSize, nil)
+	testBadSize(t, New128, KeySize128, XNonceSize, nil)
+}
+
+func Test128BadKeySize(t *testing.T) {
+	testBadSize(t, New128, KeySize, DraftNonceSize, ErrInvalidKey)
+}
+
+func Test128BadNonceSize(t *testing.T) {
+	testBadSize(t, New128, KeySize128, 3, ErrInvalidNonce)
+}
+
 func testEqual(t *testing.T, new1, new2 func(key, nonce []byte) (cipher.Stream, error), noncesize, calls int, label1, label2 string) {
 	t.Parallel()
 
@@ -478,6 +832,31 @@ func TestXEqualMultiUseGo(t *testing.T) {
 	testEqual(t, ref.NewXChaCha, codahale.NewXChaCha, XNonceSize, 5, "tmthrgd/chacha20/internal/ref", "codahale/chacha20")
 }
 
+// halfKey128 wraps a 128-bit ctor so that it takes a KeySize key, of which it
+// uses the first half, and can be passed to testEqual.
+func halfKey128(new128 func(key, nonce []byte) (cipher.Stream, error)) func(key, nonce []byte) (cipher.Stream, error) {
+	return func(key, nonce []byte) (cipher.Stream, error) {
+		return new128(key[:KeySize128], nonce)
+	}
+}
+
+func test128Equal(t *testing.T, calls int) {
+	for _, nonceSize := range []int{RFCNonceSize, DraftNonceSize, XNonceSize} {
+		nonceSize := nonceSize
+		t.Run(fmt.Sprintf("nonce%d", nonceSize), func(t *testing.T) {
+			testEqual(t, halfKey128(New128), halfKey128(ref.New128), nonceSize, calls, "tmthrgd/chacha20", "tmthrgd/chacha20/internal/ref")
+		})
+	}
+}
+
+func Test128EqualOneShot(t *testing.T) {
+	test128Equal(t, 1)
+}
+
+func Test128EqualMultiUse(t *testing.T) {
+	test128Equal(t, 5)
+}
+
 func testNewNewVar(t *testing.T, newVariant func(key, nonce []byte) (cipher.Stream, error), nonceSize int) {
 	var key [KeySize]byte
 	nonce := make([]byte, nonceSize)
@@ -515,6 +894,237 @@ func TestNewNewXChaCha(t *testing.T) {
 	testNewNewVar(t, NewXChaCha, XNonceSize)
 }
 
+func TestHChaCha20(t *testing.T) {
+	// From https://tools.ietf.org/html/draft-irtf-cfrg-xchacha-01#section-2.2.1
+	var key [KeySize]byte
+	copy(key[:], mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"))
+
+	var nonce [HNonceSize]byte
+	copy(nonce[:], mustHexDecode("000000090000004a0000000031415927"))
+
+	expected := mustHexDecode("82413b4227b27bfed30e42508a877d73a0f9e4d58a74a853c12ec41326d3ecdc")
+
+	var out [HChaChaSize]byte
+	HChaCha20(&out, &key, &nonce)
+
+	if !bytes.Equal(out[:], expected) {
+		t.Errorf("HChaCha20: expected %x, was %x", expected, out)
+	}
+
+	var outRef [HChaChaSize]byte
+	ref.HChaCha20(&outRef, key[:], nonce[:])
+
+	if !bytes.Equal(outRef[:], expected) {
+		t.Errorf("ref.HChaCha20: expected %x, was %x", expected, outRef)
+	}
+}
+
+func testSetCounter(t *testing.T, newChaCha20, newRef func(key, nonce []byte) (cipher.Stream, error), nonceSize int, counters []uint64) {
+	key := make([]byte, KeySize)
+	rand.Read(key)
+
+	nonce := make([]byte, nonceSize)
+	rand.Read(nonce)
+
+	for _, counter := range counters {
+		// c1 is moved by SetCounter part way through a block, c2 is
+		// the reference implementation moved by SetCounter and, for
+		// small counters, c3 is advanced by discarding keystream.
+		c1, err := newChaCha20(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var skip [10]byte
+		c1.XORKeyStream(skip[:], skip[:])
+		SetCounter(c1, counter)
+
+		c2, err := newRef(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		SetCounter(c2, counter)
+
+		dst1 := make([]byte, 300)
+		c1.XORKeyStream(dst1, dst1)
+
+		dst2 := make([]byte, 300)
+		c2.XORKeyStream(dst2, dst2)
+
+		if !bytes.Equal(dst1, dst2) {
+			t.Errorf("counter %d: SetCounter disagrees with internal/ref", counter)
+		}
+
+		if counter > 16 {
+			continue
+		}
+
+		c3, err := newChaCha20(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var block [64]byte
+		for i := uint64(0); i < counter; i++ {
+			c3.XORKeyStream(block[:], block[:])
+		}
+
+		dst3 := make([]byte, 300)
+		c3.XORKeyStream(dst3, dst3)
+
+		if !bytes.Equal(dst1, dst3) {
+			t.Errorf("counter %d: SetCounter disagrees with discarding keystream", counter)
+		}
+	}
+}
+
+func TestRFCSetCounter(t *testing.T) {
+	testSetCounter(t, NewRFC, ref.NewRFC, RFCNonceSize, []uint64{0, 1, 2, 7, 1<<32 - 1})
+}
+
+func TestDraftSetCounter(t *testing.T) {
+	testSetCounter(t, NewDraft, ref.NewDraft, DraftNonceSize, []uint64{0, 1, 2, 7, 1<<32 - 1, 1 << 32, 1<<64 - 1})
+}
+
+func TestXSetCounter(t *testing.T) {
+	testSetCounter(t, NewXChaCha, ref.NewXChaCha, XNonceSize, []uint64{0, 1, 2, 7, 1<<32 - 1, 1 << 32, 1<<64 - 1})
+}
+
+func testSetNonce(t *testing.T, newChaCha20, newRef func(key, nonce []byte) (cipher.Stream, error), nonceSize int) {
+	key := make([]byte, KeySize)
+	rand.Read(key)
+
+	nonce := make([]byte, nonceSize)
+	rand.Read(nonce)
+
+	// c1 has its nonce replaced part way through a block, c2 is the
+	// reference implementation with its nonce replaced and c3 is created
+	// with the new nonce.
+	c1, err := newChaCha20(key, make([]byte, nonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var skip [10]byte
+	c1.XORKeyStream(skip[:], skip[:])
+	SetNonce(c1, nonce)
+
+	c2, err := newRef(key, make([]byte, nonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c2.XORKeyStream(skip[:], skip[:])
+	SetNonce(c2, nonce)
+
+	c3, err := newChaCha20(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst1 := make([]byte, 300)
+	c1.XORKeyStream(dst1, dst1)
+
+	dst2 := make([]byte, 300)
+	c2.XORKeyStream(dst2, dst2)
+
+	dst3 := make([]byte, 300)
+	c3.XORKeyStream(dst3, dst3)
+
+	if !bytes.Equal(dst1, dst3) {
+		t.Error("SetNonce disagrees with a new stream")
+	}
+
+	if !bytes.Equal(dst2, dst3) {
+		t.Error("SetNonce disagrees with internal/ref")
+	}
+
+	for _, c := range []cipher.Stream{c1, c2} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("SetNonce did not panic for the wrong nonce length")
+				}
+			}()
+
+			SetNonce(c, make([]byte, nonceSize+1))
+		}()
+	}
+}
+
+func TestRFCSetNonce(t *testing.T) {
+	testSetNonce(t, NewRFC, ref.NewRFC, RFCNonceSize)
+}
+
+func TestDraftSetNonce(t *testing.T) {
+	testSetNonce(t, NewDraft, ref.NewDraft, DraftNonceSize)
+}
+
+func TestXSetNonce(t *testing.T) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, XNonceSize)
+
+	var streams []cipher.Stream
+	for _, newChaCha20 := range []func(key, nonce []byte) (cipher.Stream, error){
+		NewXChaCha, ref.NewXChaCha, withRounds(NewRounds, 12), withRounds(ref.NewRounds, 12),
+	} {
+		c, err := newChaCha20(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		streams = append(streams, c)
+	}
+
+	for _, new128 := range []func(key, nonce []byte) (cipher.Stream, error){New128, ref.New128} {
+		c, err := new128(key[:KeySize128], nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		streams = append(streams, c)
+	}
+
+	// The subkey is derived from the first 16 bytes of the nonce, so no
+	// nonce may replace only the last 8.
+	for _, c := range streams {
+		for _, size := range []int{DraftNonceSize, XNonceSize} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("SetNonce did not panic for an XChaCha20 stream with a %d-byte nonce", size)
+					}
+				}()
+
+				SetNonce(c, make([]byte, size))
+			}()
+		}
+	}
+}
+
+func TestRFCSetCounterTooLarge(t *testing.T) {
+	var key [KeySize]byte
+	var nonce [RFCNonceSize]byte
+
+	for _, newChaCha20 := range []func(key, nonce []byte) (cipher.Stream, error){NewRFC, ref.NewRFC} {
+		c, err := newChaCha20(key[:], nonce[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("SetCounter did not panic for a counter larger than 32 bits")
+				}
+			}()
+
+			SetCounter(c, 1<<32)
+		}()
+	}
+}
+
 func TestXOREmptyKeyStream(t *testing.T) {
 	var key [KeySize]byte
 	var nonce [RFCNonceSize]byte
@@ -62,7 +62,7 @@ ___
 
 if ($flavour =~ /^golang/) {
     $code.=<<___;
-TEXT ·chacha_20_core_x64(SB),\$`512+64`-32
+TEXT ·chacha_20_core_x64(SB),\$`512+64`-33
 	movq	out+0(FP), DX
 	movq	in+8(FP), SI
 	movq	in_len+16(FP), BX
@@ -90,6 +90,20 @@ ___
 $code.=<<___;
 movq \$0x3320646e61707865, %r8
 movq \$0x6b20657479622d32, %r9
+___
+
+if ($flavour =~ /^golang/) {
+    # "expand 16-byte k" for 128-bit keys
+    $code.=<<___;
+	cmpb	key128+32(FP), \$0
+	je	chacha_blocks_sse2_sigma
+	movq	\$0x3120646e61707865, R8
+	movq	\$0x6b20657479622d36, R9
+chacha_blocks_sse2_sigma:
+___
+}
+
+$code.=<<___;
 movd %r8, %xmm8
 movd %r9, %xmm14
 punpcklqdq %xmm14, %xmm8
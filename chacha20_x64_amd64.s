@@ -8,7 +8,7 @@
 
 #include "textflag.h"
 
-TEXT ·chacha_20_core_x64(SB),$576-32
+TEXT ·chacha_20_core_x64(SB),$576-33
 	MOVQ	out+0(FP),DX
 	MOVQ	in+8(FP),SI
 	MOVQ	in_len+16(FP),BX
@@ -19,6 +19,11 @@ TEXT ·chacha_20_core_x64(SB),$576-32
 
 	MOVQ	$3684054920433006693,R8
 	MOVQ	$7719281312240119090,R9
+	CMPB	key128+32(FP),$0
+	JE	chacha_blocks_sse2_sigma
+	MOVQ	$3539939732357150821,R8
+	MOVQ	$7719281312240119094,R9
+chacha_blocks_sse2_sigma:
 	MOVD	R8,X8
 	MOVD	R9,X14
 	PUNPCKLQDQ	X14,X8
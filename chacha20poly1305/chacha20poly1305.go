@@ -0,0 +1,238 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package chacha20poly1305 implements the ChaCha20-Poly1305 AEAD of RFC 8439
+// and its XChaCha20-Poly1305 extension on top of github.com/tmthrgd/chacha20.
+//
+// It also provides key-committing variants of both. ChaCha20-Poly1305 is not
+// key-committing: a ciphertext can be constructed that decrypts successfully
+// under two different keys. The committing variants use the CTX construction
+// of Chan and Rogaway, replacing the Poly1305 tag T with
+//
+//	SHA-256(K || N || A || T)
+//
+// which binds the ciphertext to the key K, nonce N and associated data A.
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/tmthrgd/chacha20"
+	"github.com/tmthrgd/chacha20/internal/slice"
+	"golang.org/x/crypto/poly1305"
+)
+
+const (
+	// KeySize is the length of ChaCha20-Poly1305 keys, in bytes.
+	KeySize = chacha20.KeySize
+
+	// NonceSize is the length of ChaCha20-Poly1305 nonces, in bytes.
+	NonceSize = chacha20.RFCNonceSize
+
+	// NonceSizeX is the length of XChaCha20-Poly1305 nonces, in bytes.
+	NonceSizeX = chacha20.XNonceSize
+
+	// Overhead is the length of the Poly1305 tag, in bytes.
+	Overhead = poly1305.TagSize
+
+	// CommittingOverhead is the length of the tag of the key-committing
+	// variants, in bytes.
+	CommittingOverhead = sha256.Size
+)
+
+// maxPlaintext is the length of the keystream for a 32-bit block counter,
+// less the block used for the Poly1305 key.
+const maxPlaintext = (1<<32 - 1) * 64
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	errOpen = errors.New("chacha20poly1305: message authentication failed")
+)
+
+type aead struct {
+	key [KeySize]byte
+
+	x          bool // XChaCha20-Poly1305
+	committing bool // CTX
+}
+
+// New returns a ChaCha20-Poly1305 AEAD, as specified in RFC 8439, that uses
+// the given 256-bit key.
+func New(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, false, false)
+}
+
+// NewX returns an XChaCha20-Poly1305 AEAD that uses the given 256-bit key.
+//
+// XChaCha20-Poly1305 takes a 192-bit nonce, which is long enough to be
+// chosen at random.
+func NewX(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, true, false)
+}
+
+// NewCommitting returns a key-committing ChaCha20-Poly1305 AEAD that uses the
+// given 256-bit key. Its tags are CommittingOverhead bytes long, and a
+// ciphertext only decrypts under the key, nonce and associated data it was
+// sealed with.
+func NewCommitting(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, false, true)
+}
+
+// NewXCommitting returns a key-committing XChaCha20-Poly1305 AEAD that uses
+// the given 256-bit key. It is to NewX as NewCommitting is to New.
+func NewXCommitting(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, true, true)
+}
+
+func newAEAD(key []byte, x, committing bool) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	a := &aead{x: x, committing: committing}
+	copy(a.key[:], key)
+	return a, nil
+}
+
+func (a *aead) NonceSize() int {
+	if a.x {
+		return NonceSizeX
+	}
+
+	return NonceSize
+}
+
+func (a *aead) Overhead() int {
+	if a.committing {
+		return CommittingOverhead
+	}
+
+	return Overhead
+}
+
+// stream returns the ChaCha20 stream for nonce, positioned at block one, and
+// the Poly1305 key taken from block zero.
+func (a *aead) stream(nonce []byte) (cipher.Stream, *[32]byte) {
+	key, n := a.key[:], nonce
+
+	if a.x {
+		var hNonce [chacha20.HNonceSize]byte
+		copy(hNonce[:], nonce)
+
+		var subKey [chacha20.HChaChaSize]byte
+		chacha20.HChaCha20(&subKey, &a.key, &hNonce)
+
+		var rfcNonce [NonceSize]byte
+		copy(rfcNonce[4:], nonce[chacha20.HNonceSize:])
+
+		key, n = subKey[:], rfcNonce[:]
+	}
+
+	s, err := chacha20.NewRFC(key, n)
+	if err != nil {
+		panic(err)
+	}
+
+	var block [64]byte
+	s.XORKeyStream(block[:], block[:])
+
+	var polyKey [32]byte
+	copy(polyKey[:], block[:])
+
+	for i := range block {
+		block[i] = 0
+	}
+
+	return s, &polyKey
+}
+
+// tag computes the tag over additionalData and ciphertext.
+func (a *aead) tag(polyKey *[32]byte, nonce, ciphertext, additionalData []byte) []byte {
+	m := poly1305.New(polyKey)
+	writeWithPadding(m, additionalData)
+	writeWithPadding(m, ciphertext)
+
+	var lens [16]byte
+	binary.LittleEndian.PutUint64(lens[0:], uint64(len(additionalData)))
+	binary.LittleEndian.PutUint64(lens[8:], uint64(len(ciphertext)))
+	m.Write(lens[:])
+
+	for i := range polyKey {
+		polyKey[i] = 0
+	}
+
+	tag := m.Sum(make([]byte, 0, CommittingOverhead))
+	if !a.committing {
+		return tag
+	}
+
+	h := sha256.New()
+	h.Write(a.key[:])
+	h.Write(nonce)
+	h.Write(additionalData)
+	h.Write(tag)
+	return h.Sum(tag[:0])
+}
+
+func (a *aead) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != a.NonceSize() {
+		panic("chacha20poly1305: bad nonce length passed to Seal")
+	}
+
+	if uint64(len(plaintext)) > maxPlaintext {
+		panic("chacha20poly1305: plaintext too large")
+	}
+
+	s, polyKey := a.stream(nonce)
+
+	ret, out := slice.ForAppend(dst, len(plaintext)+a.Overhead())
+	s.XORKeyStream(out, plaintext)
+
+	copy(out[len(plaintext):], a.tag(polyKey, nonce, out[:len(plaintext)], additionalData))
+	return ret
+}
+
+func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != a.NonceSize() {
+		panic("chacha20poly1305: bad nonce length passed to Open")
+	}
+
+	if len(ciphertext) < a.Overhead() {
+		return nil, errOpen
+	}
+
+	if uint64(len(ciphertext)-a.Overhead()) > maxPlaintext {
+		panic("chacha20poly1305: ciphertext too large")
+	}
+
+	tag := ciphertext[len(ciphertext)-a.Overhead():]
+	ciphertext = ciphertext[:len(ciphertext)-a.Overhead()]
+
+	s, polyKey := a.stream(nonce)
+
+	expected := a.tag(polyKey, nonce, ciphertext, additionalData)
+	if subtle.ConstantTimeCompare(tag, expected) != 1 {
+		return nil, errOpen
+	}
+
+	ret, out := slice.ForAppend(dst, len(ciphertext))
+	s.XORKeyStream(out, ciphertext)
+	return ret, nil
+}
+
+func writeWithPadding(m *poly1305.MAC, b []byte) {
+	m.Write(b)
+
+	if rem := len(b) % 16; rem != 0 {
+		var pad [16]byte
+		m.Write(pad[:16-rem])
+	}
+}
@@ -0,0 +1,436 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/tmthrgd/chacha20"
+	xchacha20poly1305 "golang.org/x/crypto/chacha20poly1305"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+var sunscreen = []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+
+var testVectors = []struct {
+	new                                   func(key []byte) (cipher.AEAD, error)
+	key, nonce, plaintext, ad, ciphertext []byte
+}{
+	// RFC 8439, section 2.8.2
+	{
+		New,
+		mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"),
+		mustHexDecode("070000004041424344454647"),
+		sunscreen,
+		mustHexDecode("50515253c0c1c2c3c4c5c6c7"),
+		mustHexDecode("d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d6" +
+			"3dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b36" +
+			"92ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc" +
+			"3ff4def08e4b7a9de576d26586cec64b6116" +
+			"1ae10b594f09e26a7e902ecbd0600691"),
+	},
+	// draft-irtf-cfrg-xchacha-03, appendix A.3.1
+	{
+		NewX,
+		mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"),
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		sunscreen,
+		mustHexDecode("50515253c0c1c2c3c4c5c6c7"),
+		mustHexDecode("bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb" +
+			"731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b452" +
+			"2f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff9" +
+			"21f9664c97637da9768812f615c68b13b52e" +
+			"c0875924c1c7987947deafd8780acf49"),
+	},
+	// generated with an independent Python implementation
+	{
+		NewCommitting,
+		mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"),
+		mustHexDecode("070000004041424344454647"),
+		sunscreen,
+		mustHexDecode("50515253c0c1c2c3c4c5c6c7"),
+		mustHexDecode("d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d6" +
+			"3dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b36" +
+			"92ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc" +
+			"3ff4def08e4b7a9de576d26586cec64b6116" +
+			"1c294d148a70af27feddc787f08f28466c5f744f813673f749fc7963d5714da4"),
+	},
+	{
+		NewXCommitting,
+		mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"),
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		sunscreen,
+		mustHexDecode("50515253c0c1c2c3c4c5c6c7"),
+		mustHexDecode("bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb" +
+			"731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b452" +
+			"2f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff9" +
+			"21f9664c97637da9768812f615c68b13b52e" +
+			"656ccdd39518b6d2951e281dd0d755400704482266dd3933d65fada3be442ced"),
+	},
+	{
+		NewCommitting,
+		make([]byte, KeySize),
+		make([]byte, NonceSize),
+		nil,
+		nil,
+		mustHexDecode("c88a2c27688404b0879c391f419f86642e666b9982e3a52e3639ec6539328d82"),
+	},
+	{
+		NewXCommitting,
+		make([]byte, KeySize),
+		make([]byte, NonceSizeX),
+		nil,
+		nil,
+		mustHexDecode("65f1c3507ce40f557454d2814a588c047ca107553126286add89f58e5909928f"),
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for i, v := range testVectors {
+		aead, err := v.new(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ct := aead.Seal(nil, v.nonce, v.plaintext, v.ad)
+		if !bytes.Equal(ct, v.ciphertext) {
+			t.Errorf("test vector %d: Seal: expected %x, got %x", i, v.ciphertext, ct)
+			continue
+		}
+
+		pt, err := aead.Open(nil, v.nonce, v.ciphertext, v.ad)
+		if err != nil {
+			t.Errorf("test vector %d: Open failed: %v", i, err)
+			continue
+		}
+
+		if !bytes.Equal(pt, v.plaintext) {
+			t.Errorf("test vector %d: Open: expected %x, got %x", i, v.plaintext, pt)
+		}
+	}
+}
+
+func testEqual(t *testing.T, new, newX func(key []byte) (cipher.AEAD, error)) {
+	rand := rand.New(rand.NewSource(0))
+
+	for i := 0; i < 200; i++ {
+		key := make([]byte, KeySize)
+		rand.Read(key)
+
+		a1, err := new(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a2, err := newX(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nonce := make([]byte, a1.NonceSize())
+		rand.Read(nonce)
+
+		plaintext := make([]byte, rand.Intn(1024))
+		rand.Read(plaintext)
+
+		ad := make([]byte, rand.Intn(64))
+		rand.Read(ad)
+
+		ct1 := a1.Seal(nil, nonce, plaintext, ad)
+		if ct2 := a2.Seal(nil, nonce, plaintext, ad); !bytes.Equal(ct1, ct2) {
+			t.Fatalf("Seal differs from golang.org/x/crypto/chacha20poly1305: %x vs %x", ct1, ct2)
+		}
+
+		// Open in place.
+		pt, err := a1.Open(ct1[:0], nonce, ct1, ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(pt, plaintext) {
+			t.Fatal("Open returned wrong plaintext")
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	testEqual(t, New, xchacha20poly1305.New)
+}
+
+func TestEqualX(t *testing.T) {
+	testEqual(t, NewX, xchacha20poly1305.NewX)
+}
+
+func testTampering(t *testing.T, new func(key []byte) (cipher.AEAD, error)) {
+	aead, err := new(make([]byte, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	ad := []byte("header")
+	ct := aead.Seal(nil, nonce, []byte("attack at dawn"), ad)
+
+	for i := 0; i < len(ct)*8; i++ {
+		bad := append([]byte(nil), ct...)
+		bad[i/8] ^= 1 << uint(i%8)
+
+		if _, err := aead.Open(nil, nonce, bad, ad); err == nil {
+			t.Fatalf("Open accepted ciphertext with bit %d flipped", i)
+		}
+	}
+
+	if _, err := aead.Open(nil, nonce, ct, []byte("Header")); err == nil {
+		t.Error("Open accepted wrong additional data")
+	}
+
+	if _, err := aead.Open(nil, nonce, ct[:aead.Overhead()-1], ad); err == nil {
+		t.Error("Open accepted short ciphertext")
+	}
+
+	other, err := new(bytes.Repeat([]byte{1}, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := other.Open(nil, nonce, ct, ad); err == nil {
+		t.Error("Open accepted wrong key")
+	}
+}
+
+func TestTampering(t *testing.T) {
+	for _, new := range []func(key []byte) (cipher.AEAD, error){
+		New, NewX, NewCommitting, NewXCommitting,
+	} {
+		testTampering(t, new)
+	}
+}
+
+// multiKeyCiphertext returns a two block ciphertext and tag, with no
+// associated data, that opens under both k1 and k2 with ChaCha20-Poly1305.
+//
+// With no associated data, the Poly1305 accumulator for a ciphertext of two
+// blocks c0 and c1 is c0*r^3 + c1*r^2 + l*r mod 2^130-5, where each block has
+// 2^128 added and l is the lengths block. Picking a tag fixes the accumulator
+// needed under each key, leaving two linear equations in c0 and c1.
+func multiKeyCiphertext(t *testing.T, k1, k2, nonce []byte) []byte {
+	p := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 130), big.NewInt(5))
+	two128 := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	var lens [16]byte
+	binary.LittleEndian.PutUint64(lens[8:], 32)
+	l := new(big.Int).Add(leInt(lens[:]), two128)
+
+	var r, s [2]*big.Int
+	for i, k := range [][]byte{k1, k2} {
+		st, err := chacha20.NewRFC(k, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var polyKey [32]byte
+		st.XORKeyStream(polyKey[:], polyKey[:])
+
+		polyKey[3] &= 15
+		polyKey[7] &= 15
+		polyKey[11] &= 15
+		polyKey[15] &= 15
+		polyKey[4] &= 252
+		polyKey[8] &= 252
+		polyKey[12] &= 252
+
+		r[i], s[i] = leInt(polyKey[:16]), leInt(polyKey[16:])
+	}
+
+	pow := func(x *big.Int, n int64) *big.Int {
+		return new(big.Int).Exp(x, big.NewInt(n), p)
+	}
+
+	rand := rand.New(rand.NewSource(0))
+
+	for tries := 0; tries < 1000; tries++ {
+		var tag [16]byte
+		rand.Read(tag[:])
+
+		// The accumulator must equal tag - s mod 2^128 under each key.
+		var rhs [2]*big.Int
+		for i := range rhs {
+			h := new(big.Int).Sub(leInt(tag[:]), s[i])
+			h.Mod(h, two128)
+
+			rhs[i] = h.Sub(h, new(big.Int).Mul(l, r[i]))
+			rhs[i].Mod(rhs[i], p)
+		}
+
+		// Solve a0*c0 + b0*c1 = rhs0, a1*c0 + b1*c1 = rhs1 mod p.
+		a0, b0 := pow(r[0], 3), pow(r[0], 2)
+		a1, b1 := pow(r[1], 3), pow(r[1], 2)
+
+		det := new(big.Int).Sub(new(big.Int).Mul(a0, b1), new(big.Int).Mul(a1, b0))
+		det.Mod(det, p)
+		if det.Sign() == 0 {
+			continue
+		}
+
+		det.ModInverse(det, p)
+
+		c0 := new(big.Int).Sub(new(big.Int).Mul(rhs[0], b1), new(big.Int).Mul(rhs[1], b0))
+		c0.Mul(c0, det).Mod(c0, p)
+
+		c1 := new(big.Int).Sub(new(big.Int).Mul(a0, rhs[1]), new(big.Int).Mul(a1, rhs[0]))
+		c1.Mul(c1, det).Mod(c1, p)
+
+		// Each block must be a 16 byte value plus 2^128.
+		c0.Sub(c0, two128)
+		c1.Sub(c1, two128)
+		if c0.Sign() < 0 || c0.Cmp(two128) >= 0 || c1.Sign() < 0 || c1.Cmp(two128) >= 0 {
+			continue
+		}
+
+		ct := append(leBytes(c0), leBytes(c1)...)
+		return append(ct, tag[:]...)
+	}
+
+	t.Fatal("failed to find a multi-key ciphertext")
+	return nil
+}
+
+func leInt(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i, v := range b {
+		be[len(b)-1-i] = v
+	}
+
+	return new(big.Int).SetBytes(be)
+}
+
+func leBytes(x *big.Int) []byte {
+	var b [16]byte
+	be := x.Bytes()
+	for i, v := range be {
+		b[len(be)-1-i] = v
+	}
+
+	return b[:]
+}
+
+func TestNotKeyCommitting(t *testing.T) {
+	k1 := bytes.Repeat([]byte{1}, KeySize)
+	k2 := bytes.Repeat([]byte{2}, KeySize)
+	nonce := make([]byte, NonceSize)
+
+	ct := multiKeyCiphertext(t, k1, k2, nonce)
+
+	for _, k := range [][]byte{k1, k2} {
+		aead, err := New(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := aead.Open(nil, nonce, ct, nil); err != nil {
+			t.Fatalf("multi-key ciphertext did not open under ChaCha20-Poly1305: %v", err)
+		}
+	}
+}
+
+func TestCommittingWrongKey(t *testing.T) {
+	k1 := bytes.Repeat([]byte{1}, KeySize)
+	k2 := bytes.Repeat([]byte{2}, KeySize)
+	nonce := make([]byte, NonceSize)
+
+	// The committing tag that k1 would give the multi-key ciphertext opens
+	// under k1, and not under k2, even though the Poly1305 tags match.
+	ct := multiKeyCiphertext(t, k1, k2, nonce)
+
+	a1, err := NewCommitting(k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a2, err := NewCommitting(k2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, polyKey := a1.(*aead).stream(nonce)
+
+	body := ct[:len(ct)-Overhead]
+	committed := append(append([]byte(nil), body...), a1.(*aead).tag(polyKey, nonce, body, nil)...)
+
+	if _, err := a1.Open(nil, nonce, committed, nil); err != nil {
+		t.Fatalf("Open failed under the right key: %v", err)
+	}
+
+	if _, err := a2.Open(nil, nonce, committed, nil); err == nil {
+		t.Fatal("committing ciphertext opened under the wrong key")
+	}
+
+	// Nor may the tag under k2 be substituted, as it commits to k2.
+	_, polyKey = a2.(*aead).stream(nonce)
+
+	other := append(append([]byte(nil), body...), a2.(*aead).tag(polyKey, nonce, body, nil)...)
+
+	if _, err := a1.Open(nil, nonce, other, nil); err == nil {
+		t.Fatal("ciphertext committed to k2 opened under k1")
+	}
+}
+
+func TestBadKeySize(t *testing.T) {
+	for _, new := range []func(key []byte) (cipher.AEAD, error){
+		New, NewX, NewCommitting, NewXCommitting,
+	} {
+		if _, err := new(make([]byte, KeySize-1)); err != ErrInvalidKey {
+			t.Errorf("expected ErrInvalidKey, got %v", err)
+		}
+	}
+}
+
+func benchmarkSeal(b *testing.B, new func(key []byte) (cipher.AEAD, error), size int) {
+	aead, err := new(make([]byte, KeySize))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	buf := make([]byte, size+aead.Overhead())
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		aead.Seal(buf[:0], nonce, buf[:size], nil)
+	}
+}
+
+func BenchmarkSeal(b *testing.B) {
+	benchmarkSeal(b, New, 16*1024)
+}
+
+func BenchmarkSealX(b *testing.B) {
+	benchmarkSeal(b, NewX, 16*1024)
+}
+
+func BenchmarkSealCommitting(b *testing.B) {
+	benchmarkSeal(b, NewCommitting, 16*1024)
+}
+
+func BenchmarkSealXCrypto(b *testing.B) {
+	benchmarkSeal(b, xchacha20poly1305.New, 16*1024)
+}
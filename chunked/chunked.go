@@ -0,0 +1,510 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package chunked implements a random-access authenticated encrypted
+// container on top of XChaCha20-Poly1305.
+//
+// A container is a HeaderSize byte header followed by the plaintext split
+// into chunks of a fixed size, with only the final chunk allowed to be
+// shorter. The header is
+//
+//	magic[4] || version[1] || zero[3] || be32(chunk size) || be32(key ID) || prefix[16]
+//
+// where prefix is chosen at random for each container. Chunk i is sealed
+// with the nonce prefix || be64(i) and the additional data header || last,
+// where last is 1 for the final chunk and 0 otherwise. An empty container
+// has a single empty final chunk.
+//
+// Each chunk can be authenticated and decrypted on its own, so a read only
+// verifies the chunks that it touches. Chunks cannot be reordered, moved
+// between containers or modified, and truncating the container causes the
+// new final chunk to fail to authenticate.
+package chunked
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+)
+
+const (
+	// KeySize is the length of keys, in bytes.
+	KeySize = chacha20poly1305.KeySize
+
+	// HeaderSize is the length of the container header, in bytes.
+	HeaderSize = 32
+
+	// Overhead is the number of bytes each chunk adds to its plaintext.
+	Overhead = chacha20poly1305.Overhead
+
+	// Version is the version of the container format that is written.
+	Version = 1
+
+	// DefaultChunkSize is the size of plaintext chunks used when a chunk size
+	// of zero is given.
+	DefaultChunkSize = 64 * 1024
+
+	// MaxChunkSize is the largest chunk size that may be used. It bounds the
+	// memory a malicious header can demand.
+	MaxChunkSize = 16 * 1024 * 1024
+)
+
+const (
+	magic      = "xc20"
+	prefixSize = 16
+)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	// ErrInvalidChunkSize is returned when the chunk size is negative or
+	// greater than MaxChunkSize.
+	ErrInvalidChunkSize = errors.New("invalid chunk size")
+
+	// ErrInvalidHeader is returned when the container does not begin with a
+	// valid header.
+	ErrInvalidHeader = errors.New("invalid header")
+
+	// ErrUnsupportedVersion is returned when the header has a version other
+	// than Version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+
+	// ErrInvalidOffset is returned when a negative offset is given.
+	ErrInvalidOffset = errors.New("invalid offset")
+
+	errOpen      = errors.New("chunked: message authentication failed")
+	errTruncated = errors.New("chunked: container truncated")
+	errClosed    = errors.New("chunked: write to closed Writer")
+	errRewrite   = errors.New("chunked: write to sealed chunk")
+)
+
+// Header is the unencrypted header of a container.
+type Header struct {
+	// Version is the version of the container format.
+	Version int
+
+	// ChunkSize is the size of each chunk of plaintext.
+	ChunkSize int
+
+	// KeyID identifies the key the container was encrypted with. It is
+	// chosen by the writer and is not interpreted by this package.
+	KeyID uint32
+}
+
+// ReadHeader reads the header of the container r. It may be used to find the
+// key ID before calling NewReader.
+func ReadHeader(r io.ReaderAt) (*Header, error) {
+	var hdr [HeaderSize]byte
+	if _, err := r.ReadAt(hdr[:], 0); err != nil {
+		if err == io.EOF {
+			return nil, ErrInvalidHeader
+		}
+
+		return nil, err
+	}
+
+	return parseHeader(&hdr)
+}
+
+func parseHeader(hdr *[HeaderSize]byte) (*Header, error) {
+	if string(hdr[:4]) != magic || !bytes.Equal(hdr[5:8], []byte{0, 0, 0}) {
+		return nil, ErrInvalidHeader
+	}
+
+	if hdr[4] != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	chunkSize := binary.BigEndian.Uint32(hdr[8:])
+	if chunkSize == 0 || chunkSize > MaxChunkSize {
+		return nil, ErrInvalidHeader
+	}
+
+	return &Header{
+		Version:   int(hdr[4]),
+		ChunkSize: int(chunkSize),
+		KeyID:     binary.BigEndian.Uint32(hdr[12:]),
+	}, nil
+}
+
+// container holds what is needed to seal and open chunks.
+type container struct {
+	aead      cipher.AEAD
+	header    [HeaderSize]byte
+	chunkSize int
+}
+
+func (c *container) init(key []byte, chunkSize int) error {
+	if len(key) != KeySize {
+		return ErrInvalidKey
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return err
+	}
+
+	c.aead = aead
+	c.chunkSize = chunkSize
+	return nil
+}
+
+// nonce and ad return the nonce and additional data for chunk i.
+func (c *container) nonce(i int64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	copy(nonce, c.header[HeaderSize-prefixSize:])
+	binary.BigEndian.PutUint64(nonce[prefixSize:], uint64(i))
+	return nonce
+}
+
+func (c *container) ad(last bool) []byte {
+	ad := make([]byte, HeaderSize+1)
+	copy(ad, c.header[:])
+
+	if last {
+		ad[HeaderSize] = 1
+	}
+
+	return ad
+}
+
+// offset returns the offset of chunk i in the container.
+func (c *container) offset(i int64) int64 {
+	return HeaderSize + i*int64(c.chunkSize+Overhead)
+}
+
+// Reader decrypts a container. It is safe for concurrent use.
+type Reader struct {
+	container
+	r io.ReaderAt
+
+	size    int64 // the length of the plaintext
+	chunks  int64 // the number of chunks
+	lastLen int   // the length of the sealed final chunk
+}
+
+// NewReader returns a Reader that decrypts the container r, which is size
+// bytes long, with the given 256-bit key.
+func NewReader(r io.ReaderAt, size int64, key []byte) (*Reader, error) {
+	if size < HeaderSize {
+		return nil, ErrInvalidHeader
+	}
+
+	rd := &Reader{r: r}
+
+	if _, err := r.ReadAt(rd.header[:], 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	h, err := parseHeader(&rd.header)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rd.init(key, h.ChunkSize); err != nil {
+		return nil, err
+	}
+
+	body := size - HeaderSize
+	sealed := int64(h.ChunkSize + Overhead)
+
+	rd.chunks = (body + sealed - 1) / sealed
+	if rd.chunks == 0 {
+		return nil, errTruncated
+	}
+
+	rd.lastLen = int(body - (rd.chunks-1)*sealed)
+	if rd.lastLen < Overhead {
+		return nil, errTruncated
+	}
+
+	rd.size = (rd.chunks-1)*int64(h.ChunkSize) + int64(rd.lastLen-Overhead)
+	return rd, nil
+}
+
+// Size returns the length of the plaintext.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// KeyID returns the key ID from the header.
+func (r *Reader) KeyID() uint32 {
+	return binary.BigEndian.Uint32(r.header[12:])
+}
+
+// ReadAt reads len(p) bytes of plaintext starting at offset off. Only the
+// chunks that are read from are authenticated, and no plaintext is returned
+// from a chunk that fails to authenticate.
+func (r *Reader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	if off >= r.size {
+		if len(p) == 0 {
+			return 0, nil
+		}
+
+		return 0, io.EOF
+	}
+
+	end := off + int64(len(p))
+	if end > r.size {
+		end = r.size
+	}
+
+	cs := int64(r.chunkSize)
+	buf := make([]byte, r.chunkSize+Overhead)
+
+	for i := off / cs; i <= (end-1)/cs; i++ {
+		last := i == r.chunks-1
+
+		sealed := buf
+		if last {
+			sealed = buf[:r.lastLen]
+		}
+
+		if m, err := r.r.ReadAt(sealed, r.offset(i)); m != len(sealed) {
+			if err == io.EOF {
+				err = errTruncated
+			}
+
+			return n, err
+		}
+
+		plaintext, err := r.aead.Open(sealed[:0], r.nonce(i), sealed, r.ad(last))
+		if err != nil {
+			return n, errOpen
+		}
+
+		start := i * cs
+		if off > start {
+			plaintext = plaintext[off-start:]
+		}
+
+		m := copy(p[n:end-off], plaintext)
+		n += m
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Writer encrypts a container. Writes may be made at any offset and in any
+// order, but each chunk is sealed exactly once, as sealing it again would
+// reuse its nonce. A chunk is sealed once it has been completely written and
+// data has been written beyond it; until then it is buffered in memory. Close
+// seals the remaining chunks, filling any bytes that were never written with
+// zeros.
+//
+// A Writer is safe for concurrent use.
+type Writer struct {
+	container
+	w io.WriterAt
+
+	mu sync.Mutex
+
+	size    int64                   // the length of the plaintext so far
+	pending map[int64]*pendingChunk // chunks that have not been sealed
+	sealed  []uint64                // a bitset of sealed chunks
+
+	err error
+}
+
+type pendingChunk struct {
+	buf     []byte   // the plaintext, with room for the tag
+	written []uint64 // a bitset of the bytes that have been written
+	n       int      // the number of bytes that have been written
+}
+
+// NewWriter returns a Writer that encrypts to w with the given 256-bit key.
+// The key ID is recorded in the header for the reader's benefit, and
+// chunkSize is the size of each chunk of plaintext. If chunkSize is zero,
+// DefaultChunkSize is used.
+//
+// The header is written to w before NewWriter returns.
+func NewWriter(w io.WriterAt, key []byte, keyID uint32, chunkSize int) (*Writer, error) {
+	if chunkSize < 0 || chunkSize > MaxChunkSize {
+		return nil, ErrInvalidChunkSize
+	}
+
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	wr := &Writer{
+		w:       w,
+		pending: make(map[int64]*pendingChunk),
+	}
+
+	if err := wr.init(key, chunkSize); err != nil {
+		return nil, err
+	}
+
+	copy(wr.header[:], magic)
+	wr.header[4] = Version
+	binary.BigEndian.PutUint32(wr.header[8:], uint32(chunkSize))
+	binary.BigEndian.PutUint32(wr.header[12:], keyID)
+
+	if _, err := rand.Read(wr.header[HeaderSize-prefixSize:]); err != nil {
+		return nil, err
+	}
+
+	if _, err := w.WriteAt(wr.header[:], 0); err != nil {
+		return nil, err
+	}
+
+	return wr, nil
+}
+
+// WriteAt writes len(p) bytes of plaintext at offset off. It returns an error,
+// without writing anything, if any part of p falls within a chunk that has
+// already been sealed.
+func (w *Writer) WriteAt(p []byte, off int64) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	if off < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	cs := int64(w.chunkSize)
+	end := off + int64(len(p))
+
+	for i := off / cs; i <= (end-1)/cs; i++ {
+		if w.isSealed(i) {
+			return 0, errRewrite
+		}
+	}
+
+	for i := off / cs; i <= (end-1)/cs; i++ {
+		pc := w.pending[i]
+		if pc == nil {
+			pc = &pendingChunk{
+				buf:     make([]byte, w.chunkSize, w.chunkSize+Overhead),
+				written: make([]uint64, (w.chunkSize+63)/64),
+			}
+			w.pending[i] = pc
+		}
+
+		start := i * cs
+
+		from, to := off-start, end-start
+		if from < 0 {
+			from = 0
+		}
+
+		if to > cs {
+			to = cs
+		}
+
+		copy(pc.buf[from:to], p[start+from-off:])
+
+		for j := from; j < to; j++ {
+			if pc.written[j/64]&(1<<uint(j%64)) == 0 {
+				pc.written[j/64] |= 1 << uint(j%64)
+				pc.n++
+			}
+		}
+	}
+
+	if end > w.size {
+		w.size = end
+	}
+
+	// Seal every complete chunk that is known not to be the final chunk.
+	lastIdx := (w.size - 1) / cs
+	for i, pc := range w.pending {
+		if i < lastIdx && pc.n == w.chunkSize {
+			if w.err = w.seal(i, pc.buf, false); w.err != nil {
+				return 0, w.err
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close seals the remaining chunks, including the final chunk. It does not
+// close the underlying io.WriterAt.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	cs := int64(w.chunkSize)
+
+	var lastIdx int64
+	if w.size != 0 {
+		lastIdx = (w.size - 1) / cs
+	}
+
+	for i := int64(0); i <= lastIdx; i++ {
+		if w.isSealed(i) {
+			continue
+		}
+
+		length := cs
+		if i == lastIdx {
+			length = w.size - i*cs
+		}
+
+		var buf []byte
+		if pc := w.pending[i]; pc != nil {
+			buf = pc.buf[:length]
+		} else {
+			buf = make([]byte, length, length+Overhead)
+		}
+
+		if w.err = w.seal(i, buf, i == lastIdx); w.err != nil {
+			return w.err
+		}
+	}
+
+	w.err = errClosed
+	return nil
+}
+
+func (w *Writer) isSealed(i int64) bool {
+	return i/64 < int64(len(w.sealed)) && w.sealed[i/64]&(1<<uint(i%64)) != 0
+}
+
+// seal seals chunk i, whose plaintext is in buf, and writes it out. buf must
+// have room for the tag.
+func (w *Writer) seal(i int64, buf []byte, last bool) error {
+	sealed := w.aead.Seal(buf[:0], w.nonce(i), buf, w.ad(last))
+	if _, err := w.w.WriteAt(sealed, w.offset(i)); err != nil {
+		return err
+	}
+
+	for int64(len(w.sealed)) <= i/64 {
+		w.sealed = append(w.sealed, 0)
+	}
+
+	w.sealed[i/64] |= 1 << uint(i%64)
+	delete(w.pending, i)
+	return nil
+}
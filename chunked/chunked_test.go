@@ -0,0 +1,325 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chunked
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// writerAtBuffer is an in-memory io.WriterAt.
+type writerAtBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *writerAtBuffer) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if end := int(off) + len(p); end > len(w.buf) {
+		w.buf = append(w.buf, make([]byte, end-len(w.buf))...)
+	}
+
+	return copy(w.buf[off:], p), nil
+}
+
+var testKey = make([]byte, KeySize)
+
+func encrypt(t *testing.T, plaintext []byte, chunkSize int, rand *rand.Rand) []byte {
+	var buf writerAtBuffer
+
+	w, err := NewWriter(&buf, testKey, 42, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the plaintext in random pieces in a random order.
+	type piece struct{ off, end int }
+	var pieces []piece
+
+	for off := 0; off < len(plaintext); {
+		end := off + 1 + rand.Intn(3*chunkSize)
+		if end > len(plaintext) {
+			end = len(plaintext)
+		}
+
+		pieces = append(pieces, piece{off, end})
+
+		off = end
+	}
+
+	for _, i := range rand.Perm(len(pieces)) {
+		p := pieces[i]
+		if _, err := w.WriteAt(plaintext[p.off:p.end], int64(p.off)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.buf
+}
+
+func TestRoundTrip(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	for _, chunkSize := range []int{1, 16, 100, 1024} {
+		for _, size := range []int{0, 1, chunkSize, chunkSize + 1, 3 * chunkSize, 5000} {
+			plaintext := make([]byte, size)
+			rand.Read(plaintext)
+
+			ct := encrypt(t, plaintext, chunkSize, rand)
+
+			h, err := ReadHeader(bytes.NewReader(ct))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if h.Version != Version || h.ChunkSize != chunkSize || h.KeyID != 42 {
+				t.Fatalf("wrong header %+v", h)
+			}
+
+			r, err := NewReader(bytes.NewReader(ct), int64(len(ct)), testKey)
+			if err != nil {
+				t.Fatalf("chunk size %d, size %d: %v", chunkSize, size, err)
+			}
+
+			if r.Size() != int64(size) {
+				t.Fatalf("chunk size %d, size %d: Size returned %d", chunkSize, size, r.Size())
+			}
+
+			got := make([]byte, size)
+			if _, err := r.ReadAt(got, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, plaintext) {
+				t.Fatalf("chunk size %d, size %d: wrong plaintext", chunkSize, size)
+			}
+
+			for i := 0; i < 20 && size > 0; i++ {
+				off := rand.Intn(size)
+				p := make([]byte, rand.Intn(size-off+10))
+
+				n, err := r.ReadAt(p, int64(off))
+				if off+len(p) > size {
+					if err != io.EOF || n != size-off {
+						t.Fatalf("expected %d, io.EOF; got %d, %v", size-off, n, err)
+					}
+				} else if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(p[:n], plaintext[off:off+n]) {
+					t.Fatalf("ReadAt(%d, %d): wrong plaintext", len(p), off)
+				}
+			}
+		}
+	}
+}
+
+func TestSparse(t *testing.T) {
+	var buf writerAtBuffer
+
+	w, err := NewWriter(&buf, testKey, 0, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.WriteAt([]byte("hello"), 100); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.buf), int64(len(buf.buf)), testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]byte, r.Size())
+	if _, err := r.ReadAt(got, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := append(make([]byte, 100), "hello"...); !bytes.Equal(got, expect) {
+		t.Errorf("expected %x, got %x", expect, got)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	var buf writerAtBuffer
+
+	w, err := NewWriter(&buf, testKey, 0, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.WriteAt(make([]byte, 40), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.WriteAt([]byte{1}, 15); err != errRewrite {
+		t.Errorf("expected errRewrite, got %v", err)
+	}
+
+	// The final, partial, chunk may still be written to.
+	if _, err := w.WriteAt([]byte{1}, 39); err != nil {
+		t.Error(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.WriteAt([]byte{1}, 40); err != errClosed {
+		t.Errorf("expected errClosed, got %v", err)
+	}
+}
+
+func TestTampering(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	const chunkSize = 64
+	sealed := chunkSize + Overhead
+
+	plaintext := make([]byte, 4*chunkSize+10)
+	rand.Read(plaintext)
+
+	ct := encrypt(t, plaintext, chunkSize, rand)
+
+	readAll := func(ct []byte) error {
+		r, err := NewReader(bytes.NewReader(ct), int64(len(ct)), testKey)
+		if err != nil {
+			return err
+		}
+
+		_, err = r.ReadAt(make([]byte, r.Size()), 0)
+		return err
+	}
+
+	chunk := func(i int) []byte {
+		return ct[HeaderSize+i*sealed : HeaderSize+(i+1)*sealed]
+	}
+
+	for name, bad := range map[string][]byte{
+		"drop final chunk":     ct[:HeaderSize+4*sealed],
+		"truncate final chunk": ct[:len(ct)-1],
+		"truncate mid chunk":   ct[:HeaderSize+2*sealed+10],
+		"header only":          ct[:HeaderSize],
+		"short header":         ct[:HeaderSize-1],
+		"swap chunks": append(append(append(append([]byte(nil), ct[:HeaderSize]...),
+			chunk(1)...), chunk(0)...), ct[HeaderSize+2*sealed:]...),
+	} {
+		if err := readAll(bad); err == nil {
+			t.Errorf("%s: decryption succeeded", name)
+		}
+	}
+
+	for i := 0; i < len(ct); i += 3 {
+		bad := append([]byte(nil), ct...)
+		bad[i] ^= 0x40
+
+		if err := readAll(bad); err == nil {
+			t.Fatalf("decryption succeeded with byte %d modified", i)
+		}
+	}
+
+	// Chunks cannot be moved between containers.
+	other := encrypt(t, plaintext, chunkSize, rand)
+	copy(other[HeaderSize:], chunk(0))
+
+	if err := readAll(other); err == nil {
+		t.Error("decryption succeeded with chunk from another container")
+	}
+
+	if err := readAll(ct); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadOnlyTouchedChunks(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	const chunkSize = 64
+	sealed := chunkSize + Overhead
+
+	plaintext := make([]byte, 4*chunkSize)
+	rand.Read(plaintext)
+
+	ct := encrypt(t, plaintext, chunkSize, rand)
+
+	// Corrupt chunk 1.
+	ct[HeaderSize+sealed+5] ^= 1
+
+	r, err := NewReader(bytes.NewReader(ct), int64(len(ct)), testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := make([]byte, chunkSize)
+	if _, err := r.ReadAt(p, 2*chunkSize); err != nil {
+		t.Errorf("reading an intact chunk failed: %v", err)
+	}
+
+	n, err := r.ReadAt(make([]byte, 2*chunkSize), 10)
+	if err == nil {
+		t.Fatal("reading a corrupt chunk succeeded")
+	}
+
+	if n != chunkSize-10 {
+		t.Errorf("expected %d bytes from the intact chunk, got %d", chunkSize-10, n)
+	}
+}
+
+func TestBadParameters(t *testing.T) {
+	var buf writerAtBuffer
+
+	if _, err := NewWriter(&buf, testKey[1:], 0, 0); err != ErrInvalidKey {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+
+	if _, err := NewWriter(&buf, testKey, 0, MaxChunkSize+1); err != ErrInvalidChunkSize {
+		t.Errorf("expected ErrInvalidChunkSize, got %v", err)
+	}
+
+	ct := encrypt(t, []byte("hello"), 16, rand.New(rand.NewSource(0)))
+
+	if _, err := NewReader(bytes.NewReader(ct), int64(len(ct)), testKey[1:]); err != ErrInvalidKey {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+
+	bad := append([]byte(nil), ct...)
+	bad[4] = Version + 1
+
+	if _, err := NewReader(bytes.NewReader(bad), int64(len(bad)), testKey); err != ErrUnsupportedVersion {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+
+	bad[4] = Version
+	bad[8] = 0xff
+
+	if _, err := NewReader(bytes.NewReader(bad), int64(len(bad)), testKey); err != ErrInvalidHeader {
+		t.Errorf("expected ErrInvalidHeader, got %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(ct), int64(len(ct)), testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.ReadAt(make([]byte, 1), -1); err != ErrInvalidOffset {
+		t.Errorf("expected ErrInvalidOffset, got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package etm implements an encrypt-then-MAC AEAD that pairs ChaCha20 or
+// XChaCha20 with HMAC-SHA-256 or keyed BLAKE2b, for use where Poly1305 is not
+// acceptable.
+//
+// Separate encryption and MAC keys are derived from the 256-bit key K with
+// HChaCha20:
+//
+//	Ke = HChaCha20(K, "chacha20 etm enc")
+//	Km = HChaCha20(K, "chacha20 etm mac")
+//
+// A message P with nonce N and associated data A is then sealed as
+//
+//	C = P XOR ChaCha20(Ke, N)
+//	T = MAC(Km, N || A || C || le64(len(A)) || le64(len(C)))
+//
+// where ChaCha20 is XChaCha20 for a 192-bit nonce and the RFC 8439 variant
+// for a 96-bit nonce. The output is C || T. HMAC-SHA-256 tags are truncated
+// to the tag size, while BLAKE2b is computed with the tag size as its digest
+// length.
+//
+// PASETO v4.local tokens are produced and opened by EncryptPASETOv4 and
+// DecryptPASETOv4. That construction derives its keys with BLAKE2b, from the
+// key K and the random 256-bit nonce N of each token, rather than with
+// HChaCha20:
+//
+//	Ek || N2 = BLAKE2b-448(key = K, "paseto-encryption-key" || N)
+//	Ak       = BLAKE2b-256(key = K, "paseto-auth-key-for-aead" || N)
+//
+// and authenticates the pre-authentication encoding of the header, nonce,
+// ciphertext, footer F and implicit assertion I:
+//
+//	C = P XOR XChaCha20(Ek, N2)
+//	T = BLAKE2b-256(key = Ak, PAE("v4.local.", N, C, F, I))
+//
+// The token is "v4.local." || base64url(N || C || T), followed by
+// "." || base64url(F) if F is not empty.
+package etm
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+
+	"github.com/tmthrgd/chacha20"
+	"github.com/tmthrgd/chacha20/internal/slice"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// KeySize is the length of keys, in bytes.
+	KeySize = chacha20.KeySize
+
+	// NonceSize is the length of the nonces taken by New, in bytes.
+	NonceSize = chacha20.XNonceSize
+
+	// NonceSizeRFC is the length of the nonces taken by NewRFC, in bytes.
+	NonceSizeRFC = chacha20.RFCNonceSize
+
+	// MinTagSize is the shortest tag that may be used, in bytes.
+	MinTagSize = 16
+
+	// MaxTagSize is the longest tag that may be used, in bytes.
+	MaxTagSize = 32
+)
+
+// Hash selects the MAC used to authenticate the ciphertext.
+type Hash int
+
+const (
+	// HMACSHA256 authenticates with HMAC-SHA-256.
+	HMACSHA256 Hash = iota
+
+	// BLAKE2b authenticates with keyed BLAKE2b.
+	BLAKE2b
+)
+
+// maxRFCPlaintext is the length of the keystream for a 96-bit nonce.
+const maxRFCPlaintext = 1 << 38
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	// ErrInvalidTagSize is returned when the tag size is less than MinTagSize
+	// or greater than MaxTagSize.
+	ErrInvalidTagSize = errors.New("invalid tag size")
+
+	errOpen = errors.New("etm: message authentication failed")
+)
+
+// The HChaCha20 nonces for the encryption and MAC keys.
+const (
+	encLabel = "chacha20 etm enc"
+	macLabel = "chacha20 etm mac"
+)
+
+type aead struct {
+	encKey [chacha20.HChaChaSize]byte
+	macKey [chacha20.HChaChaSize]byte
+
+	hash      Hash
+	tagSize   int
+	nonceSize int
+}
+
+// New returns an encrypt-then-MAC AEAD using XChaCha20, with a 192-bit nonce,
+// and the given MAC truncated to tagSize bytes. It panics if hash is not a
+// known Hash.
+func New(key []byte, hash Hash, tagSize int) (cipher.AEAD, error) {
+	return newAEAD(key, hash, tagSize, NonceSize)
+}
+
+// NewRFC returns an encrypt-then-MAC AEAD using the RFC 8439 variant of
+// ChaCha20, with a 96-bit nonce, and the given MAC truncated to tagSize bytes.
+// It panics if hash is not a known Hash.
+func NewRFC(key []byte, hash Hash, tagSize int) (cipher.AEAD, error) {
+	return newAEAD(key, hash, tagSize, NonceSizeRFC)
+}
+
+func newAEAD(key []byte, hash Hash, tagSize, nonceSize int) (cipher.AEAD, error) {
+	switch hash {
+	case HMACSHA256, BLAKE2b:
+	default:
+		panic("etm: invalid Hash")
+	}
+
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	if tagSize < MinTagSize || tagSize > MaxTagSize {
+		return nil, ErrInvalidTagSize
+	}
+
+	var k [chacha20.KeySize]byte
+	copy(k[:], key)
+
+	a := &aead{
+		hash:      hash,
+		tagSize:   tagSize,
+		nonceSize: nonceSize,
+	}
+
+	var label [chacha20.HNonceSize]byte
+	copy(label[:], encLabel)
+	chacha20.HChaCha20(&a.encKey, &k, &label)
+
+	copy(label[:], macLabel)
+	chacha20.HChaCha20(&a.macKey, &k, &label)
+
+	for i := range k {
+		k[i] = 0
+	}
+
+	return a, nil
+}
+
+func (a *aead) NonceSize() int {
+	return a.nonceSize
+}
+
+func (a *aead) Overhead() int {
+	return a.tagSize
+}
+
+func (a *aead) xorKeyStream(nonce, dst, src []byte) {
+	s, err := chacha20.New(a.encKey[:], nonce)
+	if err != nil {
+		panic(err)
+	}
+
+	s.XORKeyStream(dst, src)
+}
+
+func (a *aead) tag(nonce, ciphertext, additionalData []byte) []byte {
+	var m hash.Hash
+	if a.hash == BLAKE2b {
+		m, _ = blake2b.New(a.tagSize, a.macKey[:])
+	} else {
+		m = hmac.New(sha256.New, a.macKey[:])
+	}
+
+	m.Write(nonce)
+	m.Write(additionalData)
+	m.Write(ciphertext)
+
+	var lens [16]byte
+	binary.LittleEndian.PutUint64(lens[0:], uint64(len(additionalData)))
+	binary.LittleEndian.PutUint64(lens[8:], uint64(len(ciphertext)))
+	m.Write(lens[:])
+
+	return m.Sum(make([]byte, 0, MaxTagSize))[:a.tagSize]
+}
+
+func (a *aead) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != a.nonceSize {
+		panic("etm: incorrect nonce length given to Seal")
+	}
+
+	if a.nonceSize == NonceSizeRFC && uint64(len(plaintext)) > maxRFCPlaintext {
+		panic("etm: plaintext too large")
+	}
+
+	ret, out := slice.ForAppend(dst, len(plaintext)+a.tagSize)
+	a.xorKeyStream(nonce, out, plaintext)
+
+	copy(out[len(plaintext):], a.tag(nonce, out[:len(plaintext)], additionalData))
+	return ret
+}
+
+func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != a.nonceSize {
+		panic("etm: incorrect nonce length given to Open")
+	}
+
+	if len(ciphertext) < a.tagSize {
+		return nil, errOpen
+	}
+
+	tag := ciphertext[len(ciphertext)-a.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-a.tagSize]
+
+	if subtle.ConstantTimeCompare(tag, a.tag(nonce, ciphertext, additionalData)) != 1 {
+		return nil, errOpen
+	}
+
+	ret, out := slice.ForAppend(dst, len(ciphertext))
+	a.xorKeyStream(nonce, out, ciphertext)
+	return ret, nil
+}
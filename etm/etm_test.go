@@ -0,0 +1,224 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package etm
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+var (
+	testKey   = mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	sunscreen = []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	testAD    = mustHexDecode("50515253c0c1c2c3c4c5c6c7")
+)
+
+// generated with an independent Python implementation
+var testVectors = []struct {
+	new                  func(key []byte, hash Hash, tagSize int) (cipher.AEAD, error)
+	hash                 Hash
+	tagSize              int
+	nonce, plaintext, ad []byte
+	ciphertext           []byte
+}{
+	{
+		New, HMACSHA256, 32,
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		sunscreen, testAD,
+		mustHexDecode("9a74d4148b46cf8bdf0712115800be3d7b58e430fb14916a3a3e1e7aa8137062" +
+			"1b67ee6a88f07ede70e5a4f2457320fa0b77fcd7a35e5adf01ffe343f6d35b08" +
+			"7f607600984f7aa6a9493bc121c08750339e6c25a40065629b6ebe31588836c1" +
+			"9df942b2b64baf046f9815110369833ae9ec" +
+			"b36d988a6610bd90f27a54a44ba4d61074d5b6012ffb8675a0c1d85eb19887f9"),
+	},
+	{
+		New, BLAKE2b, 32,
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		sunscreen, testAD,
+		mustHexDecode("9a74d4148b46cf8bdf0712115800be3d7b58e430fb14916a3a3e1e7aa8137062" +
+			"1b67ee6a88f07ede70e5a4f2457320fa0b77fcd7a35e5adf01ffe343f6d35b08" +
+			"7f607600984f7aa6a9493bc121c08750339e6c25a40065629b6ebe31588836c1" +
+			"9df942b2b64baf046f9815110369833ae9ec" +
+			"4338318d835d05a4432433f86dd01d347bad3e9ff92667336652ac16535abf70"),
+	},
+	{
+		NewRFC, HMACSHA256, 16,
+		mustHexDecode("070000004041424344454647"),
+		sunscreen, testAD,
+		mustHexDecode("3add471d1204c330742dde81b5c41e9d9482325007c304fb2dc661f9e0ae930f" +
+			"4d967186238cc21bffe5b318ff328efa92a232ebe54e6f037da5d28fe2714c13" +
+			"552101fffecab7e2545029b43067ec05e6389d2060a70d140a560c5cfb1fcf32" +
+			"fb723161cc08597e1ccbba097109624219d5" +
+			"7dfc46d3ae5241c51cbb797fd99e8a0a"),
+	},
+	{
+		NewRFC, BLAKE2b, 24,
+		mustHexDecode("070000004041424344454647"),
+		nil, nil,
+		mustHexDecode("958d4c32469e6a9a26c68b4c47a01a5d879be77656a787b3"),
+	},
+	{
+		New, BLAKE2b, 16,
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		nil, testAD,
+		mustHexDecode("1bb8e66971808b0e2a078d7c819d45a8"),
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for i, v := range testVectors {
+		aead, err := v.new(testKey, v.hash, v.tagSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ct := aead.Seal(nil, v.nonce, v.plaintext, v.ad)
+		if !bytes.Equal(ct, v.ciphertext) {
+			t.Errorf("test vector %d: Seal: expected %x, got %x", i, v.ciphertext, ct)
+			continue
+		}
+
+		pt, err := aead.Open(nil, v.nonce, v.ciphertext, v.ad)
+		if err != nil {
+			t.Errorf("test vector %d: Open failed: %v", i, err)
+			continue
+		}
+
+		if !bytes.Equal(pt, v.plaintext) {
+			t.Errorf("test vector %d: Open: expected %x, got %x", i, v.plaintext, pt)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	for _, new := range []func(key []byte, hash Hash, tagSize int) (cipher.AEAD, error){New, NewRFC} {
+		for _, hash := range []Hash{HMACSHA256, BLAKE2b} {
+			for tagSize := MinTagSize; tagSize <= MaxTagSize; tagSize++ {
+				key := make([]byte, KeySize)
+				rand.Read(key)
+
+				aead, err := new(key, hash, tagSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if aead.Overhead() != tagSize {
+					t.Fatalf("expected Overhead of %d, got %d", tagSize, aead.Overhead())
+				}
+
+				nonce := make([]byte, aead.NonceSize())
+				rand.Read(nonce)
+
+				plaintext := make([]byte, rand.Intn(1024))
+				rand.Read(plaintext)
+
+				ad := make([]byte, rand.Intn(64))
+				rand.Read(ad)
+
+				ct := aead.Seal(nil, nonce, plaintext, ad)
+
+				pt, err := aead.Open(ct[:0], nonce, ct, ad)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(pt, plaintext) {
+					t.Fatal("Open returned wrong plaintext")
+				}
+			}
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	for _, hash := range []Hash{HMACSHA256, BLAKE2b} {
+		aead, err := New(testKey, hash, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nonce := make([]byte, NonceSize)
+		ct := aead.Seal(nil, nonce, []byte("attack at dawn"), testAD)
+
+		for i := 0; i < len(ct)*8; i++ {
+			bad := append([]byte(nil), ct...)
+			bad[i/8] ^= 1 << uint(i%8)
+
+			if _, err := aead.Open(nil, nonce, bad, testAD); err == nil {
+				t.Fatalf("Open accepted ciphertext with bit %d flipped", i)
+			}
+		}
+
+		if _, err := aead.Open(nil, nonce, ct, testAD[1:]); err == nil {
+			t.Error("Open accepted wrong additional data")
+		}
+
+		nonce[0] ^= 1
+		if _, err := aead.Open(nil, nonce, ct, testAD); err == nil {
+			t.Error("Open accepted wrong nonce")
+		}
+	}
+
+	// A tag of one size must not verify as a tag of another.
+	a16, _ := New(testKey, BLAKE2b, 16)
+	a17, _ := New(testKey, BLAKE2b, 17)
+
+	ct := a17.Seal(nil, make([]byte, NonceSize), nil, nil)
+	if _, err := a16.Open(nil, make([]byte, NonceSize), ct[:16], nil); err == nil {
+		t.Error("truncated BLAKE2b tag verified")
+	}
+}
+
+func TestBadSizes(t *testing.T) {
+	if _, err := New(testKey[1:], HMACSHA256, 16); err != ErrInvalidKey {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+
+	for _, tagSize := range []int{0, MinTagSize - 1, MaxTagSize + 1} {
+		if _, err := NewRFC(testKey, BLAKE2b, tagSize); err != ErrInvalidTagSize {
+			t.Errorf("tag size %d: expected ErrInvalidTagSize, got %v", tagSize, err)
+		}
+	}
+}
+
+func benchmarkSeal(b *testing.B, hash Hash) {
+	aead, err := New(make([]byte, KeySize), hash, 32)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	nonce := make([]byte, NonceSize)
+	buf := make([]byte, 16*1024+32)
+
+	b.SetBytes(16 * 1024)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		aead.Seal(buf[:0], nonce, buf[:16*1024], nil)
+	}
+}
+
+func BenchmarkSealHMACSHA256(b *testing.B) {
+	benchmarkSeal(b, HMACSHA256)
+}
+
+func BenchmarkSealBLAKE2b(b *testing.B) {
+	benchmarkSeal(b, BLAKE2b)
+}
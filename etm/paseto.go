@@ -0,0 +1,187 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package etm
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/tmthrgd/chacha20"
+	"golang.org/x/crypto/blake2b"
+)
+
+// PASETO v4.local, as specified at
+// https://github.com/paseto-standard/paseto-spec/blob/master/docs/01-Protocol-Versions/Version4.md.
+const (
+	pasetoHeader = "v4.local."
+
+	pasetoEncLabel = "paseto-encryption-key"
+	pasetoMACLabel = "paseto-auth-key-for-aead"
+
+	// PASETONonceSize is the length of the nonce of a PASETO v4.local token,
+	// in bytes.
+	PASETONonceSize = 32
+
+	pasetoTagSize = 32
+)
+
+var (
+	// ErrInvalidToken is returned by DecryptPASETOv4 when the token is not a
+	// well formed PASETO v4.local token.
+	ErrInvalidToken = errors.New("etm: invalid PASETO v4.local token")
+
+	pasetoB64 = base64.RawURLEncoding.Strict()
+)
+
+// EncryptPASETOv4 encrypts message as a PASETO v4.local token under the
+// 256-bit key, with a random nonce. footer is appended to the token in the
+// clear and implicit is authenticated without being included in the token.
+// Both are authenticated and may be empty.
+func EncryptPASETOv4(key, message, footer, implicit []byte) (string, error) {
+	if len(key) != KeySize {
+		return "", ErrInvalidKey
+	}
+
+	var nonce [PASETONonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+
+	return encryptPASETOv4(key, nonce[:], message, footer, implicit), nil
+}
+
+func encryptPASETOv4(key, nonce, message, footer, implicit []byte) string {
+	encKey, encNonce, macKey := pasetoKeys(key, nonce)
+
+	payload := make([]byte, PASETONonceSize+len(message)+pasetoTagSize)
+	copy(payload, nonce)
+
+	c := payload[PASETONonceSize : PASETONonceSize+len(message)]
+	pasetoXORKeyStream(encKey, encNonce, c, message)
+
+	copy(payload[PASETONonceSize+len(message):], pasetoTag(macKey, nonce, c, footer, implicit))
+
+	token := pasetoHeader + pasetoB64.EncodeToString(payload)
+	if len(footer) != 0 {
+		token += "." + pasetoB64.EncodeToString(footer)
+	}
+
+	return token
+}
+
+// DecryptPASETOv4 authenticates and decrypts a PASETO v4.local token under the
+// 256-bit key, returning the message and the footer. implicit must be the
+// implicit assertion the token was encrypted with.
+//
+// The footer is authenticated, but the caller must check that it is the one
+// expected.
+func DecryptPASETOv4(key []byte, token string, implicit []byte) (message, footer []byte, err error) {
+	if len(key) != KeySize {
+		return nil, nil, ErrInvalidKey
+	}
+
+	if !strings.HasPrefix(token, pasetoHeader) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(token[len(pasetoHeader):], ".")
+	if len(parts) > 2 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	payload, err := pasetoB64.DecodeString(parts[0])
+	if err != nil || len(payload) < PASETONonceSize+pasetoTagSize {
+		return nil, nil, ErrInvalidToken
+	}
+
+	if len(parts) == 2 {
+		if footer, err = pasetoB64.DecodeString(parts[1]); err != nil || len(footer) == 0 {
+			return nil, nil, ErrInvalidToken
+		}
+	}
+
+	nonce := payload[:PASETONonceSize]
+	c := payload[PASETONonceSize : len(payload)-pasetoTagSize]
+	tag := payload[len(payload)-pasetoTagSize:]
+
+	encKey, encNonce, macKey := pasetoKeys(key, nonce)
+
+	if subtle.ConstantTimeCompare(tag, pasetoTag(macKey, nonce, c, footer, implicit)) != 1 {
+		return nil, nil, errOpen
+	}
+
+	message = make([]byte, len(c))
+	pasetoXORKeyStream(encKey, encNonce, message, c)
+	return message, footer, nil
+}
+
+// pasetoKeys derives the encryption key and nonce, and the MAC key, for a
+// token with the given nonce.
+func pasetoKeys(key, nonce []byte) (encKey, encNonce, macKey []byte) {
+	m, err := blake2b.New(KeySize+chacha20.XNonceSize, key)
+	if err != nil {
+		panic(err)
+	}
+
+	m.Write([]byte(pasetoEncLabel))
+	m.Write(nonce)
+	tmp := m.Sum(nil)
+
+	m, err = blake2b.New(KeySize, key)
+	if err != nil {
+		panic(err)
+	}
+
+	m.Write([]byte(pasetoMACLabel))
+	m.Write(nonce)
+
+	return tmp[:KeySize], tmp[KeySize:], m.Sum(nil)
+}
+
+func pasetoXORKeyStream(key, nonce, dst, src []byte) {
+	s, err := chacha20.NewXChaCha(key, nonce)
+	if err != nil {
+		panic(err)
+	}
+
+	s.XORKeyStream(dst, src)
+}
+
+func pasetoTag(macKey, nonce, ciphertext, footer, implicit []byte) []byte {
+	m, err := blake2b.New(pasetoTagSize, macKey)
+	if err != nil {
+		panic(err)
+	}
+
+	m.Write(pae([]byte(pasetoHeader), nonce, ciphertext, footer, implicit))
+	return m.Sum(nil)
+}
+
+// pae is the pre-authentication encoding of PASETO. Each length is a 64-bit
+// little endian integer with the most significant bit cleared.
+func pae(pieces ...[]byte) []byte {
+	n := 8
+	for _, p := range pieces {
+		n += 8 + len(p)
+	}
+
+	out := make([]byte, 8, n)
+	binary.LittleEndian.PutUint64(out, uint64(len(pieces))&(1<<63-1))
+
+	for _, p := range pieces {
+		var l [8]byte
+		binary.LittleEndian.PutUint64(l[:], uint64(len(p))&(1<<63-1))
+
+		out = append(out, l[:]...)
+		out = append(out, p...)
+	}
+
+	return out
+}
@@ -0,0 +1,171 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package etm
+
+import (
+	"bytes"
+	"testing"
+)
+
+var pasetoTestKey = mustHexDecode("707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f")
+
+// The v4.local test vectors from
+// https://github.com/paseto-standard/test-vectors/blob/master/v4.json.
+var pasetoTestVectors = []struct {
+	name string
+	fail bool
+
+	nonce   []byte
+	token   string
+	payload []byte
+
+	footer, implicit []byte
+}{
+	{
+		"4-E-1", false,
+		mustHexDecode("0000000000000000000000000000000000000000000000000000000000000000"),
+		"v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg",
+		[]byte("{\"data\":\"this is a secret message\",\"exp\":\"2022-01-01T00:00:00+00:00\"}"),
+		[]byte(""), []byte(""),
+	},
+	{
+		"4-E-2", false,
+		mustHexDecode("0000000000000000000000000000000000000000000000000000000000000000"),
+		"v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvS2csCgglvpk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XIemu9chy3WVKvRBfg6t8wwYHK0ArLxxfZP73W_vfwt5A",
+		[]byte("{\"data\":\"this is a hidden message\",\"exp\":\"2022-01-01T00:00:00+00:00\"}"),
+		[]byte(""), []byte(""),
+	},
+	{
+		"4-E-3", false,
+		mustHexDecode("df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"),
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6-tyebyWG6Ov7kKvBdkrrAJ837lKP3iDag2hzUPHuMKA",
+		[]byte("{\"data\":\"this is a secret message\",\"exp\":\"2022-01-01T00:00:00+00:00\"}"),
+		[]byte(""), []byte(""),
+	},
+	{
+		"4-E-4", false,
+		mustHexDecode("df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"),
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4gt6TiLm55vIH8c_lGxxZpE3AWlH4WTR0v45nsWoU3gQ",
+		[]byte("{\"data\":\"this is a hidden message\",\"exp\":\"2022-01-01T00:00:00+00:00\"}"),
+		[]byte(""), []byte(""),
+	},
+	{
+		"4-E-5", false,
+		mustHexDecode("df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"),
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4x-RMNXtQNbz7FvFZ_G-lFpk5RG3EOrwDL6CgDqcerSQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		[]byte("{\"data\":\"this is a secret message\",\"exp\":\"2022-01-01T00:00:00+00:00\"}"),
+		[]byte("{\"kid\":\"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN\"}"), []byte(""),
+	},
+	{
+		"4-E-6", false,
+		mustHexDecode("df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"),
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6pWSA5HX2wjb3P-xLQg5K5feUCX4P2fpVK3ZLWFbMSxQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		[]byte("{\"data\":\"this is a hidden message\",\"exp\":\"2022-01-01T00:00:00+00:00\"}"),
+		[]byte("{\"kid\":\"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN\"}"), []byte(""),
+	},
+	{
+		"4-E-7", false,
+		mustHexDecode("df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"),
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t40KCCWLA7GYL9KFHzKlwY9_RnIfRrMQpueydLEAZGGcA.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		[]byte("{\"data\":\"this is a secret message\",\"exp\":\"2022-01-01T00:00:00+00:00\"}"),
+		[]byte("{\"kid\":\"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN\"}"), []byte("{\"test-vector\":\"4-E-7\"}"),
+	},
+	{
+		"4-E-8", false,
+		mustHexDecode("df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"),
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t5uvqQbMGlLLNYBc7A6_x7oqnpUK5WLvj24eE4DVPDZjw.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		[]byte("{\"data\":\"this is a hidden message\",\"exp\":\"2022-01-01T00:00:00+00:00\"}"),
+		[]byte("{\"kid\":\"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN\"}"), []byte("{\"test-vector\":\"4-E-8\"}"),
+	},
+	{
+		"4-E-9", false,
+		mustHexDecode("df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"),
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6tybdlmnMwcDMw0YxA_gFSE_IUWl78aMtOepFYSWYfQA.YXJiaXRyYXJ5LXN0cmluZy10aGF0LWlzbid0LWpzb24",
+		[]byte("{\"data\":\"this is a hidden message\",\"exp\":\"2022-01-01T00:00:00+00:00\"}"),
+		[]byte("arbitrary-string-that-isn't-json"), []byte("{\"test-vector\":\"4-E-9\"}"),
+	},
+	{
+		"4-F-3", true,
+		mustHexDecode("26f7553354482a1d91d4784627854b8da6b8042a7966523c2b404e8dbbe7f7f2"),
+		"v3.local.23e_2PiqpQBPvRFKzB0zHhjmxK3sKo2grFZRRLM-U7L0a8uHxuF9RlVz3Ic6WmdUUWTxCaYycwWV1yM8gKbZB2JhygDMKvHQ7eBf8GtF0r3K0Q_gF1PXOxcOgztak1eD1dPe9rLVMSgR0nHJXeIGYVuVrVoLWQ.YXJiaXRyYXJ5LXN0cmluZy10aGF0LWlzbid0LWpzb24",
+		nil,
+		[]byte("arbitrary-string-that-isn't-json"), []byte("{\"test-vector\":\"4-F-3\"}"),
+	},
+	{
+		"4-F-4", true,
+		mustHexDecode("df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"),
+		"v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQh",
+		nil,
+		[]byte(""), []byte(""),
+	},
+	{
+		"4-F-5", true,
+		mustHexDecode("df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"),
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4x-RMNXtQNbz7FvFZ_G-lFpk5RG3EOrwDL6CgDqcerSQ==.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		nil,
+		[]byte("{\"kid\":\"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN\"}"), []byte(""),
+	},
+}
+
+func TestPASETOv4Vectors(t *testing.T) {
+	for _, v := range pasetoTestVectors {
+		if !v.fail {
+			if token := encryptPASETOv4(pasetoTestKey, v.nonce, v.payload, v.footer, v.implicit); token != v.token {
+				t.Errorf("%s: encryptPASETOv4: expected %s, got %s", v.name, v.token, token)
+			}
+		}
+
+		payload, footer, err := DecryptPASETOv4(pasetoTestKey, v.token, v.implicit)
+		if v.fail {
+			if err == nil {
+				t.Errorf("%s: DecryptPASETOv4 succeeded for an invalid token", v.name)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: DecryptPASETOv4: %v", v.name, err)
+			continue
+		}
+
+		if !bytes.Equal(payload, v.payload) {
+			t.Errorf("%s: DecryptPASETOv4: expected %q, got %q", v.name, v.payload, payload)
+		}
+
+		if !bytes.Equal(footer, v.footer) {
+			t.Errorf("%s: DecryptPASETOv4: expected footer %q, got %q", v.name, v.footer, footer)
+		}
+	}
+}
+
+func TestPASETOv4RoundTrip(t *testing.T) {
+	message := []byte("hello, world")
+	footer := []byte("footer")
+	implicit := []byte("implicit")
+
+	token, err := EncryptPASETOv4(pasetoTestKey, message, footer, implicit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, gotFooter, err := DecryptPASETOv4(pasetoTestKey, token, implicit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, message) || !bytes.Equal(gotFooter, footer) {
+		t.Error("round trip failed")
+	}
+
+	if _, _, err := DecryptPASETOv4(pasetoTestKey, token, []byte("other")); err == nil {
+		t.Error("DecryptPASETOv4 succeeded with the wrong implicit assertion")
+	}
+
+	if _, err := EncryptPASETOv4(pasetoTestKey[:16], message, nil, nil); err != ErrInvalidKey {
+		t.Errorf("expected %v for a short key, got %v", ErrInvalidKey, err)
+	}
+}
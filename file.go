@@ -0,0 +1,235 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+	"os"
+)
+
+// maxRFCOffset is the length of the keystream for a 96-bit nonce, 2^32
+// blocks of 64 bytes.
+const maxRFCOffset = 1 << 38
+
+// ErrInvalidOffset is returned when reading or writing at a negative offset,
+// or beyond the 2^38 bytes of keystream available with a 96-bit nonce.
+var ErrInvalidOffset = errors.New("invalid offset")
+
+// keyStreamAt produces the keystream at arbitrary offsets. For XChaCha20 the
+// subkey is derived once up front, so each call only has to set up a stream
+// and seek it.
+type keyStreamAt struct {
+	key   [KeySize]byte
+	nonce []byte // either RFCNonceSize or DraftNonceSize bytes long
+}
+
+func newKeyStreamAt(key, nonce []byte) (*keyStreamAt, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	k := new(keyStreamAt)
+
+	switch len(nonce) {
+	case RFCNonceSize, DraftNonceSize:
+		copy(k.key[:], key)
+		k.nonce = append([]byte(nil), nonce...)
+	case XNonceSize:
+		var hKey [KeySize]byte
+		copy(hKey[:], key)
+
+		var hNonce [HNonceSize]byte
+		copy(hNonce[:], nonce[:HNonceSize])
+
+		hChaCha20(&k.key, &hKey, &hNonce)
+		k.nonce = append([]byte(nil), nonce[HNonceSize:]...)
+	default:
+		return nil, ErrInvalidNonce
+	}
+
+	return k, nil
+}
+
+// xorAt XORs src with the keystream starting off bytes in, writing the result
+// to dst.
+func (k *keyStreamAt) xorAt(dst, src []byte, off int64) error {
+	if off < 0 {
+		return ErrInvalidOffset
+	}
+
+	if len(k.nonce) == RFCNonceSize && uint64(off)+uint64(len(src)) > maxRFCOffset {
+		return ErrInvalidOffset
+	}
+
+	if len(src) == 0 {
+		return nil
+	}
+
+	var s cipher.Stream
+	if len(k.nonce) == RFCNonceSize {
+		s, _ = NewRFC(k.key[:], k.nonce)
+	} else {
+		s, _ = NewDraft(k.key[:], k.nonce)
+	}
+
+	SetCounter(s, uint64(off)/blockSize)
+
+	if skip := off % blockSize; skip != 0 {
+		var b [blockSize]byte
+		s.XORKeyStream(b[:skip], b[:skip])
+	}
+
+	s.XORKeyStream(dst, src)
+	return nil
+}
+
+type readerAt struct {
+	r io.ReaderAt
+	k *keyStreamAt
+}
+
+// NewReaderAt returns an io.ReaderAt that decrypts data read from r, where the
+// byte at offset n of r was encrypted with the byte at offset n of the
+// keystream for key and nonce. The key and nonce arguments are as for New.
+//
+// The block counter is computed from the offset of each read, so reads may be
+// made in any order and, as long as r permits it, concurrently.
+func NewReaderAt(r io.ReaderAt, key, nonce []byte) (io.ReaderAt, error) {
+	k, err := newKeyStreamAt(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readerAt{r, k}, nil
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (n int, err error) {
+	n, err = r.r.ReadAt(p, off)
+
+	if xerr := r.k.xorAt(p[:n], p[:n], off); xerr != nil {
+		return 0, xerr
+	}
+
+	return n, err
+}
+
+type writerAt struct {
+	w io.WriterAt
+	k *keyStreamAt
+}
+
+// NewWriterAt returns an io.WriterAt that encrypts data written to it before
+// writing it to w, such that the byte at offset n of w is encrypted with the
+// byte at offset n of the keystream for key and nonce. The key and nonce
+// arguments are as for New.
+//
+// The block counter is computed from the offset of each write, so writes may
+// be made in any order and, as long as w permits it, concurrently.
+//
+// Writing different data to an offset that has already been written reuses
+// keystream, and reveals the XOR of the old and new plaintexts to anyone who
+// sees both ciphertexts.
+func NewWriterAt(w io.WriterAt, key, nonce []byte) (io.WriterAt, error) {
+	k, err := newKeyStreamAt(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &writerAt{w, k}, nil
+}
+
+func (w *writerAt) WriteAt(p []byte, off int64) (n int, err error) {
+	buf := make([]byte, len(p))
+	if err := w.k.xorAt(buf, p, off); err != nil {
+		return 0, err
+	}
+
+	return w.w.WriteAt(buf, off)
+}
+
+// File is an io.ReadWriteSeeker, io.ReaderAt and io.WriterAt over an
+// *os.File whose contents are encrypted as with NewReaderAt and NewWriterAt.
+//
+// File keeps its own offset for Read, Write and Seek and leaves the offset
+// of the underlying *os.File alone. As with *os.File, Read, Write and Seek
+// must not be called concurrently, while ReadAt and WriteAt may be.
+type File struct {
+	f *os.File
+	k *keyStreamAt
+
+	off int64
+}
+
+// NewFile returns a File over f that encrypts and decrypts with key and
+// nonce. The key and nonce arguments are as for New. f must not have been
+// opened with os.O_APPEND.
+func NewFile(f *os.File, key, nonce []byte) (*File, error) {
+	k, err := newKeyStreamAt(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{f: f, k: k}, nil
+}
+
+// Read reads and decrypts up to len(p) bytes from the current offset.
+func (f *File) Read(p []byte) (n int, err error) {
+	n, err = f.ReadAt(p, f.off)
+	f.off += int64(n)
+
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+
+	return n, err
+}
+
+// Write encrypts and writes p at the current offset.
+func (f *File) Write(p []byte) (n int, err error) {
+	n, err = f.WriteAt(p, f.off)
+	f.off += int64(n)
+	return n, err
+}
+
+// Seek sets the offset for the next Read or Write as described by
+// io.Seeker.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		fi, err := f.f.Stat()
+		if err != nil {
+			return 0, err
+		}
+
+		offset += fi.Size()
+	default:
+		return 0, errors.New("chacha20: invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	f.off = offset
+	return offset, nil
+}
+
+// ReadAt reads and decrypts len(p) bytes starting at offset off.
+func (f *File) ReadAt(p []byte, off int64) (n int, err error) {
+	r := readerAt{f.f, f.k}
+	return r.ReadAt(p, off)
+}
+
+// WriteAt encrypts and writes p starting at offset off.
+func (f *File) WriteAt(p []byte, off int64) (n int, err error) {
+	w := writerAt{f.f, f.k}
+	return w.WriteAt(p, off)
+}
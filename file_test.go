@@ -0,0 +1,294 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+)
+
+// writerAtBuffer is an in-memory io.WriterAt.
+type writerAtBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *writerAtBuffer) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if end := int(off) + len(p); end > len(w.buf) {
+		w.buf = append(w.buf, make([]byte, end-len(w.buf))...)
+	}
+
+	return copy(w.buf[off:], p), nil
+}
+
+func testReaderAt(t *testing.T, nonceSize int) {
+	rand := rand.New(rand.NewSource(0))
+
+	key := make([]byte, KeySize)
+	nonce := make([]byte, nonceSize)
+	rand.Read(key)
+	rand.Read(nonce)
+
+	plaintext := make([]byte, 64*1024+37)
+	rand.Read(plaintext)
+
+	c, err := New(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext := make([]byte, len(plaintext))
+	c.XORKeyStream(ciphertext, plaintext)
+
+	r, err := NewReaderAt(bytes.NewReader(ciphertext), key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+
+	for i := 0; i < 8; i++ {
+		// Pick the reads up front, rand.Rand is not safe for concurrent
+		// use.
+		offs := make([]int, 100)
+		lens := make([]int, len(offs))
+		for j := range offs {
+			offs[j] = rand.Intn(len(plaintext))
+			lens[j] = rand.Intn(len(plaintext) - offs[j] + 1)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j, off := range offs {
+				p := make([]byte, lens[j])
+
+				n, err := r.ReadAt(p, int64(off))
+				if n != len(p) || err != nil && (err != io.EOF || off+n != len(plaintext)) {
+					errs <- err
+					return
+				}
+
+				if !bytes.Equal(p, plaintext[off:off+n]) {
+					errs <- errors.New("decrypted plaintext differs")
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("ReadAt failed: %v", err)
+	}
+}
+
+func TestRFCReaderAt(t *testing.T) {
+	testReaderAt(t, RFCNonceSize)
+}
+
+func TestDraftReaderAt(t *testing.T) {
+	testReaderAt(t, DraftNonceSize)
+}
+
+func TestXReaderAt(t *testing.T) {
+	testReaderAt(t, XNonceSize)
+}
+
+func testWriterAt(t *testing.T, nonceSize int) {
+	rand := rand.New(rand.NewSource(0))
+
+	key := make([]byte, KeySize)
+	nonce := make([]byte, nonceSize)
+	rand.Read(key)
+	rand.Read(nonce)
+
+	plaintext := make([]byte, 64*1024+37)
+	rand.Read(plaintext)
+
+	var buf writerAtBuffer
+
+	w, err := NewWriterAt(&buf, key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the plaintext in randomly sized pieces in a random order.
+	var offs []int
+	for off := 0; off < len(plaintext); {
+		offs = append(offs, off)
+		off += 1 + rand.Intn(300)
+	}
+	offs = append(offs, len(plaintext))
+
+	for _, i := range rand.Perm(len(offs) - 1) {
+		if _, err := w.WriteAt(plaintext[offs[i]:offs[i+1]], int64(offs[i])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := New(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext := make([]byte, len(plaintext))
+	c.XORKeyStream(ciphertext, plaintext)
+
+	if !bytes.Equal(buf.buf, ciphertext) {
+		t.Fatal("WriteAt produced different ciphertext to XORKeyStream")
+	}
+}
+
+func TestRFCWriterAt(t *testing.T) {
+	testWriterAt(t, RFCNonceSize)
+}
+
+func TestDraftWriterAt(t *testing.T) {
+	testWriterAt(t, DraftNonceSize)
+}
+
+func TestXWriterAt(t *testing.T) {
+	testWriterAt(t, XNonceSize)
+}
+
+func TestInvalidOffset(t *testing.T) {
+	for _, nonceSize := range []int{RFCNonceSize, DraftNonceSize} {
+		r, err := NewReaderAt(bytes.NewReader(nil), make([]byte, KeySize), make([]byte, nonceSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := r.ReadAt(make([]byte, 1), -1); err != ErrInvalidOffset {
+			t.Errorf("expected ErrInvalidOffset for negative offset, got %v", err)
+		}
+
+		w, err := NewWriterAt(new(writerAtBuffer), make([]byte, KeySize), make([]byte, nonceSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.WriteAt(make([]byte, 1), -1); err != ErrInvalidOffset {
+			t.Errorf("expected ErrInvalidOffset for negative offset, got %v", err)
+		}
+	}
+
+	k, err := newKeyStreamAt(make([]byte, KeySize), make([]byte, RFCNonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.xorAt(make([]byte, 64), make([]byte, 64), maxRFCOffset-64); err != nil {
+		t.Errorf("expected last block to be valid, got %v", err)
+	}
+
+	if err := k.xorAt(make([]byte, 2), make([]byte, 2), maxRFCOffset-1); err != ErrInvalidOffset {
+		t.Errorf("expected ErrInvalidOffset beyond 2^38 bytes, got %v", err)
+	}
+
+	k, err = newKeyStreamAt(make([]byte, KeySize), make([]byte, DraftNonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.xorAt(make([]byte, 2), make([]byte, 2), maxRFCOffset-1); err != nil {
+		t.Errorf("expected 64-bit counter to allow offsets beyond 2^38 bytes, got %v", err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	key := make([]byte, KeySize)
+	nonce := make([]byte, XNonceSize)
+	rand.Read(key)
+	rand.Read(nonce)
+
+	plaintext := make([]byte, 10*1024+5)
+	rand.Read(plaintext)
+
+	f, err := ioutil.TempFile("", "chacha20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	ef, err := NewFile(f, key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the second half first, then seek back and write the first.
+	half := len(plaintext) / 2
+
+	if _, err := ef.Seek(int64(half), io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ef.Write(plaintext[half:]); err != nil {
+		t.Fatal(err)
+	}
+
+	if off, err := ef.Seek(-int64(len(plaintext)), io.SeekEnd); err != nil || off != 0 {
+		t.Fatalf("Seek to start failed: offset %d, %v", off, err)
+	}
+
+	if _, err := ef.Write(plaintext[:half]); err != nil {
+		t.Fatal(err)
+	}
+
+	if off, err := ef.Seek(0, io.SeekCurrent); err != nil || off != int64(half) {
+		t.Fatalf("expected offset %d, got %d, %v", half, off, err)
+	}
+
+	c, err := New(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext := make([]byte, len(plaintext))
+	c.XORKeyStream(ciphertext, plaintext)
+
+	onDisk, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(onDisk, ciphertext) {
+		t.Fatal("file contents differ from XORKeyStream")
+	}
+
+	if _, err := ef.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadAll(ef)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, plaintext) {
+		t.Fatal("Read returned wrong plaintext")
+	}
+
+	if _, err := ef.Seek(-1, io.SeekStart); err != ErrInvalidOffset {
+		t.Errorf("expected ErrInvalidOffset, got %v", err)
+	}
+}
@@ -62,7 +62,7 @@ ___
 
 if ($flavour =~ /^golang/) {
     $code.=<<___;
-TEXT ·hchacha_20_x64(SB),\$0-24
+TEXT ·hchacha_20_x64(SB),\$0-25
 	movq	key+0(FP), DI
 	movq	nonce+8(FP), SI
 	movq	out+16(FP), DX
@@ -81,6 +81,20 @@ $code.=<<___;
 movq \$20, %rbx
 movq \$0x3320646e61707865, %rax
 movq \$0x6b20657479622d32, %r8
+___
+
+if ($flavour =~ /^golang/) {
+    # "expand 16-byte k" for 128-bit keys
+    $code.=<<___;
+	cmpb	key128+24(FP), \$0
+	je	hchacha_sse2_sigma
+	movq	\$0x3120646e61707865, AX
+	movq	\$0x6b20657479622d36, R8
+hchacha_sse2_sigma:
+___
+}
+
+$code.=<<___;
 movd %rax, %xmm0
 movd %r8, %xmm4
 punpcklqdq %xmm4, %xmm0
@@ -8,7 +8,7 @@
 
 #include "textflag.h"
 
-TEXT ·hchacha_20_x64(SB),$0-24
+TEXT ·hchacha_20_x64(SB),$0-25
 	MOVQ	key+0(FP),DI
 	MOVQ	nonce+8(FP),SI
 	MOVQ	out+16(FP),DX
@@ -16,6 +16,11 @@ TEXT ·hchacha_20_x64(SB),$0-24
 	MOVQ	$20,BX
 	MOVQ	$3684054920433006693,AX
 	MOVQ	$7719281312240119090,R8
+	CMPB	key128+24(FP),$0
+	JE	hchacha_sse2_sigma
+	MOVQ	$3539939732357150821,AX
+	MOVQ	$7719281312240119094,R8
+hchacha_sse2_sigma:
 	MOVD	AX,X0
 	MOVD	R8,X4
 	PUNPCKLQDQ	X4,X0
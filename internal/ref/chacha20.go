@@ -22,14 +22,16 @@ package ref
 import (
 	"crypto/cipher"
 	"encoding/binary"
-	"unsafe"
 
+	"github.com/tmthrgd/chacha20/internal/vec"
 	"github.com/tmthrgd/chacha20/internal/xor"
 )
 
 const (
 	// KeySize is the length of ChaCha20 keys, in bytes.
 	KeySize = 32
+	// KeySize128 is the length of 128-bit ChaCha20 keys, in bytes.
+	KeySize128 = 16
 	// RFCNonceSize is the length of ChaCha20-RFC nonces, in bytes.
 	RFCNonceSize = 12
 	// DraftNonceSize is the length of ChaCha20-draft nonces, in bytes.
@@ -55,10 +57,8 @@ func NewRFC(key []byte, nonce []byte) (cipher.Stream, error) {
 		panic("invalid nonce length")
 	}
 
-	s := new(stream)
+	s := &stream{rounds: 20}
 	s.init(key, nonce)
-	s.advance()
-
 	return s, nil
 }
 
@@ -75,10 +75,8 @@ func NewDraft(key []byte, nonce []byte) (cipher.Stream, error) {
 		panic("invalid nonce length")
 	}
 
-	s := new(stream)
+	s := &stream{rounds: 20}
 	s.init(key, nonce)
-	s.advance()
-
 	return s, nil
 }
 
@@ -95,7 +93,7 @@ func NewXChaCha(key, nonce []byte) (cipher.Stream, error) {
 		panic("invalid nonce length")
 	}
 
-	s := new(stream)
+	s := &stream{rounds: 20}
 
 	// Call HChaCha to derive the subkey using the key and the first 16 bytes
 	// of the nonce.
@@ -106,68 +104,293 @@ func NewXChaCha(key, nonce []byte) (cipher.Stream, error) {
 
 	// Re-initialize the state using the subkey and the remaining nonce.
 	s.init(subKey[:], nonce[HNonceSize:])
-	s.advance()
+	s.xchacha = true
 	return s, nil
 }
 
+// New128 creates and returns a new cipher.Stream that uses a 128-bit key. The
+// key argument must be 128 bits long, and the nonce argument must be either 64,
+// 96 or 192 bits long. For XChaCha20, HChaCha20 is keyed with the 128-bit key
+// and the resulting 256-bit subkey is used as normal.
+func New128(key, nonce []byte) (cipher.Stream, error) {
+	if len(key) != KeySize128 {
+		panic("invalid key length")
+	}
+
+	s := &stream{rounds: 20}
+
+	switch len(nonce) {
+	case RFCNonceSize, DraftNonceSize:
+		s.init(key, nonce)
+	case XNonceSize:
+		s.init(key, nonce[:HNonceSize])
+
+		var subKey [HChaChaSize]byte
+		s.hChaCha20(&subKey)
+
+		s.init(subKey[:], nonce[HNonceSize:])
+		s.xchacha = true
+	default:
+		panic("invalid nonce length")
+	}
+
+	return s, nil
+}
+
+// NewRounds creates and returns a new cipher.Stream for ChaCha reduced to the
+// given number of rounds, which must be 8, 12 or 20. The key argument must be
+// 256 bits long, and the nonce argument must be either 64, 96 or 192 bits long,
+// selecting the same variant as New. Unlike XSalsa20, the XChaCha subkey is
+// derived with the same reduced number of rounds of HChaCha.
+func NewRounds(key, nonce []byte, rounds int) (cipher.Stream, error) {
+	if len(key) != KeySize {
+		panic("invalid key length")
+	}
+
+	switch rounds {
+	case 8, 12, 20:
+	default:
+		panic("invalid number of rounds")
+	}
+
+	s := &stream{rounds: rounds}
+
+	switch len(nonce) {
+	case RFCNonceSize, DraftNonceSize:
+		s.init(key, nonce)
+	case XNonceSize:
+		s.init(key, nonce[:HNonceSize])
+
+		var subKey [HChaChaSize]byte
+		s.hChaCha20(&subKey)
+
+		s.init(subKey[:], nonce[HNonceSize:])
+		s.xchacha = true
+	default:
+		panic("invalid nonce length")
+	}
+
+	return s, nil
+}
+
+// HChaCha20 derives a 256-bit subkey from a 256-bit key and a 128-bit nonce,
+// writing it to out.
+func HChaCha20(out *[HChaChaSize]byte, key, nonce []byte) {
+	if len(key) != KeySize {
+		panic("invalid key length")
+	}
+
+	if len(nonce) != HNonceSize {
+		panic("invalid nonce length")
+	}
+
+	s := stream{rounds: 20}
+	s.init(key, nonce)
+	s.hChaCha20(out)
+}
+
 type stream struct {
-	state  [stateSize]uint32 // the state as an array of 16 32-bit words
-	block  [blockSize]byte   // the keystream as an array of 64 bytes
-	offset int               // the offset of used bytes in block
+	state   [stateSize]uint32 // the state as an array of 16 32-bit words
+	rfc     bool              // whether the counter is only word 12
+	xchacha bool              // whether the key is an HChaCha subkey
+	rounds  int               // the number of rounds, 20 unless reduced
+
+	block  [blockSize]byte // keystream left over from a partial block
+	buffer []byte          // the unused portion of block
 }
 
 func (s *stream) hChaCha20(out *[HChaChaSize]byte) {
-	core(&s.state, (*[stateSize]uint32)(unsafe.Pointer(&s.block)), 20, true)
-
-	copy(out[:16], s.block[:16])
-	copy(out[16:], s.block[48:])
+	var x [stateSize]uint32
+	hChaChaCore(&s.state, &x, s.rounds)
+
+	binary.LittleEndian.PutUint32(out[0:], x[0])
+	binary.LittleEndian.PutUint32(out[4:], x[1])
+	binary.LittleEndian.PutUint32(out[8:], x[2])
+	binary.LittleEndian.PutUint32(out[12:], x[3])
+	binary.LittleEndian.PutUint32(out[16:], x[12])
+	binary.LittleEndian.PutUint32(out[20:], x[13])
+	binary.LittleEndian.PutUint32(out[24:], x[14])
+	binary.LittleEndian.PutUint32(out[28:], x[15])
 }
 
 func (s *stream) XORKeyStream(dst, src []byte) {
-	// Stride over the input in 64-byte blocks, minus the amount of keystream
-	// previously used. This will produce best results when processing blocks
-	// of a size evenly divisible by 64.
-	i := 0
-	max := len(src)
-	for i < max {
-		gap := blockSize - s.offset
-
-		limit := i + gap
-		if limit > max {
-			limit = max
+	if len(src) == 0 {
+		return
+	}
+
+	if len(s.buffer) != 0 {
+		i := xor.Bytes(dst, s.buffer, src)
+
+		b := s.buffer[:i]
+		for j := range b {
+			b[j] = 0
 		}
 
-		j := xor.Bytes(dst[i:limit], src[i:limit], s.block[s.offset:])
+		s.buffer = s.buffer[i:]
+		src = src[i:]
+		dst = dst[i:]
 
-		b := s.block[s.offset : s.offset+j]
-		for o := range b {
-			b[o] = 0
+		if len(src) == 0 {
+			return
 		}
+	}
 
-		s.offset += j
-		i += gap
+	// Whole blocks are XORed directly with the keystream, four at a time
+	// where possible, without passing through the block buffer.
+	whole := len(src) &^ (blockSize - 1)
+	s.XORBlocks(dst[:whole], src[:whole])
 
-		if s.offset == blockSize {
-			s.advance()
+	src = src[whole:]
+	dst = dst[whole:]
+
+	if todo := len(src); todo != 0 {
+		// The block buffer is always zero here, so this leaves the
+		// keystream for the next block in it.
+		xorBlock(&s.state, s.block[:], s.block[:], s.rounds)
+
+		xor.Bytes(dst, s.block[:todo], src)
+
+		b := s.block[:todo]
+		for i := range b {
+			b[i] = 0
 		}
+
+		s.buffer = s.block[todo:]
 	}
 }
 
-func (s *stream) init(key []byte, nonce []byte) {
+// XORKeyStreamVec XORs the fragments of src with the keystream as a single
+// message, computing whole blocks directly even when they straddle fragments.
+func (s *stream) XORKeyStreamVec(dst, src [][]byte) {
+	vec.XORKeyStream(s, dst, src, blockSize)
+}
+
+// Buffered returns the number of bytes of keystream left over from a partial
+// block.
+func (s *stream) Buffered() int {
+	return len(s.buffer)
+}
+
+// XORBlocks XORs src, a whole number of blocks, with the keystream, four
+// blocks at a time where possible. There must be no buffered keystream.
+func (s *stream) XORBlocks(dst, src []byte) {
+	for len(src) >= 4*blockSize {
+		xorBlocks4(&s.state, dst, src, s.rounds)
+
+		src = src[4*blockSize:]
+		dst = dst[4*blockSize:]
+	}
+
+	for len(src) >= blockSize {
+		xorBlock(&s.state, dst, src, s.rounds)
+
+		src = src[blockSize:]
+		dst = dst[blockSize:]
+	}
+}
+
+// SetCounter sets the block counter, discarding any buffered keystream.
+func (s *stream) SetCounter(counter uint64) {
+	if s.rfc && counter > uint64(^uint32(0)) {
+		panic("chacha20: counter too large for a 96-bit nonce")
+	}
+
+	s.state[12] = uint32(counter)
+	if !s.rfc {
+		s.state[13] = uint32(counter >> 32)
+	}
+
+	b := s.buffer
+	for i := range b {
+		b[i] = 0
+	}
+
+	s.buffer = nil
+}
+
+// SetNonce replaces the nonce, which must be the same length as the nonce
+// the stream was created with, and resets the block counter to zero,
+// discarding any buffered keystream.
+func (s *stream) SetNonce(nonce []byte) {
+	if s.xchacha {
+		panic("chacha20: cannot replace the nonce of an XChaCha stream")
+	}
+
+	if s.rfc {
+		if len(nonce) != RFCNonceSize {
+			panic("chacha20: invalid nonce length")
+		}
+
+		s.state[13] = binary.LittleEndian.Uint32(nonce[0:])
+		s.state[14] = binary.LittleEndian.Uint32(nonce[4:])
+		s.state[15] = binary.LittleEndian.Uint32(nonce[8:])
+	} else {
+		if len(nonce) != DraftNonceSize {
+			panic("chacha20: invalid nonce length")
+		}
+
+		s.state[14] = binary.LittleEndian.Uint32(nonce[0:])
+		s.state[15] = binary.LittleEndian.Uint32(nonce[4:])
+	}
+
+	s.SetCounter(0)
+}
+
+// SetKey replaces the key with a 256-bit key and resets the block counter to
+// zero, discarding any buffered keystream.
+func (s *stream) SetKey(key []byte) {
+	if len(key) != KeySize {
+		panic("invalid key length")
+	}
+
 	// the magic constants for 256-bit keys
 	s.state[0] = 0x61707865
 	s.state[1] = 0x3320646e
 	s.state[2] = 0x79622d32
 	s.state[3] = 0x6b206574
 
+	for i := range s.state[4:12] {
+		s.state[4+i] = binary.LittleEndian.Uint32(key[i*4:])
+	}
+
+	s.SetCounter(0)
+}
+
+func (s *stream) init(key []byte, nonce []byte) {
 	s.state[4] = binary.LittleEndian.Uint32(key[0:])
 	s.state[5] = binary.LittleEndian.Uint32(key[4:])
 	s.state[6] = binary.LittleEndian.Uint32(key[8:])
 	s.state[7] = binary.LittleEndian.Uint32(key[12:])
-	s.state[8] = binary.LittleEndian.Uint32(key[16:])
-	s.state[9] = binary.LittleEndian.Uint32(key[20:])
-	s.state[10] = binary.LittleEndian.Uint32(key[24:])
-	s.state[11] = binary.LittleEndian.Uint32(key[28:])
+
+	switch len(key) {
+	case KeySize:
+		// the magic constants for 256-bit keys
+		s.state[0] = 0x61707865
+		s.state[1] = 0x3320646e
+		s.state[2] = 0x79622d32
+		s.state[3] = 0x6b206574
+
+		s.state[8] = binary.LittleEndian.Uint32(key[16:])
+		s.state[9] = binary.LittleEndian.Uint32(key[20:])
+		s.state[10] = binary.LittleEndian.Uint32(key[24:])
+		s.state[11] = binary.LittleEndian.Uint32(key[28:])
+	case KeySize128:
+		// the magic constants for 128-bit keys, the key is repeated
+		s.state[0] = 0x61707865
+		s.state[1] = 0x3120646e
+		s.state[2] = 0x79622d36
+		s.state[3] = 0x6b206574
+
+		s.state[8] = s.state[4]
+		s.state[9] = s.state[5]
+		s.state[10] = s.state[6]
+		s.state[11] = s.state[7]
+	default:
+		// Never happens, the ctors validate the key length.
+		panic("invalid key size")
+	}
+
+	s.rfc = len(nonce) == RFCNonceSize
 
 	switch len(nonce) {
 	case RFCNonceSize:
@@ -190,50 +413,13 @@ func (s *stream) init(key []byte, nonce []byte) {
 		s.state[14] = binary.LittleEndian.Uint32(nonce[8:])
 		s.state[15] = binary.LittleEndian.Uint32(nonce[12:])
 	default:
-		// Never happens, both ctors validate the nonce length.
+		// Never happens, the ctors validate the nonce length.
 		panic("invalid nonce size")
 	}
 }
 
-// BUG(codahale): Totally untested on big-endian CPUs. Would very much
-// appreciate someone with an ARM device giving this a swing.
-
-// advances the keystream
-func (s *stream) advance() {
-	core(&s.state, (*[stateSize]uint32)(unsafe.Pointer(&s.block)), 20, false)
-
-	if bigEndian {
-		j := blockSize - 1
-		for i := 0; i < blockSize/2; i++ {
-			s.block[j], s.block[i] = s.block[i], s.block[j]
-			j--
-		}
-	}
-
-	s.offset = 0
-	i := s.state[12] + 1
-	s.state[12] = i
-	if i == 0 {
-		s.state[13]++
-	}
-}
-
 const (
 	wordSize  = 4                    // the size of ChaCha20's words
 	stateSize = 16                   // the size of ChaCha20's state, in words
 	blockSize = stateSize * wordSize // the size of ChaCha20's block, in bytes
 )
-
-var (
-	bigEndian bool // whether or not we're running on a bigEndian CPU
-)
-
-// Do some up-front bookkeeping on what sort of CPU we're using. ChaCha20 treats
-// its state as a little-endian byte array when it comes to generating the
-// keystream, which allows for a zero-copy approach to the core transform. On
-// big-endian architectures, we have to take a hit to reverse the bytes.
-func init() {
-	x := uint32(0x04030201)
-	y := [4]byte{0x1, 0x2, 0x3, 0x4}
-	bigEndian = *(*[4]byte)(unsafe.Pointer(&x)) != y
-}
@@ -7,7 +7,29 @@
 
 package ref
 
-func core(input, output *[stateSize]uint32, rounds uint8, hchacha bool) {
+import "encoding/binary"
+
+// quarterRound is the ChaCha quarter round. It is small enough to be inlined.
+func quarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d ^= a
+	d = (d << 16) | (d >> 16)
+	c += d
+	b ^= c
+	b = (b << 12) | (b >> 20)
+	a += b
+	d ^= a
+	d = (d << 8) | (d >> 24)
+	c += d
+	b ^= c
+	b = (b << 7) | (b >> 25)
+	return a, b, c, d
+}
+
+// hChaChaCore computes the ChaCha permutation of input, writing the result to
+// output. Unlike the block function, input is not added back to the permuted
+// state, as HChaCha20 requires.
+func hChaChaCore(input, output *[stateSize]uint32, rounds int) {
 	var (
 		x00 = input[0]
 		x01 = input[1]
@@ -27,144 +49,253 @@ func core(input, output *[stateSize]uint32, rounds uint8, hchacha bool) {
 		x15 = input[15]
 	)
 
-	var x uint32
-
-	// Unrolling all 20 rounds kills performance on modern Intel processors
-	// (Tested on a i5 Haswell, likely applies to Sandy Bridge+), due to uop
-	// cache thrashing.  The straight forward 2 rounds per loop implementation
-	// of this has double the performance of the fully unrolled version.
-	for i := uint8(0); i < rounds; i += 2 {
-		x00 += x04
-		x = x12 ^ x00
-		x12 = (x << 16) | (x >> 16)
-		x08 += x12
-		x = x04 ^ x08
-		x04 = (x << 12) | (x >> 20)
-		x00 += x04
-		x = x12 ^ x00
-		x12 = (x << 8) | (x >> 24)
-		x08 += x12
-		x = x04 ^ x08
-		x04 = (x << 7) | (x >> 25)
-		x01 += x05
-		x = x13 ^ x01
-		x13 = (x << 16) | (x >> 16)
-		x09 += x13
-		x = x05 ^ x09
-		x05 = (x << 12) | (x >> 20)
-		x01 += x05
-		x = x13 ^ x01
-		x13 = (x << 8) | (x >> 24)
-		x09 += x13
-		x = x05 ^ x09
-		x05 = (x << 7) | (x >> 25)
-		x02 += x06
-		x = x14 ^ x02
-		x14 = (x << 16) | (x >> 16)
-		x10 += x14
-		x = x06 ^ x10
-		x06 = (x << 12) | (x >> 20)
-		x02 += x06
-		x = x14 ^ x02
-		x14 = (x << 8) | (x >> 24)
-		x10 += x14
-		x = x06 ^ x10
-		x06 = (x << 7) | (x >> 25)
-		x03 += x07
-		x = x15 ^ x03
-		x15 = (x << 16) | (x >> 16)
-		x11 += x15
-		x = x07 ^ x11
-		x07 = (x << 12) | (x >> 20)
-		x03 += x07
-		x = x15 ^ x03
-		x15 = (x << 8) | (x >> 24)
-		x11 += x15
-		x = x07 ^ x11
-		x07 = (x << 7) | (x >> 25)
-		x00 += x05
-		x = x15 ^ x00
-		x15 = (x << 16) | (x >> 16)
-		x10 += x15
-		x = x05 ^ x10
-		x05 = (x << 12) | (x >> 20)
-		x00 += x05
-		x = x15 ^ x00
-		x15 = (x << 8) | (x >> 24)
-		x10 += x15
-		x = x05 ^ x10
-		x05 = (x << 7) | (x >> 25)
-		x01 += x06
-		x = x12 ^ x01
-		x12 = (x << 16) | (x >> 16)
-		x11 += x12
-		x = x06 ^ x11
-		x06 = (x << 12) | (x >> 20)
-		x01 += x06
-		x = x12 ^ x01
-		x12 = (x << 8) | (x >> 24)
-		x11 += x12
-		x = x06 ^ x11
-		x06 = (x << 7) | (x >> 25)
-		x02 += x07
-		x = x13 ^ x02
-		x13 = (x << 16) | (x >> 16)
-		x08 += x13
-		x = x07 ^ x08
-		x07 = (x << 12) | (x >> 20)
-		x02 += x07
-		x = x13 ^ x02
-		x13 = (x << 8) | (x >> 24)
-		x08 += x13
-		x = x07 ^ x08
-		x07 = (x << 7) | (x >> 25)
-		x03 += x04
-		x = x14 ^ x03
-		x14 = (x << 16) | (x >> 16)
-		x09 += x14
-		x = x04 ^ x09
-		x04 = (x << 12) | (x >> 20)
-		x03 += x04
-		x = x14 ^ x03
-		x14 = (x << 8) | (x >> 24)
-		x09 += x14
-		x = x04 ^ x09
-		x04 = (x << 7) | (x >> 25)
+	for i := 0; i < rounds; i += 2 {
+		x00, x04, x08, x12 = quarterRound(x00, x04, x08, x12)
+		x01, x05, x09, x13 = quarterRound(x01, x05, x09, x13)
+		x02, x06, x10, x14 = quarterRound(x02, x06, x10, x14)
+		x03, x07, x11, x15 = quarterRound(x03, x07, x11, x15)
+
+		x00, x05, x10, x15 = quarterRound(x00, x05, x10, x15)
+		x01, x06, x11, x12 = quarterRound(x01, x06, x11, x12)
+		x02, x07, x08, x13 = quarterRound(x02, x07, x08, x13)
+		x03, x04, x09, x14 = quarterRound(x03, x04, x09, x14)
+	}
+
+	output[0] = x00
+	output[1] = x01
+	output[2] = x02
+	output[3] = x03
+	output[4] = x04
+	output[5] = x05
+	output[6] = x06
+	output[7] = x07
+	output[8] = x08
+	output[9] = x09
+	output[10] = x10
+	output[11] = x11
+	output[12] = x12
+	output[13] = x13
+	output[14] = x14
+	output[15] = x15
+}
+
+// xorBlock XORs one block of keystream with src, writing the result to dst,
+// and advances the block counter. Both dst and src must be at least blockSize
+// bytes long.
+func xorBlock(state *[stateSize]uint32, dst, src []byte, rounds int) {
+	_, _ = dst[blockSize-1], src[blockSize-1] // bounds check hint to compiler
+
+	var (
+		x00 = state[0]
+		x01 = state[1]
+		x02 = state[2]
+		x03 = state[3]
+		x04 = state[4]
+		x05 = state[5]
+		x06 = state[6]
+		x07 = state[7]
+		x08 = state[8]
+		x09 = state[9]
+		x10 = state[10]
+		x11 = state[11]
+		x12 = state[12]
+		x13 = state[13]
+		x14 = state[14]
+		x15 = state[15]
+	)
+
+	for i := 0; i < rounds; i += 2 {
+		x00, x04, x08, x12 = quarterRound(x00, x04, x08, x12)
+		x01, x05, x09, x13 = quarterRound(x01, x05, x09, x13)
+		x02, x06, x10, x14 = quarterRound(x02, x06, x10, x14)
+		x03, x07, x11, x15 = quarterRound(x03, x07, x11, x15)
+
+		x00, x05, x10, x15 = quarterRound(x00, x05, x10, x15)
+		x01, x06, x11, x12 = quarterRound(x01, x06, x11, x12)
+		x02, x07, x08, x13 = quarterRound(x02, x07, x08, x13)
+		x03, x04, x09, x14 = quarterRound(x03, x04, x09, x14)
+	}
+
+	binary.LittleEndian.PutUint32(dst[0:], binary.LittleEndian.Uint32(src[0:])^(x00+state[0]))
+	binary.LittleEndian.PutUint32(dst[4:], binary.LittleEndian.Uint32(src[4:])^(x01+state[1]))
+	binary.LittleEndian.PutUint32(dst[8:], binary.LittleEndian.Uint32(src[8:])^(x02+state[2]))
+	binary.LittleEndian.PutUint32(dst[12:], binary.LittleEndian.Uint32(src[12:])^(x03+state[3]))
+	binary.LittleEndian.PutUint32(dst[16:], binary.LittleEndian.Uint32(src[16:])^(x04+state[4]))
+	binary.LittleEndian.PutUint32(dst[20:], binary.LittleEndian.Uint32(src[20:])^(x05+state[5]))
+	binary.LittleEndian.PutUint32(dst[24:], binary.LittleEndian.Uint32(src[24:])^(x06+state[6]))
+	binary.LittleEndian.PutUint32(dst[28:], binary.LittleEndian.Uint32(src[28:])^(x07+state[7]))
+	binary.LittleEndian.PutUint32(dst[32:], binary.LittleEndian.Uint32(src[32:])^(x08+state[8]))
+	binary.LittleEndian.PutUint32(dst[36:], binary.LittleEndian.Uint32(src[36:])^(x09+state[9]))
+	binary.LittleEndian.PutUint32(dst[40:], binary.LittleEndian.Uint32(src[40:])^(x10+state[10]))
+	binary.LittleEndian.PutUint32(dst[44:], binary.LittleEndian.Uint32(src[44:])^(x11+state[11]))
+	binary.LittleEndian.PutUint32(dst[48:], binary.LittleEndian.Uint32(src[48:])^(x12+state[12]))
+	binary.LittleEndian.PutUint32(dst[52:], binary.LittleEndian.Uint32(src[52:])^(x13+state[13]))
+	binary.LittleEndian.PutUint32(dst[56:], binary.LittleEndian.Uint32(src[56:])^(x14+state[14]))
+	binary.LittleEndian.PutUint32(dst[60:], binary.LittleEndian.Uint32(src[60:])^(x15+state[15]))
+
+	// Words 12 and 13 are treated as a single 64-bit block counter.
+	state[12]++
+	if state[12] == 0 {
+		state[13]++
 	}
+}
+
+// xorBlocks4 XORs four consecutive blocks of keystream with src, writing the
+// result to dst, and advances the block counter by four. Both dst and src must
+// be at least 4*blockSize bytes long.
+//
+// The four blocks are computed together, with the x, y, z and w variables
+// holding the state of each. Every step of a round applies the quarter round
+// to all four, giving the CPU four independent chains of operations to
+// overlap.
+func xorBlocks4(state *[stateSize]uint32, dst, src []byte, rounds int) {
+	_, _ = dst[4*blockSize-1], src[4*blockSize-1] // bounds check hint to compiler
+
+	// Words 12 and 13 are treated as a single 64-bit block counter, so each
+	// block has its own copy of both.
+	var c12, c13 [4]uint32
+	for i := range c12 {
+		c12[i] = state[12] + uint32(i)
+		c13[i] = state[13]
+
+		if c12[i] < state[12] {
+			c13[i]++
+		}
+	}
+
+	var (
+		x00, y00, z00, w00 = state[0], state[0], state[0], state[0]
+		x01, y01, z01, w01 = state[1], state[1], state[1], state[1]
+		x02, y02, z02, w02 = state[2], state[2], state[2], state[2]
+		x03, y03, z03, w03 = state[3], state[3], state[3], state[3]
+		x04, y04, z04, w04 = state[4], state[4], state[4], state[4]
+		x05, y05, z05, w05 = state[5], state[5], state[5], state[5]
+		x06, y06, z06, w06 = state[6], state[6], state[6], state[6]
+		x07, y07, z07, w07 = state[7], state[7], state[7], state[7]
+		x08, y08, z08, w08 = state[8], state[8], state[8], state[8]
+		x09, y09, z09, w09 = state[9], state[9], state[9], state[9]
+		x10, y10, z10, w10 = state[10], state[10], state[10], state[10]
+		x11, y11, z11, w11 = state[11], state[11], state[11], state[11]
+		x12, y12, z12, w12 = c12[0], c12[1], c12[2], c12[3]
+		x13, y13, z13, w13 = c13[0], c13[1], c13[2], c13[3]
+		x14, y14, z14, w14 = state[14], state[14], state[14], state[14]
+		x15, y15, z15, w15 = state[15], state[15], state[15], state[15]
+	)
+
+	for i := 0; i < rounds; i += 2 {
+		x00, x04, x08, x12 = quarterRound(x00, x04, x08, x12)
+		y00, y04, y08, y12 = quarterRound(y00, y04, y08, y12)
+		z00, z04, z08, z12 = quarterRound(z00, z04, z08, z12)
+		w00, w04, w08, w12 = quarterRound(w00, w04, w08, w12)
+		x01, x05, x09, x13 = quarterRound(x01, x05, x09, x13)
+		y01, y05, y09, y13 = quarterRound(y01, y05, y09, y13)
+		z01, z05, z09, z13 = quarterRound(z01, z05, z09, z13)
+		w01, w05, w09, w13 = quarterRound(w01, w05, w09, w13)
+		x02, x06, x10, x14 = quarterRound(x02, x06, x10, x14)
+		y02, y06, y10, y14 = quarterRound(y02, y06, y10, y14)
+		z02, z06, z10, z14 = quarterRound(z02, z06, z10, z14)
+		w02, w06, w10, w14 = quarterRound(w02, w06, w10, w14)
+		x03, x07, x11, x15 = quarterRound(x03, x07, x11, x15)
+		y03, y07, y11, y15 = quarterRound(y03, y07, y11, y15)
+		z03, z07, z11, z15 = quarterRound(z03, z07, z11, z15)
+		w03, w07, w11, w15 = quarterRound(w03, w07, w11, w15)
+
+		x00, x05, x10, x15 = quarterRound(x00, x05, x10, x15)
+		y00, y05, y10, y15 = quarterRound(y00, y05, y10, y15)
+		z00, z05, z10, z15 = quarterRound(z00, z05, z10, z15)
+		w00, w05, w10, w15 = quarterRound(w00, w05, w10, w15)
+		x01, x06, x11, x12 = quarterRound(x01, x06, x11, x12)
+		y01, y06, y11, y12 = quarterRound(y01, y06, y11, y12)
+		z01, z06, z11, z12 = quarterRound(z01, z06, z11, z12)
+		w01, w06, w11, w12 = quarterRound(w01, w06, w11, w12)
+		x02, x07, x08, x13 = quarterRound(x02, x07, x08, x13)
+		y02, y07, y08, y13 = quarterRound(y02, y07, y08, y13)
+		z02, z07, z08, z13 = quarterRound(z02, z07, z08, z13)
+		w02, w07, w08, w13 = quarterRound(w02, w07, w08, w13)
+		x03, x04, x09, x14 = quarterRound(x03, x04, x09, x14)
+		y03, y04, y09, y14 = quarterRound(y03, y04, y09, y14)
+		z03, z04, z09, z14 = quarterRound(z03, z04, z09, z14)
+		w03, w04, w09, w14 = quarterRound(w03, w04, w09, w14)
+	}
+
+	out := dst[0*blockSize : 1*blockSize]
+	in := src[0*blockSize : 1*blockSize]
+	binary.LittleEndian.PutUint32(out[0:], binary.LittleEndian.Uint32(in[0:])^(x00+state[0]))
+	binary.LittleEndian.PutUint32(out[4:], binary.LittleEndian.Uint32(in[4:])^(x01+state[1]))
+	binary.LittleEndian.PutUint32(out[8:], binary.LittleEndian.Uint32(in[8:])^(x02+state[2]))
+	binary.LittleEndian.PutUint32(out[12:], binary.LittleEndian.Uint32(in[12:])^(x03+state[3]))
+	binary.LittleEndian.PutUint32(out[16:], binary.LittleEndian.Uint32(in[16:])^(x04+state[4]))
+	binary.LittleEndian.PutUint32(out[20:], binary.LittleEndian.Uint32(in[20:])^(x05+state[5]))
+	binary.LittleEndian.PutUint32(out[24:], binary.LittleEndian.Uint32(in[24:])^(x06+state[6]))
+	binary.LittleEndian.PutUint32(out[28:], binary.LittleEndian.Uint32(in[28:])^(x07+state[7]))
+	binary.LittleEndian.PutUint32(out[32:], binary.LittleEndian.Uint32(in[32:])^(x08+state[8]))
+	binary.LittleEndian.PutUint32(out[36:], binary.LittleEndian.Uint32(in[36:])^(x09+state[9]))
+	binary.LittleEndian.PutUint32(out[40:], binary.LittleEndian.Uint32(in[40:])^(x10+state[10]))
+	binary.LittleEndian.PutUint32(out[44:], binary.LittleEndian.Uint32(in[44:])^(x11+state[11]))
+	binary.LittleEndian.PutUint32(out[48:], binary.LittleEndian.Uint32(in[48:])^(x12+c12[0]))
+	binary.LittleEndian.PutUint32(out[52:], binary.LittleEndian.Uint32(in[52:])^(x13+c13[0]))
+	binary.LittleEndian.PutUint32(out[56:], binary.LittleEndian.Uint32(in[56:])^(x14+state[14]))
+	binary.LittleEndian.PutUint32(out[60:], binary.LittleEndian.Uint32(in[60:])^(x15+state[15]))
+
+	out = dst[1*blockSize : 2*blockSize]
+	in = src[1*blockSize : 2*blockSize]
+	binary.LittleEndian.PutUint32(out[0:], binary.LittleEndian.Uint32(in[0:])^(y00+state[0]))
+	binary.LittleEndian.PutUint32(out[4:], binary.LittleEndian.Uint32(in[4:])^(y01+state[1]))
+	binary.LittleEndian.PutUint32(out[8:], binary.LittleEndian.Uint32(in[8:])^(y02+state[2]))
+	binary.LittleEndian.PutUint32(out[12:], binary.LittleEndian.Uint32(in[12:])^(y03+state[3]))
+	binary.LittleEndian.PutUint32(out[16:], binary.LittleEndian.Uint32(in[16:])^(y04+state[4]))
+	binary.LittleEndian.PutUint32(out[20:], binary.LittleEndian.Uint32(in[20:])^(y05+state[5]))
+	binary.LittleEndian.PutUint32(out[24:], binary.LittleEndian.Uint32(in[24:])^(y06+state[6]))
+	binary.LittleEndian.PutUint32(out[28:], binary.LittleEndian.Uint32(in[28:])^(y07+state[7]))
+	binary.LittleEndian.PutUint32(out[32:], binary.LittleEndian.Uint32(in[32:])^(y08+state[8]))
+	binary.LittleEndian.PutUint32(out[36:], binary.LittleEndian.Uint32(in[36:])^(y09+state[9]))
+	binary.LittleEndian.PutUint32(out[40:], binary.LittleEndian.Uint32(in[40:])^(y10+state[10]))
+	binary.LittleEndian.PutUint32(out[44:], binary.LittleEndian.Uint32(in[44:])^(y11+state[11]))
+	binary.LittleEndian.PutUint32(out[48:], binary.LittleEndian.Uint32(in[48:])^(y12+c12[1]))
+	binary.LittleEndian.PutUint32(out[52:], binary.LittleEndian.Uint32(in[52:])^(y13+c13[1]))
+	binary.LittleEndian.PutUint32(out[56:], binary.LittleEndian.Uint32(in[56:])^(y14+state[14]))
+	binary.LittleEndian.PutUint32(out[60:], binary.LittleEndian.Uint32(in[60:])^(y15+state[15]))
+
+	out = dst[2*blockSize : 3*blockSize]
+	in = src[2*blockSize : 3*blockSize]
+	binary.LittleEndian.PutUint32(out[0:], binary.LittleEndian.Uint32(in[0:])^(z00+state[0]))
+	binary.LittleEndian.PutUint32(out[4:], binary.LittleEndian.Uint32(in[4:])^(z01+state[1]))
+	binary.LittleEndian.PutUint32(out[8:], binary.LittleEndian.Uint32(in[8:])^(z02+state[2]))
+	binary.LittleEndian.PutUint32(out[12:], binary.LittleEndian.Uint32(in[12:])^(z03+state[3]))
+	binary.LittleEndian.PutUint32(out[16:], binary.LittleEndian.Uint32(in[16:])^(z04+state[4]))
+	binary.LittleEndian.PutUint32(out[20:], binary.LittleEndian.Uint32(in[20:])^(z05+state[5]))
+	binary.LittleEndian.PutUint32(out[24:], binary.LittleEndian.Uint32(in[24:])^(z06+state[6]))
+	binary.LittleEndian.PutUint32(out[28:], binary.LittleEndian.Uint32(in[28:])^(z07+state[7]))
+	binary.LittleEndian.PutUint32(out[32:], binary.LittleEndian.Uint32(in[32:])^(z08+state[8]))
+	binary.LittleEndian.PutUint32(out[36:], binary.LittleEndian.Uint32(in[36:])^(z09+state[9]))
+	binary.LittleEndian.PutUint32(out[40:], binary.LittleEndian.Uint32(in[40:])^(z10+state[10]))
+	binary.LittleEndian.PutUint32(out[44:], binary.LittleEndian.Uint32(in[44:])^(z11+state[11]))
+	binary.LittleEndian.PutUint32(out[48:], binary.LittleEndian.Uint32(in[48:])^(z12+c12[2]))
+	binary.LittleEndian.PutUint32(out[52:], binary.LittleEndian.Uint32(in[52:])^(z13+c13[2]))
+	binary.LittleEndian.PutUint32(out[56:], binary.LittleEndian.Uint32(in[56:])^(z14+state[14]))
+	binary.LittleEndian.PutUint32(out[60:], binary.LittleEndian.Uint32(in[60:])^(z15+state[15]))
+
+	out = dst[3*blockSize : 4*blockSize]
+	in = src[3*blockSize : 4*blockSize]
+	binary.LittleEndian.PutUint32(out[0:], binary.LittleEndian.Uint32(in[0:])^(w00+state[0]))
+	binary.LittleEndian.PutUint32(out[4:], binary.LittleEndian.Uint32(in[4:])^(w01+state[1]))
+	binary.LittleEndian.PutUint32(out[8:], binary.LittleEndian.Uint32(in[8:])^(w02+state[2]))
+	binary.LittleEndian.PutUint32(out[12:], binary.LittleEndian.Uint32(in[12:])^(w03+state[3]))
+	binary.LittleEndian.PutUint32(out[16:], binary.LittleEndian.Uint32(in[16:])^(w04+state[4]))
+	binary.LittleEndian.PutUint32(out[20:], binary.LittleEndian.Uint32(in[20:])^(w05+state[5]))
+	binary.LittleEndian.PutUint32(out[24:], binary.LittleEndian.Uint32(in[24:])^(w06+state[6]))
+	binary.LittleEndian.PutUint32(out[28:], binary.LittleEndian.Uint32(in[28:])^(w07+state[7]))
+	binary.LittleEndian.PutUint32(out[32:], binary.LittleEndian.Uint32(in[32:])^(w08+state[8]))
+	binary.LittleEndian.PutUint32(out[36:], binary.LittleEndian.Uint32(in[36:])^(w09+state[9]))
+	binary.LittleEndian.PutUint32(out[40:], binary.LittleEndian.Uint32(in[40:])^(w10+state[10]))
+	binary.LittleEndian.PutUint32(out[44:], binary.LittleEndian.Uint32(in[44:])^(w11+state[11]))
+	binary.LittleEndian.PutUint32(out[48:], binary.LittleEndian.Uint32(in[48:])^(w12+c12[3]))
+	binary.LittleEndian.PutUint32(out[52:], binary.LittleEndian.Uint32(in[52:])^(w13+c13[3]))
+	binary.LittleEndian.PutUint32(out[56:], binary.LittleEndian.Uint32(in[56:])^(w14+state[14]))
+	binary.LittleEndian.PutUint32(out[60:], binary.LittleEndian.Uint32(in[60:])^(w15+state[15]))
 
-	if !hchacha {
-		output[0] = x00 + input[0]
-		output[1] = x01 + input[1]
-		output[2] = x02 + input[2]
-		output[3] = x03 + input[3]
-		output[4] = x04 + input[4]
-		output[5] = x05 + input[5]
-		output[6] = x06 + input[6]
-		output[7] = x07 + input[7]
-		output[8] = x08 + input[8]
-		output[9] = x09 + input[9]
-		output[10] = x10 + input[10]
-		output[11] = x11 + input[11]
-		output[12] = x12 + input[12]
-		output[13] = x13 + input[13]
-		output[14] = x14 + input[14]
-		output[15] = x15 + input[15]
-	} else {
-		output[0] = x00
-		output[1] = x01
-		output[2] = x02
-		output[3] = x03
-		output[4] = x04
-		output[5] = x05
-		output[6] = x06
-		output[7] = x07
-		output[8] = x08
-		output[9] = x09
-		output[10] = x10
-		output[11] = x11
-		output[12] = x12
-		output[13] = x13
-		output[14] = x14
-		output[15] = x15
+	state[12] += 4
+	if state[12] < 4 {
+		state[13]++
 	}
 }
@@ -0,0 +1,79 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package ref
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func newTestState(rand *rand.Rand) *[stateSize]uint32 {
+	var state [stateSize]uint32
+	for i := range state {
+		state[i] = rand.Uint32()
+	}
+
+	return &state
+}
+
+func TestXORBlocks4(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	src := make([]byte, 4*blockSize)
+	rand.Read(src)
+
+	for _, rounds := range []int{8, 12, 20} {
+		// The counter in word 12 carries into word 13 before, at and
+		// after each of the four blocks.
+		for _, counter := range []uint32{0, 1, ^uint32(0) - 4, ^uint32(0) - 3, ^uint32(0) - 2, ^uint32(0) - 1, ^uint32(0)} {
+			state := newTestState(rand)
+			state[12] = counter
+
+			want := *state
+			expect := make([]byte, 4*blockSize)
+
+			for i := 0; i < 4; i++ {
+				xorBlock(&want, expect[i*blockSize:], src[i*blockSize:], rounds)
+			}
+
+			got := make([]byte, 4*blockSize)
+			xorBlocks4(state, got, src, rounds)
+
+			if !bytes.Equal(got, expect) {
+				t.Errorf("rounds %d, counter %#x: keystream differs from four single blocks", rounds, counter)
+			}
+
+			if *state != want {
+				t.Errorf("rounds %d, counter %#x: state %x, expected %x", rounds, counter, state[12:14], want[12:14])
+			}
+		}
+	}
+}
+
+func BenchmarkXORBlock(b *testing.B) {
+	state := newTestState(rand.New(rand.NewSource(0)))
+	buf := make([]byte, 4*blockSize)
+
+	b.SetBytes(int64(len(buf)))
+
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 4; j++ {
+			xorBlock(state, buf[j*blockSize:], buf[j*blockSize:], 20)
+		}
+	}
+}
+
+func BenchmarkXORBlocks4(b *testing.B) {
+	state := newTestState(rand.New(rand.NewSource(0)))
+	buf := make([]byte, 4*blockSize)
+
+	b.SetBytes(int64(len(buf)))
+
+	for i := 0; i < b.N; i++ {
+		xorBlocks4(state, buf, buf, 20)
+	}
+}
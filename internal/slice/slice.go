@@ -0,0 +1,22 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package slice provides helpers for the append-style APIs of cipher.AEAD.
+package slice
+
+// ForAppend takes a slice and a requested number of bytes. It returns a slice
+// with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes.
+func ForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+
+	tail = head[len(in):]
+	return
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package vec implements XORKeyStreamVec for the streams of chacha20 and
+// internal/ref.
+package vec
+
+// MaxBlockSize is the largest block size XORKeyStream supports.
+const MaxBlockSize = 128
+
+// gatherSize is the size of the buffer small fragments are gathered into.
+const gatherSize = 4 * MaxBlockSize
+
+// Stream is a keystream that can be XORed a whole number of blocks at a time.
+type Stream interface {
+	// XORKeyStream is as for cipher.Stream. It keeps the keystream left
+	// over from a trailing partial block for the next call.
+	XORKeyStream(dst, src []byte)
+
+	// Buffered returns the number of bytes of keystream left over from a
+	// previous call to XORKeyStream.
+	Buffered() int
+
+	// XORBlocks XORs src with whole blocks of keystream, writing the
+	// result to dst. len(src) must be a multiple of the block size and
+	// Buffered must be zero.
+	XORBlocks(dst, src []byte)
+}
+
+// XORKeyStream XORs the fragments of src with the keystream of s, writing
+// the result to the corresponding fragments of dst, as though they were a
+// single message. Runs of whole blocks within a fragment are passed directly
+// to s.XORBlocks. Blocks that straddle fragments are gathered into a buffer
+// on the stack, along with any small fragments that follow them, XORed
+// together and scattered back. Only the keystream for a final partial block
+// is left buffered in s.
+//
+// dst and src must have the same number of fragments, each fragment of dst
+// must be at least as long as the corresponding fragment of src and
+// blockSize must be a power of two no larger than MaxBlockSize.
+func XORKeyStream(s Stream, dst, src [][]byte, blockSize int) {
+	var buf [gatherSize]byte
+
+	// off is the offset into the current fragment, src[i].
+	for i, off := 0, 0; i < len(src); {
+		in, out := src[i][off:], dst[i][off:len(src[i])]
+
+		if n := s.Buffered(); n != 0 {
+			if n > len(in) {
+				n = len(in)
+			}
+
+			s.XORKeyStream(out[:n], in[:n])
+			in, out = in[n:], out[n:]
+		}
+
+		if n := len(in) &^ (blockSize - 1); n != 0 {
+			s.XORBlocks(out[:n], in[:n])
+			in = in[n:]
+		}
+
+		if len(in) == 0 {
+			i, off = i+1, 0
+			continue
+		}
+
+		off = len(src[i]) - len(in)
+
+		n := gather(buf[:], src, i, off, blockSize)
+		if n < blockSize {
+			// Less than a block remains, so keep the rest of the
+			// keystream for the next call.
+			s.XORKeyStream(dst[i][off:], src[i][off:])
+
+			for k := i + 1; k < len(src); k++ {
+				s.XORKeyStream(dst[k], src[k])
+			}
+
+			return
+		}
+
+		n &^= blockSize - 1
+		s.XORBlocks(buf[:n], buf[:n])
+
+		i, off = scatter(dst, src, i, off, buf[:n])
+	}
+}
+
+// gather copies the fragments of src, starting from offset off of src[i],
+// into buf. Only enough of a fragment at least half the size of buf to
+// complete the first block is copied, as the rest is better XORed in place.
+func gather(buf []byte, src [][]byte, i, off, blockSize int) (n int) {
+	for i < len(src) && n < len(buf) {
+		p := src[i][off:]
+
+		end := len(buf)
+		if len(p) >= len(buf)/2 {
+			if n >= blockSize {
+				break
+			}
+
+			end = blockSize
+		}
+
+		m := copy(buf[n:end], p)
+		n += m
+
+		if off += m; off == len(src[i]) {
+			i, off = i+1, 0
+		}
+	}
+
+	return n
+}
+
+// scatter copies buf into the fragments of dst, starting from offset off of
+// dst[i], and returns the fragment and offset following it.
+func scatter(dst, src [][]byte, i, off int, buf []byte) (int, int) {
+	for len(buf) != 0 {
+		m := copy(dst[i][off:len(src[i])], buf)
+		buf = buf[m:]
+
+		if off += m; off == len(src[i]) {
+			i, off = i+1, 0
+		}
+	}
+
+	return i, off
+}
@@ -0,0 +1,15 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build amd64,!gccgo,!appengine
+
+#include "textflag.h"
+
+// func hasAVX2() bool
+// returns whether AVX2 is supported
+TEXT ·hasAVX2(SB),NOSPLIT,$0
+	MOVB runtime·support_avx2(SB), CX
+	MOVB CX, ret+0(FP)
+	RET
@@ -0,0 +1,34 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build amd64,!gccgo,!appengine
+
+package xor
+
+var useAVX2 = hasAVX2()
+
+// xorBytes xors len(dst) bytes of a and b into dst. All three slices must be
+// the same length and non-empty.
+func xorBytes(dst, a, b []byte) {
+	_, _ = a[len(dst)-1], b[len(dst)-1] // the assembly reads len(dst) bytes
+
+	if useAVX2 {
+		xorBytesAVX2(&dst[0], &a[0], &b[0], len(dst))
+	} else {
+		xorBytesSSE2(&dst[0], &a[0], &b[0], len(dst))
+	}
+}
+
+// This function is implemented in avx_amd64.s
+//go:noescape
+func hasAVX2() bool
+
+// This function is implemented in xor_amd64.s
+//go:noescape
+func xorBytesSSE2(dst, a, b *byte, n int)
+
+// This function is implemented in xor_amd64.s
+//go:noescape
+func xorBytesAVX2(dst, a, b *byte, n int)
@@ -0,0 +1,133 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build amd64,!gccgo,!appengine
+
+#include "textflag.h"
+
+// XOR_TAIL xors the remaining CX (< 64) bytes at SI and DX into DI, 16, 8 and
+// then 1 byte at a time.
+#define XOR_TAIL \
+tail16: \
+	CMPQ CX, $16; \
+	JB tail8; \
+	MOVOU 0(SI), X0; \
+	MOVOU 0(DX), X1; \
+	PXOR X1, X0; \
+	MOVOU X0, 0(DI); \
+	ADDQ $16, SI; \
+	ADDQ $16, DX; \
+	ADDQ $16, DI; \
+	SUBQ $16, CX; \
+	JMP tail16; \
+tail8: \
+	CMPQ CX, $8; \
+	JB tail1; \
+	MOVQ 0(SI), AX; \
+	XORQ 0(DX), AX; \
+	MOVQ AX, 0(DI); \
+	ADDQ $8, SI; \
+	ADDQ $8, DX; \
+	ADDQ $8, DI; \
+	SUBQ $8, CX; \
+tail1: \
+	TESTQ CX, CX; \
+	JZ done; \
+	MOVB 0(SI), AX; \
+	XORB 0(DX), AX; \
+	MOVB AX, 0(DI); \
+	INCQ SI; \
+	INCQ DX; \
+	INCQ DI; \
+	DECQ CX; \
+	JMP tail1; \
+done:
+
+// func xorBytesSSE2(dst, a, b *byte, n int)
+TEXT ·xorBytesSSE2(SB),NOSPLIT,$0-32
+	MOVQ dst+0(FP), DI
+	MOVQ a+8(FP), SI
+	MOVQ b+16(FP), DX
+	MOVQ n+24(FP), CX
+
+loop64:
+	CMPQ CX, $64
+	JB tail16
+
+	MOVOU 0(SI), X0
+	MOVOU 16(SI), X1
+	MOVOU 32(SI), X2
+	MOVOU 48(SI), X3
+	MOVOU 0(DX), X4
+	MOVOU 16(DX), X5
+	MOVOU 32(DX), X6
+	MOVOU 48(DX), X7
+	PXOR X4, X0
+	PXOR X5, X1
+	PXOR X6, X2
+	PXOR X7, X3
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+
+	ADDQ $64, SI
+	ADDQ $64, DX
+	ADDQ $64, DI
+	SUBQ $64, CX
+	JMP loop64
+
+	XOR_TAIL
+	RET
+
+// func xorBytesAVX2(dst, a, b *byte, n int)
+TEXT ·xorBytesAVX2(SB),NOSPLIT,$0-32
+	MOVQ dst+0(FP), DI
+	MOVQ a+8(FP), SI
+	MOVQ b+16(FP), DX
+	MOVQ n+24(FP), CX
+
+loop128:
+	CMPQ CX, $128
+	JB loop32
+
+	VMOVDQU 0(SI), Y0
+	VMOVDQU 32(SI), Y1
+	VMOVDQU 64(SI), Y2
+	VMOVDQU 96(SI), Y3
+	VPXOR 0(DX), Y0, Y0
+	VPXOR 32(DX), Y1, Y1
+	VPXOR 64(DX), Y2, Y2
+	VPXOR 96(DX), Y3, Y3
+	VMOVDQU Y0, 0(DI)
+	VMOVDQU Y1, 32(DI)
+	VMOVDQU Y2, 64(DI)
+	VMOVDQU Y3, 96(DI)
+
+	ADDQ $128, SI
+	ADDQ $128, DX
+	ADDQ $128, DI
+	SUBQ $128, CX
+	JMP loop128
+
+loop32:
+	CMPQ CX, $32
+	JB avx2done
+
+	VMOVDQU 0(SI), Y0
+	VPXOR 0(DX), Y0, Y0
+	VMOVDQU Y0, 0(DI)
+
+	ADDQ $32, SI
+	ADDQ $32, DX
+	ADDQ $32, DI
+	SUBQ $32, CX
+	JMP loop32
+
+avx2done:
+	VZEROUPPER
+
+	XOR_TAIL
+	RET
@@ -0,0 +1,28 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build amd64,!gccgo,!appengine
+
+package xor
+
+import "testing"
+
+func TestXORSSE2(t *testing.T) {
+	oldAVX2 := useAVX2
+	useAVX2 = false
+	defer func() {
+		useAVX2 = oldAVX2
+	}()
+
+	testXOR(t, Bytes)
+}
+
+func TestXORAVX2(t *testing.T) {
+	if !useAVX2 {
+		t.Skip("skipping: do not have AVX2 implementation")
+	}
+
+	testXOR(t, Bytes)
+}
@@ -0,0 +1,47 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !amd64 gccgo appengine
+
+package xor
+
+import (
+	"encoding/binary"
+	"runtime"
+)
+
+const supportsUnaligned = runtime.GOARCH == "386" ||
+	runtime.GOARCH == "amd64" ||
+	runtime.GOARCH == "arm64" ||
+	runtime.GOARCH == "ppc64" ||
+	runtime.GOARCH == "ppc64le" ||
+	runtime.GOARCH == "s390x"
+
+// xorBytes xors len(dst) bytes of a and b into dst. All three slices must be
+// the same length.
+func xorBytes(dst, a, b []byte) {
+	if supportsUnaligned {
+		fastXORBytes(dst, a, b)
+	} else {
+		safeXORBytes(dst, a, b)
+	}
+}
+
+// fastXORBytes xors in bulk. It is only fast on architectures where the
+// compiler turns encoding/binary loads and stores into unaligned word
+// accesses.
+func fastXORBytes(dst, a, b []byte) {
+	n := len(dst)
+	a, b = a[:n], b[:n]
+
+	i := 0
+	for ; n-i >= 8; i += 8 {
+		v := binary.LittleEndian.Uint64(a[i:]) ^ binary.LittleEndian.Uint64(b[i:])
+		binary.LittleEndian.PutUint64(dst[i:], v)
+	}
+
+	for ; i < n; i++ {
+		dst[i] = a[i] ^ b[i]
+	}
+}
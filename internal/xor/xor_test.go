@@ -6,23 +6,100 @@ package xor
 
 import (
 	"bytes"
+	"math/rand"
 	"testing"
 )
 
-func TestXOR(t *testing.T) {
-	for alignP := 0; alignP < 2; alignP++ {
-		for alignQ := 0; alignQ < 2; alignQ++ {
-			for alignD := 0; alignD < 2; alignD++ {
-				p := make([]byte, 1024)[alignP:]
-				q := make([]byte, 1024)[alignQ:]
-				d1 := make([]byte, 1024+alignD)[alignD:]
-				d2 := make([]byte, 1024+alignD)[alignD:]
-				Bytes(d1, p, q)
-				safeXORBytes(d2, p, q)
-				if bytes.Compare(d1, d2) != 0 {
-					t.Error("not equal")
+var testLengths = []int{
+	0, 1, 2, 3, 7, 8, 9, 15, 16, 17, 31, 32, 33, 63, 64, 65,
+	95, 96, 97, 127, 128, 129, 191, 192, 193, 255, 256, 257,
+	1023, 1024, 1025, 4096 + 13,
+}
+
+const maxAlign = 33
+
+func testXOR(t *testing.T, xor func(dst, a, b []byte) int) {
+	rand := rand.New(rand.NewSource(0))
+
+	for _, n := range testLengths {
+		p := make([]byte, n+maxAlign)
+		q := make([]byte, n+maxAlign)
+		rand.Read(p)
+		rand.Read(q)
+
+		for alignP := 0; alignP < maxAlign; alignP++ {
+			for alignQ := 0; alignQ < maxAlign; alignQ += 3 {
+				for alignD := 0; alignD < maxAlign; alignD += 5 {
+					// Guard bytes either side of the destination
+					// catch writes past len(a) or len(b).
+					d1 := make([]byte, n+maxAlign+2)
+					d2 := make([]byte, n+maxAlign+2)
+					for i := range d1 {
+						d1[i] = 0xa5
+						d2[i] = 0xa5
+					}
+
+					a, b := p[alignP:alignP+n], q[alignQ:]
+
+					if i := xor(d1[1+alignD:], a, b); i != n {
+						t.Fatalf("n=%d: returned %d", n, i)
+					}
+
+					safeXORBytes(d2[1+alignD:], a, b)
+
+					if !bytes.Equal(d1, d2) {
+						t.Fatalf("n=%d alignP=%d alignQ=%d alignD=%d: not equal",
+							n, alignP, alignQ, alignD)
+					}
 				}
 			}
 		}
 	}
 }
+
+func TestXOR(t *testing.T) {
+	testXOR(t, Bytes)
+}
+
+func TestXORInPlace(t *testing.T) {
+	for _, n := range testLengths {
+		p := make([]byte, n)
+		q := make([]byte, n)
+		rand.Read(p)
+		rand.Read(q)
+
+		d := make([]byte, n)
+		safeXORBytes(d, p, q)
+
+		Bytes(p, p, q)
+
+		if !bytes.Equal(p, d) {
+			t.Fatalf("n=%d: not equal", n)
+		}
+	}
+}
+
+func benchmarkXOR(b *testing.B, n int) {
+	dst := make([]byte, n)
+	p := make([]byte, n)
+	q := make([]byte, n)
+
+	b.SetBytes(int64(n))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Bytes(dst, p, q)
+	}
+}
+
+func BenchmarkXOR16(b *testing.B) {
+	benchmarkXOR(b, 16)
+}
+
+func BenchmarkXOR128(b *testing.B) {
+	benchmarkXOR(b, 128)
+}
+
+func BenchmarkXOR8K(b *testing.B) {
+	benchmarkXOR(b, 8*1024)
+}
@@ -0,0 +1,180 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package noise implements the CipherState and SymmetricState objects of the
+// Noise Protocol Framework, with the ChaChaPoly cipher functions, on top of
+// github.com/tmthrgd/chacha20.
+//
+// ChaChaPoly is the ChaCha20-Poly1305 AEAD of RFC 7539 with a nonce of four
+// zero bytes followed by a 64-bit little-endian counter. The HandshakeState,
+// with its Diffie-Hellman functions and handshake patterns, is left to the
+// caller.
+package noise
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/tmthrgd/chacha20"
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+)
+
+const (
+	// KeySize is the length of cipher keys, in bytes.
+	KeySize = chacha20.KeySize
+
+	// Overhead is the number of bytes added to each message by a
+	// CipherState with a key.
+	Overhead = chacha20poly1305.Overhead
+
+	// MaxNonce is the nonce, 2^64-1, that is reserved for Rekey. A
+	// CipherState refuses to encrypt or decrypt with it.
+	MaxNonce = math.MaxUint64
+)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	// ErrNonceExhausted is returned by EncryptWithAd and DecryptWithAd once
+	// every nonce below MaxNonce has been used.
+	ErrNonceExhausted = errors.New("noise: nonce exhausted")
+
+	errOpen = errors.New("noise: message authentication failed")
+)
+
+// CipherState is the Noise CipherState object, a key k and a nonce n. The zero
+// value is a CipherState without a key. It is not safe for concurrent use.
+type CipherState struct {
+	aead cipher.AEAD // nil if there is no key
+	n    uint64
+}
+
+// NewCipherState returns a CipherState with the given key and a nonce of zero.
+func NewCipherState(key []byte) (*CipherState, error) {
+	c := new(CipherState)
+	if err := c.InitializeKey(key); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// InitializeKey sets the key to key and the nonce to zero. An empty key
+// leaves the CipherState without a key.
+func (c *CipherState) InitializeKey(key []byte) error {
+	switch len(key) {
+	case 0:
+		c.aead = nil
+	case KeySize:
+		aead, err := chacha20poly1305.New(key)
+		if err != nil {
+			return err
+		}
+
+		c.aead = aead
+	default:
+		return ErrInvalidKey
+	}
+
+	c.n = 0
+	return nil
+}
+
+// HasKey reports whether the CipherState has a key.
+func (c *CipherState) HasKey() bool {
+	return c.aead != nil
+}
+
+// SetNonce sets the nonce to n. It is used by protocols that deliver
+// transport messages out of order and carry the nonce explicitly.
+func (c *CipherState) SetNonce(n uint64) {
+	c.n = n
+}
+
+// Nonce returns the nonce that will be used for the next message.
+func (c *CipherState) Nonce() uint64 {
+	return c.n
+}
+
+// makeNonce returns the ChaChaPoly nonce for n.
+func makeNonce(n uint64) (nonce [chacha20poly1305.NonceSize]byte) {
+	binary.LittleEndian.PutUint64(nonce[4:], n)
+	return
+}
+
+// EncryptWithAd encrypts and authenticates plaintext, authenticates ad,
+// appends the result to dst and returns the updated slice. The nonce is
+// incremented. If the CipherState has no key, plaintext is appended as is.
+//
+// To reuse plaintext's storage for the encrypted output, use plaintext[:0]
+// as dst. Otherwise, the remaining capacity of dst must not overlap
+// plaintext.
+func (c *CipherState) EncryptWithAd(dst, ad, plaintext []byte) ([]byte, error) {
+	if c.aead == nil {
+		return append(dst, plaintext...), nil
+	}
+
+	if c.n == MaxNonce {
+		return nil, ErrNonceExhausted
+	}
+
+	nonce := makeNonce(c.n)
+	c.n++
+	return c.aead.Seal(dst, nonce[:], plaintext, ad), nil
+}
+
+// DecryptWithAd authenticates ciphertext and ad, decrypts ciphertext,
+// appends the result to dst and returns the updated slice. The nonce is only
+// incremented if the message is authentic. If the CipherState has no key,
+// ciphertext is appended as is.
+//
+// To reuse ciphertext's storage for the decrypted output, use ciphertext[:0]
+// as dst. Otherwise, the remaining capacity of dst must not overlap
+// ciphertext.
+func (c *CipherState) DecryptWithAd(dst, ad, ciphertext []byte) ([]byte, error) {
+	if c.aead == nil {
+		return append(dst, ciphertext...), nil
+	}
+
+	if c.n == MaxNonce {
+		return nil, ErrNonceExhausted
+	}
+
+	nonce := makeNonce(c.n)
+
+	out, err := c.aead.Open(dst, nonce[:], ciphertext, ad)
+	if err != nil {
+		return nil, errOpen
+	}
+
+	c.n++
+	return out, nil
+}
+
+// Rekey replaces the key with the first 32 bytes of the encryption of 32
+// zero bytes, with no additional data, under MaxNonce. The nonce is left
+// unchanged. Rekey does nothing if the CipherState has no key.
+func (c *CipherState) Rekey() {
+	if c.aead == nil {
+		return
+	}
+
+	var zeros [KeySize]byte
+	nonce := makeNonce(MaxNonce)
+	key := c.aead.Seal(zeros[:0], nonce[:], zeros[:], nil)
+
+	n := c.n
+	if err := c.InitializeKey(key[:KeySize]); err != nil {
+		panic(err)
+	}
+	c.n = n
+
+	for i := range key {
+		key[i] = 0
+	}
+}
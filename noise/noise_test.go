@@ -0,0 +1,525 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package noise
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"strings"
+	"testing"
+
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// The keys and inputs shared by the test vectors below.
+var (
+	testInitStatic    = mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	testRespStatic    = mustHexDecode("0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+	testInitEphemeral = mustHexDecode("202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f")
+	testRespEphemeral = mustHexDecode("4142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f60")
+	testPrologue      = mustHexDecode("6e6f74736563726574")
+	testPSK           = mustHexDecode("2176657279736563726574766572797365637265747665727973656372657421")
+)
+
+// testPatterns are the handshake patterns used by the test vectors. The first
+// message is sent by the initiator. For pre, the responder's static key is
+// known to the initiator in advance.
+var testPatterns = map[string]struct {
+	pre      bool
+	messages []string
+}{
+	"NN":     {false, []string{"e", "e ee"}},
+	"NK":     {true, []string{"e es", "e ee"}},
+	"XX":     {false, []string{"e", "e ee s es", "s se"}},
+	"IK":     {true, []string{"e es s ss", "e ee se"}},
+	"NNpsk0": {false, []string{"psk e", "e ee"}},
+	"XXpsk3": {false, []string{"e", "e ee s es", "s se psk"}},
+}
+
+var testHashes = map[string]func() hash.Hash{
+	"SHA256":  sha256.New,
+	"SHA512":  sha512.New,
+	"BLAKE2s": BLAKE2s,
+	"BLAKE2b": BLAKE2b,
+}
+
+// These test vectors are from vectors.txt of github.com/flynn/noise v1.1.0,
+// which uses the same format as the cacophony and snow test vectors. The
+// messages after the handshake are transport messages, alternately sent by
+// the initiator and the responder.
+var testVectors = []struct {
+	protocol string
+	messages []struct{ payload, ciphertext string }
+}{
+	{
+		protocol: "Noise_NN_25519_ChaChaPoly_SHA256",
+		messages: []struct{ payload, ciphertext string }{
+			{"746573745f6d73675f30", "358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd166254746573745f6d73675f30"},
+			{"746573745f6d73675f31", "64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d48466bb598b7e636e9475d9a74243a419c31324b40cc77cc7a7ea3b24"},
+			{"79656c6c6f777375626d6172696e65", "96cd46be111804586a935795eeb4ce62bdec121048a10520b00266b22722eb"},
+			{"7375626d6172696e6579656c6c6f77", "fe2bc534e31964c0bd56337223e921565e39dbc5f156aa04766ced4689a2a2"},
+		},
+	},
+	{
+		protocol: "Noise_NK_25519_ChaChaPoly_BLAKE2s",
+		messages: []struct{ payload, ciphertext string }{
+			{"746573745f6d73675f30", "358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd166254bc7e9bcabcd39b9278b37f9892f7dec16e155389121da24e1fad"},
+			{"746573745f6d73675f31", "64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d48466060fcddff00afaa37fd11c440d18031d7f9a735d2dd1ea6bfe24"},
+			{"79656c6c6f777375626d6172696e65", "56a475d3db0d0d5931542a93e3cd57c7dc51b29fc6d0a7cea41aea05d99fe5"},
+			{"7375626d6172696e6579656c6c6f77", "5c239eb65b5f0d0641f6c6c20aec65646626249f9194e4211a2f8e761c2d72"},
+		},
+	},
+	{
+		protocol: "Noise_XX_25519_ChaChaPoly_BLAKE2s",
+		messages: []struct{ payload, ciphertext string }{
+			{"746573745f6d73675f30", "358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd166254746573745f6d73675f30"},
+			{"746573745f6d73675f31", "64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d48466c7f9c130891d2fcc2454ad9808ce708c7fde0ef21e72e985c38a6ed8cdaadcd9e07ed4c7d77e83b721e41d9bb2a8b57761f5532ce998f718c56f18083ab9e2f47c3f7f545a5eabbc4ece"},
+			{"746573745f6d73675f32", "e42e3908de4cd096b8b86320dfe9d03127451fdbfc423fd9ef86b4659fae03c897f77a2af21f5ce18cde8740fe9e5912f6cfb3372d0d7f9f5da0d9be88017bb339b951c56929f77fe9d6"},
+			{"79656c6c6f777375626d6172696e65", "7086fc0466ee7523680d09ff7c272e2a2817a6e2d6c4ec1c209506506e8957"},
+			{"7375626d6172696e6579656c6c6f77", "e3beadf28ea871a3be666f43eaf457d030e538eb371ba48076a7db36a9a1bf"},
+		},
+	},
+	{
+		protocol: "Noise_XX_25519_ChaChaPoly_SHA512",
+		messages: []struct{ payload, ciphertext string }{
+			{"746573745f6d73675f30", "358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd166254746573745f6d73675f30"},
+			{"746573745f6d73675f31", "64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d4846692e5b8dda95b4ec55e42c2cbded11735474b3612a895298bcb02e8469353fe82b4cd9a14f8ead39d89dfbc1caa392541d221c75462cbc2798cc052f73a84342b5476620ae41849b8965c"},
+			{"746573745f6d73675f32", "ac3087e2342498dfa6606faf700dc5782b9612bdbc8bbb67a87181baac2d693d79ea79b6110288f4e89aae84921c40605a36853cf1f1ced5ddda854ea5ce29deb956bd1c54de796b357f"},
+			{"79656c6c6f777375626d6172696e65", "2dcb8503b438910b2a2ffcf242ef705e6cce2d25bd30444402427981ee2064"},
+			{"7375626d6172696e6579656c6c6f77", "56d2ce5c1e7e28b7406b99aff512114313b811e17c0af6497baa906165ba31"},
+		},
+	},
+	{
+		protocol: "Noise_IK_25519_ChaChaPoly_BLAKE2b",
+		messages: []struct{ payload, ciphertext string }{
+			{"746573745f6d73675f30", "358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd16625471316e70ec2670fe80a4529101864a5dac3d5f9c0924e8d38cecd60c54adbaa2f602a28ed62afc1421fb6217fa8bb34e6e5ed547305fd8e63d0c7272edad8555d9482a258f9fcd94b9b2"},
+			{"746573745f6d73675f31", "64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d48466b0981ce42d3aee24e4004d6ea9acd8a847242a19f3f0f4cb0976"},
+			{"79656c6c6f777375626d6172696e65", "dc52cf04c64e4b750c00444789e41cb1abe496381a2d1b42303b231e809437"},
+			{"7375626d6172696e6579656c6c6f77", "4e39fa2317aba599efd3f7a7ca1de12dfae13bc630cc8768ce6326894fb250"},
+		},
+	},
+	{
+		protocol: "Noise_NNpsk0_25519_ChaChaPoly_SHA256",
+		messages: []struct{ payload, ciphertext string }{
+			{"746573745f6d73675f30", "358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd1662547c78f22f8cea986f934ab17c2484a24a990a6473d588a4f20e99"},
+			{"746573745f6d73675f31", "64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d484666913ea64c74c2f63ee5e32a5358320d459322d624c9ccc975fa0"},
+			{"79656c6c6f777375626d6172696e65", "b349a522c145762c7c737ac1d1425ce1fb25c7cca626177ee4ceed3cd6fb3d"},
+			{"7375626d6172696e6579656c6c6f77", "b41e24399dc3f1ad2faf82868700e4bf31bb89f6616e1d6a92802bb8ad80d6"},
+		},
+	},
+	{
+		protocol: "Noise_XXpsk3_25519_ChaChaPoly_BLAKE2s",
+		messages: []struct{ payload, ciphertext string }{
+			{"746573745f6d73675f30", "358072d6365880d1aeea329adf9121383851ed21a28e3b75e965d0d2cd1662544fd0dcd87f6b5d78feddd20bcb8ab9ed16ac202410f730729c74"},
+			{"746573745f6d73675f31", "64b101b1d0be5a8704bd078f9895001fc03e8e9f9522f188dd128d9846d48466d6a3135623749084e7af54bdb3cbefc74483b5a11791e66803483ca71b7a1cb9415f46d643edb50ac242a475f8c3b60dfe7df0a60a03a8c732ae2747ed5de74ce5ba4eca1461b96283f4"},
+			{"746573745f6d73675f32", "27f05826a4958e7232360fc6f2d5742baa781214efa55d1adfbbe6526577bfeec049bd84112dd940b7032911a753f227c454a891d33df307ce814db7b657f732eb230e8da83fea82aa08"},
+			{"79656c6c6f777375626d6172696e65", "6964e5f2c89c4cc61086163641d1b0af9ecfb4c3596726e00ad65361db462e"},
+			{"7375626d6172696e6579656c6c6f77", "cf239ff75b592d7dcf14cb9d91cc682b9f216c8b98871a9e53461f0cd027de"},
+		},
+	},
+}
+
+func x25519(scalar, point []byte) []byte {
+	out, err := curve25519.X25519(scalar, point)
+	if err != nil {
+		panic(err)
+	}
+
+	return out
+}
+
+// testParty is a minimal HandshakeState for the test vectors' patterns.
+type testParty struct {
+	ss *SymmetricState
+
+	initiator bool
+	psk       bool
+
+	s, e   []byte // private keys
+	rs, re []byte // public keys
+}
+
+func (p *testParty) token(tok string) {
+	switch tok {
+	case "ee":
+		p.ss.MixKey(x25519(p.e, p.re))
+	case "es":
+		if p.initiator {
+			p.ss.MixKey(x25519(p.e, p.rs))
+		} else {
+			p.ss.MixKey(x25519(p.s, p.re))
+		}
+	case "se":
+		if p.initiator {
+			p.ss.MixKey(x25519(p.s, p.re))
+		} else {
+			p.ss.MixKey(x25519(p.e, p.rs))
+		}
+	case "ss":
+		p.ss.MixKey(x25519(p.s, p.rs))
+	case "psk":
+		p.ss.MixKeyAndHash(testPSK)
+	default:
+		panic("unknown token " + tok)
+	}
+}
+
+func (p *testParty) writeMessage(pattern string, payload []byte) []byte {
+	var msg []byte
+
+	for _, tok := range strings.Fields(pattern) {
+		switch tok {
+		case "e":
+			pub := x25519(p.e, curve25519.Basepoint)
+			msg = append(msg, pub...)
+			p.ss.MixHash(pub)
+
+			if p.psk {
+				p.ss.MixKey(pub)
+			}
+		case "s":
+			var err error
+			if msg, err = p.ss.EncryptAndHash(msg, x25519(p.s, curve25519.Basepoint)); err != nil {
+				panic(err)
+			}
+		default:
+			p.token(tok)
+		}
+	}
+
+	msg, err := p.ss.EncryptAndHash(msg, payload)
+	if err != nil {
+		panic(err)
+	}
+
+	return msg
+}
+
+func (p *testParty) readMessage(pattern string, msg []byte) ([]byte, error) {
+	for _, tok := range strings.Fields(pattern) {
+		switch tok {
+		case "e":
+			p.re, msg = msg[:curve25519.PointSize], msg[curve25519.PointSize:]
+			p.ss.MixHash(p.re)
+
+			if p.psk {
+				p.ss.MixKey(p.re)
+			}
+		case "s":
+			n := curve25519.PointSize
+			if p.ss.HasKey() {
+				n += Overhead
+			}
+
+			var err error
+			if p.rs, err = p.ss.DecryptAndHash(nil, msg[:n]); err != nil {
+				return nil, err
+			}
+
+			msg = msg[n:]
+		default:
+			p.token(tok)
+		}
+	}
+
+	return p.ss.DecryptAndHash(nil, msg)
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range testVectors {
+		parts := strings.Split(v.protocol, "_")
+		pattern := testPatterns[parts[1]]
+
+		init := &testParty{
+			ss:        NewSymmetricState(testHashes[parts[4]], v.protocol),
+			initiator: true,
+			psk:       strings.Contains(parts[1], "psk"),
+			s:         testInitStatic,
+			e:         testInitEphemeral,
+		}
+		resp := &testParty{
+			ss:  NewSymmetricState(testHashes[parts[4]], v.protocol),
+			psk: init.psk,
+			s:   testRespStatic,
+			e:   testRespEphemeral,
+		}
+
+		init.ss.MixHash(testPrologue)
+		resp.ss.MixHash(testPrologue)
+
+		if pattern.pre {
+			init.rs = x25519(testRespStatic, curve25519.Basepoint)
+			init.ss.MixHash(init.rs)
+			resp.ss.MixHash(init.rs)
+		}
+
+		var c1Init, c2Init, c1Resp, c2Resp *CipherState
+
+		for i, m := range v.messages {
+			payload, expected := mustHexDecode(m.payload), mustHexDecode(m.ciphertext)
+
+			var msg, got []byte
+			var err error
+
+			if i < len(pattern.messages) {
+				writer, reader := init, resp
+				if i%2 != 0 {
+					writer, reader = resp, init
+				}
+
+				msg = writer.writeMessage(pattern.messages[i], payload)
+				got, err = reader.readMessage(pattern.messages[i], msg)
+
+				if i == len(pattern.messages)-1 {
+					if !bytes.Equal(init.ss.HandshakeHash(), resp.ss.HandshakeHash()) {
+						t.Errorf("%s: handshake hashes differ", v.protocol)
+					}
+
+					c1Init, c2Init = init.ss.Split()
+					c1Resp, c2Resp = resp.ss.Split()
+				}
+			} else {
+				enc, dec := c1Init, c1Resp
+				if (i-len(pattern.messages))%2 != 0 {
+					enc, dec = c2Resp, c2Init
+				}
+
+				if msg, err = enc.EncryptWithAd(nil, nil, payload); err != nil {
+					t.Fatal(err)
+				}
+
+				got, err = dec.DecryptWithAd(nil, nil, msg)
+			}
+
+			if !bytes.Equal(msg, expected) {
+				t.Errorf("%s: message %d: invalid ciphertext, expected %x, got %x", v.protocol, i, expected, msg)
+			}
+
+			if err != nil {
+				t.Errorf("%s: message %d: decryption failed: %v", v.protocol, i, err)
+			} else if !bytes.Equal(got, payload) {
+				t.Errorf("%s: message %d: invalid payload, expected %x, got %x", v.protocol, i, payload, got)
+			}
+		}
+	}
+}
+
+var testKey = mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+
+func TestCipherStateNonce(t *testing.T) {
+	c, err := NewCipherState(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := chacha20poly1305.New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ad, plaintext := []byte("additional data"), []byte("plaintext")
+
+	for _, n := range []uint64{0, 1, 2, 0x0102030405060708, MaxNonce - 1} {
+		c.SetNonce(n)
+
+		ct, err := c.EncryptWithAd(nil, ad, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nonce := []byte{0, 0, 0, 0, byte(n), byte(n >> 8), byte(n >> 16), byte(n >> 24),
+			byte(n >> 32), byte(n >> 40), byte(n >> 48), byte(n >> 56)}
+		if expected := ref.Seal(nil, nonce, plaintext, ad); !bytes.Equal(ct, expected) {
+			t.Errorf("nonce %#x: invalid ciphertext, expected %x, got %x", n, expected, ct)
+		}
+
+		if c.Nonce() != n+1 {
+			t.Errorf("nonce %#x: nonce was not incremented", n)
+		}
+	}
+}
+
+func TestNonceExhausted(t *testing.T) {
+	c, err := NewCipherState(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetNonce(MaxNonce - 1)
+
+	ct, err := c.EncryptWithAd(nil, nil, []byte("last"))
+	if err != nil {
+		t.Fatalf("EncryptWithAd failed for MaxNonce-1: %v", err)
+	}
+
+	if _, err := c.EncryptWithAd(nil, nil, []byte("too many")); err != ErrNonceExhausted {
+		t.Error("EncryptWithAd used MaxNonce")
+	}
+
+	if _, err := c.DecryptWithAd(nil, nil, ct); err != ErrNonceExhausted {
+		t.Error("DecryptWithAd used MaxNonce")
+	}
+
+	c.SetNonce(MaxNonce - 1)
+
+	if _, err := c.DecryptWithAd(nil, nil, ct); err != nil {
+		t.Errorf("DecryptWithAd failed for MaxNonce-1: %v", err)
+	}
+}
+
+func TestDecryptFailure(t *testing.T) {
+	enc, err := NewCipherState(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := NewCipherState(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ad := []byte("additional data")
+
+	ct, err := enc.EncryptWithAd(nil, ad, []byte("plaintext"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range ct {
+		tampered := append([]byte(nil), ct...)
+		tampered[i] ^= 0x01
+
+		if _, err := dec.DecryptWithAd(nil, ad, tampered); err == nil {
+			t.Errorf("DecryptWithAd succeeded with byte %d modified", i)
+		}
+	}
+
+	if _, err := dec.DecryptWithAd(nil, ad[1:], ct); err == nil {
+		t.Error("DecryptWithAd succeeded with modified additional data")
+	}
+
+	if dec.Nonce() != 0 {
+		t.Error("failed DecryptWithAd incremented the nonce")
+	}
+
+	pt, err := dec.DecryptWithAd(ct[:0], ad, ct)
+	if err != nil || string(pt) != "plaintext" {
+		t.Errorf("DecryptWithAd failed: %v", err)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	c, err := NewCipherState(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetNonce(42)
+	c.Rekey()
+
+	if c.Nonce() != 42 {
+		t.Error("Rekey modified the nonce")
+	}
+
+	ref, err := chacha20poly1305.New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maxNonce := []byte{0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	key := ref.Seal(nil, maxNonce, make([]byte, KeySize), nil)[:KeySize]
+
+	expected, err := NewCipherState(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected.SetNonce(42)
+
+	ct1, err := c.EncryptWithAd(nil, nil, []byte("plaintext"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct2, err := expected.EncryptWithAd(nil, nil, []byte("plaintext"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(ct1, ct2) {
+		t.Errorf("Rekey derived the wrong key, expected %x, got %x", ct2, ct1)
+	}
+}
+
+func TestNoKey(t *testing.T) {
+	var c CipherState
+
+	if c.HasKey() {
+		t.Error("zero CipherState has key")
+	}
+
+	ct, err := c.EncryptWithAd([]byte("a"), []byte("ad"), []byte("bc"))
+	if err != nil || string(ct) != "abc" {
+		t.Error("EncryptWithAd without key modified plaintext")
+	}
+
+	pt, err := c.DecryptWithAd([]byte("a"), []byte("ad"), []byte("bc"))
+	if err != nil || string(pt) != "abc" {
+		t.Error("DecryptWithAd without key modified ciphertext")
+	}
+
+	c.Rekey()
+
+	if c.HasKey() || c.Nonce() != 0 {
+		t.Error("CipherState without key was modified")
+	}
+
+	if err := c.InitializeKey(testKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.InitializeKey(nil); err != nil || c.HasKey() {
+		t.Error("InitializeKey did not clear key")
+	}
+}
+
+func TestBadKey(t *testing.T) {
+	if _, err := NewCipherState(testKey[:16]); err != ErrInvalidKey {
+		t.Error("NewCipherState accepted 128-bit key")
+	}
+}
+
+func BenchmarkEncryptWithAd(b *testing.B) {
+	c, err := NewCipherState(testKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	plaintext := make([]byte, 16*1024)
+	buf := make([]byte, 0, len(plaintext)+Overhead)
+
+	b.SetBytes(int64(len(plaintext)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.EncryptWithAd(buf, nil, plaintext); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
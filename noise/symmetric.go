@@ -0,0 +1,200 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package noise
+
+import (
+	"crypto/hmac"
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+)
+
+// BLAKE2s returns a new hash.Hash computing the unkeyed BLAKE2s-256 checksum,
+// the Noise BLAKE2s hash function.
+func BLAKE2s() hash.Hash {
+	h, err := blake2s.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+// BLAKE2b returns a new hash.Hash computing the unkeyed BLAKE2b-512 checksum,
+// the Noise BLAKE2b hash function.
+func BLAKE2b() hash.Hash {
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+// SymmetricState is the Noise SymmetricState object: a CipherState, a
+// chaining key ck and a handshake hash h. It is not safe for concurrent use.
+type SymmetricState struct {
+	cs CipherState
+
+	newHash func() hash.Hash
+	ck, h   []byte
+}
+
+// NewSymmetricState returns a SymmetricState initialized with protocolName,
+// the full name of the Noise protocol, such as
+// "Noise_XX_25519_ChaChaPoly_SHA256".
+//
+// newHash is the protocol's hash function: sha256.New, sha512.New, BLAKE2s or
+// BLAKE2b. NewSymmetricState panics if the hash length is not 32 or 64 bytes.
+func NewSymmetricState(newHash func() hash.Hash, protocolName string) *SymmetricState {
+	size := newHash().Size()
+	if size != 32 && size != 64 {
+		panic("noise: invalid hash length")
+	}
+
+	s := &SymmetricState{
+		newHash: newHash,
+		h:       make([]byte, size),
+	}
+
+	if len(protocolName) <= size {
+		copy(s.h, protocolName)
+	} else {
+		s.h = s.hash(nil, []byte(protocolName))
+	}
+
+	s.ck = append([]byte(nil), s.h...)
+	return s
+}
+
+// hash returns HASH(a || b).
+func (s *SymmetricState) hash(a, b []byte) []byte {
+	h := s.newHash()
+	h.Write(a)
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+// hkdf is the Noise HKDF function. It returns len(outputs) outputs of
+// HASHLEN bytes derived from the chaining key and ikm.
+func (s *SymmetricState) hkdf(ikm []byte, outputs ...*[]byte) {
+	m := hmac.New(s.newHash, s.ck)
+	m.Write(ikm)
+	tempKey := m.Sum(nil)
+
+	m = hmac.New(s.newHash, tempKey)
+
+	var prev []byte
+	for i, out := range outputs {
+		m.Reset()
+		m.Write(prev)
+		m.Write([]byte{byte(i + 1)})
+		prev = m.Sum(nil)
+		*out = prev
+	}
+
+	for i := range tempKey {
+		tempKey[i] = 0
+	}
+}
+
+// MixKey mixes ikm, such as the output of a Diffie-Hellman function, into the
+// chaining key and sets a new cipher key.
+func (s *SymmetricState) MixKey(ikm []byte) {
+	var tempK []byte
+	s.hkdf(ikm, &s.ck, &tempK)
+
+	if err := s.cs.InitializeKey(tempK[:KeySize]); err != nil {
+		panic(err)
+	}
+}
+
+// MixHash mixes data into the handshake hash.
+func (s *SymmetricState) MixHash(data []byte) {
+	s.h = s.hash(s.h, data)
+}
+
+// MixKeyAndHash mixes ikm, such as a pre-shared key, into the chaining key and
+// the handshake hash and sets a new cipher key.
+func (s *SymmetricState) MixKeyAndHash(ikm []byte) {
+	var tempH, tempK []byte
+	s.hkdf(ikm, &s.ck, &tempH, &tempK)
+	s.MixHash(tempH)
+
+	if err := s.cs.InitializeKey(tempK[:KeySize]); err != nil {
+		panic(err)
+	}
+}
+
+// HandshakeHash returns the handshake hash h. It is intended for channel
+// binding once the handshake has completed, and must not be modified.
+func (s *SymmetricState) HandshakeHash() []byte {
+	return s.h
+}
+
+// HasKey reports whether a cipher key has been set by MixKey or
+// MixKeyAndHash.
+func (s *SymmetricState) HasKey() bool {
+	return s.cs.HasKey()
+}
+
+// EncryptAndHash encrypts plaintext with the handshake hash as additional
+// data, appends the result to dst, mixes it into the handshake hash and
+// returns the updated slice. Before a key has been set, plaintext is
+// appended as is.
+//
+// To reuse plaintext's storage for the encrypted output, use plaintext[:0]
+// as dst. Otherwise, the remaining capacity of dst must not overlap
+// plaintext.
+func (s *SymmetricState) EncryptAndHash(dst, plaintext []byte) ([]byte, error) {
+	ret, err := s.cs.EncryptWithAd(dst, s.h, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	s.MixHash(ret[len(dst):])
+	return ret, nil
+}
+
+// DecryptAndHash decrypts ciphertext with the handshake hash as additional
+// data, appends the result to dst, mixes ciphertext into the handshake hash
+// and returns the updated slice. The handshake hash is only updated if
+// ciphertext is authentic.
+//
+// To reuse ciphertext's storage for the decrypted output, use ciphertext[:0]
+// as dst. Otherwise, the remaining capacity of dst must not overlap
+// ciphertext.
+func (s *SymmetricState) DecryptAndHash(dst, ciphertext []byte) ([]byte, error) {
+	h := s.hash(s.h, ciphertext)
+
+	ret, err := s.cs.DecryptWithAd(dst, s.h, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	s.h = h
+	return ret, nil
+}
+
+// Split returns the pair of CipherStates for transport messages: c1 for
+// messages sent by the initiator and c2 for messages sent by the responder.
+func (s *SymmetricState) Split() (c1, c2 *CipherState) {
+	var tempK1, tempK2 []byte
+	s.hkdf(nil, &tempK1, &tempK2)
+
+	c1, err := NewCipherState(tempK1[:KeySize])
+	if err != nil {
+		panic(err)
+	}
+
+	c2, err = NewCipherState(tempK2[:KeySize])
+	if err != nil {
+		panic(err)
+	}
+
+	return c1, c2
+}
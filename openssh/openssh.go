@@ -0,0 +1,168 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package openssh implements OpenSSH's chacha20-poly1305@openssh.com
+// transport cipher, as described in its PROTOCOL.chacha20poly1305, on top of
+// github.com/tmthrgd/chacha20.
+//
+// The 64-byte key is split into a 256-bit main key, K_2, and a 256-bit
+// header key, K_1, each used with the original 64-bit nonce variant of
+// ChaCha20 and the packet sequence number as the nonce. The 4-byte packet
+// length is encrypted with K_1. The first 32 bytes of block 0 of the K_2
+// keystream form the Poly1305 key, and the rest of the packet is encrypted
+// with K_2 from block 1. The tag covers the encrypted length and the
+// encrypted packet.
+//
+// As the streams are created with chacha20.NewDraft, the SSE, AVX and AVX2
+// implementations are used where available.
+package openssh
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+
+	"github.com/tmthrgd/chacha20"
+	"github.com/tmthrgd/chacha20/internal/slice"
+	"golang.org/x/crypto/poly1305"
+)
+
+const (
+	// KeySize is the length of keys, in bytes.
+	KeySize = 2 * chacha20.KeySize
+
+	// LengthSize is the length of the encrypted packet length, in bytes.
+	LengthSize = 4
+
+	// Overhead is the number of bytes added to each packet: the encrypted
+	// length and the Poly1305 tag.
+	Overhead = LengthSize + poly1305.TagSize
+)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	errOpen = errors.New("openssh: message authentication failed")
+)
+
+// Cipher is an instance of chacha20-poly1305@openssh.com for one direction
+// of a connection.
+type Cipher struct {
+	mainKey   [chacha20.KeySize]byte // K_2
+	headerKey [chacha20.KeySize]byte // K_1
+}
+
+// New returns a Cipher using the given 512-bit key, as derived by the SSH key
+// exchange. The first half of the key is K_2 and the second half is K_1.
+func New(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	c := new(Cipher)
+	copy(c.mainKey[:], key[:chacha20.KeySize])
+	copy(c.headerKey[:], key[chacha20.KeySize:])
+	return c, nil
+}
+
+// stream returns the ChaCha20 keystream for key with seq as the nonce.
+func stream(key *[chacha20.KeySize]byte, seq uint32) cipher.Stream {
+	var nonce [chacha20.DraftNonceSize]byte
+	binary.BigEndian.PutUint64(nonce[:], uint64(seq))
+
+	s, err := chacha20.NewDraft(key[:], nonce[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// mainStream returns the K_2 keystream for seq, positioned at block 1, and
+// the Poly1305 key taken from block 0.
+func (c *Cipher) mainStream(seq uint32) (cipher.Stream, *[32]byte) {
+	s := stream(&c.mainKey, seq)
+
+	var block [64]byte
+	s.XORKeyStream(block[:], block[:])
+
+	var polyKey [32]byte
+	copy(polyKey[:], block[:])
+
+	for i := range block {
+		block[i] = 0
+	}
+
+	return s, &polyKey
+}
+
+// DecryptLength decrypts the encrypted packet length, the first LengthSize
+// bytes of a packet with the given sequence number. It allows the length of
+// the packet to be known before it has been received, and so before it can
+// be authenticated. The length does not include the length field itself or
+// the tag.
+//
+// The length must be checked against the maximum packet size before it is
+// used.
+func (c *Cipher) DecryptLength(seq uint32, encryptedLength []byte) uint32 {
+	if len(encryptedLength) != LengthSize {
+		panic("openssh: invalid length size")
+	}
+
+	var length [LengthSize]byte
+	stream(&c.headerKey, seq).XORKeyStream(length[:], encryptedLength)
+	return binary.BigEndian.Uint32(length[:])
+}
+
+// Seal encrypts and authenticates packet, which is the padding length,
+// payload and padding of an SSH binary packet, with the given sequence
+// number. It appends the encrypted length, the encrypted packet and the tag
+// to dst and returns the updated slice. The remaining capacity of dst must
+// not overlap packet.
+func (c *Cipher) Seal(dst []byte, seq uint32, packet []byte) []byte {
+	if uint64(len(packet)) > 1<<32-1 {
+		panic("openssh: packet too large")
+	}
+
+	ret, out := slice.ForAppend(dst, len(packet)+Overhead)
+	n := LengthSize + len(packet)
+
+	binary.BigEndian.PutUint32(out, uint32(len(packet)))
+	stream(&c.headerKey, seq).XORKeyStream(out[:LengthSize], out[:LengthSize])
+
+	s, polyKey := c.mainStream(seq)
+	s.XORKeyStream(out[LengthSize:n], packet)
+
+	var tag [poly1305.TagSize]byte
+	poly1305.Sum(&tag, out[:n], polyKey)
+	copy(out[n:], tag[:])
+	return ret
+}
+
+// Open authenticates and decrypts ciphertext, which is a complete packet
+// including its encrypted length and tag, with the given sequence number. It
+// appends the padding length, payload and padding to dst and returns the
+// updated slice. The remaining capacity of dst must not overlap ciphertext.
+func (c *Cipher) Open(dst []byte, seq uint32, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < Overhead ||
+		uint64(c.DecryptLength(seq, ciphertext[:LengthSize])) != uint64(len(ciphertext)-Overhead) {
+		return nil, errOpen
+	}
+
+	n := len(ciphertext) - poly1305.TagSize
+
+	var tag [poly1305.TagSize]byte
+	copy(tag[:], ciphertext[n:])
+
+	s, polyKey := c.mainStream(seq)
+	if !poly1305.Verify(&tag, ciphertext[:n], polyKey) {
+		return nil, errOpen
+	}
+
+	ret, out := slice.ForAppend(dst, n-LengthSize)
+	s.XORKeyStream(out, ciphertext[LengthSize:n])
+	return ret, nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package openssh
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// These were captured from the client to server direction of a session
+// opened by OpenSSH_9.2p1, with the key taken from the server.
+var testKey = mustHexDecode("53e813450acc3f98ea8d121ba9f9fc4162744ffb28d08834643a1e9c1136e5a6" +
+	"be98e0568e8de85e8b0bfe85ae555499d06585fdb2f591bbd863eada022eabc5")
+
+var testVectors = []struct {
+	seq                uint32
+	packet, ciphertext []byte
+}{
+	{ // SSH_MSG_SERVICE_REQUEST "ssh-userauth"
+		seq:        3,
+		packet:     mustHexDecode("06050000000c7373682d7573657261757468e6dec9ce2593"),
+		ciphertext: mustHexDecode("fce5be0f458cfd4da5b7a78ad6cded8dcb17ac6fbb9e61e6dc14ec70c7ab747f49ed67c79dc27eae86d599fc"),
+	},
+	{ // SSH_MSG_USERAUTH_REQUEST "none"
+		seq: 4,
+		packet: mustHexDecode("0a32000000067465737465720000000e7373682d636f6e6e656374696f6e0000" +
+			"00046e6f6e65e2149ce16119ebd61b3f"),
+		ciphertext: mustHexDecode("dc2bffa7963f607685bc2a2452b7edf2524b5b99d44cc5bb128207fa02f53ec1" +
+			"b031746b4299a2ff73e08dbc5270e6b4d827d8f4f48b834479038a472bd10b5a" +
+			"489d908f"),
+	},
+	{ // SSH_MSG_CHANNEL_REQUEST "exec" "echo hello"
+		seq: 8,
+		packet: mustHexDecode("0b62000000000000000465786563010000000a6563686f2068656c6c6fa94733" +
+			"550111a51885a2de"),
+		ciphertext: mustHexDecode("507576cf293691c7b5f98e7a064b0a4427cbb7e6e1cebb5a487d905959abdaf6" +
+			"0ce7e17ea6c5c83c588ca656a963b38de373f597aeb1f0ff1e903374"),
+	},
+	{ // SSH_MSG_DISCONNECT
+		seq: 11,
+		packet: mustHexDecode("06010000000b00000014646973636f6e6e6563746564206279207573657200" +
+			"000000e0e85fc4b3f1"),
+		ciphertext: mustHexDecode("647dd5f4bd47dbc8465f12267da33c8c769fc531125eac98ccdbdf679697c5b1" +
+			"572216b760f24dd1cb2d887b03ac48215735900fa0ae095df7a6405e"),
+	},
+}
+
+func TestSeal(t *testing.T) {
+	c, err := New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range testVectors {
+		if ct := c.Seal(nil, v.seq, v.packet); !bytes.Equal(ct, v.ciphertext) {
+			t.Errorf("%d: invalid ciphertext, expected %x, got %x", i, v.ciphertext, ct)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	c, err := New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range testVectors {
+		if n := c.DecryptLength(v.seq, v.ciphertext[:LengthSize]); int(n) != len(v.packet) {
+			t.Errorf("%d: invalid length, expected %d, got %d", i, len(v.packet), n)
+		}
+
+		packet, err := c.Open(nil, v.seq, v.ciphertext)
+		if err != nil {
+			t.Errorf("%d: Open failed: %v", i, err)
+			continue
+		}
+
+		if !bytes.Equal(packet, v.packet) {
+			t.Errorf("%d: invalid packet, expected %x, got %x", i, v.packet, packet)
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	c, err := New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range testVectors {
+		for j := range v.ciphertext {
+			ct := append([]byte(nil), v.ciphertext...)
+			ct[j] ^= 0x01
+
+			if _, err := c.Open(nil, v.seq, ct); err == nil {
+				t.Errorf("%d: Open succeeded with byte %d modified", i, j)
+			}
+		}
+
+		if _, err := c.Open(nil, v.seq+1, v.ciphertext); err == nil {
+			t.Errorf("%d: Open succeeded with wrong sequence number", i)
+		}
+
+		if _, err := c.Open(nil, v.seq, v.ciphertext[:len(v.ciphertext)-1]); err == nil {
+			t.Errorf("%d: Open succeeded with truncated packet", i)
+		}
+	}
+
+	if _, err := c.Open(nil, 0, make([]byte, Overhead-1)); err == nil {
+		t.Error("Open succeeded with packet shorter than overhead")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	c, err := New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := []byte("prefix")
+
+	for n := 0; n < 1024; n += 1 + n/2 {
+		packet := bytes.Repeat([]byte{0x5a}, n)
+		seq := 0xffffffff - uint32(n)
+
+		ct := c.Seal(append([]byte(nil), prefix...), seq, packet)
+		if !bytes.Equal(ct[:len(prefix)], prefix) || len(ct) != len(prefix)+n+Overhead {
+			t.Fatalf("Seal returned wrong slice for %d bytes", n)
+		}
+
+		got, err := c.Open(prefix, seq, ct[len(prefix):])
+		if err != nil {
+			t.Fatalf("Open failed for %d bytes: %v", n, err)
+		}
+
+		if !bytes.Equal(got[:len(prefix)], prefix) || !bytes.Equal(got[len(prefix):], packet) {
+			t.Fatalf("round trip failed for %d bytes", n)
+		}
+	}
+}
+
+func TestBadKey(t *testing.T) {
+	if _, err := New(testKey[:32]); err != ErrInvalidKey {
+		t.Error("New accepted 256-bit key")
+	}
+}
+
+func BenchmarkSeal(b *testing.B) {
+	c, err := New(testKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	packet := make([]byte, 32*1024)
+	buf := make([]byte, 0, len(packet)+Overhead)
+
+	b.SetBytes(int64(len(packet)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.Seal(buf, uint32(i), packet)
+	}
+}
+
+func BenchmarkOpen(b *testing.B) {
+	c, err := New(testKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	packet := make([]byte, 32*1024)
+	ct := c.Seal(nil, 0, packet)
+	buf := make([]byte, 0, len(packet))
+
+	b.SetBytes(int64(len(packet)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Open(buf, 0, ct); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package passphrase implements passphrase-based file encryption with
+// XChaCha20-Poly1305.
+//
+// The key is derived from the passphrase with Argon2id or scrypt and a
+// random salt. An encrypted file is a HeaderSize byte header
+//
+//	magic[4] || version[1] || kdf[1] || salt[16] || be32(p1) || be32(p2) || be32(p3) || be32(chunk size)
+//
+// followed by the plaintext encrypted with XChaCha20-Poly1305 as a STREAM,
+// as implemented by github.com/tmthrgd/chacha20/stream, with an all zero
+// nonce prefix. As the salt makes each key unique, the nonce prefix need not
+// be. For Argon2id, p1, p2 and p3 are the time, memory in KiB and threads
+// parameters; for scrypt, they are log2(N), r and p.
+//
+// Any change to the header either changes the key or the chunk size, and so
+// causes decryption to fail.
+package passphrase
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+	"github.com/tmthrgd/chacha20/stream"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// HeaderSize is the length of the header, in bytes.
+	HeaderSize = 38
+
+	// Version is the version of the format that is written.
+	Version = 1
+
+	// MaxChunkSize is the largest chunk size that may be used.
+	MaxChunkSize = 16 * 1024 * 1024
+)
+
+const (
+	magic    = "xcpw"
+	saltSize = 16
+)
+
+// KDF selects the function used to derive the key from the passphrase.
+type KDF int
+
+const (
+	// Argon2id derives the key with Argon2id. It is the default.
+	Argon2id KDF = iota + 1
+
+	// Scrypt derives the key with scrypt.
+	Scrypt
+)
+
+// Options configure Encrypt. A zero field takes its default value.
+type Options struct {
+	// KDF is the key derivation function. The default is Argon2id.
+	KDF KDF
+
+	// Time, Memory and Threads are the Argon2id parameters. Memory is in
+	// KiB. The defaults are 3, 64 MiB and 4 respectively.
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+
+	// LogN, R and P are the scrypt parameters, with N = 2^LogN. The defaults
+	// are 15, 8 and 1 respectively.
+	LogN uint8
+	R, P uint32
+
+	// ChunkSize is the size of each chunk of plaintext. The default is
+	// stream.DefaultChunkSize.
+	ChunkSize int
+}
+
+// Limits bound the cost of the key derivation that Decrypt will perform,
+// so that a malicious header cannot demand excessive memory or time.
+type Limits struct {
+	// MaxMemory is the most memory, in bytes, that the KDF may use.
+	MaxMemory uint64
+
+	// MaxPasses is the largest Argon2id time parameter, or scrypt p
+	// parameter, that is accepted.
+	MaxPasses uint32
+
+	// MaxThreads is the largest Argon2id threads parameter that is accepted.
+	MaxThreads uint8
+}
+
+// DefaultLimits are the limits used by Decrypt. They accept the default
+// Options.
+var DefaultLimits = &Limits{
+	MaxMemory:  1 << 30,
+	MaxPasses:  16,
+	MaxThreads: 16,
+}
+
+var (
+	// ErrInvalidOptions is returned when the Options are invalid.
+	ErrInvalidOptions = errors.New("invalid options")
+
+	// ErrInvalidHeader is returned when the input does not begin with a
+	// valid header.
+	ErrInvalidHeader = errors.New("invalid header")
+
+	// ErrUnsupportedVersion is returned when the header has a version other
+	// than Version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+
+	// ErrLimitsExceeded is returned when the header requests a key
+	// derivation that exceeds the Limits.
+	ErrLimitsExceeded = errors.New("key derivation exceeds limits")
+)
+
+// params are the KDF parameters recorded in the header.
+type params struct {
+	kdf        KDF
+	salt       [saltSize]byte
+	p1, p2, p3 uint32
+	chunkSize  int
+}
+
+func (p *params) marshal() []byte {
+	hdr := make([]byte, HeaderSize)
+	copy(hdr, magic)
+	hdr[4] = Version
+	hdr[5] = byte(p.kdf)
+	copy(hdr[6:], p.salt[:])
+	binary.BigEndian.PutUint32(hdr[22:], p.p1)
+	binary.BigEndian.PutUint32(hdr[26:], p.p2)
+	binary.BigEndian.PutUint32(hdr[30:], p.p3)
+	binary.BigEndian.PutUint32(hdr[34:], uint32(p.chunkSize))
+	return hdr
+}
+
+func unmarshal(hdr []byte) (*params, error) {
+	if string(hdr[:4]) != magic {
+		return nil, ErrInvalidHeader
+	}
+
+	if hdr[4] != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	p := &params{
+		kdf:       KDF(hdr[5]),
+		p1:        binary.BigEndian.Uint32(hdr[22:]),
+		p2:        binary.BigEndian.Uint32(hdr[26:]),
+		p3:        binary.BigEndian.Uint32(hdr[30:]),
+		chunkSize: int(binary.BigEndian.Uint32(hdr[34:])),
+	}
+	copy(p.salt[:], hdr[6:])
+
+	if err := p.validate(); err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	return p, nil
+}
+
+// validate checks that the parameters are usable.
+func (p *params) validate() error {
+	switch p.kdf {
+	case Argon2id:
+		if p.p1 == 0 || p.p3 == 0 || p.p3 > 255 || p.p2 < 8*p.p3 {
+			return ErrInvalidOptions
+		}
+	case Scrypt:
+		if p.p1 == 0 || p.p1 >= 64 || p.p2 == 0 || p.p3 == 0 ||
+			uint64(p.p2)*uint64(p.p3) >= 1<<30 {
+			return ErrInvalidOptions
+		}
+	default:
+		return ErrInvalidOptions
+	}
+
+	if p.chunkSize <= 0 || p.chunkSize > MaxChunkSize {
+		return ErrInvalidOptions
+	}
+
+	return nil
+}
+
+// check returns an error if deriving the key would exceed l.
+func (p *params) check(l *Limits) error {
+	var memory uint64
+	var passes, threads uint32
+
+	switch p.kdf {
+	case Argon2id:
+		memory, passes, threads = uint64(p.p2)*1024, p.p1, p.p3
+	case Scrypt:
+		memory, passes, threads = 128*uint64(p.p2), p.p3, 1
+
+		if memory<<p.p1>>p.p1 != memory {
+			return ErrLimitsExceeded
+		}
+
+		memory <<= p.p1
+	}
+
+	if memory > l.MaxMemory || passes > l.MaxPasses || threads > uint32(l.MaxThreads) {
+		return ErrLimitsExceeded
+	}
+
+	return nil
+}
+
+func (p *params) key(passphrase []byte) ([]byte, error) {
+	switch p.kdf {
+	case Argon2id:
+		return argon2.IDKey(passphrase, p.salt[:], p.p1, p.p2, uint8(p.p3), chacha20poly1305.KeySize), nil
+	case Scrypt:
+		return scrypt.Key(passphrase, p.salt[:], 1<<p.p1, int(p.p2), int(p.p3), chacha20poly1305.KeySize)
+	default:
+		panic("passphrase: invalid KDF")
+	}
+}
+
+func newParams(opts *Options) (*params, error) {
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	p := &params{
+		kdf:       opts.KDF,
+		chunkSize: opts.ChunkSize,
+	}
+
+	if p.kdf == 0 {
+		p.kdf = Argon2id
+	}
+
+	if p.chunkSize == 0 {
+		p.chunkSize = stream.DefaultChunkSize
+	}
+
+	switch p.kdf {
+	case Argon2id:
+		p.p1, p.p2, p.p3 = orDefault(opts.Time, 3), orDefault(opts.Memory, 64*1024), orDefault(uint32(opts.Threads), 4)
+	case Scrypt:
+		p.p1, p.p2, p.p3 = orDefault(uint32(opts.LogN), 15), orDefault(opts.R, 8), orDefault(opts.P, 1)
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := rand.Read(p.salt[:]); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func orDefault(v, def uint32) uint32 {
+	if v == 0 {
+		return def
+	}
+
+	return v
+}
+
+// Encrypt writes a header to w and returns an io.WriteCloser that encrypts
+// data written to it with a key derived from passphrase. Close must be called
+// to finish the encryption; it does not close w. If opts is nil, the default
+// Options are used.
+func Encrypt(w io.Writer, passphrase []byte, opts *Options) (io.WriteCloser, error) {
+	p, err := newParams(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := p.key(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(p.marshal()); err != nil {
+		return nil, err
+	}
+
+	return stream.NewWriter(w, aead, make([]byte, aead.NonceSize()-stream.NonceOverhead), p.chunkSize)
+}
+
+// Decrypt reads the header from r and returns an io.Reader that decrypts the
+// remainder of r with a key derived from passphrase. It uses DefaultLimits.
+//
+// A wrong passphrase is only detected when the first chunk is read.
+func Decrypt(r io.Reader, passphrase []byte) (io.Reader, error) {
+	return DefaultLimits.Decrypt(r, passphrase)
+}
+
+// Decrypt is like the package level Decrypt, but rejects headers whose key
+// derivation exceeds l.
+func (l *Limits) Decrypt(r io.Reader, passphrase []byte) (io.Reader, error) {
+	hdr := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidHeader
+		}
+
+		return nil, err
+	}
+
+	p, err := unmarshal(hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.check(l); err != nil {
+		return nil, err
+	}
+
+	key, err := p.key(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return stream.NewReader(r, aead, make([]byte, aead.NonceSize()-stream.NonceOverhead), p.chunkSize)
+}
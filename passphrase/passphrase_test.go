@@ -0,0 +1,184 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package passphrase
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+// Cheap parameters so the tests run quickly.
+var testOptions = []*Options{
+	{Time: 1, Memory: 64, Threads: 1, ChunkSize: 100},
+	{KDF: Scrypt, LogN: 4, R: 8, P: 1, ChunkSize: 100},
+}
+
+func encrypt(t *testing.T, plaintext, passphrase []byte, opts *Options) []byte {
+	var buf bytes.Buffer
+
+	w, err := Encrypt(&buf, passphrase, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func decrypt(ct, passphrase []byte) ([]byte, error) {
+	r, err := Decrypt(bytes.NewReader(ct), passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+	passphrase := []byte("correct horse battery staple")
+
+	for _, opts := range testOptions {
+		for _, size := range []int{0, 1, 100, 1000} {
+			plaintext := make([]byte, size)
+			rand.Read(plaintext)
+
+			ct := encrypt(t, plaintext, passphrase, opts)
+
+			got, err := decrypt(ct, passphrase)
+			if err != nil {
+				t.Fatalf("KDF %d, size %d: %v", opts.KDF, size, err)
+			}
+
+			if !bytes.Equal(got, plaintext) {
+				t.Fatalf("KDF %d, size %d: wrong plaintext", opts.KDF, size)
+			}
+		}
+	}
+}
+
+func TestUniqueSalt(t *testing.T) {
+	a := encrypt(t, []byte("hello"), []byte("pass"), testOptions[0])
+	b := encrypt(t, []byte("hello"), []byte("pass"), testOptions[0])
+
+	if bytes.Equal(a, b) {
+		t.Error("encrypting twice produced the same output")
+	}
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	for _, opts := range testOptions {
+		ct := encrypt(t, []byte("hello"), []byte("pass"), opts)
+
+		if _, err := decrypt(ct, []byte("Pass")); err == nil {
+			t.Errorf("KDF %d: decryption succeeded with the wrong passphrase", opts.KDF)
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	ct := encrypt(t, make([]byte, 250), []byte("pass"), testOptions[1])
+
+	for i := range ct {
+		bad := append([]byte(nil), ct...)
+		bad[i] ^= 0x01
+
+		if _, err := decrypt(bad, []byte("pass")); err == nil {
+			t.Fatalf("decryption succeeded with byte %d modified", i)
+		}
+	}
+
+	if _, err := decrypt(ct[:len(ct)-1], []byte("pass")); err == nil {
+		t.Error("decryption succeeded with truncated input")
+	}
+
+	if _, err := decrypt(ct[:HeaderSize-1], []byte("pass")); err != ErrInvalidHeader {
+		t.Errorf("expected ErrInvalidHeader, got %v", err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for _, opts := range testOptions {
+		ct := encrypt(t, []byte("hello"), []byte("pass"), opts)
+
+		for _, l := range []*Limits{
+			{MaxMemory: 1024, MaxPasses: 16, MaxThreads: 16},
+			{MaxMemory: 1 << 30, MaxPasses: 0, MaxThreads: 16},
+		} {
+			if _, err := l.Decrypt(bytes.NewReader(ct), []byte("pass")); err != ErrLimitsExceeded {
+				t.Errorf("KDF %d: expected ErrLimitsExceeded, got %v", opts.KDF, err)
+			}
+		}
+	}
+
+	// A header demanding 4 TiB of memory must be rejected before any work
+	// is done.
+	var p params
+	p.kdf, p.p1, p.p2, p.p3, p.chunkSize = Argon2id, 1, 1<<32-1, 1, 100
+
+	if _, err := Decrypt(bytes.NewReader(p.marshal()), []byte("pass")); err != ErrLimitsExceeded {
+		t.Errorf("expected ErrLimitsExceeded, got %v", err)
+	}
+
+	p.kdf, p.p1, p.p2 = Scrypt, 40, 1<<20
+
+	if _, err := Decrypt(bytes.NewReader(p.marshal()), []byte("pass")); err != ErrLimitsExceeded {
+		t.Errorf("expected ErrLimitsExceeded, got %v", err)
+	}
+
+	p.p1 = 63
+
+	if _, err := Decrypt(bytes.NewReader(p.marshal()), []byte("pass")); err != ErrLimitsExceeded {
+		t.Errorf("expected ErrLimitsExceeded, got %v", err)
+	}
+}
+
+func TestBadHeader(t *testing.T) {
+	ct := encrypt(t, []byte("hello"), []byte("pass"), testOptions[0])
+
+	bad := append([]byte(nil), ct...)
+	bad[4] = Version + 1
+
+	if _, err := decrypt(bad, []byte("pass")); err != ErrUnsupportedVersion {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+
+	bad = append([]byte(nil), ct...)
+	bad[5] = 3
+
+	if _, err := decrypt(bad, []byte("pass")); err != ErrInvalidHeader {
+		t.Errorf("expected ErrInvalidHeader, got %v", err)
+	}
+
+	bad = append([]byte(nil), ct...)
+	bad[0] ^= 1
+
+	if _, err := decrypt(bad, []byte("pass")); err != ErrInvalidHeader {
+		t.Errorf("expected ErrInvalidHeader, got %v", err)
+	}
+}
+
+func TestBadOptions(t *testing.T) {
+	for _, opts := range []*Options{
+		{KDF: 3},
+		{ChunkSize: -1},
+		{ChunkSize: MaxChunkSize + 1},
+		{KDF: Scrypt, LogN: 64},
+	} {
+		if _, err := Encrypt(ioutil.Discard, []byte("pass"), opts); err != ErrInvalidOptions {
+			t.Errorf("%+v: expected ErrInvalidOptions, got %v", opts, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20
+
+import (
+	"crypto/cipher"
+	"sync"
+
+	"github.com/tmthrgd/chacha20/internal/vec"
+	"github.com/tmthrgd/chacha20/internal/xor"
+)
+
+const (
+	// DefaultPrecomputeWindow is the number of bytes of keystream that
+	// NewPrecomputed keeps ready when it is passed a window of zero.
+	DefaultPrecomputeWindow = 64 * 1024
+
+	// precomputeChunk is the most keystream generated before it is made
+	// available to XORKeyStream.
+	precomputeChunk = 4 * 1024
+
+	// precomputeBlock is the unit keystream is generated in. It is a
+	// multiple of the block size of every stream in this package,
+	// including the 128 bytes used with AVX2, so the wrapped stream never
+	// has to buffer keystream of its own.
+	precomputeBlock = vec.MaxBlockSize
+
+	blockSize = 64
+)
+
+// PrecomputedStream is a cipher.Stream that generates keystream ahead of use
+// on a background goroutine. XORKeyStream then only has to XOR its input with
+// keystream that is already waiting, which takes keystream generation off the
+// critical path as long as the stream is not used faster than the keystream
+// can be generated.
+//
+// The output of a PrecomputedStream is identical to that of the stream it
+// wraps. Keystream is zeroed as soon as it has been used and the whole window
+// is zeroed by Close, along with any state the wrapped stream buffers.
+type PrecomputedStream struct {
+	mu   sync.Mutex
+	cond sync.Cond
+
+	s   cipher.Stream
+	buf []byte // the ring buffer
+
+	r, w  int // read and write offsets into buf
+	ready int // keystream bytes waiting at r
+	free  int // zeroed bytes available at w
+
+	closed bool
+	done   chan struct{}
+}
+
+// NewPrecomputed wraps s in a PrecomputedStream that keeps up to window bytes
+// of keystream ready. window is rounded up to a multiple of 128 bytes. If window is zero or negative, DefaultPrecomputeWindow is used.
+//
+// s must not be used directly once it has been passed to NewPrecomputed, and
+// Close must be called once the PrecomputedStream is no longer needed to stop
+// the background goroutine.
+func NewPrecomputed(s cipher.Stream, window int) *PrecomputedStream {
+	if window <= 0 {
+		window = DefaultPrecomputeWindow
+	}
+
+	window = (window + precomputeBlock - 1) &^ (precomputeBlock - 1)
+
+	p := &PrecomputedStream{
+		s:    s,
+		buf:  make([]byte, window),
+		free: window,
+		done: make(chan struct{}),
+	}
+	p.cond.L = &p.mu
+
+	go p.generate()
+	return p
+}
+
+// generate fills the free part of the ring buffer with keystream until the
+// stream is closed. Free space is always zero, so the wrapped stream XORs it
+// in place to produce keystream.
+func (p *PrecomputedStream) generate() {
+	defer close(p.done)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		// Only whole multiples of precomputeBlock are generated so
+		// that w stays aligned and the wrapped stream never has to
+		// buffer.
+		for !p.closed && p.free < precomputeBlock {
+			p.cond.Wait()
+		}
+
+		if p.closed {
+			return
+		}
+
+		n := p.free &^ (precomputeBlock - 1)
+		if n > len(p.buf)-p.w {
+			n = len(p.buf) - p.w
+		}
+
+		if n > precomputeChunk {
+			n = precomputeChunk
+		}
+
+		b := p.buf[p.w : p.w+n]
+		p.free -= n
+
+		p.mu.Unlock()
+		p.s.XORKeyStream(b, b)
+		p.mu.Lock()
+
+		p.w += n
+		if p.w == len(p.buf) {
+			p.w = 0
+		}
+
+		p.ready += n
+		p.cond.Broadcast()
+	}
+}
+
+// XORKeyStream XORs each byte in the given slice with a byte from the
+// cipher's key stream. Dst and src may point to the same memory. If
+// precomputed keystream is not yet available, XORKeyStream waits for it.
+//
+// XORKeyStream panics if it is called after Close.
+func (p *PrecomputedStream) XORKeyStream(dst, src []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(src) != 0 {
+		for !p.closed && p.ready == 0 {
+			p.cond.Wait()
+		}
+
+		if p.closed {
+			panic("chacha20: use of closed PrecomputedStream")
+		}
+
+		n := p.ready
+		if n > len(p.buf)-p.r {
+			n = len(p.buf) - p.r
+		}
+
+		if n > len(src) {
+			n = len(src)
+		}
+
+		b := p.buf[p.r : p.r+n]
+		xor.Bytes(dst, b, src)
+
+		for i := range b {
+			b[i] = 0
+		}
+
+		p.r += n
+		if p.r == len(p.buf) {
+			p.r = 0
+		}
+
+		p.ready -= n
+		p.free += n
+		p.cond.Broadcast()
+
+		dst = dst[n:]
+		src = src[n:]
+	}
+}
+
+// Close stops the background goroutine and zeroes any keystream that has
+// been generated but not used. If the wrapped stream supports SetCounter, as
+// the streams in this package do, it is reset to clear any keystream it has
+// buffered. It always returns nil.
+func (p *PrecomputedStream) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	<-p.done
+
+	p.mu.Lock()
+	for i := range p.buf {
+		p.buf[i] = 0
+	}
+	p.ready, p.free = 0, 0
+
+	if cs, ok := p.s.(interface {
+		SetCounter(counter uint64)
+	}); ok {
+		cs.SetCounter(0)
+	}
+	p.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestPrecomputedEqual(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	for _, window := range []int{0, 1, 64, 100, 4096, 1 << 20} {
+		key := make([]byte, KeySize)
+		nonce := make([]byte, XNonceSize)
+		rand.Read(key)
+		rand.Read(nonce)
+
+		c1, err := NewXChaCha(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c2, err := NewXChaCha(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := NewPrecomputed(c2, window)
+
+		for i := 0; i < 100; i++ {
+			src := make([]byte, rand.Intn(3*4096))
+			rand.Read(src)
+
+			dst1 := make([]byte, len(src))
+			c1.XORKeyStream(dst1, src)
+
+			dst2 := make([]byte, len(src))
+			p.XORKeyStream(dst2, src)
+
+			if !bytes.Equal(dst1, dst2) {
+				t.Fatalf("window=%d: output differs from synchronous stream", window)
+			}
+		}
+
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPrecomputedZeroized(t *testing.T) {
+	c, err := NewRFC(make([]byte, KeySize), make([]byte, RFCNonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPrecomputed(c, 1024)
+
+	// Using less than a block keeps the background goroutine from
+	// refilling the bytes that were just used.
+	var buf [40]byte
+	p.XORKeyStream(buf[:], buf[:])
+
+	p.mu.Lock()
+	for i := range buf {
+		if p.buf[i] != 0 {
+			p.mu.Unlock()
+			t.Fatal("used keystream was not zeroed")
+		}
+	}
+	p.mu.Unlock()
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(p.buf, make([]byte, len(p.buf))) {
+		t.Error("Close did not zero precomputed keystream")
+	}
+}
+
+func TestPrecomputedWipesStream(t *testing.T) {
+	c, err := NewXChaCha(make([]byte, KeySize), make([]byte, XNonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Leave c part way through a block so that it is still buffering
+	// keystream when the background goroutine starts.
+	var buf [10]byte
+	c.XORKeyStream(buf[:], buf[:])
+
+	p := NewPrecomputed(c, 1024)
+	p.XORKeyStream(buf[:], buf[:])
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := c.(interface {
+		Buffered() int
+	}).Buffered(); n != 0 {
+		t.Errorf("wrapped stream still buffers %d bytes of keystream after Close", n)
+	}
+}
+
+func TestPrecomputedUseAfterClose(t *testing.T) {
+	c, err := NewRFC(make([]byte, KeySize), make([]byte, RFCNonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPrecomputed(c, 0)
+	p.Close()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("XORKeyStream did not panic after Close")
+		}
+	}()
+
+	var buf [1]byte
+	p.XORKeyStream(buf[:], buf[:])
+}
+
+func BenchmarkPrecomputed(b *testing.B) {
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			key := make([]byte, KeySize)
+			nonce := make([]byte, RFCNonceSize)
+			c, _ := NewRFC(key, nonce)
+
+			p := NewPrecomputed(c, 0)
+			defer p.Close()
+
+			benchmarkStream(b, p, size.l)
+		})
+	}
+}
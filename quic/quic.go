@@ -0,0 +1,133 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package quic implements the ChaCha20 header protection of QUIC, as
+// specified in section 5.4 of RFC 9001, on top of
+// github.com/tmthrgd/chacha20.
+//
+// A 16-byte sample is taken from the packet's ciphertext. Its first 4 bytes
+// are the little-endian block counter and the remaining 12 are the nonce,
+// and the first 5 bytes of the resulting ChaCha20 keystream form the mask.
+// This is the layout of OpenSSL's EVP_chacha20 IV, and so the mask is
+// computed with chacha20.NewOpenSSL.
+//
+// The record number encryption of DTLS 1.3, RFC 9147 section 4.2.3, uses the
+// same mask.
+package quic
+
+import (
+	"errors"
+
+	"github.com/tmthrgd/chacha20"
+)
+
+const (
+	// KeySize is the length of header protection keys, in bytes.
+	KeySize = chacha20.KeySize
+
+	// SampleSize is the length of the ciphertext sample, in bytes.
+	SampleSize = chacha20.OpenSSLIVSize
+
+	// MaskSize is the length of the header protection mask, in bytes.
+	MaskSize = 5
+)
+
+const (
+	// sampleOffset is the distance from the start of the packet number to
+	// the sample, which assumes a 4 byte packet number.
+	sampleOffset = 4
+
+	longHeader = 0x80
+)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	// ErrPacketTooShort is returned when the packet does not contain a full
+	// sample after the packet number.
+	ErrPacketTooShort = errors.New("quic: packet too short to sample")
+)
+
+// HeaderProtectionMask returns the header protection mask for the given
+// 256-bit key and 16-byte sample. It panics if either is the wrong length.
+func HeaderProtectionMask(key, sample []byte) [MaskSize]byte {
+	if len(key) != KeySize {
+		panic("quic: invalid key length")
+	}
+
+	if len(sample) != SampleSize {
+		panic("quic: invalid sample length")
+	}
+
+	s, err := chacha20.NewOpenSSL(key, sample)
+	if err != nil {
+		panic(err)
+	}
+
+	var mask [MaskSize]byte
+	s.XORKeyStream(mask[:], mask[:])
+	return mask
+}
+
+// ProtectHeader applies header protection to packet in place. pnOffset is
+// the offset of the packet number within packet, which must hold the
+// complete packet with its payload already encrypted. The length of the
+// packet number is taken from the unprotected first byte.
+//
+// Both short and long headers are supported; the form is taken from the
+// most significant bit of the first byte.
+func ProtectHeader(key, packet []byte, pnOffset int) error {
+	mask, err := packetMask(key, packet, pnOffset)
+	if err != nil {
+		return err
+	}
+
+	pnLen := int(packet[0]&0x03) + 1
+	applyMask(packet, pnOffset, pnLen, &mask)
+	return nil
+}
+
+// UnprotectHeader removes header protection from packet in place, and
+// returns the length of the packet number, which is between 1 and 4 bytes.
+// It is the inverse of ProtectHeader.
+func UnprotectHeader(key, packet []byte, pnOffset int) (int, error) {
+	mask, err := packetMask(key, packet, pnOffset)
+	if err != nil {
+		return 0, err
+	}
+
+	// The packet number length is protected, so the first byte must be
+	// unmasked before it can be read.
+	pnLen := int((packet[0]^mask[0])&0x03) + 1
+	applyMask(packet, pnOffset, pnLen, &mask)
+	return pnLen, nil
+}
+
+func packetMask(key, packet []byte, pnOffset int) ([MaskSize]byte, error) {
+	if len(key) != KeySize {
+		return [MaskSize]byte{}, ErrInvalidKey
+	}
+
+	if pnOffset < 1 || len(packet)-sampleOffset-SampleSize < pnOffset {
+		return [MaskSize]byte{}, ErrPacketTooShort
+	}
+
+	sample := packet[pnOffset+sampleOffset : pnOffset+sampleOffset+SampleSize]
+	return HeaderProtectionMask(key, sample), nil
+}
+
+// applyMask XORs the mask into the first byte and the packet number.
+func applyMask(packet []byte, pnOffset, pnLen int, mask *[MaskSize]byte) {
+	if packet[0]&longHeader != 0 {
+		packet[0] ^= mask[0] & 0x0f
+	} else {
+		packet[0] ^= mask[0] & 0x1f
+	}
+
+	for i := 0; i < pnLen; i++ {
+		packet[pnOffset+i] ^= mask[1+i]
+	}
+}
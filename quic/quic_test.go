@@ -0,0 +1,167 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package quic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// These are from the ChaCha20-Poly1305 short header packet of RFC 9001,
+// appendix A.5.
+var (
+	rfcKey       = mustHexDecode("25a282b9e82f06f21f488917a4fc8f1b73573685608597d0efcb076b0ab7a7a4")
+	rfcSample    = mustHexDecode("5e5cd55c41f69080575d7999c25a5bfb")
+	rfcMask      = mustHexDecode("aefefe7d03")
+	rfcPacket    = mustHexDecode("4200bff4655e5cd55c41f69080575d7999c25a5bfb")
+	rfcProtected = mustHexDecode("4cfe4189655e5cd55c41f69080575d7999c25a5bfb")
+)
+
+const rfcPNOffset = 1
+
+func TestHeaderProtectionMask(t *testing.T) {
+	mask := HeaderProtectionMask(rfcKey, rfcSample)
+	if !bytes.Equal(mask[:], rfcMask) {
+		t.Errorf("invalid mask, expected %x, got %x", rfcMask, mask)
+	}
+}
+
+func TestProtectHeader(t *testing.T) {
+	packet := append([]byte(nil), rfcPacket...)
+
+	if err := ProtectHeader(rfcKey, packet, rfcPNOffset); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(packet, rfcProtected) {
+		t.Errorf("invalid protected packet, expected %x, got %x", rfcProtected, packet)
+	}
+}
+
+func TestUnprotectHeader(t *testing.T) {
+	packet := append([]byte(nil), rfcProtected...)
+
+	pnLen, err := UnprotectHeader(rfcKey, packet, rfcPNOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pnLen != 3 {
+		t.Errorf("invalid packet number length, expected 3, got %d", pnLen)
+	}
+
+	if !bytes.Equal(packet, rfcPacket) {
+		t.Errorf("invalid unprotected packet, expected %x, got %x", rfcPacket, packet)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	key := make([]byte, KeySize)
+	rand.Read(key)
+
+	for _, first := range []byte{0x40, 0xc0} {
+		for pnLen := 1; pnLen <= 4; pnLen++ {
+			for i := 0; i < 32; i++ {
+				pnOffset := 1 + rand.Intn(32)
+
+				packet := make([]byte, pnOffset+sampleOffset+SampleSize+rand.Intn(64))
+				rand.Read(packet)
+				packet[0] = first | byte(rand.Intn(4))<<2 | byte(pnLen-1)
+
+				orig := append([]byte(nil), packet...)
+
+				if err := ProtectHeader(key, packet, pnOffset); err != nil {
+					t.Fatal(err)
+				}
+
+				fixed := byte(0xe0)
+				if first&longHeader != 0 {
+					fixed = 0xf0
+				}
+
+				if packet[0]&fixed != orig[0]&fixed {
+					t.Fatalf("ProtectHeader modified fixed bits of first byte %02x", orig[0])
+				}
+
+				if !bytes.Equal(packet[1:pnOffset], orig[1:pnOffset]) ||
+					!bytes.Equal(packet[pnOffset+pnLen:], orig[pnOffset+pnLen:]) {
+					t.Fatal("ProtectHeader modified bytes outside the packet number")
+				}
+
+				got, err := UnprotectHeader(key, packet, pnOffset)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got != pnLen {
+					t.Fatalf("invalid packet number length, expected %d, got %d", pnLen, got)
+				}
+
+				if !bytes.Equal(packet, orig) {
+					t.Fatalf("round trip failed, expected %x, got %x", orig, packet)
+				}
+			}
+		}
+	}
+}
+
+func TestBadParameters(t *testing.T) {
+	packet := append([]byte(nil), rfcPacket...)
+
+	if err := ProtectHeader(rfcKey[:16], packet, rfcPNOffset); err != ErrInvalidKey {
+		t.Error("ProtectHeader accepted invalid key")
+	}
+
+	if err := ProtectHeader(rfcKey, packet[:len(packet)-1], rfcPNOffset); err != ErrPacketTooShort {
+		t.Error("ProtectHeader accepted packet without full sample")
+	}
+
+	if _, err := UnprotectHeader(rfcKey, packet, 0); err != ErrPacketTooShort {
+		t.Error("UnprotectHeader accepted packet number at offset zero")
+	}
+
+	if !bytes.Equal(packet, rfcPacket) {
+		t.Error("packet modified after error")
+	}
+
+	for _, v := range []struct{ key, sample []byte }{
+		{rfcKey[:16], rfcSample},
+		{rfcKey, rfcSample[:12]},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("HeaderProtectionMask did not panic for invalid parameters")
+				}
+			}()
+
+			HeaderProtectionMask(v.key, v.sample)
+		}()
+	}
+}
+
+func BenchmarkProtectHeader(b *testing.B) {
+	packet := append([]byte(nil), rfcPacket...)
+
+	for i := 0; i < b.N; i++ {
+		if err := ProtectHeader(rfcKey, packet, rfcPNOffset); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
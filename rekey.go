@@ -0,0 +1,177 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrInvalidInterval is returned by NewRekeying when the rekey interval is
+// zero, or too long for the keystream of a 96-bit nonce.
+var ErrInvalidInterval = errors.New("invalid rekey interval")
+
+// RekeyMethod selects how a RekeyingStream derives each new key.
+type RekeyMethod int
+
+const (
+	// RekeyKeystream replaces the key with the 32 bytes of keystream that
+	// follow each interval. These bytes are never used for encryption.
+	RekeyKeystream RekeyMethod = iota
+
+	// RekeyHChaCha20 replaces the key with the output of HChaCha20 keyed
+	// with the current key. The HChaCha20 nonce is the number of times the
+	// stream has already been rekeyed as a 64-bit little endian integer,
+	// followed by eight zero bytes.
+	RekeyHChaCha20
+)
+
+// RekeyingStream is a cipher.Stream that replaces its key at fixed intervals
+// and wipes the old one. Compromise of the current state then reveals nothing
+// about the keystream used before the most recent rekey.
+//
+// After every interval bytes have been encrypted, a new key is derived as
+// selected by the RekeyMethod and the keystream restarts from block zero with
+// the same nonce. For XChaCha20, it is the subkey derived from the original
+// key that is replaced and the last 64 bits of the nonce that are reused.
+//
+// The rekeys happen at fixed stream offsets, so both ends of a connection
+// stay in step regardless of how the data is split into calls to
+// XORKeyStream.
+type RekeyingStream struct {
+	key    [KeySize]byte
+	method RekeyMethod
+	epoch  uint64 // the number of rekeys so far
+
+	s interface {
+		cipher.Stream
+		SetKey(key []byte)
+	}
+
+	interval uint64
+	left     uint64 // bytes until the next rekey
+}
+
+// NewRekeying creates and returns a new RekeyingStream that rekeys after
+// every interval bytes. The key and nonce arguments are as for New, and the
+// nonce must still be randomly generated or used only once for key.
+//
+// With a 96-bit nonce, interval must not exceed 2^38 bytes, less the 32 bytes
+// taken by RekeyKeystream. NewRekeying panics if method is not a known
+// RekeyMethod.
+func NewRekeying(key, nonce []byte, interval uint64, method RekeyMethod) (*RekeyingStream, error) {
+	k, err := newKeyStreamAt(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	max := uint64(maxRFCOffset)
+	switch method {
+	case RekeyKeystream:
+		max -= KeySize
+	case RekeyHChaCha20:
+	default:
+		panic("chacha20: invalid RekeyMethod")
+	}
+
+	if interval == 0 || len(k.nonce) == RFCNonceSize && interval > max {
+		return nil, ErrInvalidInterval
+	}
+
+	var s cipher.Stream
+	if len(k.nonce) == RFCNonceSize {
+		s, _ = NewRFC(k.key[:], k.nonce)
+	} else {
+		s, _ = NewDraft(k.key[:], k.nonce)
+	}
+
+	r := &RekeyingStream{
+		key:    k.key,
+		method: method,
+
+		interval: interval,
+		left:     interval,
+	}
+	r.s = s.(interface {
+		cipher.Stream
+		SetKey(key []byte)
+	})
+
+	for i := range k.key {
+		k.key[i] = 0
+	}
+
+	return r, nil
+}
+
+// XORKeyStream XORs each byte in the given slice with a byte from the
+// cipher's key stream, rekeying each time an interval is reached. Dst and src
+// may point to the same memory.
+//
+// XORKeyStream panics if it is called after Close.
+func (r *RekeyingStream) XORKeyStream(dst, src []byte) {
+	if r.s == nil {
+		panic("chacha20: use of closed RekeyingStream")
+	}
+
+	for len(src) != 0 {
+		n := len(src)
+		if uint64(n) > r.left {
+			n = int(r.left)
+		}
+
+		r.s.XORKeyStream(dst[:n], src[:n])
+
+		dst = dst[n:]
+		src = src[n:]
+
+		if r.left -= uint64(n); r.left == 0 {
+			r.rekey()
+		}
+	}
+}
+
+func (r *RekeyingStream) rekey() {
+	if r.method == RekeyKeystream {
+		for i := range r.key {
+			r.key[i] = 0
+		}
+
+		r.s.XORKeyStream(r.key[:], r.key[:])
+	} else {
+		var nonce [HNonceSize]byte
+		binary.LittleEndian.PutUint64(nonce[:], r.epoch)
+
+		var key [KeySize]byte
+		hChaCha20(&key, &r.key, &nonce)
+
+		r.key = key
+
+		for i := range key {
+			key[i] = 0
+		}
+	}
+
+	r.s.SetKey(r.key[:])
+
+	r.epoch++
+	r.left = r.interval
+}
+
+// Close wipes the current key. It always returns nil.
+func (r *RekeyingStream) Close() error {
+	for i := range r.key {
+		r.key[i] = 0
+	}
+
+	if r.s != nil {
+		r.s.SetKey(r.key[:])
+		r.s = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+// rekeyingRef encrypts src as a RekeyingStream would, with a new stream for
+// each interval.
+func rekeyingRef(t *testing.T, key, nonce []byte, interval int, method RekeyMethod, src []byte) []byte {
+	key = append([]byte(nil), key...)
+
+	// For XChaCha20, it is the subkey that is replaced.
+	if len(nonce) == XNonceSize {
+		var hKey [KeySize]byte
+		copy(hKey[:], key)
+
+		var hNonce [HNonceSize]byte
+		copy(hNonce[:], nonce)
+
+		var subKey [HChaChaSize]byte
+		HChaCha20(&subKey, &hKey, &hNonce)
+
+		key, nonce = subKey[:], nonce[HNonceSize:]
+	}
+
+	out := make([]byte, len(src))
+	dst := out
+
+	for epoch := uint64(0); ; epoch++ {
+		c, err := New(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n := interval
+		if n > len(src) {
+			n = len(src)
+		}
+
+		c.XORKeyStream(dst[:n], src[:n])
+
+		if n == len(src) {
+			return out
+		}
+
+		dst, src = dst[n:], src[n:]
+
+		if method == RekeyKeystream {
+			for i := range key {
+				key[i] = 0
+			}
+
+			c.XORKeyStream(key, key)
+		} else {
+			var hKey [KeySize]byte
+			copy(hKey[:], key)
+
+			var hNonce [HNonceSize]byte
+			binary.LittleEndian.PutUint64(hNonce[:], epoch)
+
+			var out [HChaChaSize]byte
+			HChaCha20(&out, &hKey, &hNonce)
+			key = out[:]
+		}
+	}
+}
+
+func testRekeying(t *testing.T, method RekeyMethod) {
+	rand := rand.New(rand.NewSource(0))
+
+	for _, nonceSize := range []int{RFCNonceSize, DraftNonceSize, XNonceSize} {
+		for _, interval := range []int{1, 31, 64, 100, 4096} {
+			key := make([]byte, KeySize)
+			nonce := make([]byte, nonceSize)
+			rand.Read(key)
+			rand.Read(nonce)
+
+			src := make([]byte, 20*1024)
+			rand.Read(src)
+
+			want := rekeyingRef(t, key, nonce, interval, method, src)
+
+			r, err := NewRekeying(key, nonce, uint64(interval), method)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := make([]byte, len(src))
+			for b, d := src, dst; len(b) != 0; {
+				n := rand.Intn(len(b) + 1)
+				r.XORKeyStream(d[:n], b[:n])
+				b, d = b[n:], d[n:]
+			}
+
+			if !bytes.Equal(dst, want) {
+				t.Errorf("nonce size %d, interval %d: output differs from reference", nonceSize, interval)
+			}
+
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestRekeyingKeystream(t *testing.T) {
+	testRekeying(t, RekeyKeystream)
+}
+
+func TestRekeyingHChaCha20(t *testing.T) {
+	testRekeying(t, RekeyHChaCha20)
+}
+
+func TestRekeyingInvalidInterval(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	for _, c := range []struct {
+		nonceSize int
+		interval  uint64
+		method    RekeyMethod
+		err       error
+	}{
+		{DraftNonceSize, 0, RekeyKeystream, ErrInvalidInterval},
+		{RFCNonceSize, 0, RekeyHChaCha20, ErrInvalidInterval},
+		{RFCNonceSize, maxRFCOffset - KeySize, RekeyKeystream, nil},
+		{RFCNonceSize, maxRFCOffset - KeySize + 1, RekeyKeystream, ErrInvalidInterval},
+		{RFCNonceSize, maxRFCOffset, RekeyHChaCha20, nil},
+		{RFCNonceSize, maxRFCOffset + 1, RekeyHChaCha20, ErrInvalidInterval},
+		{DraftNonceSize, maxRFCOffset + 1, RekeyKeystream, nil},
+		{XNonceSize, 1 << 63, RekeyHChaCha20, nil},
+	} {
+		if _, err := NewRekeying(key, make([]byte, c.nonceSize), c.interval, c.method); err != c.err {
+			t.Errorf("nonce size %d, interval %d: expected %v, got %v", c.nonceSize, c.interval, c.err, err)
+		}
+	}
+
+	if _, err := NewRekeying(key[:3], make([]byte, RFCNonceSize), 64, RekeyKeystream); err != ErrInvalidKey {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestRekeyingClose(t *testing.T) {
+	r, err := NewRekeying(make([]byte, KeySize), make([]byte, XNonceSize), 64, RekeyKeystream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf [100]byte
+	r.XORKeyStream(buf[:], buf[:])
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.key != [KeySize]byte{} {
+		t.Error("Close did not wipe the key")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("XORKeyStream did not panic after Close")
+		}
+	}()
+
+	r.XORKeyStream(buf[:], buf[:])
+}
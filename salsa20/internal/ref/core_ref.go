@@ -0,0 +1,120 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// The Salsa20 core transform.
+// An unrolled and inlined implementation in pure Go.
+
+package ref
+
+import "encoding/binary"
+
+// quarterRound is the Salsa20 quarter round. It is small enough to be inlined.
+func quarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	t := a + d
+	b ^= (t << 7) | (t >> 25)
+	t = b + a
+	c ^= (t << 9) | (t >> 23)
+	t = c + b
+	d ^= (t << 13) | (t >> 19)
+	t = d + c
+	a ^= (t << 18) | (t >> 14)
+	return a, b, c, d
+}
+
+// core computes the Salsa20 permutation of input, writing the result to
+// output. If hsalsa is false, input is added to the permuted state as the
+// Salsa20 block function requires, otherwise the raw permutation is output for
+// HSalsa20.
+func core(input, output *[stateSize]uint32, rounds int, hsalsa bool) {
+	var (
+		x00 = input[0]
+		x01 = input[1]
+		x02 = input[2]
+		x03 = input[3]
+		x04 = input[4]
+		x05 = input[5]
+		x06 = input[6]
+		x07 = input[7]
+		x08 = input[8]
+		x09 = input[9]
+		x10 = input[10]
+		x11 = input[11]
+		x12 = input[12]
+		x13 = input[13]
+		x14 = input[14]
+		x15 = input[15]
+	)
+
+	for i := 0; i < rounds; i += 2 {
+		// column round
+		x00, x04, x08, x12 = quarterRound(x00, x04, x08, x12)
+		x05, x09, x13, x01 = quarterRound(x05, x09, x13, x01)
+		x10, x14, x02, x06 = quarterRound(x10, x14, x02, x06)
+		x15, x03, x07, x11 = quarterRound(x15, x03, x07, x11)
+
+		// row round
+		x00, x01, x02, x03 = quarterRound(x00, x01, x02, x03)
+		x05, x06, x07, x04 = quarterRound(x05, x06, x07, x04)
+		x10, x11, x08, x09 = quarterRound(x10, x11, x08, x09)
+		x15, x12, x13, x14 = quarterRound(x15, x12, x13, x14)
+	}
+
+	if hsalsa {
+		output[0] = x00
+		output[1] = x01
+		output[2] = x02
+		output[3] = x03
+		output[4] = x04
+		output[5] = x05
+		output[6] = x06
+		output[7] = x07
+		output[8] = x08
+		output[9] = x09
+		output[10] = x10
+		output[11] = x11
+		output[12] = x12
+		output[13] = x13
+		output[14] = x14
+		output[15] = x15
+		return
+	}
+
+	output[0] = x00 + input[0]
+	output[1] = x01 + input[1]
+	output[2] = x02 + input[2]
+	output[3] = x03 + input[3]
+	output[4] = x04 + input[4]
+	output[5] = x05 + input[5]
+	output[6] = x06 + input[6]
+	output[7] = x07 + input[7]
+	output[8] = x08 + input[8]
+	output[9] = x09 + input[9]
+	output[10] = x10 + input[10]
+	output[11] = x11 + input[11]
+	output[12] = x12 + input[12]
+	output[13] = x13 + input[13]
+	output[14] = x14 + input[14]
+	output[15] = x15 + input[15]
+}
+
+// xorBlock XORs one block of keystream with src, writing the result to dst,
+// and advances the block counter. Both dst and src must be at least blockSize
+// bytes long.
+func xorBlock(state *[stateSize]uint32, dst, src []byte, rounds int) {
+	_, _ = dst[blockSize-1], src[blockSize-1] // bounds check hint to compiler
+
+	var x [stateSize]uint32
+	core(state, &x, rounds, false)
+
+	for i, v := range x {
+		binary.LittleEndian.PutUint32(dst[i*wordSize:], binary.LittleEndian.Uint32(src[i*wordSize:])^v)
+	}
+
+	// Words 8 and 9 are treated as a single 64-bit block counter.
+	state[8]++
+	if state[8] == 0 {
+		state[9]++
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package ref provides a pure Go implementation of Salsa20, the stream cipher
+// ChaCha20 is a variant of.
+//
+// For more information, see http://cr.yp.to/snuffle.html
+package ref
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+
+	"github.com/tmthrgd/chacha20/internal/xor"
+)
+
+const (
+	// KeySize is the length of Salsa20 keys, in bytes.
+	KeySize = 32
+	// NonceSize is the length of Salsa20 nonces, in bytes.
+	NonceSize = 8
+	// XNonceSize is the length of XSalsa20 nonces, in bytes.
+	XNonceSize = 24
+	// HNonceSize is the length of HSalsa20 nonces, in bytes.
+	HNonceSize = 16
+	// HSalsaSize is the length of HSalsa20 output, in bytes.
+	HSalsaSize = blockSize / 2
+)
+
+// NewRounds creates and returns a new cipher.Stream for Salsa20 reduced to the
+// given number of rounds, which must be 8, 12 or 20. The key argument must be
+// 256 bits long, and the nonce argument must be either 64 or 192 bits long. If
+// the nonce is 192 bits long, XSalsa20 is used. The subkey is always derived
+// with the full 20 rounds of HSalsa20.
+func NewRounds(key, nonce []byte, rounds int) (cipher.Stream, error) {
+	if len(key) != KeySize {
+		panic("invalid key length")
+	}
+
+	switch rounds {
+	case 8, 12, 20:
+	default:
+		panic("invalid number of rounds")
+	}
+
+	s := &stream{rounds: rounds}
+
+	switch len(nonce) {
+	case NonceSize:
+		s.init(key, nonce)
+	case XNonceSize:
+		// Call HSalsa to derive the subkey using the key and the first
+		// 16 bytes of the nonce.
+		var subKey [HSalsaSize]byte
+		HSalsa20(&subKey, key, nonce[:HNonceSize])
+
+		// Initialize the state using the subkey and the remaining nonce.
+		s.init(subKey[:], nonce[HNonceSize:])
+	default:
+		panic("invalid nonce length")
+	}
+
+	return s, nil
+}
+
+// HSalsa20 derives a 256-bit subkey from key and a 128-bit nonce, writing it
+// to out.
+func HSalsa20(out *[HSalsaSize]byte, key, nonce []byte) {
+	if len(key) != KeySize {
+		panic("invalid key length")
+	}
+
+	if len(nonce) != HNonceSize {
+		panic("invalid nonce length")
+	}
+
+	var s stream
+	s.init(key, nonce)
+
+	var x [stateSize]uint32
+	core(&s.state, &x, 20, true)
+
+	binary.LittleEndian.PutUint32(out[0:], x[0])
+	binary.LittleEndian.PutUint32(out[4:], x[5])
+	binary.LittleEndian.PutUint32(out[8:], x[10])
+	binary.LittleEndian.PutUint32(out[12:], x[15])
+	binary.LittleEndian.PutUint32(out[16:], x[6])
+	binary.LittleEndian.PutUint32(out[20:], x[7])
+	binary.LittleEndian.PutUint32(out[24:], x[8])
+	binary.LittleEndian.PutUint32(out[28:], x[9])
+}
+
+type stream struct {
+	state  [stateSize]uint32 // the state as an array of 16 32-bit words
+	rounds int
+
+	block  [blockSize]byte // keystream left over from a partial block
+	buffer []byte          // the unused portion of block
+}
+
+func (s *stream) XORKeyStream(dst, src []byte) {
+	if len(src) == 0 {
+		return
+	}
+
+	if len(s.buffer) != 0 {
+		i := xor.Bytes(dst, s.buffer, src)
+
+		b := s.buffer[:i]
+		for j := range b {
+			b[j] = 0
+		}
+
+		s.buffer = s.buffer[i:]
+		src = src[i:]
+		dst = dst[i:]
+
+		if len(src) == 0 {
+			return
+		}
+	}
+
+	for len(src) >= blockSize {
+		xorBlock(&s.state, dst, src, s.rounds)
+
+		src = src[blockSize:]
+		dst = dst[blockSize:]
+	}
+
+	if todo := len(src); todo != 0 {
+		// The block buffer is always zero here, so this leaves the
+		// keystream for the next block in it.
+		xorBlock(&s.state, s.block[:], s.block[:], s.rounds)
+
+		xor.Bytes(dst, s.block[:todo], src)
+
+		b := s.block[:todo]
+		for i := range b {
+			b[i] = 0
+		}
+
+		s.buffer = s.block[todo:]
+	}
+}
+
+func (s *stream) init(key []byte, nonce []byte) {
+	// the magic constants for 256-bit keys
+	s.state[0] = 0x61707865
+	s.state[5] = 0x3320646e
+	s.state[10] = 0x79622d32
+	s.state[15] = 0x6b206574
+
+	s.state[1] = binary.LittleEndian.Uint32(key[0:])
+	s.state[2] = binary.LittleEndian.Uint32(key[4:])
+	s.state[3] = binary.LittleEndian.Uint32(key[8:])
+	s.state[4] = binary.LittleEndian.Uint32(key[12:])
+	s.state[11] = binary.LittleEndian.Uint32(key[16:])
+	s.state[12] = binary.LittleEndian.Uint32(key[20:])
+	s.state[13] = binary.LittleEndian.Uint32(key[24:])
+	s.state[14] = binary.LittleEndian.Uint32(key[28:])
+
+	s.state[6] = binary.LittleEndian.Uint32(nonce[0:])
+	s.state[7] = binary.LittleEndian.Uint32(nonce[4:])
+
+	switch len(nonce) {
+	case NonceSize:
+		// Words 8 and 9 are the block counter.
+		s.state[8] = 0
+		s.state[9] = 0
+	case HNonceSize:
+		// HSalsa20 takes all 16 bytes of its nonce in words 6-9.
+		s.state[8] = binary.LittleEndian.Uint32(nonce[8:])
+		s.state[9] = binary.LittleEndian.Uint32(nonce[12:])
+	default:
+		// Never happens, the ctors validate the nonce length.
+		panic("invalid nonce size")
+	}
+}
+
+const (
+	wordSize  = 4                    // the size of Salsa20's words
+	stateSize = 16                   // the size of Salsa20's state, in words
+	blockSize = stateSize * wordSize // the size of Salsa20's block, in bytes
+)
@@ -0,0 +1,111 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package salsa20 provides an SSE2/pure-Go implementation of Salsa20, the
+// stream cipher ChaCha20 is a variant of, and of XSalsa20 and HSalsa20.
+//
+// The API mirrors that of github.com/tmthrgd/chacha20.
+//
+// For more information, see http://cr.yp.to/snuffle.html
+package salsa20
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+const (
+	// KeySize is the length of Salsa20 keys, in bytes.
+	KeySize = 32
+
+	// NonceSize is the length of Salsa20 nonces, in bytes.
+	NonceSize = 8
+
+	// XNonceSize is the length of XSalsa20 nonces, in bytes.
+	XNonceSize = 24
+
+	// HNonceSize is the length of HSalsa20 nonces, in bytes.
+	HNonceSize = 16
+
+	// HSalsaSize is the length of HSalsa20 output, in bytes.
+	HSalsaSize = 32
+)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	// ErrInvalidNonce is returned when the provided nonce is not NonceSize or
+	// XNonceSize bytes long.
+	ErrInvalidNonce = errors.New("invalid nonce length")
+
+	// ErrInvalidRounds is returned when the number of rounds is not 8, 12
+	// or 20.
+	ErrInvalidRounds = errors.New("invalid number of rounds")
+)
+
+// New creates and returns a new cipher.Stream. The key argument must be 256
+// bits long, and the nonce argument must be either 64 or 192 bits long. The
+// nonce must be randomly generated or used only once. If the nonce argument is
+// 64 bits long, New behaves like NewSalsa. If the nonce argument is 192 bits
+// long, New behaves like NewXSalsa.
+func New(key, nonce []byte) (cipher.Stream, error) {
+	return NewRounds(key, nonce, 20)
+}
+
+// NewSalsa creates and returns a new Salsa20 cipher.Stream. The key argument
+// must be 256 bits long, and the nonce argument must be 64 bits long. The
+// nonce must be randomly generated or used only once. This Stream instance
+// must not be used to encrypt more than 2^70 bytes (~1 zettabyte).
+func NewSalsa(key, nonce []byte) (cipher.Stream, error) {
+	if len(nonce) != NonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	return NewRounds(key, nonce, 20)
+}
+
+// NewXSalsa creates and returns a new XSalsa20 cipher.Stream. The key argument
+// must be 256 bits long, and the nonce argument must be 192 bits long. The
+// nonce must be randomly generated or only used once. This Stream instance
+// must not be used to encrypt more than 2^70 bytes (~1 zettabyte).
+func NewXSalsa(key, nonce []byte) (cipher.Stream, error) {
+	if len(nonce) != XNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	return NewRounds(key, nonce, 20)
+}
+
+// NewRounds creates and returns a new cipher.Stream for Salsa20/8, Salsa20/12
+// or Salsa20/20, as selected by rounds. The key and nonce arguments are as for
+// New. For 192-bit nonces the subkey is always derived with the full 20 rounds
+// of HSalsa20, only the keystream is generated with the reduced number of
+// rounds.
+//
+// In most cases New, NewSalsa or NewXSalsa should be used instead.
+func NewRounds(key, nonce []byte, rounds int) (cipher.Stream, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	if len(nonce) != NonceSize && len(nonce) != XNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	switch rounds {
+	case 8, 12, 20:
+	default:
+		return nil, ErrInvalidRounds
+	}
+
+	return newStream(key, nonce, rounds), nil
+}
+
+// HSalsa20 derives a 256-bit subkey from a 256-bit key and a 128-bit nonce as
+// XSalsa20 does, writing it to out.
+func HSalsa20(out *[HSalsaSize]byte, key *[KeySize]byte, nonce *[HNonceSize]byte) {
+	hSalsa20(out, key, nonce)
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build amd64,!gccgo,!appengine
+
+package salsa20
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+
+	"github.com/tmthrgd/chacha20/internal/xor"
+)
+
+// diagonal maps the index of each word of the Salsa20 state to its index in
+// the order used by salsa20_x64_amd64.s.
+var diagonal = [16]int{0, 13, 10, 7, 4, 1, 14, 11, 8, 5, 2, 15, 12, 9, 6, 3}
+
+// initState sets the state for the key and either an 8 byte nonce with a zero
+// block counter, or a 16 byte HSalsa20 nonce.
+func initState(state *[64]byte, key, nonce []byte) {
+	var in [64]byte
+
+	// the magic constants for 256-bit keys
+	copy(in[0:], "expa")
+	copy(in[20:], "nd 3")
+	copy(in[40:], "2-by")
+	copy(in[60:], "te k")
+
+	copy(in[4:20], key[:16])
+	copy(in[44:60], key[16:])
+	copy(in[24:40], nonce)
+
+	for i, j := range diagonal {
+		binary.LittleEndian.PutUint32(state[j*4:], binary.LittleEndian.Uint32(in[i*4:]))
+	}
+}
+
+func newStream(key, nonce []byte, rounds int) cipher.Stream {
+	s := &stream{rounds: uint64(rounds)}
+
+	if len(nonce) == XNonceSize {
+		var hKey [KeySize]byte
+		copy(hKey[:], key)
+
+		var hNonce [HNonceSize]byte
+		copy(hNonce[:], nonce[:HNonceSize])
+
+		var subKey [HSalsaSize]byte
+		hSalsa20(&subKey, &hKey, &hNonce)
+
+		initState(&s.state, subKey[:], nonce[HNonceSize:])
+	} else {
+		initState(&s.state, key, nonce)
+	}
+
+	return s
+}
+
+func hSalsa20(out *[HSalsaSize]byte, key *[KeySize]byte, nonce *[HNonceSize]byte) {
+	var state [64]byte
+	initState(&state, key[:], nonce[:])
+
+	hsalsa_20_sse2(&state, out)
+}
+
+type stream struct {
+	state  [64]byte
+	rounds uint64
+
+	backing [64]byte
+	buffer  []byte
+}
+
+func (s *stream) XORKeyStream(dst, src []byte) {
+	if len(src) == 0 {
+		return
+	}
+
+	if len(s.buffer) != 0 {
+		i := xor.Bytes(dst, s.buffer, src)
+
+		b := s.buffer[:i]
+		for j := range b {
+			b[j] = 0
+		}
+
+		s.buffer = s.buffer[i:]
+		src = src[i:]
+		dst = dst[i:]
+
+		if len(src) == 0 {
+			return
+		}
+	}
+
+	salsa_20_core_sse2(&dst[0], &src[0], uint64(len(src)), &s.state, s.rounds)
+
+	if todo := len(src) & 63; todo != 0 {
+		// The backing buffer is always zero here, so this leaves the
+		// keystream for the next block in it.
+		salsa_20_core_sse2(&s.backing[0], &s.backing[0], 64, &s.state, s.rounds)
+
+		xor.Bytes(dst[len(src)-todo:], s.backing[:todo], src[len(src)-todo:])
+
+		b := s.backing[:todo]
+		for i := range b {
+			b[i] = 0
+		}
+
+		s.buffer = s.backing[todo:]
+	}
+}
+
+//go:generate perl salsa20_x64.pl golang-no-avx salsa20_x64_amd64.s
+
+// This function is implemented in salsa20_x64_amd64.s
+//go:noescape
+func salsa_20_core_sse2(out, in *byte, in_len uint64, state *[64]byte, rounds uint64)
+
+// This function is implemented in salsa20_x64_amd64.s
+//go:noescape
+func hsalsa_20_sse2(state *[64]byte, out *[HSalsaSize]byte)
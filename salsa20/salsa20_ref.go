@@ -0,0 +1,23 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build !amd64 gccgo appengine
+
+package salsa20
+
+import (
+	"crypto/cipher"
+
+	"github.com/tmthrgd/chacha20/salsa20/internal/ref"
+)
+
+func newStream(key, nonce []byte, rounds int) cipher.Stream {
+	s, _ := ref.NewRounds(key, nonce, rounds)
+	return s
+}
+
+func hSalsa20(out *[HSalsaSize]byte, key *[KeySize]byte, nonce *[HNonceSize]byte) {
+	ref.HSalsa20(out, key[:], nonce[:])
+}
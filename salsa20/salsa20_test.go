@@ -0,0 +1,441 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package salsa20
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/tmthrgd/chacha20/salsa20/internal/ref"
+	xsalsa20 "golang.org/x/crypto/salsa20"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+type testVector struct {
+	key       []byte
+	nonce     []byte
+	keyStream []byte
+	counter   uint64
+}
+
+// The 256-bit key set 1, vector 0 and set 6, vector 0 cases from the eSTREAM
+// (ECRYPT) Salsa20 test vectors, the second also from block 3 onwards.
+var salsa20TestVectors = []testVector{
+	testVector{
+		mustHexDecode("8000000000000000000000000000000000000000000000000000000000000000"),
+		mustHexDecode("0000000000000000"),
+		mustHexDecode("e3be8fdd8beca2e3ea8ef9475b29a6e7003951e1097a5c38d23b7a5fad9f6844" +
+			"b22c97559e2723c7cbbd3fe4fc8d9a0744652a83e72a9c461876af4d7ef1a117" +
+			"8da2b74eef1b6283e7e20166abcae538e9716e4669e2816b6b20c5c356802001" +
+			"cc1403a9a117d12a2669f456366d6ebb0f1246f1265150f793cdb4b253e348ae"),
+		0,
+	},
+	testVector{
+		mustHexDecode("0053a6f94c9ff24598eb3e91e4378add3083d6297ccf2275c81b6ec11467ba0d"),
+		mustHexDecode("0d74db42a91077de"),
+		mustHexDecode("f5fad53f79f9df58c4aea0d0ed9a9601f278112ca7180d565b420a48019670ea" +
+			"f24ce493a86263f677b46ace1924773d2bb25571e1aa8593758fc382b1280b71" +
+			"86b8fe274643aa1e1e54b2fd37620b2284ebbebc53e2d61247bdc698a18f9926" +
+			"3bded15fb9fc1218314466472b5b9b1fd0b3a38c01a454a9f332b284a674566a"),
+		0,
+	},
+	testVector{
+		mustHexDecode("0053a6f94c9ff24598eb3e91e4378add3083d6297ccf2275c81b6ec11467ba0d"),
+		mustHexDecode("0d74db42a91077de"),
+		mustHexDecode("97e2d8f2b57393936d3dbfe613bca6f852a220624d20763db8628e5a560005d0" +
+			"d1875b2c1caa688d72b82d214732cd31d0c253b3051f58c6420b676288d2c254" +
+			"a5777721b1cd7701e8558401e97000d56f842d657ca155c8ff589ee54d5b8138" +
+			"7fdc232a4bc4ca6db57fa5159dc554e2e0a62c2706ec6ba4367b67bd0c3096a6"),
+		3,
+	},
+}
+
+// The same cases for Salsa20/12 and Salsa20/8, generated with an independent
+// implementation.
+var salsa12TestVectors = []testVector{
+	testVector{
+		mustHexDecode("8000000000000000000000000000000000000000000000000000000000000000"),
+		mustHexDecode("0000000000000000"),
+		mustHexDecode("afe411ed1c4e07e4d0cde3b33e31ec190fa4cc796a58bafb848ead8d07d02cd2" +
+			"d4b6f9f30cb0b57007e3733895cc8d1060107975acaeeb689b6cf614ab64a3d6" +
+			"08e397d664b03a12cb31711c6a3f1528dd0031b6adc87574a2ad8e8f470f4aa1" +
+			"8f21c6ed7b5178a9978847dff457edd5192c7d70b7a8636da61a98eb2053c285"),
+		0,
+	},
+	testVector{
+		mustHexDecode("0053a6f94c9ff24598eb3e91e4378add3083d6297ccf2275c81b6ec11467ba0d"),
+		mustHexDecode("0d74db42a91077de"),
+		mustHexDecode("52e20cf8775ae882f200c2999fe4ba31a7a18f1d5c9716191d123175e147bd4e" +
+			"8ca6ed166ce0fc8e65a5ca608420fc6544c9700a0f2138e8c1a286fb8c1fbfa0" +
+			"f08ad1921c3f54d6d3a1d13e9c3e4ac4ea8aac90398e4928f9154684292ebb1b" +
+			"1ba74e85616a025cbf159a568ee10f4340757007fef9f641fba561ba2fe9feed"),
+		0,
+	},
+	testVector{
+		mustHexDecode("0053a6f94c9ff24598eb3e91e4378add3083d6297ccf2275c81b6ec11467ba0d"),
+		mustHexDecode("0d74db42a91077de"),
+		mustHexDecode("ba7a557c442aed7665813a4919c533ab9c6de02534efa290416ca65c21c71253" +
+			"c89c7c36723a8edab17db6d976a8967847cd4efcf1673f2c8487af2805e99c1c" +
+			"ff407ed8579d36ea78c5cf8b6c1028a33d3216059fb2165e87da54f8409b7089" +
+			"380283c7f17cf9152b442c8cdb7a731a83ee209a143e2c1e53785b59196ac6e4"),
+		3,
+	},
+}
+
+var salsa8TestVectors = []testVector{
+	testVector{
+		mustHexDecode("8000000000000000000000000000000000000000000000000000000000000000"),
+		mustHexDecode("0000000000000000"),
+		mustHexDecode("b1f599e9b0d96df436ae31f5ef589565b92d245db5a1d4c7a78e5e8d0146f8a4" +
+			"9d326c1a3bf50c052c9c8f114dc74972c4469591e31c9ed11927aa9871f38583" +
+			"150767f120f97365c8724d01ff69c19b595b6ec7ab21e26a45998898ddb05a06" +
+			"6d24d896dbadcba13b6dec08daed1344ff897bcc9f7ff134b486612d1e2e7b7a"),
+		0,
+	},
+	testVector{
+		mustHexDecode("0053a6f94c9ff24598eb3e91e4378add3083d6297ccf2275c81b6ec11467ba0d"),
+		mustHexDecode("0d74db42a91077de"),
+		mustHexDecode("420609c7cdda902d6fa7cb264ab0c89a030db2e4bf18d179bf7a3114f2266e2d" +
+			"5519f123a079233b5ff82481e508828dab2620e5521056faf60be1489a716971" +
+			"26056ced8419ba6a02476bc0d745aab55568666c69c490df5579d151cccd6124" +
+			"65b0d5103debdaf777fdf90d742e46a392d9fc70dd0545ea8284aac8e9f6df59"),
+		0,
+	},
+	testVector{
+		mustHexDecode("0053a6f94c9ff24598eb3e91e4378add3083d6297ccf2275c81b6ec11467ba0d"),
+		mustHexDecode("0d74db42a91077de"),
+		mustHexDecode("d885a3ba3545c5132f2eeb9fd7916660079c5c007093bcfba7bd0b5fb35b594c" +
+			"cf84745b53860a5c140d9ae3de4a02fb75262164d1b46fa9234a1c0e77a589a0" +
+			"34700b65fa606df97bae3301d7cbd0a6d99b9afb6175b100597cb2ac64c10f58" +
+			"65a8bd9fe3d52b20439c080d00c728657bb429912518822d6bb469cecb6c2671"),
+		3,
+	},
+}
+
+// generated with an independent implementation
+var xSalsaTestVectors = []testVector{
+	testVector{
+		mustHexDecode("0000000000000000000000000000000000000000000000000000000000000000"),
+		mustHexDecode("000000000000000000000000000000000000000000000000"),
+		mustHexDecode("ba6e26df4b2ea2cf64d2d3636623b5f45c8636d9998d194d605ac3ba3cff1512" +
+			"c63ebbfffe85ce2cebdef7dc42f494576d05bdd7b929ebb045f2a793f740277d" +
+			"05439702d7bfea6b0419b7b7d02af740b47288d28a90d49db233762dc465e2a9" +
+			"791efbb232cf4c845ef0341f4e3b4f334e07d1509a6f00e77e3bf2f4f7424c63"),
+		0,
+	},
+	testVector{
+		mustHexDecode("746869732069732033322d62797465206b657920666f72207873616c73613230"),
+		mustHexDecode("32342d62797465206e6f6e636520666f72207873616c7361"),
+		mustHexDecode("4848297feb1fb52fb66d81609bd547fabcbe7026edc8b5e5e449d088bfa69c08" +
+			"8f5d8da1d791267c2c195a7f8cae9c4b4050d08ce6d3a151ec265f3a58e47648" +
+			"124228e79a73635812f586dbf775c0fc05d789e9b62fdf7e7c134f7b16ef0f39" +
+			"f639949e0d820cc2e9402f41d4bc1a59bb54f2f1ce21472b880927a09304c4f3"),
+		0,
+	},
+}
+
+func testSalsa20(t *testing.T, newSalsa20 func(key, nonce []byte) (cipher.Stream, error), vectors []testVector) {
+	for i, vector := range vectors {
+		t.Run(fmt.Sprintf("vector%d", i), func(t *testing.T) {
+			c, err := newSalsa20(vector.key, vector.nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var block [64]byte
+			for i := uint64(0); i < vector.counter; i++ {
+				c.XORKeyStream(block[:], block[:])
+			}
+
+			dst := make([]byte, len(vector.keyStream))
+			c.XORKeyStream(dst, dst)
+
+			if bytes.Equal(vector.keyStream, dst) {
+				return
+			}
+
+			t.Error("Bad keystream:")
+			t.Errorf("\texpected %x", vector.keyStream)
+			t.Errorf("\twas      %x", dst)
+
+			for i, v := range vector.keyStream {
+				if dst[i] != v {
+					t.Logf("\tMismatch at offset %d: %x vs %x", i, v, dst[i])
+					break
+				}
+			}
+		})
+	}
+}
+
+func withRounds(newRounds func(key, nonce []byte, rounds int) (cipher.Stream, error), rounds int) func(key, nonce []byte) (cipher.Stream, error) {
+	return func(key, nonce []byte) (cipher.Stream, error) {
+		return newRounds(key, nonce, rounds)
+	}
+}
+
+func TestSalsa20(t *testing.T) {
+	testSalsa20(t, NewSalsa, salsa20TestVectors)
+}
+
+func TestSalsa20Go(t *testing.T) {
+	testSalsa20(t, withRounds(ref.NewRounds, 20), salsa20TestVectors)
+}
+
+func TestSalsa2012(t *testing.T) {
+	testSalsa20(t, withRounds(NewRounds, 12), salsa12TestVectors)
+}
+
+func TestSalsa2012Go(t *testing.T) {
+	testSalsa20(t, withRounds(ref.NewRounds, 12), salsa12TestVectors)
+}
+
+func TestSalsa208(t *testing.T) {
+	testSalsa20(t, withRounds(NewRounds, 8), salsa8TestVectors)
+}
+
+func TestSalsa208Go(t *testing.T) {
+	testSalsa20(t, withRounds(ref.NewRounds, 8), salsa8TestVectors)
+}
+
+func TestXSalsa20(t *testing.T) {
+	testSalsa20(t, NewXSalsa, xSalsaTestVectors)
+}
+
+func TestXSalsa20Go(t *testing.T) {
+	testSalsa20(t, withRounds(ref.NewRounds, 20), xSalsaTestVectors)
+}
+
+func TestHSalsa20(t *testing.T) {
+	// From the crypto_box test in NaCl, the first subkey derived from the
+	// shared secret.
+	var key [KeySize]byte
+	copy(key[:], mustHexDecode("4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742"))
+
+	var nonce [HNonceSize]byte
+
+	expected := mustHexDecode("1b27556473e985d462cd51197a9a46c76009549eac6474f206c4ee0844f68389")
+
+	var out [HSalsaSize]byte
+	HSalsa20(&out, &key, &nonce)
+
+	if !bytes.Equal(out[:], expected) {
+		t.Errorf("HSalsa20: expected %x, was %x", expected, out)
+	}
+
+	var outRef [HSalsaSize]byte
+	ref.HSalsa20(&outRef, key[:], nonce[:])
+
+	if !bytes.Equal(outRef[:], expected) {
+		t.Errorf("ref.HSalsa20: expected %x, was %x", expected, outRef)
+	}
+}
+
+func testBadSize(t *testing.T, newSalsa20 func(key, nonce []byte) (cipher.Stream, error), keySize, nonceSize int, expect error) {
+	key := make([]byte, keySize)
+	nonce := make([]byte, nonceSize)
+
+	_, err := newSalsa20(key, nonce)
+
+	if err != expect {
+		t.Errorf("expected error %v, got %v", expect, err)
+	}
+}
+
+func TestRightSizes(t *testing.T) {
+	testBadSize(t, New, KeySize, NonceSize, nil)
+	testBadSize(t, New, KeySize, XNonceSize, nil)
+	testBadSize(t, NewSalsa, KeySize, NonceSize, nil)
+	testBadSize(t, NewXSalsa, KeySize, XNonceSize, nil)
+}
+
+func TestBadKeySize(t *testing.T) {
+	testBadSize(t, New, 3, NonceSize, ErrInvalidKey)
+	testBadSize(t, NewSalsa, 3, NonceSize, ErrInvalidKey)
+	testBadSize(t, NewXSalsa, 3, XNonceSize, ErrInvalidKey)
+}
+
+func TestBadNonceSize(t *testing.T) {
+	testBadSize(t, New, KeySize, 3, ErrInvalidNonce)
+	testBadSize(t, NewSalsa, KeySize, XNonceSize, ErrInvalidNonce)
+	testBadSize(t, NewXSalsa, KeySize, NonceSize, ErrInvalidNonce)
+}
+
+func TestBadRounds(t *testing.T) {
+	for _, rounds := range []int{0, 7, 10, 24} {
+		testBadSize(t, withRounds(NewRounds, rounds), KeySize, NonceSize, ErrInvalidRounds)
+	}
+}
+
+// xcrypto wraps the one-shot golang.org/x/crypto/salsa20 API in a
+// cipher.Stream that may only be used once.
+type xcrypto struct {
+	key   [32]byte
+	nonce []byte
+}
+
+func newXCrypto(key, nonce []byte) (cipher.Stream, error) {
+	x := &xcrypto{nonce: nonce}
+	copy(x.key[:], key)
+	return x, nil
+}
+
+func (x *xcrypto) XORKeyStream(dst, src []byte) {
+	xsalsa20.XORKeyStream(dst, src, x.nonce, &x.key)
+}
+
+func testEqual(t *testing.T, new1, new2 func(key, nonce []byte) (cipher.Stream, error), nonceSize int, split bool, label1, label2 string) {
+	t.Parallel()
+
+	if err := quick.Check(func(key, nonce, src []byte, splits []int) bool {
+		c1, err := new1(key, nonce)
+		if err != nil {
+			t.Error(err)
+			return false
+		}
+
+		c2, err := new2(key, nonce)
+		if err != nil {
+			t.Error(err)
+			return false
+		}
+
+		dst1 := make([]byte, len(src))
+		dst2 := make([]byte, len(src))
+
+		// Only c1 is split across calls, c2 may be one-shot.
+		var off int
+		for _, n := range splits {
+			if off+n > len(src) {
+				break
+			}
+
+			c1.XORKeyStream(dst1[off:off+n], src[off:off+n])
+			off += n
+		}
+
+		c1.XORKeyStream(dst1[off:], src[off:])
+		c2.XORKeyStream(dst2, src)
+
+		if bytes.Equal(dst1, dst2) {
+			return true
+		}
+
+		t.Error("Bad output:")
+		t.Errorf("\t%s: %x", label2, dst2)
+		t.Errorf("\t%s: %x", label1, dst1)
+
+		for i, v := range dst2 {
+			if dst1[i] != v {
+				t.Logf("\tMismatch at offset %d: %x vs %x", i, v, dst1[i])
+				break
+			}
+		}
+
+		return false
+	}, &quick.Config{
+		Values: func(args []reflect.Value, rand *rand.Rand) {
+			key := make([]byte, KeySize)
+			rand.Read(key)
+			args[0] = reflect.ValueOf(key)
+
+			nonce := make([]byte, nonceSize)
+			rand.Read(nonce)
+			args[1] = reflect.ValueOf(nonce)
+
+			src := make([]byte, 1+rand.Intn(1024*1024))
+			rand.Read(src)
+			args[2] = reflect.ValueOf(src)
+
+			var splits []int
+			if split {
+				splits = make([]int, rand.Intn(16))
+				for i := range splits {
+					splits[i] = rand.Intn(200)
+				}
+			}
+			args[3] = reflect.ValueOf(splits)
+		},
+
+		MaxCountScale: 0.5,
+	}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEqualOneShot(t *testing.T) {
+	testEqual(t, New, newXCrypto, NonceSize, false, "tmthrgd/chacha20/salsa20", "x/crypto/salsa20")
+}
+
+func TestEqualMultiUse(t *testing.T) {
+	testEqual(t, New, newXCrypto, NonceSize, true, "tmthrgd/chacha20/salsa20", "x/crypto/salsa20")
+}
+
+func TestEqualMultiUseGo(t *testing.T) {
+	testEqual(t, withRounds(ref.NewRounds, 20), newXCrypto, NonceSize, true, "tmthrgd/chacha20/salsa20/internal/ref", "x/crypto/salsa20")
+}
+
+func TestXEqualOneShot(t *testing.T) {
+	testEqual(t, New, newXCrypto, XNonceSize, false, "tmthrgd/chacha20/salsa20", "x/crypto/salsa20")
+}
+
+func TestXEqualMultiUse(t *testing.T) {
+	testEqual(t, New, newXCrypto, XNonceSize, true, "tmthrgd/chacha20/salsa20", "x/crypto/salsa20")
+}
+
+func TestXEqualMultiUseGo(t *testing.T) {
+	testEqual(t, withRounds(ref.NewRounds, 20), newXCrypto, XNonceSize, true, "tmthrgd/chacha20/salsa20/internal/ref", "x/crypto/salsa20")
+}
+
+func TestReducedRoundsEqual(t *testing.T) {
+	for _, rounds := range []int{8, 12} {
+		rounds := rounds
+		t.Run(fmt.Sprintf("rounds%d", rounds), func(t *testing.T) {
+			testEqual(t, withRounds(NewRounds, rounds), withRounds(ref.NewRounds, rounds), NonceSize, true, "tmthrgd/chacha20/salsa20", "tmthrgd/chacha20/salsa20/internal/ref")
+		})
+	}
+}
+
+func benchmarkSalsa20(b *testing.B, newSalsa20 func(key, nonce []byte) (cipher.Stream, error), size int) {
+	var key [KeySize]byte
+	var nonce [NonceSize]byte
+
+	c, err := newSalsa20(key[:], nonce[:])
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	buf := make([]byte, size)
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.XORKeyStream(buf, buf)
+	}
+}
+
+func BenchmarkSalsa20(b *testing.B) {
+	benchmarkSalsa20(b, New, 1024*1024)
+}
+
+func BenchmarkSalsa20Go(b *testing.B) {
+	benchmarkSalsa20(b, withRounds(ref.NewRounds, 20), 1024*1024)
+}
@@ -0,0 +1,324 @@
+#!/usr/bin/env perl
+
+##############################################################################
+#                                                                            #
+# Copyright 2017 Tom Thorogood. All rights reserved.                         #
+# Use of this source code is governed by a                                   #
+# Modified BSD License license that can be found in                          #
+# the LICENSE file.                                                          #
+#                                                                            #
+##############################################################################
+
+# An SSE2 implementation of the Salsa20 core.
+#
+# The state is kept in memory in the "diagonal" order used by the SIMD
+# implementations in SUPERCOP, so that each quarter round of a column round
+# operates on whole rows:
+#
+#	a = x0  x5  x10 x15
+#	b = x4  x9  x14 x3
+#	c = x8  x13 x2  x7
+#	d = x12 x1  x6  x11
+#
+# After rotating b, c and d by a word, the quarter rounds of a row round also
+# operate on whole rows, with the roles of b and d exchanged.
+
+$flavour = shift;
+$output  = shift;
+if ($flavour =~ /\./) { $output = $flavour; undef $flavour; }
+
+$win64=0; $win64=1 if ($flavour =~ /[nm]asm|mingw64/ || $output =~ /\.asm$/);
+
+$0 =~ m/(.*[\/\\])[^\/\\]+$/; $dir=$1;
+( $xlate="${dir}x86_64-xlate.pl" and -f $xlate ) or
+( $xlate="${dir}../x86_64-xlate.pl" and -f $xlate) or
+( $xlate="${dir}../../perlasm/x86_64-xlate.pl" and -f $xlate) or
+die "can't locate x86_64-xlate.pl";
+
+open OUT,"| \"$^X\" $xlate $flavour $output";
+*STDOUT=*OUT;
+
+if ($flavour =~ /^golang/) {
+    $code.=<<___;
+// Created by salsa20_x64.pl - DO NOT EDIT
+// perl salsa20_x64.pl golang-no-avx salsa20_x64_amd64.s
+
+// +build amd64,!gccgo,!appengine
+
+#include "textflag.h"
+
+___
+}
+
+# Two blocks are computed at once where possible, with their instructions
+# interleaved, as a single block is limited by the latency of each quarter
+# round rather than throughput.
+my @b0=map("%xmm$_",(0..5));
+my @b1=map("%xmm$_",(6..11));
+
+# rotate adds x and y into a temporary, rotates it left by n bits and XORs the
+# result into z.
+sub rotate {
+my ($z,$x,$y,$n,$t0,$t1)=@_;
+return (
+"movdqa $x, $t0",
+"paddd $y, $t0",
+"movdqa $t0, $t1",
+"pslld \$$n, $t0",
+"psrld \$".(32-$n).", $t1",
+"pxor $t0, $z",
+"pxor $t1, $z");
+}
+
+sub quarter_round {
+my ($a,$b,$c,$d,$t0,$t1)=@_;
+return (
+&rotate($b,$a,$d,7,$t0,$t1),
+&rotate($c,$b,$a,9,$t0,$t1),
+&rotate($d,$c,$b,13,$t0,$t1),
+&rotate($a,$d,$c,18,$t0,$t1));
+}
+
+# double_round returns a column round followed by a row round of the state in
+# rows a, b, c and d, clobbering t0 and t1.
+sub double_round {
+my ($a,$b,$c,$d,$t0,$t1)=@_;
+return (
+&quarter_round($a,$b,$c,$d,$t0,$t1),
+"pshufd \$0x93,$b,$b",
+"pshufd \$0x4e,$c,$c",
+"pshufd \$0x39,$d,$d",
+&quarter_round($a,$d,$c,$b,$t0,$t1),
+"pshufd \$0x39,$b,$b",
+"pshufd \$0x4e,$c,$c",
+"pshufd \$0x93,$d,$d");
+}
+
+# interleave merges the instruction lists of independent blocks.
+sub interleave {
+my @lists=@_;
+my $code="";
+while (grep { @$_ } @lists) {
+	foreach my $list (@lists) {
+		$code.=(shift @$list)."\n" if (@$list);
+	}
+}
+return $code;
+}
+
+# rounds applies the number of rounds in %r9 to the states of each block.
+sub rounds {
+my $label=shift;
+$code.="$label:\n";
+$code.=&interleave(map { [&double_round(@$_)] } @_);
+$code.=<<___;
+subq \$2, %r9
+ja $label
+___
+}
+
+# rows undoes the diagonal order of a, b, c and d, leaving words 0-3 in a,
+# 4-7 in b, 8-11 in u and 12-15 in w. v and x are clobbered. The mask must
+# select words 0 and 2 of a row.
+sub rows {
+my ($a,$b,$c,$d,$u,$v,$w,$x,$mask)=@_;
+$code.=<<___;
+movdqa $a, $u
+pxor $d, $u
+pand $mask, $u
+pxor $d, $u
+movdqa $c, $v
+pxor $b, $v
+pand $mask, $v
+pxor $b, $v
+movdqa $b, $w
+pxor $a, $w
+pand $mask, $w
+pxor $a, $w
+movdqa $d, $x
+pxor $c, $x
+pand $mask, $x
+pxor $c, $x
+movdqa $v, $a
+movsd $u, $a
+movsd $v, $u
+movdqa $x, $b
+movsd $w, $b
+movsd $x, $w
+___
+}
+
+# xor_block XORs the rows a, b, u and w with the block of input at in_off
+# and writes it to the output at out_off, clobbering t.
+sub xor_block {
+my ($a,$b,$u,$w,$off,$t)=@_;
+$code.=<<___;
+movdqu `$off+0`(%rsi), $t
+pxor $t, $a
+movdqu $a, `$off+0`(%rdi)
+movdqu `$off+16`(%rsi), $t
+pxor $t, $b
+movdqu $b, `$off+16`(%rdi)
+movdqu `$off+32`(%rsi), $t
+pxor $t, $u
+movdqu $u, `$off+32`(%rdi)
+movdqu `$off+48`(%rsi), $t
+pxor $t, $w
+movdqu $w, `$off+48`(%rdi)
+___
+}
+
+sub load_state {
+my ($a,$b,$c,$d)=@_;
+$code.=<<___;
+movdqu 0(%rcx), $a
+movdqu 16(%rcx), $b
+movdqu 32(%rcx), $c
+movdqu 48(%rcx), $d
+___
+}
+
+# The block counter is words 8 and 9, which are in c and b respectively.
+sub increment_counter {
+$code.=<<___;
+addl \$1, 32(%rcx)
+adcl \$0, 20(%rcx)
+___
+}
+
+if ($flavour =~ /^golang/) {
+    $code.=<<___;
+TEXT ·salsa_20_core_sse2(SB),\$0-40
+	movq	out+0(FP), DI
+	movq	in+8(FP), SI
+	movq	in_len+16(FP), DX
+	movq	state+24(FP), CX
+	movq	rounds+32(FP), R8
+
+___
+} else {
+    $code.=<<___;
+.globl salsa_20_core_sse2
+.type  salsa_20_core_sse2 ,\@function,5
+.align 64
+salsa_20_core_sse2:
+___
+}
+
+$code.=<<___;
+shrq \$6, %rdx
+jz salsa_sse2_done
+cmpq \$2, %rdx
+jb salsa_sse2_one
+salsa_sse2_two:
+___
+&load_state(@b0[0..3]);
+$code.=<<___;
+movdqa $b0[1], %xmm12
+movdqa $b0[2], %xmm13
+___
+&increment_counter();
+&load_state(@b1[0..3]);
+$code.=<<___;
+movq %r8, %r9
+___
+&rounds("salsa_sse2_two_rounds", [@b0], [@b1]);
+$code.=<<___;
+movdqu 0(%rcx), %xmm14
+paddd %xmm14, $b0[0]
+paddd %xmm14, $b1[0]
+movdqu 48(%rcx), %xmm14
+paddd %xmm14, $b0[3]
+paddd %xmm14, $b1[3]
+paddd %xmm12, $b0[1]
+paddd %xmm13, $b0[2]
+movdqu 16(%rcx), %xmm14
+paddd %xmm14, $b1[1]
+movdqu 32(%rcx), %xmm14
+paddd %xmm14, $b1[2]
+pcmpeqd %xmm15, %xmm15
+psrlq \$32, %xmm15
+___
+&increment_counter();
+&rows(@b0[0..3], "%xmm4", "%xmm5", "%xmm10", "%xmm11", "%xmm15");
+&xor_block($b0[0], $b0[1], "%xmm4", "%xmm10", 0, "%xmm14");
+&rows(@b1[0..3], "%xmm0", "%xmm1", "%xmm2", "%xmm3", "%xmm15");
+&xor_block($b1[0], $b1[1], "%xmm0", "%xmm2", 64, "%xmm14");
+$code.=<<___;
+addq \$128, %rsi
+addq \$128, %rdi
+subq \$2, %rdx
+jz salsa_sse2_done
+cmpq \$2, %rdx
+jae salsa_sse2_two
+salsa_sse2_one:
+___
+&load_state(@b0[0..3]);
+$code.=<<___;
+movq %r8, %r9
+___
+&rounds("salsa_sse2_one_rounds", [@b0]);
+$code.=<<___;
+movdqu 0(%rcx), %xmm14
+paddd %xmm14, $b0[0]
+movdqu 16(%rcx), %xmm14
+paddd %xmm14, $b0[1]
+movdqu 32(%rcx), %xmm14
+paddd %xmm14, $b0[2]
+movdqu 48(%rcx), %xmm14
+paddd %xmm14, $b0[3]
+pcmpeqd %xmm15, %xmm15
+psrlq \$32, %xmm15
+___
+&rows(@b0[0..3], "%xmm4", "%xmm5", "%xmm6", "%xmm7", "%xmm15");
+&xor_block($b0[0], $b0[1], "%xmm4", "%xmm6", 0, "%xmm14");
+&increment_counter();
+$code.=<<___;
+salsa_sse2_done:
+ret
+.size salsa_20_core_sse2,.-salsa_20_core_sse2
+___
+
+if ($flavour =~ /^golang/) {
+    $code.=<<___;
+
+TEXT ·hsalsa_20_sse2(SB),\$0-16
+	movq	state+0(FP), CX
+	movq	out+8(FP), DI
+
+___
+} else {
+    $code.=<<___;
+.globl hsalsa_20_sse2
+.type  hsalsa_20_sse2 ,\@function,2
+.align 64
+hsalsa_20_sse2:
+___
+}
+
+# HSalsa20 outputs words 0, 5, 10 and 15, which are already in a, followed by
+# words 6-9, without adding the input.
+&load_state(@b0[0..3]);
+$code.=<<___;
+movq \$20, %r9
+___
+&rounds("hsalsa_sse2_rounds", [@b0]);
+$code.=<<___;
+movdqu $b0[0], 0(%rdi)
+pcmpeqd %xmm15, %xmm15
+psrlq \$32, %xmm15
+___
+&rows(@b0[0..3], "%xmm4", "%xmm5", "%xmm6", "%xmm7", "%xmm15");
+$code.=<<___;
+pshufd \$0x4e,$b0[1],%xmm5
+punpcklqdq %xmm4,%xmm5
+movdqu %xmm5, 16(%rdi)
+ret
+.size hsalsa_20_sse2,.-hsalsa_20_sse2
+___
+
+$code =~ s/\`([^\`]*)\`/eval($1)/gem;
+
+print $code;
+
+close STDOUT;
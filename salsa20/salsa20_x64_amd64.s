@@ -0,0 +1,681 @@
+// Created by salsa20_x64.pl - DO NOT EDIT
+// perl salsa20_x64.pl golang-no-avx salsa20_x64_amd64.s
+
+// +build amd64,!gccgo,!appengine
+
+#include "textflag.h"
+
+TEXT ·salsa_20_core_sse2(SB),$0-40
+	MOVQ	out+0(FP),DI
+	MOVQ	in+8(FP),SI
+	MOVQ	in_len+16(FP),DX
+	MOVQ	state+24(FP),CX
+	MOVQ	rounds+32(FP),R8
+
+	SHRQ	$6,DX
+	JZ	salsa_sse2_done
+	CMPQ	DX,$2
+	JB	salsa_sse2_one
+salsa_sse2_two:
+	// MOVDQU	0(CX),X0
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x01
+	// MOVDQU	16(CX),X1
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x49; BYTE $0x10
+	// MOVDQU	32(CX),X2
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x51; BYTE $0x20
+	// MOVDQU	48(CX),X3
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x59; BYTE $0x30
+	// MOVDQA	X1,X12
+	BYTE $0x66; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0xe1
+	// MOVDQA	X2,X13
+	BYTE $0x66; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0xea
+	ADDL	$1,32(CX)
+	ADCL	$0,20(CX)
+	// MOVDQU	0(CX),X6
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x31
+	// MOVDQU	16(CX),X7
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x79; BYTE $0x10
+	// MOVDQU	32(CX),X8
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x41; BYTE $0x20
+	// MOVDQU	48(CX),X9
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x49; BYTE $0x30
+	MOVQ	R8,R9
+salsa_sse2_two_rounds:
+	// MOVDQA	X0,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe0
+	// MOVDQA	X6,X10
+	BYTE $0x66; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0xd6
+	PADDD	X3,X4
+	PADDD	X9,X10
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// MOVDQA	X10,X11
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xda
+	// PSLLD	$7,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x07
+	// PSLLD	$7,X10
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xf2; BYTE $0x07
+	// PSRLD	$25,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x19
+	// PSRLD	$25,X11
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xd3; BYTE $0x19
+	PXOR	X4,X1
+	PXOR	X10,X7
+	PXOR	X5,X1
+	PXOR	X11,X7
+	// MOVDQA	X1,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe1
+	// MOVDQA	X7,X10
+	BYTE $0x66; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0xd7
+	PADDD	X0,X4
+	PADDD	X6,X10
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// MOVDQA	X10,X11
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xda
+	// PSLLD	$9,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x09
+	// PSLLD	$9,X10
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xf2; BYTE $0x09
+	// PSRLD	$23,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x17
+	// PSRLD	$23,X11
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xd3; BYTE $0x17
+	PXOR	X4,X2
+	PXOR	X10,X8
+	PXOR	X5,X2
+	PXOR	X11,X8
+	// MOVDQA	X2,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe2
+	// MOVDQA	X8,X10
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xd0
+	PADDD	X1,X4
+	PADDD	X7,X10
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// MOVDQA	X10,X11
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xda
+	// PSLLD	$13,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x0d
+	// PSLLD	$13,X10
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xf2; BYTE $0x0d
+	// PSRLD	$19,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x13
+	// PSRLD	$19,X11
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xd3; BYTE $0x13
+	PXOR	X4,X3
+	PXOR	X10,X9
+	PXOR	X5,X3
+	PXOR	X11,X9
+	// MOVDQA	X3,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe3
+	// MOVDQA	X9,X10
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xd1
+	PADDD	X2,X4
+	PADDD	X8,X10
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// MOVDQA	X10,X11
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xda
+	// PSLLD	$18,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x12
+	// PSLLD	$18,X10
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xf2; BYTE $0x12
+	// PSRLD	$14,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x0e
+	// PSRLD	$14,X11
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xd3; BYTE $0x0e
+	PXOR	X4,X0
+	PXOR	X10,X6
+	PXOR	X5,X0
+	PXOR	X11,X6
+	PSHUFD	$147,X1,X1
+	PSHUFD	$147,X7,X7
+	PSHUFD	$78,X2,X2
+	PSHUFD	$78,X8,X8
+	PSHUFD	$57,X3,X3
+	PSHUFD	$57,X9,X9
+	// MOVDQA	X0,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe0
+	// MOVDQA	X6,X10
+	BYTE $0x66; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0xd6
+	PADDD	X1,X4
+	PADDD	X7,X10
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// MOVDQA	X10,X11
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xda
+	// PSLLD	$7,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x07
+	// PSLLD	$7,X10
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xf2; BYTE $0x07
+	// PSRLD	$25,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x19
+	// PSRLD	$25,X11
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xd3; BYTE $0x19
+	PXOR	X4,X3
+	PXOR	X10,X9
+	PXOR	X5,X3
+	PXOR	X11,X9
+	// MOVDQA	X3,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe3
+	// MOVDQA	X9,X10
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xd1
+	PADDD	X0,X4
+	PADDD	X6,X10
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// MOVDQA	X10,X11
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xda
+	// PSLLD	$9,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x09
+	// PSLLD	$9,X10
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xf2; BYTE $0x09
+	// PSRLD	$23,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x17
+	// PSRLD	$23,X11
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xd3; BYTE $0x17
+	PXOR	X4,X2
+	PXOR	X10,X8
+	PXOR	X5,X2
+	PXOR	X11,X8
+	// MOVDQA	X2,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe2
+	// MOVDQA	X8,X10
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xd0
+	PADDD	X3,X4
+	PADDD	X9,X10
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// MOVDQA	X10,X11
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xda
+	// PSLLD	$13,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x0d
+	// PSLLD	$13,X10
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xf2; BYTE $0x0d
+	// PSRLD	$19,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x13
+	// PSRLD	$19,X11
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xd3; BYTE $0x13
+	PXOR	X4,X1
+	PXOR	X10,X7
+	PXOR	X5,X1
+	PXOR	X11,X7
+	// MOVDQA	X1,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe1
+	// MOVDQA	X7,X10
+	BYTE $0x66; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0xd7
+	PADDD	X2,X4
+	PADDD	X8,X10
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// MOVDQA	X10,X11
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x6f; BYTE $0xda
+	// PSLLD	$18,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x12
+	// PSLLD	$18,X10
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xf2; BYTE $0x12
+	// PSRLD	$14,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x0e
+	// PSRLD	$14,X11
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x72; BYTE $0xd3; BYTE $0x0e
+	PXOR	X4,X0
+	PXOR	X10,X6
+	PXOR	X5,X0
+	PXOR	X11,X6
+	PSHUFD	$57,X1,X1
+	PSHUFD	$57,X7,X7
+	PSHUFD	$78,X2,X2
+	PSHUFD	$78,X8,X8
+	PSHUFD	$147,X3,X3
+	PSHUFD	$147,X9,X9
+	SUBQ	$2,R9
+	JA	salsa_sse2_two_rounds
+	// MOVDQU	0(CX),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x31
+	PADDD	X14,X0
+	PADDD	X14,X6
+	// MOVDQU	48(CX),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x71; BYTE $0x30
+	PADDD	X14,X3
+	PADDD	X14,X9
+	PADDD	X12,X1
+	PADDD	X13,X2
+	// MOVDQU	16(CX),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x71; BYTE $0x10
+	PADDD	X14,X7
+	// MOVDQU	32(CX),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x71; BYTE $0x20
+	PADDD	X14,X8
+	// PCMPEQD	X15,X15
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x76; BYTE $0xff
+	PSRLQ	$32,X15
+	ADDL	$1,32(CX)
+	ADCL	$0,20(CX)
+	// MOVDQA	X0,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe0
+	PXOR	X3,X4
+	PAND	X15,X4
+	PXOR	X3,X4
+	// MOVDQA	X2,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xea
+	PXOR	X1,X5
+	PAND	X15,X5
+	PXOR	X1,X5
+	// MOVDQA	X1,X10
+	BYTE $0x66; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0xd1
+	PXOR	X0,X10
+	PAND	X15,X10
+	PXOR	X0,X10
+	// MOVDQA	X3,X11
+	BYTE $0x66; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0xdb
+	PXOR	X2,X11
+	PAND	X15,X11
+	PXOR	X2,X11
+	// MOVDQA	X5,X0
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xc5
+	MOVSD	X4,X0
+	MOVSD	X5,X4
+	// MOVDQA	X11,X1
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x6f; BYTE $0xcb
+	MOVSD	X10,X1
+	MOVSD	X11,X10
+	// MOVDQU	0(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x36
+	PXOR	X14,X0
+	// MOVDQU	X0,0(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x07
+	// MOVDQU	16(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x10
+	PXOR	X14,X1
+	// MOVDQU	X1,16(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x4f; BYTE $0x10
+	// MOVDQU	32(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x20
+	PXOR	X14,X4
+	// MOVDQU	X4,32(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x67; BYTE $0x20
+	// MOVDQU	48(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x30
+	PXOR	X14,X10
+	// MOVDQU	X10,48(DI)
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x7f; BYTE $0x57; BYTE $0x30
+	// MOVDQA	X6,X0
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xc6
+	PXOR	X9,X0
+	PAND	X15,X0
+	PXOR	X9,X0
+	// MOVDQA	X8,X1
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x6f; BYTE $0xc8
+	PXOR	X7,X1
+	PAND	X15,X1
+	PXOR	X7,X1
+	// MOVDQA	X7,X2
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xd7
+	PXOR	X6,X2
+	PAND	X15,X2
+	PXOR	X6,X2
+	// MOVDQA	X9,X3
+	BYTE $0x66; BYTE $0x41; BYTE $0x0f; BYTE $0x6f; BYTE $0xd9
+	PXOR	X8,X3
+	PAND	X15,X3
+	PXOR	X8,X3
+	// MOVDQA	X1,X6
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xf1
+	MOVSD	X0,X6
+	MOVSD	X1,X0
+	// MOVDQA	X3,X7
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xfb
+	MOVSD	X2,X7
+	MOVSD	X3,X2
+	// MOVDQU	64(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x40
+	PXOR	X14,X6
+	// MOVDQU	X6,64(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x77; BYTE $0x40
+	// MOVDQU	80(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x50
+	PXOR	X14,X7
+	// MOVDQU	X7,80(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x7f; BYTE $0x50
+	// MOVDQU	96(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x60
+	PXOR	X14,X0
+	// MOVDQU	X0,96(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x47; BYTE $0x60
+	// MOVDQU	112(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x70
+	PXOR	X14,X2
+	// MOVDQU	X2,112(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x57; BYTE $0x70
+	ADDQ	$128,SI
+	ADDQ	$128,DI
+	SUBQ	$2,DX
+	JZ	salsa_sse2_done
+	CMPQ	DX,$2
+	JAE	salsa_sse2_two
+salsa_sse2_one:
+	// MOVDQU	0(CX),X0
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x01
+	// MOVDQU	16(CX),X1
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x49; BYTE $0x10
+	// MOVDQU	32(CX),X2
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x51; BYTE $0x20
+	// MOVDQU	48(CX),X3
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x59; BYTE $0x30
+	MOVQ	R8,R9
+salsa_sse2_one_rounds:
+	// MOVDQA	X0,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe0
+	PADDD	X3,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$7,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x07
+	// PSRLD	$25,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x19
+	PXOR	X4,X1
+	PXOR	X5,X1
+	// MOVDQA	X1,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe1
+	PADDD	X0,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$9,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x09
+	// PSRLD	$23,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x17
+	PXOR	X4,X2
+	PXOR	X5,X2
+	// MOVDQA	X2,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe2
+	PADDD	X1,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$13,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x0d
+	// PSRLD	$19,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x13
+	PXOR	X4,X3
+	PXOR	X5,X3
+	// MOVDQA	X3,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe3
+	PADDD	X2,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$18,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x12
+	// PSRLD	$14,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x0e
+	PXOR	X4,X0
+	PXOR	X5,X0
+	PSHUFD	$147,X1,X1
+	PSHUFD	$78,X2,X2
+	PSHUFD	$57,X3,X3
+	// MOVDQA	X0,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe0
+	PADDD	X1,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$7,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x07
+	// PSRLD	$25,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x19
+	PXOR	X4,X3
+	PXOR	X5,X3
+	// MOVDQA	X3,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe3
+	PADDD	X0,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$9,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x09
+	// PSRLD	$23,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x17
+	PXOR	X4,X2
+	PXOR	X5,X2
+	// MOVDQA	X2,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe2
+	PADDD	X3,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$13,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x0d
+	// PSRLD	$19,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x13
+	PXOR	X4,X1
+	PXOR	X5,X1
+	// MOVDQA	X1,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe1
+	PADDD	X2,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$18,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x12
+	// PSRLD	$14,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x0e
+	PXOR	X4,X0
+	PXOR	X5,X0
+	PSHUFD	$57,X1,X1
+	PSHUFD	$78,X2,X2
+	PSHUFD	$147,X3,X3
+	SUBQ	$2,R9
+	JA	salsa_sse2_one_rounds
+	// MOVDQU	0(CX),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x31
+	PADDD	X14,X0
+	// MOVDQU	16(CX),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x71; BYTE $0x10
+	PADDD	X14,X1
+	// MOVDQU	32(CX),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x71; BYTE $0x20
+	PADDD	X14,X2
+	// MOVDQU	48(CX),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x71; BYTE $0x30
+	PADDD	X14,X3
+	// PCMPEQD	X15,X15
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x76; BYTE $0xff
+	PSRLQ	$32,X15
+	// MOVDQA	X0,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe0
+	PXOR	X3,X4
+	PAND	X15,X4
+	PXOR	X3,X4
+	// MOVDQA	X2,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xea
+	PXOR	X1,X5
+	PAND	X15,X5
+	PXOR	X1,X5
+	// MOVDQA	X1,X6
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xf1
+	PXOR	X0,X6
+	PAND	X15,X6
+	PXOR	X0,X6
+	// MOVDQA	X3,X7
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xfb
+	PXOR	X2,X7
+	PAND	X15,X7
+	PXOR	X2,X7
+	// MOVDQA	X5,X0
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xc5
+	MOVSD	X4,X0
+	MOVSD	X5,X4
+	// MOVDQA	X7,X1
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xcf
+	MOVSD	X6,X1
+	MOVSD	X7,X6
+	// MOVDQU	0(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x36
+	PXOR	X14,X0
+	// MOVDQU	X0,0(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x07
+	// MOVDQU	16(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x10
+	PXOR	X14,X1
+	// MOVDQU	X1,16(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x4f; BYTE $0x10
+	// MOVDQU	32(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x20
+	PXOR	X14,X4
+	// MOVDQU	X4,32(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x67; BYTE $0x20
+	// MOVDQU	48(SI),X14
+	BYTE $0xf3; BYTE $0x44; BYTE $0x0f; BYTE $0x6f; BYTE $0x76; BYTE $0x30
+	PXOR	X14,X6
+	// MOVDQU	X6,48(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x77; BYTE $0x30
+	ADDL	$1,32(CX)
+	ADCL	$0,20(CX)
+salsa_sse2_done:
+	RET
+
+
+TEXT ·hsalsa_20_sse2(SB),$0-16
+	MOVQ	state+0(FP),CX
+	MOVQ	out+8(FP),DI
+
+	// MOVDQU	0(CX),X0
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x01
+	// MOVDQU	16(CX),X1
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x49; BYTE $0x10
+	// MOVDQU	32(CX),X2
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x51; BYTE $0x20
+	// MOVDQU	48(CX),X3
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x6f; BYTE $0x59; BYTE $0x30
+	MOVQ	$20,R9
+hsalsa_sse2_rounds:
+	// MOVDQA	X0,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe0
+	PADDD	X3,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$7,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x07
+	// PSRLD	$25,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x19
+	PXOR	X4,X1
+	PXOR	X5,X1
+	// MOVDQA	X1,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe1
+	PADDD	X0,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$9,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x09
+	// PSRLD	$23,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x17
+	PXOR	X4,X2
+	PXOR	X5,X2
+	// MOVDQA	X2,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe2
+	PADDD	X1,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$13,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x0d
+	// PSRLD	$19,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x13
+	PXOR	X4,X3
+	PXOR	X5,X3
+	// MOVDQA	X3,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe3
+	PADDD	X2,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$18,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x12
+	// PSRLD	$14,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x0e
+	PXOR	X4,X0
+	PXOR	X5,X0
+	PSHUFD	$147,X1,X1
+	PSHUFD	$78,X2,X2
+	PSHUFD	$57,X3,X3
+	// MOVDQA	X0,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe0
+	PADDD	X1,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$7,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x07
+	// PSRLD	$25,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x19
+	PXOR	X4,X3
+	PXOR	X5,X3
+	// MOVDQA	X3,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe3
+	PADDD	X0,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$9,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x09
+	// PSRLD	$23,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x17
+	PXOR	X4,X2
+	PXOR	X5,X2
+	// MOVDQA	X2,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe2
+	PADDD	X3,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$13,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x0d
+	// PSRLD	$19,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x13
+	PXOR	X4,X1
+	PXOR	X5,X1
+	// MOVDQA	X1,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe1
+	PADDD	X2,X4
+	// MOVDQA	X4,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xec
+	// PSLLD	$18,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xf4; BYTE $0x12
+	// PSRLD	$14,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x72; BYTE $0xd5; BYTE $0x0e
+	PXOR	X4,X0
+	PXOR	X5,X0
+	PSHUFD	$57,X1,X1
+	PSHUFD	$78,X2,X2
+	PSHUFD	$147,X3,X3
+	SUBQ	$2,R9
+	JA	hsalsa_sse2_rounds
+	// MOVDQU	X0,0(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x07
+	// PCMPEQD	X15,X15
+	BYTE $0x66; BYTE $0x45; BYTE $0x0f; BYTE $0x76; BYTE $0xff
+	PSRLQ	$32,X15
+	// MOVDQA	X0,X4
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xe0
+	PXOR	X3,X4
+	PAND	X15,X4
+	PXOR	X3,X4
+	// MOVDQA	X2,X5
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xea
+	PXOR	X1,X5
+	PAND	X15,X5
+	PXOR	X1,X5
+	// MOVDQA	X1,X6
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xf1
+	PXOR	X0,X6
+	PAND	X15,X6
+	PXOR	X0,X6
+	// MOVDQA	X3,X7
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xfb
+	PXOR	X2,X7
+	PAND	X15,X7
+	PXOR	X2,X7
+	// MOVDQA	X5,X0
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xc5
+	MOVSD	X4,X0
+	MOVSD	X5,X4
+	// MOVDQA	X7,X1
+	BYTE $0x66; BYTE $0x0f; BYTE $0x6f; BYTE $0xcf
+	MOVSD	X6,X1
+	MOVSD	X7,X6
+	PSHUFD	$78,X1,X5
+	PUNPCKLQDQ	X4,X5
+	// MOVDQU	X5,16(DI)
+	BYTE $0xf3; BYTE $0x0f; BYTE $0x7f; BYTE $0x6f; BYTE $0x10
+	RET
+
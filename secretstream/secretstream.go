@@ -0,0 +1,306 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package secretstream implements libsodium's
+// crypto_secretstream_xchacha20poly1305 on top of
+// github.com/tmthrgd/chacha20.
+//
+// Its output is byte-for-byte identical to that of libsodium, so streams
+// encrypted by either can be decrypted by the other.
+//
+// A stream begins with a HeaderSize byte header, produced by NewEncryptor and
+// consumed by NewDecryptor, and is followed by a sequence of messages, each
+// Overhead bytes longer than its plaintext. Every message carries a tag that
+// is authenticated along with it, so a stream whose last message is not
+// tagged TagFinal has been truncated.
+package secretstream
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/tmthrgd/chacha20"
+	"github.com/tmthrgd/chacha20/internal/slice"
+	"golang.org/x/crypto/poly1305"
+)
+
+const (
+	// KeySize is the length of keys, in bytes.
+	KeySize = chacha20.KeySize
+
+	// HeaderSize is the length of the stream header, in bytes.
+	HeaderSize = chacha20.XNonceSize
+
+	// Overhead is the number of bytes each message adds to its plaintext: a
+	// one byte encrypted tag and a 16 byte Poly1305 MAC.
+	Overhead = 1 + poly1305.TagSize
+)
+
+// The message tags.
+const (
+	// TagMessage is the tag of most messages.
+	TagMessage byte = 0x00
+
+	// TagPush marks the end of a set of messages, but not of the stream.
+	TagPush byte = 0x01
+
+	// TagRekey causes the key to be rekeyed after the message.
+	TagRekey byte = 0x02
+
+	// TagFinal marks the end of the stream and causes the key to be
+	// rekeyed.
+	TagFinal = TagPush | TagRekey
+)
+
+// maxMessage is the longest plaintext that may be pushed, as it would
+// otherwise reuse the keystream.
+const maxMessage = 64 * (1<<32 - 2)
+
+const (
+	counterSize = 4
+	inonceSize  = 8
+)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	// ErrInvalidHeader is returned when the provided header is not HeaderSize
+	// bytes long.
+	ErrInvalidHeader = errors.New("invalid header length")
+
+	errOpen = errors.New("secretstream: message authentication failed")
+)
+
+// state is crypto_secretstream_xchacha20poly1305_state: the subkey and a
+// nonce made up of a 32-bit little-endian counter followed by the inonce.
+type state struct {
+	key   [chacha20.HChaChaSize]byte
+	nonce [chacha20.RFCNonceSize]byte
+}
+
+func (s *state) init(key, header []byte) error {
+	if len(key) != KeySize {
+		return ErrInvalidKey
+	}
+
+	if len(header) != HeaderSize {
+		return ErrInvalidHeader
+	}
+
+	var k [KeySize]byte
+	copy(k[:], key)
+
+	var hNonce [chacha20.HNonceSize]byte
+	copy(hNonce[:], header)
+
+	chacha20.HChaCha20(&s.key, &k, &hNonce)
+
+	for i := range k {
+		k[i] = 0
+	}
+
+	copy(s.nonce[counterSize:], header[chacha20.HNonceSize:])
+	s.resetCounter()
+	return nil
+}
+
+func (s *state) resetCounter() {
+	binary.LittleEndian.PutUint32(s.nonce[:counterSize], 1)
+}
+
+// Rekey explicitly rekeys the stream, as
+// crypto_secretstream_xchacha20poly1305_rekey does. The other end of the
+// stream must call Rekey at the same point.
+func (s *state) Rekey() {
+	var buf [chacha20.HChaChaSize + inonceSize]byte
+	copy(buf[:], s.key[:])
+	copy(buf[chacha20.HChaChaSize:], s.nonce[counterSize:])
+
+	s.stream(0).XORKeyStream(buf[:], buf[:])
+
+	copy(s.key[:], buf[:])
+	copy(s.nonce[counterSize:], buf[chacha20.HChaChaSize:])
+
+	for i := range buf {
+		buf[i] = 0
+	}
+
+	s.resetCounter()
+}
+
+func (s *state) stream(counter uint64) cipher.Stream {
+	c, err := chacha20.NewRFC(s.key[:], s.nonce[:])
+	if err != nil {
+		panic(err)
+	}
+
+	chacha20.SetCounter(c, counter)
+	return c
+}
+
+// mac returns the Poly1305 MAC of additionalData, the encrypted tag block
+// and ciphertext.
+//
+// libsodium pads the ciphertext with len(ciphertext)%16 zero bytes, rather
+// than to a multiple of 16, and this is reproduced here.
+func (s *state) mac(block *[64]byte, ciphertext, additionalData []byte) *[poly1305.TagSize]byte {
+	var polyKey [32]byte
+	s.stream(0).XORKeyStream(polyKey[:], polyKey[:])
+
+	m := poly1305.New(&polyKey)
+
+	for i := range polyKey {
+		polyKey[i] = 0
+	}
+
+	var pad [16]byte
+
+	m.Write(additionalData)
+	m.Write(pad[:(16-len(additionalData))&0xf])
+
+	m.Write(block[:])
+
+	m.Write(ciphertext)
+	m.Write(pad[:(16-len(block)+len(ciphertext))&0xf])
+
+	var lens [16]byte
+	binary.LittleEndian.PutUint64(lens[0:], uint64(len(additionalData)))
+	binary.LittleEndian.PutUint64(lens[8:], uint64(len(block)+len(ciphertext)))
+	m.Write(lens[:])
+
+	var tag [poly1305.TagSize]byte
+	m.Sum(tag[:0])
+	return &tag
+}
+
+// advance updates the state after a message with the given tag and MAC.
+func (s *state) advance(tag byte, mac *[poly1305.TagSize]byte) {
+	for i := 0; i < inonceSize; i++ {
+		s.nonce[counterSize+i] ^= mac[i]
+	}
+
+	counter := binary.LittleEndian.Uint32(s.nonce[:counterSize]) + 1
+	binary.LittleEndian.PutUint32(s.nonce[:counterSize], counter)
+
+	if tag&TagRekey != 0 || counter == 0 {
+		s.Rekey()
+	}
+}
+
+// Encryptor encrypts the messages of a stream. It is equivalent to
+// crypto_secretstream_xchacha20poly1305_push.
+type Encryptor struct {
+	state
+}
+
+// NewEncryptor returns an Encryptor for a new stream, using the given 256-bit
+// key, along with the header that must be sent ahead of the stream. The
+// header is chosen at random.
+func NewEncryptor(key []byte) (*Encryptor, []byte, error) {
+	if len(key) != KeySize {
+		return nil, nil, ErrInvalidKey
+	}
+
+	header := make([]byte, HeaderSize)
+	if _, err := rand.Read(header); err != nil {
+		return nil, nil, err
+	}
+
+	e, err := newEncryptor(key, header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return e, header, nil
+}
+
+func newEncryptor(key, header []byte) (*Encryptor, error) {
+	e := new(Encryptor)
+	if err := e.init(key, header); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Push encrypts and authenticates message and additionalData with the given
+// tag, appends the result to dst and returns the updated slice. The
+// additional data is not included in the output and must be passed to Pull.
+// The remaining capacity of dst must not overlap message.
+func (e *Encryptor) Push(dst, message, additionalData []byte, tag byte) []byte {
+	if uint64(len(message)) > maxMessage {
+		panic("secretstream: message too large")
+	}
+
+	ret, out := slice.ForAppend(dst, len(message)+Overhead)
+
+	var block [64]byte
+	block[0] = tag
+	e.stream(1).XORKeyStream(block[:], block[:])
+
+	out[0] = block[0]
+
+	c := out[1 : 1+len(message)]
+	e.stream(2).XORKeyStream(c, message)
+
+	mac := e.mac(&block, c, additionalData)
+	copy(out[1+len(message):], mac[:])
+
+	e.advance(tag, mac)
+	return ret
+}
+
+// Decryptor decrypts the messages of a stream. It is equivalent to
+// crypto_secretstream_xchacha20poly1305_pull.
+type Decryptor struct {
+	state
+}
+
+// NewDecryptor returns a Decryptor for the stream with the given 256-bit key
+// and header.
+func NewDecryptor(key, header []byte) (*Decryptor, error) {
+	d := new(Decryptor)
+	if err := d.init(key, header); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Pull authenticates and decrypts ciphertext and additionalData, appends the
+// message to dst and returns the updated slice along with the message's tag.
+// If the message fails to authenticate, the state is left unchanged and the
+// message may be retried. The remaining capacity of dst must not overlap
+// ciphertext.
+func (d *Decryptor) Pull(dst, ciphertext, additionalData []byte) ([]byte, byte, error) {
+	if len(ciphertext) < Overhead || uint64(len(ciphertext)-Overhead) > maxMessage {
+		return nil, 0, errOpen
+	}
+
+	c := ciphertext[1 : len(ciphertext)-poly1305.TagSize]
+	expected := ciphertext[len(ciphertext)-poly1305.TagSize:]
+
+	var block [64]byte
+	block[0] = ciphertext[0]
+	d.stream(1).XORKeyStream(block[:], block[:])
+
+	tag := block[0]
+	block[0] = ciphertext[0]
+
+	mac := d.mac(&block, c, additionalData)
+	if subtle.ConstantTimeCompare(mac[:], expected) != 1 {
+		return nil, 0, errOpen
+	}
+
+	ret, out := slice.ForAppend(dst, len(c))
+	d.stream(2).XORKeyStream(out, c)
+
+	d.advance(tag, mac)
+	return ret, tag, nil
+}
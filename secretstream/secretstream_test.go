@@ -0,0 +1,247 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package secretstream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// These were captured from libsodium 1.0.18. The header was generated by
+// crypto_secretstream_xchacha20poly1305_init_push.
+var (
+	testKey    = mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	testHeader = mustHexDecode("d30a47178fe11f42d581fdf6223e70f08e85d73875a3c72b")
+)
+
+const (
+	stepMessage = iota
+	stepRekey   // crypto_secretstream_xchacha20poly1305_rekey
+	stepWrap    // set the counter to 0xffffffff
+)
+
+var testVectors = []struct {
+	step                    int
+	message, ad, ciphertext []byte
+	tag                     byte
+}{
+	{
+		message:    []byte(""),
+		tag:        TagMessage,
+		ciphertext: mustHexDecode("0271e7f6cbab6846d8970ab44af9e68ecf"),
+	},
+	{
+		message: []byte("Ladies and Gentlemen of the class of '99"),
+		ad:      []byte("ad"),
+		tag:     TagMessage,
+		ciphertext: mustHexDecode("ca4b5063604a90b21badfeb8463df261c7686ca85e927e23eea2875803aa3cd4" +
+			"9c92711b0f9aca41a0edf7b6809cda08e3c2a8b5c657181163"),
+	},
+	{
+		message: []byte("If I could offer you only one tip for the future, sunscreen would be it."),
+		tag:     TagPush,
+		ciphertext: mustHexDecode("d772d7c4288c01dcdfebbc378efd64297fb5b1c5ab140411b71c20ac738e0596" +
+			"4c9f37d12dd294c91f195e0e9855b75e7e16a7a64da69c4dcec26d2e44074bb7" +
+			"33e82cee6772ecf0215d1a367dae84611adeca59a3c288c035"),
+	},
+	{
+		message:    []byte("rekey"),
+		ad:         []byte(""),
+		tag:        TagRekey,
+		ciphertext: mustHexDecode("a27b481f724e7456f7d82234040c9f261f3f24add7ae"),
+	},
+	{
+		message:    []byte("after rekey"),
+		tag:        TagMessage,
+		ciphertext: mustHexDecode("659c98e8cffaab64ce7d3bdb86dde26b9b5098faaeb5d05b860fa73a"),
+	},
+	{step: stepRekey},
+	{
+		message: []byte("after explicit rekey"),
+		ad:      []byte("xxxxxxxxxxxxxxxxx"),
+		tag:     TagMessage,
+		ciphertext: mustHexDecode("f38f498057d464f6c69f51d45426438fd8bc3ec49c31fcfb9a197d51e0a275b9" +
+			"cc82545919"),
+	},
+	{step: stepWrap},
+	{
+		message:    []byte("wrap"),
+		tag:        TagMessage,
+		ciphertext: mustHexDecode("922acdc164e4a06008c1a27de7cfc93cc8a132a6e0"),
+	},
+	{
+		message:    []byte("final"),
+		tag:        TagFinal,
+		ciphertext: mustHexDecode("9ab179babdfb28121fb714a3389026cec5148320686d"),
+	},
+}
+
+func TestPush(t *testing.T) {
+	e, err := newEncryptor(testKey, testHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range testVectors {
+		switch v.step {
+		case stepRekey:
+			e.Rekey()
+			continue
+		case stepWrap:
+			binary.LittleEndian.PutUint32(e.nonce[:counterSize], 0xffffffff)
+			continue
+		}
+
+		if ct := e.Push(nil, v.message, v.ad, v.tag); !bytes.Equal(ct, v.ciphertext) {
+			t.Fatalf("test vector %d: expected %x, got %x", i, v.ciphertext, ct)
+		}
+	}
+}
+
+func TestPull(t *testing.T) {
+	d, err := NewDecryptor(testKey, testHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range testVectors {
+		switch v.step {
+		case stepRekey:
+			d.Rekey()
+			continue
+		case stepWrap:
+			binary.LittleEndian.PutUint32(d.nonce[:counterSize], 0xffffffff)
+			continue
+		}
+
+		m, tag, err := d.Pull(nil, v.ciphertext, v.ad)
+		if err != nil {
+			t.Fatalf("test vector %d: %v", i, err)
+		}
+
+		if !bytes.Equal(m, v.message) {
+			t.Errorf("test vector %d: expected %q, got %q", i, v.message, m)
+		}
+
+		if tag != v.tag {
+			t.Errorf("test vector %d: expected tag %#x, got %#x", i, v.tag, tag)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	e, header, err := NewEncryptor(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := NewDecryptor(testKey, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		message := bytes.Repeat([]byte{byte(i)}, i*7)
+		ad := bytes.Repeat([]byte{0xff}, i%20)
+		tag := byte(i) & TagFinal
+
+		ct := e.Push(make([]byte, 3, 3+len(message)+Overhead), message, ad, tag)
+
+		m, gotTag, err := d.Pull(nil, ct[3:], ad)
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+
+		if !bytes.Equal(m, message) || gotTag != tag {
+			t.Fatalf("message %d: wrong message or tag", i)
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	for i := range testVectors[1].ciphertext {
+		d, err := NewDecryptor(testKey, testHeader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := d.Pull(nil, testVectors[0].ciphertext, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		bad := append([]byte(nil), testVectors[1].ciphertext...)
+		bad[i] ^= 0x10
+
+		if _, _, err := d.Pull(nil, bad, testVectors[1].ad); err == nil {
+			t.Fatalf("Pull accepted ciphertext with byte %d modified", i)
+		}
+
+		// A failed Pull leaves the state unchanged.
+		if _, _, err := d.Pull(nil, testVectors[1].ciphertext, testVectors[1].ad); err != nil {
+			t.Fatalf("Pull failed after rejecting a modified message: %v", err)
+		}
+	}
+
+	d, err := NewDecryptor(testKey, testHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages may not be skipped or reordered.
+	if _, _, err := d.Pull(nil, testVectors[1].ciphertext, testVectors[1].ad); err == nil {
+		t.Error("Pull accepted out of order message")
+	}
+
+	if _, _, err := d.Pull(nil, testVectors[0].ciphertext, []byte("ad")); err == nil {
+		t.Error("Pull accepted wrong additional data")
+	}
+
+	if _, _, err := d.Pull(nil, testVectors[0].ciphertext[:Overhead-1], nil); err == nil {
+		t.Error("Pull accepted short ciphertext")
+	}
+}
+
+func TestBadSizes(t *testing.T) {
+	if _, _, err := NewEncryptor(testKey[1:]); err != ErrInvalidKey {
+		t.Errorf("NewEncryptor: expected ErrInvalidKey, got %v", err)
+	}
+
+	if _, err := NewDecryptor(testKey[1:], testHeader); err != ErrInvalidKey {
+		t.Errorf("NewDecryptor: expected ErrInvalidKey, got %v", err)
+	}
+
+	if _, err := NewDecryptor(testKey, testHeader[1:]); err != ErrInvalidHeader {
+		t.Errorf("NewDecryptor: expected ErrInvalidHeader, got %v", err)
+	}
+}
+
+func BenchmarkPush(b *testing.B) {
+	e, _, err := NewEncryptor(testKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	buf := make([]byte, 16*1024)
+	out := make([]byte, 0, len(buf)+Overhead)
+
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		e.Push(out, buf, nil, TagMessage)
+	}
+}
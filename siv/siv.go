@@ -0,0 +1,212 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package siv implements XChaCha20-Poly1305-SIV, a nonce-misuse-resistant
+// AEAD built from XChaCha20, HChaCha20 and Poly1305.
+//
+// Like AES-GCM-SIV, the tag is a synthetic IV computed over the whole message
+// before it is encrypted. Reusing a nonce therefore only reveals whether two
+// messages with the same associated data were equal, and with no nonce at all
+// the AEAD is deterministic, which is suitable for key wrapping.
+//
+// For a 256-bit key K, a 192-bit nonce N and associated data A, a message P is
+// sealed as follows:
+//
+//	M || H || E = XChaCha20(K, N)[0:96]
+//	S = Poly1305(M, A || pad16(A) || P || pad16(P) || le64(len(A)) || le64(len(P)))
+//	T = HChaCha20(H, S)[0:16]
+//	C = P XOR XChaCha20(E, T || 0^64)
+//
+// and the output is C || T. The Poly1305 result is never revealed directly:
+// it only feeds into HChaCha20 used as a PRF under a key of its own.
+//
+// The deterministic AEAD uses an all-zero nonce.
+package siv
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/tmthrgd/chacha20"
+	"github.com/tmthrgd/chacha20/internal/slice"
+	"golang.org/x/crypto/poly1305"
+)
+
+const (
+	// KeySize is the length of XChaCha20-Poly1305-SIV keys, in bytes.
+	KeySize = chacha20.KeySize
+
+	// NonceSize is the length of the nonces taken by the AEAD returned from
+	// New, in bytes.
+	NonceSize = chacha20.XNonceSize
+
+	// TagSize is the length of the synthetic IV appended to each message,
+	// in bytes.
+	TagSize = poly1305.TagSize
+)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	errOpen = errors.New("siv: message authentication failed")
+)
+
+type aead struct {
+	key       [KeySize]byte
+	nonceSize int
+}
+
+// New returns an XChaCha20-Poly1305-SIV AEAD that takes a 192-bit nonce.
+// Nonces should still be unique, but reusing one only reveals whether two
+// messages were identical.
+func New(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, NonceSize)
+}
+
+// NewDeterministic returns an XChaCha20-Poly1305-SIV AEAD that takes no nonce.
+// Sealing the same plaintext with the same associated data always produces
+// the same ciphertext. It is intended for key wrapping and other cases where
+// the plaintexts are unique.
+func NewDeterministic(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, 0)
+}
+
+func newAEAD(key []byte, nonceSize int) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	a := &aead{nonceSize: nonceSize}
+	copy(a.key[:], key)
+	return a, nil
+}
+
+func (a *aead) NonceSize() int {
+	return a.nonceSize
+}
+
+func (a *aead) Overhead() int {
+	return TagSize
+}
+
+// keys derives the Poly1305, HChaCha20 and XChaCha20 keys for nonce.
+func (a *aead) keys(nonce []byte) (keys [3 * KeySize]byte) {
+	var n [chacha20.XNonceSize]byte
+	copy(n[:], nonce)
+
+	s, err := chacha20.NewXChaCha(a.key[:], n[:])
+	if err != nil {
+		panic(err)
+	}
+
+	s.XORKeyStream(keys[:], keys[:])
+	return
+}
+
+// tag computes the synthetic IV for plaintext and additionalData.
+func tag(keys *[3 * KeySize]byte, plaintext, additionalData []byte) (t [TagSize]byte) {
+	var macKey [32]byte
+	copy(macKey[:], keys[:KeySize])
+
+	m := poly1305.New(&macKey)
+	writeWithPadding(m, additionalData)
+	writeWithPadding(m, plaintext)
+
+	var lens [16]byte
+	binary.LittleEndian.PutUint64(lens[0:], uint64(len(additionalData)))
+	binary.LittleEndian.PutUint64(lens[8:], uint64(len(plaintext)))
+	m.Write(lens[:])
+
+	var s [chacha20.HNonceSize]byte
+	m.Sum(s[:0])
+
+	var prfKey [chacha20.KeySize]byte
+	copy(prfKey[:], keys[KeySize:2*KeySize])
+
+	var out [chacha20.HChaChaSize]byte
+	chacha20.HChaCha20(&out, &prfKey, &s)
+
+	copy(t[:], out[:])
+	return
+}
+
+// xorKeyStream encrypts or decrypts src with the keystream selected by t.
+func xorKeyStream(keys *[3 * KeySize]byte, t *[TagSize]byte, dst, src []byte) {
+	var iv [chacha20.XNonceSize]byte
+	copy(iv[:], t[:])
+
+	s, err := chacha20.NewXChaCha(keys[2*KeySize:], iv[:])
+	if err != nil {
+		panic(err)
+	}
+
+	s.XORKeyStream(dst, src)
+}
+
+func (a *aead) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != a.nonceSize {
+		panic("siv: incorrect nonce length given to XChaCha20-Poly1305-SIV")
+	}
+
+	keys := a.keys(nonce)
+	t := tag(&keys, plaintext, additionalData)
+
+	ret, out := slice.ForAppend(dst, len(plaintext)+TagSize)
+	xorKeyStream(&keys, &t, out, plaintext)
+	copy(out[len(plaintext):], t[:])
+
+	for i := range keys {
+		keys[i] = 0
+	}
+
+	return ret
+}
+
+func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != a.nonceSize {
+		panic("siv: incorrect nonce length given to XChaCha20-Poly1305-SIV")
+	}
+
+	if len(ciphertext) < TagSize {
+		return nil, errOpen
+	}
+
+	var t [TagSize]byte
+	copy(t[:], ciphertext[len(ciphertext)-TagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-TagSize]
+
+	keys := a.keys(nonce)
+	defer func() {
+		for i := range keys {
+			keys[i] = 0
+		}
+	}()
+
+	ret, out := slice.ForAppend(dst, len(ciphertext))
+	xorKeyStream(&keys, &t, out, ciphertext)
+
+	expected := tag(&keys, out, additionalData)
+	if subtle.ConstantTimeCompare(t[:], expected[:]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+
+		return nil, errOpen
+	}
+
+	return ret, nil
+}
+
+func writeWithPadding(m *poly1305.MAC, b []byte) {
+	m.Write(b)
+
+	if rem := len(b) % 16; rem != 0 {
+		var pad [16]byte
+		m.Write(pad[:16-rem])
+	}
+}
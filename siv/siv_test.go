@@ -0,0 +1,230 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package siv
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+var testVectors = []struct {
+	key, nonce, plaintext, ad, ciphertext []byte
+}{
+	{
+		mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"),
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		nil,
+		nil,
+		mustHexDecode("322ba9806c90286b35fc5b1250c61c97"),
+	},
+	{
+		mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"),
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		[]byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it."),
+		mustHexDecode("50515253c0c1c2c3c4c5c6c7"),
+		mustHexDecode("10cb81fc362469f9041969efdd2c18a299f67db5009a49da088c5bb71160097b" +
+			"c4f6841d9b60c209d84fe7d5320b2a21e0bb824847793fee0a4b2655cc16176e" +
+			"86b532781f0af7b7755380c9cc40e592b9e0dec3a80dd407ebd5b8e9d065e253" +
+			"c10a20973b8fe4a63b9625fc71ac902ce36e9c3daca3a2601a871c0a66374acf" +
+			"8968"),
+	},
+	{
+		mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"),
+		nil,
+		mustHexDecode("202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f"),
+		[]byte("key wrap"),
+		mustHexDecode("f4775f6dbb6924576433246152e5476212f4fca2631c54d9aa7fc04eb1894a42" +
+			"900583cb020d0b6930817f02853491fb"),
+	},
+	{
+		mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"),
+		mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		mustHexDecode("00070e151c232a31383f464d545b626970777e858c939aa1a8afb6bdc4cbd2d9" +
+			"e0e7eef5fc030a11181f262d343b424950575e656c737a81888f969da4abb2b9" +
+			"c0c7ced5dce3eaf1f8ff060d141b222930373e454c535a61686f767d848b9299" +
+			"a0a7aeb5bcc3cad1d8dfe6edf4fb020910171e252c333a41484f565d646b7279" +
+			"8087"),
+		[]byte("abcdefg"),
+		mustHexDecode("051bcf7a84e541d7b8483e94d1a1b5ae40548ef36f9663242c621fcb26507400" +
+			"1131ca621f7ed490445f32eb964736a4988043fc8b0afa0143ab4246490b97c3" +
+			"7ae54802260e171780adb0e9781c31b087cae4382116957a42a474d1ceed2aac" +
+			"31dbf912563e2e4253214bbd1c480c1ed740f4086fc39fbef294d4580462993d" +
+			"8868c00378e0ea5d87c7a58e7c56a7ff9de2"),
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for i, v := range testVectors {
+		newAEAD := New
+		if v.nonce == nil {
+			newAEAD = NewDeterministic
+		}
+
+		aead, err := newAEAD(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ct := aead.Seal(nil, v.nonce, v.plaintext, v.ad)
+		if !bytes.Equal(ct, v.ciphertext) {
+			t.Errorf("test vector %d: Seal: expected %x, got %x", i, v.ciphertext, ct)
+			continue
+		}
+
+		pt, err := aead.Open(nil, v.nonce, v.ciphertext, v.ad)
+		if err != nil {
+			t.Errorf("test vector %d: Open failed: %v", i, err)
+			continue
+		}
+
+		if !bytes.Equal(pt, v.plaintext) {
+			t.Errorf("test vector %d: Open: expected %x, got %x", i, v.plaintext, pt)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	key := make([]byte, KeySize)
+	rand.Read(key)
+
+	aead, err := New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 200; i++ {
+		nonce := make([]byte, NonceSize)
+		rand.Read(nonce)
+
+		plaintext := make([]byte, rand.Intn(1024))
+		rand.Read(plaintext)
+
+		ad := make([]byte, rand.Intn(64))
+		rand.Read(ad)
+
+		ct := aead.Seal(nil, nonce, plaintext, ad)
+
+		// Sealing and opening in place must give the same result.
+		buf := append(make([]byte, 0, len(plaintext)+TagSize), plaintext...)
+		ct2 := aead.Seal(buf[:0], nonce, buf, ad)
+		if !bytes.Equal(ct, ct2) {
+			t.Fatal("in place Seal differs")
+		}
+
+		pt, err := aead.Open(ct2[:0], nonce, ct2, ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(pt, plaintext) {
+			t.Fatal("Open returned wrong plaintext")
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, NonceSize)
+	plaintext := []byte("attack at dawn, attack at dawn!!")
+	ad := []byte("header")
+
+	aead, err := New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct := aead.Seal(nil, nonce, plaintext, ad)
+
+	for i := 0; i < len(ct)*8; i++ {
+		bad := append([]byte(nil), ct...)
+		bad[i/8] ^= 1 << uint(i%8)
+
+		if _, err := aead.Open(nil, nonce, bad, ad); err == nil {
+			t.Fatalf("Open accepted ciphertext with bit %d flipped", i)
+		}
+	}
+
+	if _, err := aead.Open(nil, nonce, ct, []byte("Header")); err == nil {
+		t.Error("Open accepted wrong additional data")
+	}
+
+	nonce[0] ^= 1
+	if _, err := aead.Open(nil, nonce, ct, ad); err == nil {
+		t.Error("Open accepted wrong nonce")
+	}
+
+	if _, err := aead.Open(nil, nonce, ct[:TagSize-1], ad); err == nil {
+		t.Error("Open accepted short ciphertext")
+	}
+}
+
+func TestNonceReuse(t *testing.T) {
+	aead, err := New(make([]byte, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, NonceSize)
+
+	a1 := aead.Seal(nil, nonce, []byte("message one"), nil)
+	a2 := aead.Seal(nil, nonce, []byte("message one"), nil)
+	b := aead.Seal(nil, nonce, []byte("message two"), nil)
+
+	if !bytes.Equal(a1, a2) {
+		t.Error("equal messages produced different ciphertexts")
+	}
+
+	// With a repeated nonce, the tags select unrelated keystreams, so the
+	// XOR of the ciphertexts must not be the XOR of the plaintexts.
+	var x [11]byte
+	for i := range x {
+		x[i] = a1[i] ^ b[i] ^ "message one"[i] ^ "message two"[i]
+	}
+
+	if x == [11]byte{} {
+		t.Error("different messages were encrypted with the same keystream")
+	}
+}
+
+func TestBadKeySize(t *testing.T) {
+	if _, err := New(make([]byte, KeySize-1)); err != ErrInvalidKey {
+		t.Errorf("New: expected ErrInvalidKey, got %v", err)
+	}
+
+	if _, err := NewDeterministic(make([]byte, KeySize+1)); err != ErrInvalidKey {
+		t.Errorf("NewDeterministic: expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func BenchmarkSeal(b *testing.B) {
+	aead, err := New(make([]byte, KeySize))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	nonce := make([]byte, NonceSize)
+	buf := make([]byte, 16*1024+TagSize)
+
+	b.SetBytes(int64(len(buf) - TagSize))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		aead.Seal(buf[:0], nonce, buf[:len(buf)-TagSize], nil)
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package stream implements the STREAM construction of Hoang, Reyhanitabar,
+// Rogaway and Vizár for streaming authenticated encryption, typically over
+// ChaCha20-Poly1305 or XChaCha20-Poly1305.
+//
+// The plaintext is split into chunks of a fixed size, with only the final
+// chunk allowed to be shorter, and each chunk is sealed with the AEAD under
+// the nonce
+//
+//	prefix || be32(i) || last
+//
+// where i is the index of the chunk and last is 1 for the final chunk and 0
+// otherwise. The final chunk is only empty if the plaintext is. The nonce
+// prefix fills the rest of the AEAD's nonce and must be unique for each
+// stream encrypted with a key. Truncating, reordering or duplicating chunks
+// causes decryption to fail.
+package stream
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	// DefaultChunkSize is the size of plaintext chunks used when a chunk size
+	// of zero is given.
+	DefaultChunkSize = 64 * 1024
+
+	// NonceOverhead is the number of bytes of the AEAD's nonce taken by the
+	// chunk counter and last chunk flag. The nonce prefix must fill the
+	// remainder.
+	NonceOverhead = 5
+
+	maxChunks = 1 << 32
+)
+
+var (
+	// ErrInvalidNoncePrefix is returned when the nonce prefix is not
+	// NonceOverhead bytes shorter than the nonce of the AEAD.
+	ErrInvalidNoncePrefix = errors.New("invalid nonce prefix length")
+
+	// ErrInvalidChunkSize is returned when the chunk size is negative.
+	ErrInvalidChunkSize = errors.New("invalid chunk size")
+
+	errOpen      = errors.New("stream: message authentication failed")
+	errTooLarge  = errors.New("stream: too many chunks")
+	errClosed    = errors.New("stream: write to closed Writer")
+	errTruncated = errors.New("stream: stream truncated")
+	errEmpty     = errors.New("stream: empty final chunk")
+)
+
+type chunker struct {
+	aead      cipher.AEAD
+	nonce     []byte
+	chunkSize int
+
+	counter uint64
+}
+
+func newChunker(aead cipher.AEAD, noncePrefix []byte, chunkSize int) (*chunker, error) {
+	if len(noncePrefix)+NonceOverhead != aead.NonceSize() {
+		return nil, ErrInvalidNoncePrefix
+	}
+
+	if chunkSize < 0 {
+		return nil, ErrInvalidChunkSize
+	}
+
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, noncePrefix)
+
+	return &chunker{
+		aead:      aead,
+		nonce:     nonce,
+		chunkSize: chunkSize,
+	}, nil
+}
+
+// next sets the nonce for the next chunk.
+func (c *chunker) next(last bool) error {
+	if c.counter == maxChunks {
+		return errTooLarge
+	}
+
+	binary.BigEndian.PutUint32(c.nonce[len(c.nonce)-NonceOverhead:], uint32(c.counter))
+
+	if last {
+		c.nonce[len(c.nonce)-1] = 1
+	} else {
+		c.nonce[len(c.nonce)-1] = 0
+	}
+
+	c.counter++
+	return nil
+}
+
+// Writer encrypts data written to it as a STREAM and writes it to an
+// underlying io.Writer. Close must be called to write the final chunk.
+type Writer struct {
+	w io.Writer
+	c *chunker
+
+	buf []byte // the plaintext of the current chunk, with room for the tag
+	n   int    // the number of bytes buffered
+
+	err error
+}
+
+// NewWriter returns a Writer that encrypts to w with aead. The nonce prefix
+// must be NonceOverhead bytes shorter than the nonce of aead, and chunkSize is
+// the size of each chunk of plaintext. If chunkSize is zero, DefaultChunkSize
+// is used.
+func NewWriter(w io.Writer, aead cipher.AEAD, noncePrefix []byte, chunkSize int) (*Writer, error) {
+	c, err := newChunker(aead, noncePrefix, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		w: w,
+		c: c,
+
+		buf: make([]byte, c.chunkSize+aead.Overhead()),
+	}, nil
+}
+
+// Write encrypts p and writes it to the underlying io.Writer. A chunk is only
+// written once it is full and more data follows it, as the final chunk must
+// be sealed differently.
+func (w *Writer) Write(p []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	for len(p) != 0 {
+		if w.n == w.c.chunkSize {
+			if err := w.flush(false); err != nil {
+				return n, err
+			}
+		}
+
+		m := copy(w.buf[w.n:w.c.chunkSize], p)
+		w.n += m
+		n += m
+		p = p[m:]
+	}
+
+	return n, nil
+}
+
+func (w *Writer) flush(last bool) error {
+	if w.err = w.c.next(last); w.err != nil {
+		return w.err
+	}
+
+	out := w.c.aead.Seal(w.buf[:0], w.c.nonce, w.buf[:w.n], nil)
+	w.n = 0
+
+	if _, w.err = w.w.Write(out); w.err != nil {
+		return w.err
+	}
+
+	return nil
+}
+
+// Close seals and writes the final chunk, which is only empty if nothing was
+// written. It does not close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if err := w.flush(true); err != nil {
+		return err
+	}
+
+	w.err = errClosed
+	return nil
+}
+
+// Reader decrypts a STREAM read from an underlying io.Reader. It only returns
+// plaintext from chunks that have been authenticated.
+type Reader struct {
+	r io.Reader
+	c *chunker
+
+	buf   []byte // room for a sealed chunk and one more byte
+	extra byte   // a byte read past the previous chunk
+	carry bool   // whether extra is valid
+
+	plaintext []byte // the unread plaintext of the current chunk
+
+	err error
+}
+
+// NewReader returns a Reader that decrypts from r with aead. The nonce prefix
+// and chunkSize must be those the stream was encrypted with.
+func NewReader(r io.Reader, aead cipher.AEAD, noncePrefix []byte, chunkSize int) (*Reader, error) {
+	c, err := newChunker(aead, noncePrefix, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		r: r,
+		c: c,
+
+		buf: make([]byte, c.chunkSize+aead.Overhead()+1),
+	}, nil
+}
+
+// Read reads decrypted plaintext into p. It returns an error if the stream
+// fails to authenticate, including when it has been truncated, and io.EOF
+// once the final chunk has been read.
+func (r *Reader) Read(p []byte) (n int, err error) {
+	for len(r.plaintext) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		r.err = r.readChunk()
+	}
+
+	n = copy(p, r.plaintext)
+	r.plaintext = r.plaintext[n:]
+	return n, nil
+}
+
+// readChunk reads and opens the next chunk. One byte past the chunk is read
+// to tell whether it is the last.
+func (r *Reader) readChunk() error {
+	start := 0
+	if r.carry {
+		r.buf[0] = r.extra
+		start = 1
+	}
+
+	n, err := io.ReadFull(r.r, r.buf[start:])
+	n += start
+
+	sealed := len(r.buf) - 1
+
+	last := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+
+		if n < r.c.aead.Overhead() {
+			return errTruncated
+		}
+
+		sealed = n
+
+		// An empty final chunk after a full one is never written, and
+		// would give the plaintext a second encoding.
+		if sealed == r.c.aead.Overhead() && r.c.counter != 0 {
+			return errEmpty
+		}
+	default:
+		return err
+	}
+
+	if err := r.c.next(last); err != nil {
+		return err
+	}
+
+	plaintext, err := r.c.aead.Open(r.buf[:0], r.c.nonce, r.buf[:sealed], nil)
+	if err != nil {
+		return errOpen
+	}
+
+	r.plaintext = plaintext
+
+	if last {
+		return io.EOF
+	}
+
+	// Keep the byte that was read past this chunk for the next one.
+	r.extra = r.buf[sealed]
+	r.carry = true
+	return nil
+}
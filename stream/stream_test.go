@@ -0,0 +1,334 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package stream
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+)
+
+func newAEAD(t testing.TB, x bool) cipher.AEAD {
+	key := make([]byte, chacha20poly1305.KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	newAEAD := chacha20poly1305.New
+	if x {
+		newAEAD = chacha20poly1305.NewX
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return aead
+}
+
+func encrypt(t testing.TB, aead cipher.AEAD, prefix, plaintext []byte, chunkSize int, rand *rand.Rand) []byte {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, aead, prefix, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for p := plaintext; len(p) != 0; {
+		n := rand.Intn(len(p) + 1)
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+
+		p = p[n:]
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func decrypt(aead cipher.AEAD, prefix, ciphertext []byte, chunkSize int) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(ciphertext), aead, prefix, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(r)
+}
+
+func testRoundTrip(t *testing.T, x bool) {
+	rand := rand.New(rand.NewSource(0))
+	aead := newAEAD(t, x)
+
+	prefix := make([]byte, aead.NonceSize()-NonceOverhead)
+	rand.Read(prefix)
+
+	for _, chunkSize := range []int{1, 16, 100, 1024} {
+		for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3 * chunkSize, 5000} {
+			if size < 0 {
+				continue
+			}
+
+			plaintext := make([]byte, size)
+			rand.Read(plaintext)
+
+			ciphertext := encrypt(t, aead, prefix, plaintext, chunkSize, rand)
+
+			chunks := (size + chunkSize) / chunkSize
+			if size != 0 && size%chunkSize == 0 {
+				chunks--
+			}
+
+			if expect := size + chunks*aead.Overhead(); len(ciphertext) != expect {
+				t.Errorf("chunk size %d, size %d: expected %d bytes of ciphertext, got %d",
+					chunkSize, size, expect, len(ciphertext))
+			}
+
+			got, err := decrypt(aead, prefix, ciphertext, chunkSize)
+			if err != nil {
+				t.Fatalf("chunk size %d, size %d: %v", chunkSize, size, err)
+			}
+
+			if !bytes.Equal(got, plaintext) {
+				t.Fatalf("chunk size %d, size %d: wrong plaintext", chunkSize, size)
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	testRoundTrip(t, false)
+}
+
+func TestRoundTripX(t *testing.T) {
+	testRoundTrip(t, true)
+}
+
+func TestTampering(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+	aead := newAEAD(t, true)
+	prefix := make([]byte, aead.NonceSize()-NonceOverhead)
+
+	const chunkSize = 64
+	sealed := chunkSize + aead.Overhead()
+
+	plaintext := make([]byte, 4*chunkSize+10)
+	rand.Read(plaintext)
+
+	ct := encrypt(t, aead, prefix, plaintext, chunkSize, rand)
+
+	chunk := func(i int) []byte {
+		end := (i + 1) * sealed
+		if end > len(ct) {
+			end = len(ct)
+		}
+
+		return ct[i*sealed : end]
+	}
+
+	join := func(chunks ...[]byte) []byte {
+		var b []byte
+		for _, c := range chunks {
+			b = append(b, c...)
+		}
+
+		return b
+	}
+
+	for name, bad := range map[string][]byte{
+		"drop last chunk":      ct[:4*sealed],
+		"truncate last chunk":  ct[:len(ct)-1],
+		"truncate mid chunk":   ct[:2*sealed+10],
+		"truncate to tag size": ct[:aead.Overhead()-1],
+		"empty":                nil,
+		"reorder chunks":       join(chunk(1), chunk(0), chunk(2), chunk(3), chunk(4)),
+		"duplicate chunk":      join(chunk(0), chunk(0), chunk(1), chunk(2), chunk(3), chunk(4)),
+		"drop middle chunk":    join(chunk(0), chunk(2), chunk(3), chunk(4)),
+		"extend with garbage":  append(append([]byte(nil), ct...), 0),
+	} {
+		if _, err := decrypt(aead, prefix, bad, chunkSize); err == nil {
+			t.Errorf("%s: decryption succeeded", name)
+		}
+	}
+
+	for i := 0; i < len(ct); i += 7 {
+		bad := append([]byte(nil), ct...)
+		bad[i] ^= 0x80
+
+		if _, err := decrypt(aead, prefix, bad, chunkSize); err == nil {
+			t.Fatalf("decryption succeeded with byte %d modified", i)
+		}
+	}
+
+	if _, err := decrypt(aead, prefix, ct, chunkSize+1); err == nil {
+		t.Error("decryption succeeded with the wrong chunk size")
+	}
+
+	otherPrefix := append([]byte(nil), prefix...)
+	otherPrefix[0] ^= 1
+
+	if _, err := decrypt(aead, otherPrefix, ct, chunkSize); err == nil {
+		t.Error("decryption succeeded with the wrong nonce prefix")
+	}
+}
+
+func TestNoUnauthenticatedPlaintext(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+	aead := newAEAD(t, false)
+	prefix := make([]byte, aead.NonceSize()-NonceOverhead)
+
+	const chunkSize = 64
+	sealed := chunkSize + aead.Overhead()
+
+	plaintext := make([]byte, 3*chunkSize)
+	rand.Read(plaintext)
+
+	ct := encrypt(t, aead, prefix, plaintext, chunkSize, rand)
+
+	// Corrupt the tag of the second chunk.
+	ct[2*sealed-1] ^= 1
+
+	r, err := NewReader(bytes.NewReader(ct), aead, prefix, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadAll(r)
+	if err == nil {
+		t.Fatal("decryption succeeded")
+	}
+
+	if !bytes.Equal(got, plaintext[:chunkSize]) {
+		t.Fatalf("expected only the first chunk to be returned, got %d bytes", len(got))
+	}
+
+	// The error is sticky.
+	if _, err2 := r.Read(make([]byte, 1)); err2 != err {
+		t.Errorf("expected %v, got %v", err, err2)
+	}
+}
+
+func TestEmptyFinalChunk(t *testing.T) {
+	aead := newAEAD(t, false)
+	prefix := make([]byte, aead.NonceSize()-NonceOverhead)
+
+	const chunkSize = 64
+
+	// Seal a full chunk followed by an empty final chunk, which Writer
+	// never does.
+	c, err := newChunker(aead, prefix, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ct []byte
+	for _, last := range []bool{false, true} {
+		if err := c.next(last); err != nil {
+			t.Fatal(err)
+		}
+
+		n := chunkSize
+		if last {
+			n = 0
+		}
+
+		ct = aead.Seal(ct, c.nonce, make([]byte, n), nil)
+	}
+
+	if _, err := decrypt(aead, prefix, ct, chunkSize); err != errEmpty {
+		t.Errorf("expected %v, got %v", errEmpty, err)
+	}
+
+	// An empty stream is a single empty final chunk.
+	if got, err := decrypt(aead, prefix, encrypt(t, aead, prefix, nil, chunkSize, nil), chunkSize); err != nil || len(got) != 0 {
+		t.Errorf("decryption of empty stream failed: %v", err)
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	aead := newAEAD(t, false)
+
+	w, err := NewWriter(ioutil.Discard, aead, make([]byte, aead.NonceSize()-NonceOverhead), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte{0}); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+}
+
+func TestBadParameters(t *testing.T) {
+	aead := newAEAD(t, false)
+
+	if _, err := NewWriter(ioutil.Discard, aead, make([]byte, aead.NonceSize()), 0); err != ErrInvalidNoncePrefix {
+		t.Errorf("expected ErrInvalidNoncePrefix, got %v", err)
+	}
+
+	if _, err := NewReader(bytes.NewReader(nil), aead, make([]byte, aead.NonceSize()-NonceOverhead), -1); err != ErrInvalidChunkSize {
+		t.Errorf("expected ErrInvalidChunkSize, got %v", err)
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	aead := newAEAD(b, true)
+	prefix := make([]byte, aead.NonceSize()-NonceOverhead)
+	buf := make([]byte, 1<<20)
+
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		w, err := NewWriter(ioutil.Discard, aead, prefix, 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		w.Write(buf)
+		w.Close()
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	aead := newAEAD(b, true)
+	prefix := make([]byte, aead.NonceSize()-NonceOverhead)
+
+	var ct bytes.Buffer
+	w, err := NewWriter(&ct, aead, prefix, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	w.Write(make([]byte, 1<<20))
+	w.Close()
+
+	b.SetBytes(1 << 20)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r, err := NewReader(bytes.NewReader(ct.Bytes()), aead, prefix, 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := io.Copy(ioutil.Discard, r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
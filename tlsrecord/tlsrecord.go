@@ -0,0 +1,382 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package tlsrecord implements record protection for the ChaCha20-Poly1305
+// cipher suites of TLS 1.2 (RFC 7905) and TLS 1.3 (RFC 8446), such as
+// TLS_CHACHA20_POLY1305_SHA256, on top of
+// github.com/tmthrgd/chacha20/chacha20poly1305.
+//
+// A Sealer or Opener holds the write key and IV of one direction of a
+// connection, along with its sequence number. The nonce of each record is the
+// 12-byte IV XORed with the 64-bit big-endian sequence number, right aligned.
+//
+// In TLS 1.2, the additional data is
+//
+//	be64(seq) || type || version || be16(plaintext length)
+//
+// In TLS 1.3, the additional data is the 5-byte record header, and the
+// plaintext is the content, followed by its true content type and any number
+// of zero bytes of padding. The record itself always claims to be
+// application_data.
+//
+// The key schedule and the handshake are left to the caller. An error from
+// Open other than ErrInvalidRecord corresponds to a fatal alert, after which
+// the connection must not be used.
+package tlsrecord
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+	"github.com/tmthrgd/chacha20/internal/slice"
+)
+
+const (
+	// KeySize is the length of keys, in bytes.
+	KeySize = chacha20poly1305.KeySize
+
+	// IVSize is the length of the write IV, in bytes.
+	IVSize = chacha20poly1305.NonceSize
+
+	// HeaderSize is the length of the record header, in bytes.
+	HeaderSize = 5
+
+	// Overhead is the number of bytes a protected record adds to its
+	// content, excluding the header, padding and, in TLS 1.3, the content
+	// type.
+	Overhead = chacha20poly1305.Overhead
+
+	// MaxPlaintext is the largest content that may be carried by a single
+	// record, in bytes.
+	MaxPlaintext = 1 << 14
+)
+
+// Version is a TLS protocol version.
+type Version uint16
+
+// The supported TLS versions.
+const (
+	VersionTLS12 Version = 0x0303
+	VersionTLS13 Version = 0x0304
+)
+
+// ContentType is the type of a TLS record.
+type ContentType uint8
+
+// The TLS content types.
+const (
+	TypeChangeCipherSpec ContentType = 20
+	TypeAlert            ContentType = 21
+	TypeHandshake        ContentType = 22
+	TypeApplicationData  ContentType = 23
+)
+
+// legacyVersion is the record version of all TLS 1.2 and TLS 1.3 records.
+const legacyVersion = uint16(VersionTLS12)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	// ErrInvalidIV is returned when the provided IV is not IVSize bytes long.
+	ErrInvalidIV = errors.New("invalid IV length")
+
+	// ErrInvalidVersion is returned when the version is not VersionTLS12 or
+	// VersionTLS13.
+	ErrInvalidVersion = errors.New("invalid version")
+
+	// ErrInvalidLimit is returned by SetRecordSizeLimit when the limit is
+	// out of range.
+	ErrInvalidLimit = errors.New("invalid record size limit")
+
+	// ErrInvalidPadding is returned by SealPadded when the padding is
+	// negative, or non-zero for TLS 1.2.
+	ErrInvalidPadding = errors.New("invalid padding")
+
+	// ErrInvalidRecord is returned by Open when the record is shorter than
+	// its header, or the header's length does not match the record.
+	ErrInvalidRecord = errors.New("tlsrecord: invalid record")
+
+	// ErrRecordOverflow is returned when a record exceeds the record size
+	// limit. It corresponds to the record_overflow alert.
+	ErrRecordOverflow = errors.New("tlsrecord: record overflow")
+
+	// ErrBadRecordMAC is returned by Open when the record fails to
+	// authenticate. It corresponds to the bad_record_mac alert.
+	ErrBadRecordMAC = errors.New("tlsrecord: bad record MAC")
+
+	// ErrUnexpectedMessage is returned by Open when a TLS 1.3 record is not
+	// application_data, or has no content type. It corresponds to the
+	// unexpected_message alert.
+	ErrUnexpectedMessage = errors.New("tlsrecord: unexpected message")
+
+	// ErrSequenceOverflow is returned once 2^64 records have been protected
+	// with the same key, as the sequence number must not wrap.
+	ErrSequenceOverflow = errors.New("tlsrecord: sequence number exhausted")
+)
+
+// state is the key, IV and sequence number of one direction of a
+// connection.
+type state struct {
+	aead cipher.AEAD
+	iv   [IVSize]byte
+
+	version Version
+
+	seq       uint64
+	exhausted bool
+
+	// limit is the largest plaintext of a record; in TLS 1.3 it includes
+	// the content type and padding.
+	limit int
+}
+
+func newState(version Version, key, iv []byte) (*state, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStateAEAD(version, aead, iv)
+}
+
+func newStateAEAD(version Version, aead cipher.AEAD, iv []byte) (*state, error) {
+	if len(iv) != IVSize {
+		return nil, ErrInvalidIV
+	}
+
+	s := &state{aead: aead, version: version}
+	copy(s.iv[:], iv)
+
+	switch version {
+	case VersionTLS12:
+		s.limit = MaxPlaintext
+	case VersionTLS13:
+		s.limit = MaxPlaintext + 1
+	default:
+		return nil, ErrInvalidVersion
+	}
+
+	return s, nil
+}
+
+// Sequence returns the sequence number of the next record.
+func (s *state) Sequence() uint64 {
+	return s.seq
+}
+
+// SetRecordSizeLimit lowers the largest plaintext of a record to limit, as
+// negotiated by the record_size_limit extension of RFC 8449. In TLS 1.3 the
+// limit includes the content type and padding. limit must be between 64 and
+// the protocol's maximum, which is MaxPlaintext for TLS 1.2 and
+// MaxPlaintext+1 for TLS 1.3.
+func (s *state) SetRecordSizeLimit(limit int) error {
+	max := MaxPlaintext
+	if s.version == VersionTLS13 {
+		max++
+	}
+
+	if limit < 64 || limit > max {
+		return ErrInvalidLimit
+	}
+
+	s.limit = limit
+	return nil
+}
+
+// nonce returns the nonce for the current sequence number.
+func (s *state) nonce() []byte {
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], s.seq)
+
+	nonce := make([]byte, IVSize)
+	copy(nonce, s.iv[:])
+
+	for i, b := range seq {
+		nonce[IVSize-8+i] ^= b
+	}
+
+	return nonce
+}
+
+// increment advances the sequence number after a record.
+func (s *state) increment() {
+	s.seq++
+	s.exhausted = s.seq == 0
+}
+
+// additionalData12 returns the TLS 1.2 additional data.
+func (s *state) additionalData12(typ ContentType, version uint16, n int) []byte {
+	ad := make([]byte, 13)
+	binary.BigEndian.PutUint64(ad, s.seq)
+	ad[8] = byte(typ)
+	binary.BigEndian.PutUint16(ad[9:], version)
+	binary.BigEndian.PutUint16(ad[11:], uint16(n))
+	return ad
+}
+
+// Sealer protects the records sent in one direction of a connection.
+type Sealer struct {
+	state
+}
+
+// NewSealer returns a Sealer for the given version that protects records with
+// the given 256-bit write key and 12-byte write IV, starting at sequence
+// number zero.
+func NewSealer(version Version, key, iv []byte) (*Sealer, error) {
+	s, err := newState(version, key, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sealer{*s}, nil
+}
+
+// Seal protects data as a record of the given type, appends the record,
+// including its header, to dst and returns the updated slice. The remaining
+// capacity of dst must not overlap data.
+func (s *Sealer) Seal(dst []byte, typ ContentType, data []byte) ([]byte, error) {
+	return s.SealPadded(dst, typ, data, 0)
+}
+
+// SealPadded is like Seal, but adds padding zero bytes to the TLS 1.3 inner
+// plaintext to hide the length of data. padding must be zero for TLS 1.2.
+func (s *Sealer) SealPadded(dst []byte, typ ContentType, data []byte, padding int) ([]byte, error) {
+	if padding < 0 || (padding != 0 && s.version != VersionTLS13) {
+		return nil, ErrInvalidPadding
+	}
+
+	if s.exhausted {
+		return nil, ErrSequenceOverflow
+	}
+
+	n := len(data)
+	if s.version == VersionTLS13 {
+		n += 1 + padding
+	}
+
+	if n > s.limit {
+		return nil, ErrRecordOverflow
+	}
+
+	ret, out := slice.ForAppend(dst, HeaderSize+n+s.aead.Overhead())
+	hdr, payload := out[:HeaderSize], out[HeaderSize:HeaderSize+n]
+
+	copy(payload, data)
+
+	var ad []byte
+	if s.version == VersionTLS13 {
+		payload[len(data)] = byte(typ)
+
+		for i := len(data) + 1; i < n; i++ {
+			payload[i] = 0
+		}
+
+		hdr[0] = byte(TypeApplicationData)
+		ad = hdr
+	} else {
+		hdr[0] = byte(typ)
+		ad = s.additionalData12(typ, legacyVersion, n)
+	}
+
+	binary.BigEndian.PutUint16(hdr[1:], legacyVersion)
+	binary.BigEndian.PutUint16(hdr[3:], uint16(len(out)-HeaderSize))
+
+	s.aead.Seal(payload[:0], s.nonce(), payload, ad)
+
+	s.increment()
+	return ret, nil
+}
+
+// Opener removes the protection from the records received in one direction
+// of a connection.
+type Opener struct {
+	state
+}
+
+// NewOpener returns an Opener for the given version that opens records
+// protected with the given 256-bit write key and 12-byte write IV, starting
+// at sequence number zero.
+func NewOpener(version Version, key, iv []byte) (*Opener, error) {
+	s, err := newState(version, key, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Opener{*s}, nil
+}
+
+// Open authenticates and decrypts record, which must be a single complete
+// record including its header, appends its content to dst and returns the
+// updated slice along with the record's content type. For TLS 1.3, the
+// content type and padding are removed. The remaining capacity of dst must
+// not overlap record.
+//
+// If the record fails to authenticate, the sequence number is not advanced.
+func (o *Opener) Open(dst, record []byte) ([]byte, ContentType, error) {
+	if len(record) < HeaderSize ||
+		int(binary.BigEndian.Uint16(record[3:])) != len(record)-HeaderSize {
+		return nil, 0, ErrInvalidRecord
+	}
+
+	if o.exhausted {
+		return nil, 0, ErrSequenceOverflow
+	}
+
+	hdr, ciphertext := record[:HeaderSize], record[HeaderSize:]
+	typ := ContentType(hdr[0])
+
+	if o.version == VersionTLS13 && typ != TypeApplicationData {
+		return nil, 0, ErrUnexpectedMessage
+	}
+
+	if len(ciphertext) < o.aead.Overhead() {
+		return nil, 0, ErrBadRecordMAC
+	}
+
+	// As the only expansion is the tag, the length of the plaintext is
+	// known and can be checked against the limit before decrypting.
+	n := len(ciphertext) - o.aead.Overhead()
+	if n > o.limit {
+		return nil, 0, ErrRecordOverflow
+	}
+
+	var ad []byte
+	if o.version == VersionTLS13 {
+		ad = hdr
+	} else {
+		ad = o.additionalData12(typ, binary.BigEndian.Uint16(hdr[1:]), n)
+	}
+
+	ret, err := o.aead.Open(dst, o.nonce(), ciphertext, ad)
+	if err != nil {
+		return nil, 0, ErrBadRecordMAC
+	}
+
+	o.increment()
+
+	if o.version == VersionTLS12 {
+		return ret, typ, nil
+	}
+
+	// The content type is the last non-zero byte of the inner plaintext.
+	plaintext := ret[len(dst):]
+	i := len(plaintext) - 1
+	for i >= 0 && plaintext[i] == 0 {
+		i--
+	}
+
+	if i < 0 {
+		return nil, 0, ErrUnexpectedMessage
+	}
+
+	return ret[:len(dst)+i], ContentType(plaintext[i]), nil
+}
@@ -0,0 +1,477 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package tlsrecord
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"math"
+	"testing"
+)
+
+func mustHexDecode(v string) []byte {
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+var (
+	testKey = mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	testIV  = mustHexDecode("4b5b6c7d8e9fa0b1c2d3e4f5")
+)
+
+var testVectors = []struct {
+	version Version
+	seq     uint64
+	typ     ContentType
+	data    []byte
+	padding int
+	record  []byte
+}{
+	{
+		version: VersionTLS13,
+		typ:     TypeApplicationData,
+		data:    []byte("Hello, world!"),
+		record:  mustHexDecode("170303001e083bb5f0fbf2cf7a55de410a52407dff01c1a65eaab2a447a93a9c97b6a0"),
+	},
+	{
+		version: VersionTLS13,
+		seq:     1,
+		typ:     TypeHandshake,
+		data:    mustHexDecode("14000000"),
+		padding: 7,
+		record:  mustHexDecode("170303001c84fed431b53d1a4829bda9138b604c87fedc6d76c0f92e431ed22822"),
+	},
+	{
+		version: VersionTLS13,
+		seq:     0x0123456789abcdef,
+		typ:     TypeAlert,
+		data:    mustHexDecode("0100"),
+		record:  mustHexDecode("1703030013e6ee998aac931b9ab1d3128bee4680e0604caf"),
+	},
+	{
+		version: VersionTLS12,
+		typ:     TypeApplicationData,
+		data:    []byte("Hello, world!"),
+		record:  mustHexDecode("170303001d083bb5f0fbf2cf7a55de410a52529d745acf6cbde5c3ecc2d0b75913d8"),
+	},
+	{
+		version: VersionTLS12,
+		seq:     1,
+		typ:     TypeHandshake,
+		data:    mustHexDecode("14000000"),
+		record:  mustHexDecode("160303001484fed431a90466e38ac05cf6fa816fe2e9cdee97"),
+	},
+	{
+		version: VersionTLS12,
+		seq:     0x0123456789abcdef,
+		typ:     TypeAlert,
+		data:    mustHexDecode("0100"),
+		record:  mustHexDecode("1503030012e6ee92f690471098a7d3c0565bd4ba14a56a"),
+	},
+}
+
+func TestSeal(t *testing.T) {
+	for i, v := range testVectors {
+		s, err := NewSealer(v.version, testKey, testIV)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s.seq = v.seq
+
+		record, err := s.SealPadded(nil, v.typ, v.data, v.padding)
+		if err != nil {
+			t.Errorf("%d: SealPadded failed: %v", i, err)
+			continue
+		}
+
+		if !bytes.Equal(record, v.record) {
+			t.Errorf("%d: invalid record, expected %x, got %x", i, v.record, record)
+		}
+
+		if s.Sequence() != v.seq+1 {
+			t.Errorf("%d: sequence number not incremented", i)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for i, v := range testVectors {
+		o, err := NewOpener(v.version, testKey, testIV)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		o.seq = v.seq
+
+		data, typ, err := o.Open(nil, v.record)
+		if err != nil {
+			t.Errorf("%d: Open failed: %v", i, err)
+			continue
+		}
+
+		if typ != v.typ {
+			t.Errorf("%d: invalid content type, expected %d, got %d", i, v.typ, typ)
+		}
+
+		if !bytes.Equal(data, v.data) {
+			t.Errorf("%d: invalid content, expected %x, got %x", i, v.data, data)
+		}
+
+		if o.Sequence() != v.seq+1 {
+			t.Errorf("%d: sequence number not incremented", i)
+		}
+	}
+}
+
+// RFC 8448 only contains traces of TLS_AES_128_GCM_SHA256. These are the
+// application data records of section 3, which exercise the record
+// construction with AES-128-GCM in place of ChaCha20-Poly1305.
+var rfc8448Vectors = []struct {
+	key, iv []byte
+	seq     uint64
+	record  []byte
+}{
+	{ // client
+		key: mustHexDecode("17422dda596ed5d9acd890e3c63f5051"),
+		iv:  mustHexDecode("5b78923dee08579033e523d9"),
+		record: mustHexDecode("1703030043a23f7054b62c94d0affafe8228ba55cbefacea42f914aa66bcab3f" +
+			"2b9819a8a5b46b395bd54a9a20441e2b62974e1f5a6292a2977014bd1e3deae6" +
+			"3aeebb21694915e4"),
+	},
+	{ // server, following its NewSessionTicket
+		key: mustHexDecode("9f02283b6c9c07efc26bb9f2ac92e356"),
+		iv:  mustHexDecode("cf782b88dd83549aadf1e984"),
+		seq: 1,
+		record: mustHexDecode("17030300432e937e11ef4ac740e538ad36005fc4a46932fc3225d05f82aa1b36" +
+			"e30efaf97d90e6dffc602dcb501a59a8fcc49c4bf2e5f0a21c0047c2abf33254" +
+			"0dd032e167c2955d"),
+	},
+}
+
+func TestRFC8448(t *testing.T) {
+	data := make([]byte, 50)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	for i, v := range rfc8448Vectors {
+		block, err := aes.NewCipher(v.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		st, err := newStateAEAD(VersionTLS13, gcm, v.iv)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		st.seq = v.seq
+		s, o := &Sealer{*st}, &Opener{*st}
+
+		record, err := s.Seal(nil, TypeApplicationData, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(record, v.record) {
+			t.Errorf("%d: invalid record, expected %x, got %x", i, v.record, record)
+		}
+
+		got, typ, err := o.Open(nil, v.record)
+		if err != nil {
+			t.Errorf("%d: Open failed: %v", i, err)
+			continue
+		}
+
+		if typ != TypeApplicationData || !bytes.Equal(got, data) {
+			t.Errorf("%d: Open returned wrong content", i)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, version := range []Version{VersionTLS12, VersionTLS13} {
+		s, err := NewSealer(version, testKey, testIV)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		o, err := NewOpener(version, testKey, testIV)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		prefix := []byte("prefix")
+
+		for n := 0; n <= MaxPlaintext; n += 1 + n*3 {
+			data := bytes.Repeat([]byte{0xa5}, n)
+
+			padding := 0
+			if version == VersionTLS13 && n < MaxPlaintext-64 {
+				padding = n % 64
+			}
+
+			record, err := s.SealPadded(nil, TypeApplicationData, data, padding)
+			if err != nil {
+				t.Fatalf("%x: SealPadded failed for %d bytes: %v", version, n, err)
+			}
+
+			got, typ, err := o.Open(prefix, record)
+			if err != nil {
+				t.Fatalf("%x: Open failed for %d bytes: %v", version, n, err)
+			}
+
+			if typ != TypeApplicationData || !bytes.Equal(got[:len(prefix)], prefix) ||
+				!bytes.Equal(got[len(prefix):], data) {
+				t.Fatalf("%x: round trip failed for %d bytes", version, n)
+			}
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	for i, v := range testVectors {
+		for j := range v.record {
+			if j == 3 || j == 4 || (j == 0 && v.version == VersionTLS13) {
+				continue
+			}
+
+			o, err := NewOpener(v.version, testKey, testIV)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			o.seq = v.seq
+
+			record := append([]byte(nil), v.record...)
+			record[j] ^= 0x10
+
+			if _, _, err := o.Open(nil, record); err != ErrBadRecordMAC {
+				t.Errorf("%d: Open succeeded with byte %d modified", i, j)
+			}
+
+			if o.Sequence() != v.seq {
+				t.Errorf("%d: sequence number incremented after failure", i)
+			}
+		}
+
+		if v.seq == 0 {
+			continue
+		}
+
+		o, err := NewOpener(v.version, testKey, testIV)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := o.Open(nil, v.record); err != ErrBadRecordMAC {
+			t.Errorf("%d: Open succeeded with wrong sequence number", i)
+		}
+	}
+}
+
+func TestInvalidRecords(t *testing.T) {
+	o, err := NewOpener(VersionTLS13, testKey, testIV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := testVectors[0].record
+
+	if _, _, err := o.Open(nil, record[:3]); err != ErrInvalidRecord {
+		t.Error("Open accepted truncated header")
+	}
+
+	if _, _, err := o.Open(nil, record[:len(record)-1]); err != ErrInvalidRecord {
+		t.Error("Open accepted truncated record")
+	}
+
+	if _, _, err := o.Open(nil, []byte{23, 3, 3, 0, 15}); err != ErrInvalidRecord {
+		t.Error("Open accepted mismatched length")
+	}
+
+	if _, _, err := o.Open(nil, []byte{23, 3, 3, 0, 0}); err != ErrBadRecordMAC {
+		t.Error("Open accepted record shorter than tag")
+	}
+
+	hs := append([]byte(nil), record...)
+	hs[0] = byte(TypeHandshake)
+
+	if _, _, err := o.Open(nil, hs); err != ErrUnexpectedMessage {
+		t.Error("Open accepted TLS 1.3 record that was not application_data")
+	}
+}
+
+func TestNoContentType(t *testing.T) {
+	s, err := NewSealer(VersionTLS13, testKey, testIV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := NewOpener(VersionTLS13, testKey, testIV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A content type of zero is indistinguishable from padding.
+	record, err := s.SealPadded(nil, 0, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := o.Open(nil, record); err != ErrUnexpectedMessage {
+		t.Errorf("Open accepted record without content type: %v", err)
+	}
+}
+
+func TestRecordSizeLimit(t *testing.T) {
+	for _, version := range []Version{VersionTLS12, VersionTLS13} {
+		s, err := NewSealer(version, testKey, testIV)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		o, err := NewOpener(version, testKey, testIV)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.Seal(nil, TypeApplicationData, make([]byte, MaxPlaintext+1)); err != ErrRecordOverflow {
+			t.Errorf("%x: Seal accepted %d bytes", version, MaxPlaintext+1)
+		}
+
+		if version == VersionTLS13 {
+			if _, err := s.SealPadded(nil, TypeApplicationData, make([]byte, MaxPlaintext), 1); err != ErrRecordOverflow {
+				t.Errorf("%x: SealPadded accepted padding beyond the limit", version)
+			}
+		}
+
+		record, err := s.Seal(nil, TypeApplicationData, make([]byte, 100))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, limit := range []int{0, 63, MaxPlaintext + 2} {
+			if err := o.SetRecordSizeLimit(limit); err != ErrInvalidLimit {
+				t.Errorf("%x: SetRecordSizeLimit accepted %d", version, limit)
+			}
+		}
+
+		if err := o.SetRecordSizeLimit(64); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := o.Open(nil, record); err != ErrRecordOverflow {
+			t.Errorf("%x: Open accepted record beyond the limit", version)
+		}
+
+		if err := s.SetRecordSizeLimit(64); err != nil {
+			t.Fatal(err)
+		}
+
+		n := 64
+		if version == VersionTLS13 {
+			n--
+		}
+
+		if _, err := s.Seal(nil, TypeApplicationData, make([]byte, n+1)); err != ErrRecordOverflow {
+			t.Errorf("%x: Seal accepted record beyond the limit", version)
+		}
+
+		if _, err := s.Seal(nil, TypeApplicationData, make([]byte, n)); err != nil {
+			t.Errorf("%x: Seal rejected record at the limit: %v", version, err)
+		}
+	}
+}
+
+func TestSequenceOverflow(t *testing.T) {
+	s, err := NewSealer(VersionTLS13, testKey, testIV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := NewOpener(VersionTLS13, testKey, testIV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.seq, o.seq = math.MaxUint64, math.MaxUint64
+
+	record, err := s.Seal(nil, TypeApplicationData, []byte("last"))
+	if err != nil {
+		t.Fatalf("Seal failed for the last sequence number: %v", err)
+	}
+
+	if _, _, err := o.Open(nil, record); err != nil {
+		t.Fatalf("Open failed for the last sequence number: %v", err)
+	}
+
+	if _, err := s.Seal(nil, TypeApplicationData, []byte("wrapped")); err != ErrSequenceOverflow {
+		t.Error("Seal allowed the sequence number to wrap")
+	}
+
+	if _, _, err := o.Open(nil, record); err != ErrSequenceOverflow {
+		t.Error("Open allowed the sequence number to wrap")
+	}
+}
+
+func TestBadParameters(t *testing.T) {
+	if _, err := NewSealer(VersionTLS13, testKey[:16], testIV); err != ErrInvalidKey {
+		t.Error("NewSealer accepted invalid key")
+	}
+
+	if _, err := NewOpener(VersionTLS13, testKey, testIV[:8]); err != ErrInvalidIV {
+		t.Error("NewOpener accepted invalid IV")
+	}
+
+	if _, err := NewSealer(0x0302, testKey, testIV); err != ErrInvalidVersion {
+		t.Error("NewSealer accepted TLS 1.1")
+	}
+
+	s, err := NewSealer(VersionTLS12, testKey, testIV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.SealPadded(nil, TypeApplicationData, nil, 1); err != ErrInvalidPadding {
+		t.Error("SealPadded accepted padding for TLS 1.2")
+	}
+
+	if _, err := s.SealPadded(nil, TypeApplicationData, nil, -1); err != ErrInvalidPadding {
+		t.Error("SealPadded accepted negative padding")
+	}
+}
+
+func BenchmarkSeal(b *testing.B) {
+	s, err := NewSealer(VersionTLS13, testKey, testIV)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	data := make([]byte, MaxPlaintext)
+	buf := make([]byte, 0, HeaderSize+MaxPlaintext+1+Overhead)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Seal(buf, TypeApplicationData, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20
+
+import "crypto/cipher"
+
+// XORKeyStreamVec XORs each byte in the fragments of src with a byte from the
+// key stream of s, writing the result to the corresponding fragments of dst.
+// The fragments are treated as a single logical message: the output is the
+// same as concatenating src, calling XORKeyStream once and splitting the
+// result back up. It is intended for scatter/gather buffers such as
+// net.Buffers.
+//
+// dst and src must have the same number of fragments and each fragment of
+// dst must be at least as long as the corresponding fragment of src. A
+// fragment of dst may alias the corresponding fragment of src exactly but
+// must not otherwise overlap it.
+//
+// The streams returned by this package treat the fragments as one keystream.
+// Whole blocks are passed directly to the assembly implementation, even when
+// they straddle fragments, and only the keystream for a final partial block is
+// buffered. Other streams have XORKeyStream called once for each fragment.
+func XORKeyStreamVec(s cipher.Stream, dst, src [][]byte) {
+	if len(dst) != len(src) {
+		panic("chacha20: dst and src have a different number of fragments")
+	}
+
+	for i, in := range src {
+		if len(dst[i]) < len(in) {
+			panic("chacha20: output fragment smaller than input")
+		}
+	}
+
+	if vs, ok := s.(interface {
+		XORKeyStreamVec(dst, src [][]byte)
+	}); ok {
+		vs.XORKeyStreamVec(dst, src)
+		return
+	}
+
+	for i, in := range src {
+		s.XORKeyStream(dst[i], in)
+	}
+}
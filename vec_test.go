@@ -0,0 +1,218 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"math/rand"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/tmthrgd/chacha20/internal/ref"
+)
+
+func split(rand *rand.Rand, b []byte) [][]byte {
+	var frags [][]byte
+	for len(b) != 0 {
+		var n int
+		switch rand.Intn(4) {
+		case 0:
+			n = 0
+		case 1:
+			n = rand.Intn(16)
+		case 2:
+			n = rand.Intn(256)
+		default:
+			n = rand.Intn(4096)
+		}
+
+		if n > len(b) {
+			n = len(b)
+		}
+
+		frags = append(frags, b[:n])
+		b = b[n:]
+	}
+
+	return frags
+}
+
+func testXORKeyStreamVec(t *testing.T, newChaCha20 func(key, nonce []byte) (cipher.Stream, error), nonceSize int) {
+	rand := rand.New(rand.NewSource(0))
+
+	for i := 0; i < 64; i++ {
+		key := make([]byte, KeySize)
+		nonce := make([]byte, nonceSize)
+		rand.Read(key)
+		rand.Read(nonce)
+
+		src := make([]byte, rand.Intn(16*1024))
+		rand.Read(src)
+
+		c1, err := newChaCha20(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c2, err := newChaCha20(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Start part way into a block some of the time.
+		skip := rand.Intn(3) * rand.Intn(64)
+		if skip != 0 {
+			var tmp [128]byte
+			c1.XORKeyStream(tmp[:skip], tmp[:skip])
+			c2.XORKeyStream(tmp[:skip], tmp[:skip])
+		}
+
+		expect := make([]byte, len(src))
+		c1.XORKeyStream(expect, src)
+
+		dst := make([]byte, len(src))
+		copy(dst, src)
+
+		// Encrypt in place, as with net.Buffers.
+		bufs := net.Buffers(split(rand, dst))
+		XORKeyStreamVec(c2, bufs, bufs)
+
+		if !bytes.Equal(dst, expect) {
+			t.Fatalf("XORKeyStreamVec differs from XORKeyStream with %d fragments", len(bufs))
+		}
+
+		// Decrypt out of place, into longer output fragments.
+		c3, err := newChaCha20(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c3.XORKeyStream(make([]byte, skip), make([]byte, skip))
+
+		out := make([][]byte, len(bufs))
+		for j, b := range bufs {
+			out[j] = make([]byte, len(b)+rand.Intn(3))
+		}
+
+		XORKeyStreamVec(c3, out, bufs)
+
+		var got []byte
+		for j, b := range bufs {
+			got = append(got, out[j][:len(b)]...)
+		}
+
+		if !bytes.Equal(got, src) {
+			t.Fatalf("out of place XORKeyStreamVec differs with %d fragments", len(bufs))
+		}
+
+		// The keystream continues from the end of the fragments.
+		next := make([]byte, rand.Intn(256))
+		expectNext := make([]byte, len(next))
+		c1.XORKeyStream(expectNext, next)
+		c2.XORKeyStream(next, next)
+
+		if !bytes.Equal(next, expectNext) {
+			t.Fatal("keystream does not continue after XORKeyStreamVec")
+		}
+	}
+}
+
+func TestXORKeyStreamVec(t *testing.T) {
+	for _, v := range []struct {
+		name      string
+		new       func(key, nonce []byte) (cipher.Stream, error)
+		nonceSize int
+	}{
+		{"RFC", NewRFC, RFCNonceSize},
+		{"Draft", NewDraft, DraftNonceSize},
+		{"X", NewXChaCha, XNonceSize},
+		{"128", func(key, nonce []byte) (cipher.Stream, error) {
+			return New128(key[:KeySize128], nonce)
+		}, DraftNonceSize},
+		{"RFCGo", ref.NewRFC, RFCNonceSize},
+		{"DraftGo", ref.NewDraft, DraftNonceSize},
+		{"XGo", ref.NewXChaCha, XNonceSize},
+	} {
+		v := v
+		t.Run(v.name, func(t *testing.T) {
+			testXORKeyStreamVec(t, v.new, v.nonceSize)
+		})
+	}
+}
+
+func TestXORKeyStreamVecMismatch(t *testing.T) {
+	c, err := NewRFC(make([]byte, KeySize), make([]byte, RFCNonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		name     string
+		dst, src [][]byte
+	}{
+		{"count", make([][]byte, 1), make([][]byte, 2)},
+		{"length", [][]byte{make([]byte, 1)}, [][]byte{make([]byte, 2)}},
+	} {
+		t.Run(v.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("XORKeyStreamVec did not panic")
+				}
+			}()
+
+			XORKeyStreamVec(c, v.dst, v.src)
+		})
+	}
+}
+
+func benchmarkXORKeyStreamVec(b *testing.B, fragSize int, vec bool) {
+	c, err := NewRFC(make([]byte, KeySize), make([]byte, RFCNonceSize))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	buf := make([]byte, 64*1024)
+
+	var bufs net.Buffers
+	for p := buf; len(p) != 0; {
+		n := fragSize
+		if n > len(p) {
+			n = len(p)
+		}
+
+		bufs = append(bufs, p[:n])
+		p = p[n:]
+	}
+
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if vec {
+			XORKeyStreamVec(c, bufs, bufs)
+			continue
+		}
+
+		for _, frag := range bufs {
+			c.XORKeyStream(frag, frag)
+		}
+	}
+}
+
+func BenchmarkXORKeyStreamVec(b *testing.B) {
+	for _, size := range []int{13, 100, 1500} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.Run("Vec", func(b *testing.B) {
+				benchmarkXORKeyStreamVec(b, size, true)
+			})
+			b.Run("Loop", func(b *testing.B) {
+				benchmarkXORKeyStreamVec(b, size, false)
+			})
+		})
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build !race
+
+package wireguard
+
+const raceEnabled = false
@@ -0,0 +1,12 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// +build race
+
+package wireguard
+
+// The race detector drops sync.Pool entries at random, so allocations are
+// not counted reliably under it.
+const raceEnabled = true
@@ -0,0 +1,62 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package wireguard
+
+import "sync"
+
+const (
+	replayBlockShift = 6 // log2(replayBlockBits)
+	replayBlockMask  = replayRingBlocks - 1
+	replayBitMask    = replayBlockBits - 1
+)
+
+// replayFilter is the sliding window anti-replay algorithm of RFC 6479. The
+// window is a ring of bitmap blocks; when the window advances, whole blocks
+// are cleared rather than shifting the bitmap. One block is always kept
+// cleared ahead of the window, so the window is one block smaller than the
+// ring.
+type replayFilter struct {
+	mu sync.Mutex
+
+	last uint64 // the highest counter accepted
+	ring [replayRingBlocks]uint64
+}
+
+// validate reports whether counter is within the window and has not been
+// seen before, and marks it as seen.
+func (f *replayFilter) validate(counter uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	block := counter >> replayBlockShift
+
+	if counter > f.last {
+		current := f.last >> replayBlockShift
+
+		diff := block - current
+		if diff > replayRingBlocks {
+			diff = replayRingBlocks
+		}
+
+		for i := current + 1; i <= current+diff; i++ {
+			f.ring[i&replayBlockMask] = 0
+		}
+
+		f.last = counter
+	} else if f.last-counter > ReplayWindowSize {
+		return false
+	}
+
+	bit := uint64(1) << (counter & replayBitMask)
+
+	b := &f.ring[block&replayBlockMask]
+	if *b&bit != 0 {
+		return false
+	}
+
+	*b |= bit
+	return true
+}
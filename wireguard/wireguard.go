@@ -0,0 +1,285 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package wireguard implements the transport data messages of WireGuard on
+// top of github.com/tmthrgd/chacha20.
+//
+// A data message is
+//
+//	le32(4) || le32(receiver index) || le64(counter) || ChaCha20-Poly1305(packet || padding)
+//
+// where the nonce is four zero bytes followed by the little-endian counter,
+// there is no additional data, and the packet is padded with zeros to a
+// multiple of 16 bytes.
+//
+// The handshake, which derives the transport keys and indices, is left to
+// the caller.
+package wireguard
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tmthrgd/chacha20"
+	"github.com/tmthrgd/chacha20/internal/slice"
+	"golang.org/x/crypto/poly1305"
+)
+
+const (
+	// KeySize is the length of transport keys, in bytes.
+	KeySize = chacha20.KeySize
+
+	// HeaderSize is the length of the data message header, in bytes.
+	HeaderSize = 16
+
+	// Overhead is the length of the Poly1305 tag, in bytes.
+	Overhead = poly1305.TagSize
+
+	// PaddingMultiple is the multiple of bytes that packets are padded to.
+	PaddingMultiple = 16
+
+	// MessageTypeData is the type of transport data messages.
+	MessageTypeData = 4
+
+	// RejectAfterMessages is REJECT_AFTER_MESSAGES, the number of messages
+	// after which a keypair must no longer be used.
+	RejectAfterMessages = 1<<64 - 1<<13 - 1
+
+	// ReplayWindowSize is the number of counters behind the highest
+	// counter received that are still accepted, if they have not been seen
+	// before.
+	ReplayWindowSize = (replayRingBlocks - 1) * replayBlockBits
+)
+
+const (
+	replayBlockBits  = 64
+	replayRingBlocks = 2048 / replayBlockBits
+)
+
+var (
+	// ErrInvalidKey is returned when the provided key is not KeySize bytes long.
+	ErrInvalidKey = errors.New("invalid key length")
+
+	// ErrKeypairExhausted is returned by Seal once RejectAfterMessages
+	// messages have been sent with a keypair, and by Open for a message
+	// whose counter is at least RejectAfterMessages.
+	ErrKeypairExhausted = errors.New("wireguard: keypair exhausted")
+
+	// ErrInvalidMessage is returned by Open when the message is too short,
+	// is not a data message or is not addressed to the keypair.
+	ErrInvalidMessage = errors.New("wireguard: invalid data message")
+
+	// ErrReplay is returned by Open when the message's counter has already
+	// been received, or is too far behind the highest counter received.
+	ErrReplay = errors.New("wireguard: replayed message")
+
+	errOpen = errors.New("wireguard: message authentication failed")
+)
+
+// Keypair is the pair of transport keys for a session. It is safe for
+// concurrent use.
+type Keypair struct {
+	// sendCounter is the next counter to send. It is accessed atomically
+	// and is first so that it is 64-bit aligned on 32-bit platforms.
+	sendCounter uint64
+
+	localIndex, remoteIndex uint32
+
+	send, receive aead
+
+	replay replayFilter
+}
+
+// NewKeypair returns a Keypair that sends with sendKey to the peer that
+// knows the session as remoteIndex, and receives with receiveKey messages
+// addressed to localIndex.
+func NewKeypair(sendKey, receiveKey []byte, localIndex, remoteIndex uint32) (*Keypair, error) {
+	if len(sendKey) != KeySize || len(receiveKey) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	k := &Keypair{
+		localIndex:  localIndex,
+		remoteIndex: remoteIndex,
+	}
+	k.send.init(sendKey)
+	k.receive.init(receiveKey)
+	return k, nil
+}
+
+// Seal encrypts packet as a data message with the next send counter,
+// appends the message to dst and returns the updated slice. If dst has
+// enough capacity, Seal does not allocate.
+//
+// packet may overlap dst. In particular, a packet may be sealed in place by
+// placing it at buf[HeaderSize:] and passing buf[:0] as dst, where buf has
+// room for the padding and tag.
+func (k *Keypair) Seal(dst, packet []byte) ([]byte, error) {
+	counter := atomic.AddUint64(&k.sendCounter, 1) - 1
+	if counter >= RejectAfterMessages {
+		// Keep the counter from wrapping around to zero.
+		atomic.StoreUint64(&k.sendCounter, RejectAfterMessages)
+		return nil, ErrKeypairExhausted
+	}
+
+	n := (len(packet) + PaddingMultiple - 1) &^ (PaddingMultiple - 1)
+
+	ret, out := slice.ForAppend(dst, HeaderSize+n+Overhead)
+
+	// The packet is copied before the header is written in case they
+	// overlap.
+	body := out[HeaderSize : HeaderSize+n]
+	copy(body, packet)
+
+	for i := len(packet); i < n; i++ {
+		body[i] = 0
+	}
+
+	binary.LittleEndian.PutUint32(out[0:], MessageTypeData)
+	binary.LittleEndian.PutUint32(out[4:], k.remoteIndex)
+	binary.LittleEndian.PutUint64(out[8:], counter)
+
+	k.send.seal(out[HeaderSize+n:], body, counter)
+	return ret, nil
+}
+
+// Open authenticates and decrypts the data message msg, checks its counter
+// against the replay window, appends the padded packet to dst and returns
+// the updated slice. The padding must be removed by the caller, using the
+// length in the packet's IP header. If dst has enough capacity, Open does
+// not allocate.
+//
+// A message may be decrypted in place by passing msg[HeaderSize:HeaderSize]
+// as dst. Otherwise, the remaining capacity of dst must not overlap msg.
+func (k *Keypair) Open(dst, msg []byte) ([]byte, error) {
+	if len(msg) < HeaderSize+Overhead ||
+		binary.LittleEndian.Uint32(msg[0:]) != MessageTypeData ||
+		binary.LittleEndian.Uint32(msg[4:]) != k.localIndex {
+		return nil, ErrInvalidMessage
+	}
+
+	counter := binary.LittleEndian.Uint64(msg[8:])
+	if counter >= RejectAfterMessages {
+		return nil, ErrKeypairExhausted
+	}
+
+	ciphertext := msg[HeaderSize : len(msg)-Overhead]
+	tag := msg[len(msg)-Overhead:]
+
+	ret, out := slice.ForAppend(dst, len(ciphertext))
+	if !k.receive.open(out, ciphertext, tag, counter) {
+		return nil, errOpen
+	}
+
+	// The counter may only be marked as received once the message is
+	// known to be authentic.
+	if !k.replay.validate(counter) {
+		return nil, ErrReplay
+	}
+
+	return ret, nil
+}
+
+// aead is ChaCha20-Poly1305 with a WireGuard nonce and no additional data.
+// It keeps a pool of streams so that sealing and opening do not allocate.
+type aead struct {
+	key  [KeySize]byte
+	pool sync.Pool
+}
+
+// scratch is the per-message state of aead.
+type scratch struct {
+	s       cipher.Stream
+	nonce   [chacha20.RFCNonceSize]byte
+	polyKey [32]byte
+	block   [64]byte
+}
+
+func (a *aead) init(key []byte) {
+	copy(a.key[:], key)
+
+	a.pool.New = func() interface{} {
+		sc := new(scratch)
+
+		s, err := chacha20.NewRFC(a.key[:], sc.nonce[:])
+		if err != nil {
+			panic(err)
+		}
+
+		sc.s = s
+		return sc
+	}
+}
+
+// begin returns scratch state whose stream is positioned at block one of the
+// keystream for counter, and whose polyKey is taken from block zero.
+func (a *aead) begin(counter uint64) *scratch {
+	sc := a.pool.Get().(*scratch)
+
+	binary.LittleEndian.PutUint64(sc.nonce[4:], counter)
+	chacha20.SetNonce(sc.s, sc.nonce[:])
+
+	// block is always zero when scratch is in the pool.
+	sc.s.XORKeyStream(sc.block[:], sc.block[:])
+	copy(sc.polyKey[:], sc.block[:])
+	return sc
+}
+
+func (a *aead) end(sc *scratch) {
+	for i := range sc.polyKey {
+		sc.polyKey[i] = 0
+	}
+
+	for i := range sc.block {
+		sc.block[i] = 0
+	}
+
+	a.pool.Put(sc)
+}
+
+// seal encrypts body in place and writes the tag to tag.
+func (a *aead) seal(tag, body []byte, counter uint64) {
+	sc := a.begin(counter)
+	sc.s.XORKeyStream(body, body)
+	sc.tag(tag[:0], body)
+	a.end(sc)
+}
+
+// open authenticates ciphertext and tag and, if they are authentic,
+// decrypts ciphertext to out.
+func (a *aead) open(out, ciphertext, tag []byte, counter uint64) bool {
+	sc := a.begin(counter)
+	defer a.end(sc)
+
+	var expected [Overhead]byte
+	sc.tag(expected[:0], ciphertext)
+
+	if subtle.ConstantTimeCompare(expected[:], tag) != 1 {
+		return false
+	}
+
+	sc.s.XORKeyStream(out, ciphertext)
+	return true
+}
+
+// tag appends the Poly1305 tag of ciphertext, with empty additional data, to
+// dst.
+func (sc *scratch) tag(dst, ciphertext []byte) {
+	m := poly1305.New(&sc.polyKey)
+	m.Write(ciphertext)
+
+	var pad [PaddingMultiple]byte
+	m.Write(pad[:(PaddingMultiple-len(ciphertext)%PaddingMultiple)%PaddingMultiple])
+
+	var lens [16]byte
+	binary.LittleEndian.PutUint64(lens[8:], uint64(len(ciphertext)))
+	m.Write(lens[:])
+
+	m.Sum(dst)
+}
@@ -0,0 +1,377 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package wireguard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/tmthrgd/chacha20/chacha20poly1305"
+)
+
+var (
+	testKeyA = bytes.Repeat([]byte{0xa1}, KeySize)
+	testKeyB = bytes.Repeat([]byte{0xb2}, KeySize)
+)
+
+const (
+	testIndexA = 0x11223344
+	testIndexB = 0x55667788
+)
+
+// newTestPair returns the keypairs of two peers, a and b, of one session.
+func newTestPair(t testing.TB) (a, b *Keypair) {
+	a, err := NewKeypair(testKeyA, testKeyB, testIndexA, testIndexB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = NewKeypair(testKeyB, testKeyA, testIndexB, testIndexA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a, b
+}
+
+func TestSealMatchesAEAD(t *testing.T) {
+	a, _ := newTestPair(t)
+
+	ref, err := chacha20poly1305.New(testKeyA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for counter, n := range []int{0, 1, 15, 16, 17, 100, 1420} {
+		packet := bytes.Repeat([]byte{0x42}, n)
+
+		msg, err := a.Seal(nil, packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := make([]byte, HeaderSize)
+		binary.LittleEndian.PutUint32(expected[0:], MessageTypeData)
+		binary.LittleEndian.PutUint32(expected[4:], testIndexB)
+		binary.LittleEndian.PutUint64(expected[8:], uint64(counter))
+
+		var nonce [chacha20poly1305.NonceSize]byte
+		binary.LittleEndian.PutUint64(nonce[4:], uint64(counter))
+
+		padded := make([]byte, (n+15)&^15)
+		copy(padded, packet)
+		expected = ref.Seal(expected, nonce[:], padded, nil)
+
+		if !bytes.Equal(msg, expected) {
+			t.Errorf("%d bytes: invalid message, expected %x, got %x", n, expected, msg)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	a, b := newTestPair(t)
+
+	for n := 0; n < 2048; n += 1 + n/3 {
+		packet := bytes.Repeat([]byte{byte(n)}, n)
+
+		msg, err := a.Seal(nil, packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := b.Open(nil, msg)
+		if err != nil {
+			t.Fatalf("%d bytes: Open failed: %v", n, err)
+		}
+
+		if len(got)%PaddingMultiple != 0 || !bytes.Equal(got[:n], packet) ||
+			!bytes.Equal(got[n:], make([]byte, len(got)-n)) {
+			t.Fatalf("%d bytes: round trip failed", n)
+		}
+
+		// And in the other direction.
+		msg, err = b.Seal(nil, packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := a.Open(nil, msg); err != nil {
+			t.Fatalf("%d bytes: Open failed in reverse: %v", n, err)
+		}
+	}
+}
+
+func TestInPlace(t *testing.T) {
+	a, b := newTestPair(t)
+
+	packet := []byte("an IP packet that is sealed in place")
+
+	buf := make([]byte, HeaderSize+len(packet)+PaddingMultiple+Overhead)
+	copy(buf[HeaderSize:], packet)
+
+	msg, err := a.Seal(buf[:0], buf[HeaderSize:HeaderSize+len(packet)])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if &msg[0] != &buf[0] {
+		t.Error("Seal did not use the provided buffer")
+	}
+
+	got, err := b.Open(msg[HeaderSize:HeaderSize], msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if &got[0] != &buf[HeaderSize] || !bytes.Equal(got[:len(packet)], packet) {
+		t.Error("in place round trip failed")
+	}
+}
+
+func TestNoAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not counted reliably under the race detector")
+	}
+
+	a, b := newTestPair(t)
+
+	packet := make([]byte, 1420)
+	buf := make([]byte, 0, HeaderSize+len(packet)+PaddingMultiple+Overhead)
+	out := make([]byte, 0, len(packet)+PaddingMultiple)
+
+	if n := testing.AllocsPerRun(100, func() {
+		msg, err := a.Seal(buf, packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := b.Open(out, msg); err != nil {
+			t.Fatal(err)
+		}
+	}); n != 0 {
+		t.Errorf("Seal and Open allocated %.1f times", n)
+	}
+}
+
+func TestTampering(t *testing.T) {
+	a, b := newTestPair(t)
+
+	msg, err := a.Seal(nil, []byte("tamper with me"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range msg {
+		tampered := append([]byte(nil), msg...)
+		tampered[i] ^= 0x01
+
+		if _, err := b.Open(nil, tampered); err == nil {
+			t.Errorf("Open succeeded with byte %d modified", i)
+		}
+	}
+
+	if _, err := b.Open(nil, msg[:HeaderSize+Overhead-1]); err != ErrInvalidMessage {
+		t.Error("Open accepted truncated message")
+	}
+
+	// The failures must not have marked the counter as received.
+	if _, err := b.Open(nil, msg); err != nil {
+		t.Errorf("Open failed after tampered messages: %v", err)
+	}
+
+	if _, err := b.Open(nil, msg); err != ErrReplay {
+		t.Error("Open accepted replayed message")
+	}
+
+	if _, err := a.Open(nil, msg); err != ErrInvalidMessage {
+		t.Error("Open accepted message addressed to the other peer")
+	}
+}
+
+func TestRejectAfterMessages(t *testing.T) {
+	a, b := newTestPair(t)
+
+	a.sendCounter = RejectAfterMessages - 1
+
+	msg, err := a.Seal(nil, nil)
+	if err != nil {
+		t.Fatalf("Seal failed for the last counter: %v", err)
+	}
+
+	if _, err := b.Open(nil, msg); err != nil {
+		t.Fatalf("Open failed for the last counter: %v", err)
+	}
+
+	for i := 0; i < 1<<14; i++ {
+		if _, err := a.Seal(nil, nil); err != ErrKeypairExhausted {
+			t.Fatalf("Seal succeeded after RejectAfterMessages, counter %d", a.sendCounter)
+		}
+	}
+
+	binary.LittleEndian.PutUint64(msg[8:], RejectAfterMessages)
+
+	if _, err := b.Open(nil, msg); err != ErrKeypairExhausted {
+		t.Error("Open accepted counter of RejectAfterMessages")
+	}
+}
+
+func TestReplayFilter(t *testing.T) {
+	var f replayFilter
+
+	for _, v := range []struct {
+		counter uint64
+		ok      bool
+	}{
+		{0, true},
+		{1, true},
+		{1, false},
+		{9, true},
+		{8, true},
+		{7, true},
+		{7, false},
+		{ReplayWindowSize + 12, true},
+		{12, true}, // exactly ReplayWindowSize behind
+		{11, false},
+		{9, false},
+		{ReplayWindowSize + 8, true},
+		{ReplayWindowSize + 10, true},
+		{ReplayWindowSize + 10, false},
+		{1 << 20, true},
+		{1<<20 - ReplayWindowSize, true},
+		{1<<20 - ReplayWindowSize - 1, false},
+		{1<<20 - 1, true},
+		{1 << 20, false},
+		{RejectAfterMessages - 1, true},
+		{1<<20 + 1, false},
+	} {
+		if ok := f.validate(v.counter); ok != v.ok {
+			t.Errorf("counter %d: expected %t, got %t", v.counter, v.ok, ok)
+		}
+	}
+}
+
+func TestReplayFilterRandom(t *testing.T) {
+	rand := rand.New(rand.NewSource(0))
+
+	var f replayFilter
+	seen := make(map[uint64]bool)
+
+	var last uint64
+	for i := 0; i < 100000; i++ {
+		counter := last + uint64(rand.Intn(64))
+		if counter > ReplayWindowSize/2 && rand.Intn(2) == 0 {
+			counter -= uint64(rand.Intn(ReplayWindowSize / 2))
+		}
+
+		expected := !seen[counter] && counter+ReplayWindowSize >= last
+		if ok := f.validate(counter); ok != expected {
+			t.Fatalf("counter %d with last %d: expected %t, got %t", counter, last, expected, ok)
+		}
+
+		if expected {
+			seen[counter] = true
+
+			if counter > last {
+				last = counter
+			}
+		}
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	a, b := newTestPair(t)
+
+	const goroutines, perGoroutine = 8, 200
+
+	msgs := make(chan []byte, goroutines*perGoroutine)
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < perGoroutine; j++ {
+				msg, err := a.Seal(nil, []byte{byte(i), byte(j)})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				msgs <- msg
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(msgs)
+
+	counters := make(map[uint64]bool)
+	for msg := range msgs {
+		counter := binary.LittleEndian.Uint64(msg[8:])
+		if counters[counter] {
+			t.Fatalf("counter %d was used twice", counter)
+		}
+
+		counters[counter] = true
+
+		if _, err := b.Open(nil, msg); err != nil {
+			t.Fatalf("Open failed for counter %d: %v", counter, err)
+		}
+	}
+
+	if len(counters) != goroutines*perGoroutine {
+		t.Errorf("expected %d messages, got %d", goroutines*perGoroutine, len(counters))
+	}
+}
+
+func TestBadKey(t *testing.T) {
+	if _, err := NewKeypair(testKeyA[:16], testKeyB, 0, 0); err != ErrInvalidKey {
+		t.Error("NewKeypair accepted invalid send key")
+	}
+
+	if _, err := NewKeypair(testKeyA, testKeyB[:31], 0, 0); err != ErrInvalidKey {
+		t.Error("NewKeypair accepted invalid receive key")
+	}
+}
+
+func BenchmarkSeal(b *testing.B) {
+	k, _ := newTestPair(b)
+
+	packet := make([]byte, 1420)
+	buf := make([]byte, 0, HeaderSize+len(packet)+PaddingMultiple+Overhead)
+
+	b.SetBytes(int64(len(packet)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := k.Seal(buf, packet); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSealParallel(b *testing.B) {
+	k, _ := newTestPair(b)
+
+	b.SetBytes(1420)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		packet := make([]byte, 1420)
+		buf := make([]byte, 0, HeaderSize+len(packet)+PaddingMultiple+Overhead)
+
+		for pb.Next() {
+			if _, err := k.Seal(buf, packet); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -116,7 +116,7 @@ elsif (!$gas)
     $decor="\$L\$";
 }
 
-my @golang_missing_avx=qw/ VPSLLD VPSRLD VBROADCASTI128 VPINSRQ VPADDQ VMOVDQA VPERM2I128 VPADDQ VPADDD VPSHUFB VMOVQ VPALIGNR VPSLLQ VPSRLQ VPMULUDQ VPUNPCKLQDQ VMOVD VPUNPCKHQDQ VBROADCASTSS VPSRLDQ VPSLLDQ VPINSRB VPSHUFD VPERMQ VPBROADCASTQ VPERMD MOVDQU SHRDQ SHLDQ MOVZXB MOVDQA PSLLD PSRLD PUNPCKHDQ PUNPCKLDQ /;
+my @golang_missing_avx=qw/ VPSLLD VPSRLD VBROADCASTI128 VPINSRQ VPADDQ VMOVDQA VPERM2I128 VPADDQ VPADDD VPSHUFB VMOVQ VPALIGNR VPSLLQ VPSRLQ VPMULUDQ VPUNPCKLQDQ VMOVD VPUNPCKHQDQ VBROADCASTSS VPSRLDQ VPSLLDQ VPINSRB VPSHUFD VPERMQ VPBROADCASTQ VPERMD MOVDQU SHRDQ SHLDQ MOVZXB MOVDQA PSLLD PSRLD PUNPCKHDQ PUNPCKLDQ PCMPEQD /;
 my %golang_last_label_id;
 
 my $current_segment;
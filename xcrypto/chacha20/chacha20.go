@@ -0,0 +1,184 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+// Package chacha20 mirrors the API of golang.org/x/crypto/chacha20 on top of
+// github.com/tmthrgd/chacha20.
+//
+// It is a drop-in replacement for golang.org/x/crypto/chacha20, only the import
+// path needs to change, and it produces identical output.
+package chacha20
+
+import (
+	"crypto/cipher"
+	"errors"
+	"reflect"
+
+	tmthrgd "github.com/tmthrgd/chacha20"
+)
+
+const (
+	// KeySize is the size of the key used by this cipher, in bytes.
+	KeySize = tmthrgd.KeySize
+
+	// NonceSize is the size of the nonce used with the standard variant of
+	// this cipher, in bytes.
+	NonceSize = tmthrgd.RFCNonceSize
+
+	// NonceSizeX is the size of the nonce used with the XChaCha20 variant of
+	// this cipher, in bytes.
+	NonceSizeX = tmthrgd.XNonceSize
+)
+
+const (
+	blockSize = 64
+
+	// maxBytes is the length of the keystream for a 32-bit block counter.
+	maxBytes = 1 << 32 * blockSize
+)
+
+// Cipher is a stateful instance of ChaCha20 or XChaCha20 using a particular key
+// and nonce. A *Cipher implements the cipher.Stream interface.
+type Cipher struct {
+	s cipher.Stream
+
+	// pos is the offset into the keystream of the next byte.
+	pos uint64
+
+	// overflow is set once the last block of keystream has been reached,
+	// after which the counter may not be changed.
+	overflow bool
+}
+
+var _ cipher.Stream = (*Cipher)(nil)
+
+// NewUnauthenticatedCipher creates a new ChaCha20 stream cipher with the given
+// 32 bytes key and a 12 or 24 bytes nonce. If a nonce of 24 bytes is provided,
+// the XChaCha20 construction will be used. It returns an error if key or nonce
+// have any other length.
+//
+// Note that ChaCha20, like all stream ciphers, is not authenticated and allows
+// attackers to silently tamper with the plaintext.
+func NewUnauthenticatedCipher(key, nonce []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("chacha20: wrong key size")
+	}
+
+	if len(nonce) == NonceSizeX {
+		// Unlike NewXChaCha, XChaCha20 here uses a 32-bit counter with
+		// the remaining 8 bytes of the nonce preceded by 4 zero bytes.
+		var subKey [tmthrgd.HChaChaSize]byte
+		hChaCha20(&subKey, key, nonce[:tmthrgd.HNonceSize])
+
+		var cNonce [NonceSize]byte
+		copy(cNonce[4:], nonce[tmthrgd.HNonceSize:])
+
+		key, nonce = subKey[:], cNonce[:]
+	} else if len(nonce) != NonceSize {
+		return nil, errors.New("chacha20: wrong nonce size")
+	}
+
+	s, err := tmthrgd.NewRFC(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{s: s}, nil
+}
+
+// SetCounter sets the Cipher counter. The next invocation of XORKeyStream will
+// behave as if (64 * counter) bytes had been encrypted so far.
+//
+// To prevent accidental counter reuse, SetCounter panics if counter is less
+// than the current value.
+func (c *Cipher) SetCounter(counter uint32) {
+	// The current value is that of the block after the one the last byte
+	// of keystream was taken from.
+	current := (c.pos + blockSize - 1) / blockSize
+	if c.overflow || uint64(counter) < current {
+		panic("chacha20: SetCounter attempted to rollback counter")
+	}
+
+	if pos := uint64(counter) * blockSize; pos != c.pos {
+		tmthrgd.SetCounter(c.s, uint64(counter))
+		c.pos = pos
+	}
+}
+
+// XORKeyStream XORs each byte in the given slice with a byte from the
+// cipher's key stream. Dst and src must overlap entirely or not at all.
+//
+// If len(dst) < len(src), XORKeyStream will panic. It is acceptable
+// to pass a dst bigger than src, and in that case, XORKeyStream will
+// only update dst[:len(src)] and will not touch the rest of dst.
+//
+// Multiple calls to XORKeyStream behave as if the concatenation of
+// the src buffers was passed in a single run. That is, Cipher
+// maintains state and does not reset at each XORKeyStream call.
+func (c *Cipher) XORKeyStream(dst, src []byte) {
+	if len(src) == 0 {
+		return
+	}
+
+	if len(dst) < len(src) {
+		panic("chacha20: output smaller than input")
+	}
+
+	dst = dst[:len(src)]
+	if inexactOverlap(dst, src) {
+		panic("chacha20: invalid buffer overlap")
+	}
+
+	if c.pos+uint64(len(src)) > maxBytes {
+		panic("chacha20: counter overflow")
+	}
+
+	c.s.XORKeyStream(dst, src)
+	c.pos += uint64(len(src))
+
+	if c.pos > maxBytes-blockSize {
+		c.overflow = true
+	}
+}
+
+// HChaCha20 uses the ChaCha20 core to generate a derived key from a 32 bytes
+// key and a 16 bytes nonce. It returns an error if key or nonce have any other
+// length. It is used as part of the XChaCha20 construction.
+func HChaCha20(key, nonce []byte) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("chacha20: wrong HChaCha20 key size")
+	}
+
+	if len(nonce) != tmthrgd.HNonceSize {
+		return nil, errors.New("chacha20: wrong HChaCha20 nonce size")
+	}
+
+	var out [tmthrgd.HChaChaSize]byte
+	hChaCha20(&out, key, nonce)
+	return out[:], nil
+}
+
+// hChaCha20 calls tmthrgd.HChaCha20 with a key and nonce of the correct
+// lengths.
+func hChaCha20(out *[tmthrgd.HChaChaSize]byte, key, nonce []byte) {
+	var hKey [KeySize]byte
+	copy(hKey[:], key)
+
+	var hNonce [tmthrgd.HNonceSize]byte
+	copy(hNonce[:], nonce)
+
+	tmthrgd.HChaCha20(out, &hKey, &hNonce)
+}
+
+// inexactOverlap reports whether x and y share memory at any non-corresponding
+// index.
+func inexactOverlap(x, y []byte) bool {
+	if len(x) == 0 || len(y) == 0 || &x[0] == &y[0] {
+		return false
+	}
+
+	x0, x1 := reflect.ValueOf(&x[0]).Pointer(), reflect.ValueOf(&x[len(x)-1]).Pointer()
+	y0, y1 := reflect.ValueOf(&y[0]).Pointer(), reflect.ValueOf(&y[len(y)-1]).Pointer()
+	return x0 <= y1 && y0 <= x1
+}
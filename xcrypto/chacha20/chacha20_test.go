@@ -0,0 +1,177 @@
+// Copyright 2017 Tom Thorogood. All rights reserved.
+// Use of this source code is governed by a
+// Modified BSD License license that can be found in
+// the LICENSE file.
+
+package chacha20
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+)
+
+func testEqual(t *testing.T, nonceSize int) {
+	rand := rand.New(rand.NewSource(int64(nonceSize)))
+
+	for i := 0; i < 100; i++ {
+		key := make([]byte, KeySize)
+		rand.Read(key)
+
+		nonce := make([]byte, nonceSize)
+		rand.Read(nonce)
+
+		c1, err := NewUnauthenticatedCipher(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c2, err := xchacha20.NewUnauthenticatedCipher(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var counter uint32
+		for j := 0; j < 20; j++ {
+			if rand.Intn(4) == 0 {
+				// Move the counter forward, possibly to the
+				// start of the next block.
+				counter += uint32(rand.Intn(4))
+				c1.SetCounter(counter)
+				c2.SetCounter(counter)
+			}
+
+			src := make([]byte, rand.Intn(1024))
+			rand.Read(src)
+
+			dst1 := make([]byte, len(src))
+			c1.XORKeyStream(dst1, src)
+
+			dst2 := make([]byte, len(src))
+			c2.XORKeyStream(dst2, src)
+
+			if !bytes.Equal(dst1, dst2) {
+				t.Fatalf("output differs from golang.org/x/crypto/chacha20:\n\texpected %x\n\twas      %x", dst2, dst1)
+			}
+
+			counter += uint32(len(src)+63) / 64
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	testEqual(t, NonceSize)
+}
+
+func TestEqualX(t *testing.T) {
+	testEqual(t, NonceSizeX)
+}
+
+func TestHChaCha20(t *testing.T) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, 16)
+
+	for i := 0; i < 100; i++ {
+		rand.Read(key)
+		rand.Read(nonce)
+
+		out1, err := HChaCha20(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out2, err := xchacha20.HChaCha20(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(out1, out2) {
+			t.Fatalf("output differs from golang.org/x/crypto/chacha20:\n\texpected %x\n\twas      %x", out2, out1)
+		}
+	}
+}
+
+func TestBadSizes(t *testing.T) {
+	if _, err := NewUnauthenticatedCipher(make([]byte, 3), make([]byte, NonceSize)); err == nil {
+		t.Error("NewUnauthenticatedCipher accepted a bad key")
+	}
+
+	if _, err := NewUnauthenticatedCipher(make([]byte, KeySize), make([]byte, 8)); err == nil {
+		t.Error("NewUnauthenticatedCipher accepted a bad nonce")
+	}
+
+	if _, err := HChaCha20(make([]byte, 3), make([]byte, 16)); err == nil {
+		t.Error("HChaCha20 accepted a bad key")
+	}
+
+	if _, err := HChaCha20(make([]byte, KeySize), make([]byte, 12)); err == nil {
+		t.Error("HChaCha20 accepted a bad nonce")
+	}
+}
+
+func mustPanic(t *testing.T, msg string, fn func()) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic: %s", msg)
+		} else if r != msg {
+			t.Errorf("expected panic %q, got %q", msg, r)
+		}
+	}()
+
+	fn()
+}
+
+func TestPanics(t *testing.T) {
+	c, err := NewUnauthenticatedCipher(make([]byte, KeySize), make([]byte, NonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 100)
+
+	mustPanic(t, "chacha20: output smaller than input", func() {
+		c.XORKeyStream(buf[:10], buf)
+	})
+
+	mustPanic(t, "chacha20: invalid buffer overlap", func() {
+		c.XORKeyStream(buf[1:], buf[:99])
+	})
+
+	c.XORKeyStream(buf, buf)
+
+	mustPanic(t, "chacha20: SetCounter attempted to rollback counter", func() {
+		c.SetCounter(1)
+	})
+
+	c.SetCounter(2)
+	c.SetCounter(1<<32 - 1)
+	c.XORKeyStream(buf[:64], buf[:64])
+
+	mustPanic(t, "chacha20: counter overflow", func() {
+		c.XORKeyStream(buf[:1], buf[:1])
+	})
+
+	mustPanic(t, "chacha20: SetCounter attempted to rollback counter", func() {
+		c.SetCounter(1<<32 - 1)
+	})
+}
+
+func BenchmarkXORKeyStream(b *testing.B) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, NonceSize)
+	buf := make([]byte, 1024*1024)
+
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c, err := NewUnauthenticatedCipher(key, nonce)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		c.XORKeyStream(buf, buf)
+	}
+}